@@ -0,0 +1,362 @@
+// Package analytics computes conversation statistics for a group from its
+// stored messages: who is active, who replies to whom, how long threads get,
+// how fast questions are answered and which ones aren't. Members appear only
+// under the aliases the caller passes in, never by hash.
+package analytics
+
+import (
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"telegram_summarize_bot/db"
+)
+
+const (
+	// topN caps each list in a report.
+	topN = 5
+	// snippetRunes is how much of a message a report quotes.
+	snippetRunes = 60
+	// unansweredGrace is how long a question may wait for a reply before it
+	// counts as unanswered.
+	unansweredGrace = time.Hour
+	// clusterMinReplies is how many replies two members must exchange (both
+	// ways together) to be linked in a reply cluster.
+	clusterMinReplies = 2
+)
+
+// Report is the statistics for one group over a window of messages.
+type Report struct {
+	Messages     int
+	Participants int
+	// Members are the most active members, by messages.
+	Members []Member
+	// Pairs are who replies to whom most, by replies.
+	Pairs []Pair
+	// Threads are the longest reply threads, by messages.
+	Threads []Thread
+	// Replies counts the messages that got a reply from someone else; the
+	// latencies are from each such message to its first reply.
+	Replies         int
+	MedianLatency   time.Duration
+	P90Latency      time.Duration
+	Unanswered      []Question
+	UnansweredTotal int
+	// Clusters are groups of members who mostly reply to each other, largest
+	// first.
+	Clusters []Cluster
+}
+
+// Member is one member's activity.
+type Member struct {
+	Alias           string
+	Messages        int
+	RepliesSent     int
+	RepliesReceived int
+}
+
+// Pair counts the replies From sent to To.
+type Pair struct {
+	From, To string
+	Replies  int
+}
+
+// Thread is a reply tree within the window.
+type Thread struct {
+	RootTgMessageID int64
+	Snippet         string
+	Messages        int
+	Participants    int
+	Span            time.Duration // first to last message
+}
+
+// Question is a question nobody replied to.
+type Question struct {
+	TgMessageID int64
+	Alias       string
+	Snippet     string
+	Age         time.Duration
+}
+
+// Cluster is a set of members linked by replies.
+type Cluster struct {
+	Aliases []string
+	Replies int
+}
+
+// Compute builds the report for messages (oldest first) as of now. aliases
+// maps user hashes to the names the report uses; members without one are
+// left out of the member lists.
+func Compute(messages []db.Message, aliases map[string]string, now time.Time) Report {
+	r := Report{Messages: len(messages)}
+
+	byTgID := make(map[int64]db.Message, len(messages))
+	for _, m := range messages {
+		if m.TgMessageID != 0 {
+			byTgID[m.TgMessageID] = m
+		}
+	}
+
+	members := map[string]*Member{}
+	member := func(hash string) *Member {
+		alias := aliases[hash]
+		if alias == "" {
+			return nil
+		}
+		m, ok := members[alias]
+		if !ok {
+			m = &Member{Alias: alias}
+			members[alias] = m
+		}
+		return m
+	}
+	pairs := map[[2]string]int{}
+	firstReply := map[int64]time.Time{} // parent → first reply from someone else
+	replied := map[int64]bool{}         // parents with any reply
+
+	for _, m := range messages {
+		author := member(m.UserHash)
+		if author != nil {
+			author.Messages++
+		}
+		if m.ReplyToTgID == 0 {
+			continue
+		}
+		replied[m.ReplyToTgID] = true
+		parent, ok := byTgID[m.ReplyToTgID]
+		if !ok || parent.UserHash == m.UserHash {
+			continue
+		}
+		if t, seen := firstReply[parent.TgMessageID]; !seen || m.Timestamp.Before(t) {
+			firstReply[parent.TgMessageID] = m.Timestamp
+		}
+		to := member(parent.UserHash)
+		if author == nil || to == nil {
+			continue
+		}
+		author.RepliesSent++
+		to.RepliesReceived++
+		pairs[[2]string{author.Alias, to.Alias}]++
+	}
+
+	r.Participants = len(members)
+	for _, m := range members {
+		r.Members = append(r.Members, *m)
+	}
+	slices.SortFunc(r.Members, func(a, b Member) int {
+		if a.Messages != b.Messages {
+			return b.Messages - a.Messages
+		}
+		return compareAliases(a.Alias, b.Alias)
+	})
+	r.Members = top(r.Members)
+
+	for k, n := range pairs {
+		r.Pairs = append(r.Pairs, Pair{From: k[0], To: k[1], Replies: n})
+	}
+	slices.SortFunc(r.Pairs, func(a, b Pair) int {
+		if a.Replies != b.Replies {
+			return b.Replies - a.Replies
+		}
+		return strings.Compare(a.From+"\x00"+a.To, b.From+"\x00"+b.To)
+	})
+	r.Pairs = top(r.Pairs)
+
+	var latencies []time.Duration
+	for parentID, t := range firstReply {
+		latencies = append(latencies, t.Sub(byTgID[parentID].Timestamp))
+	}
+	r.Replies = len(latencies)
+	if len(latencies) > 0 {
+		slices.Sort(latencies)
+		r.MedianLatency = latencies[(len(latencies)-1)/2]
+		r.P90Latency = latencies[(len(latencies)*9+9)/10-1]
+	}
+
+	r.Threads = threads(messages, byTgID, aliases)
+
+	for _, m := range messages {
+		if m.TgMessageID == 0 || replied[m.TgMessageID] || !isQuestion(m.Text) {
+			continue
+		}
+		age := now.Sub(m.Timestamp)
+		if age < unansweredGrace {
+			continue
+		}
+		r.UnansweredTotal++
+		r.Unanswered = append(r.Unanswered, Question{
+			TgMessageID: m.TgMessageID,
+			Alias:       aliases[m.UserHash],
+			Snippet:     snippet(m.Text),
+			Age:         age,
+		})
+	}
+	// The latest questions are the ones still worth answering.
+	slices.Reverse(r.Unanswered)
+	r.Unanswered = top(r.Unanswered)
+
+	r.Clusters = clusters(pairs)
+	return r
+}
+
+// threads groups the messages into reply trees by their root within the
+// window and returns the longest ones.
+func threads(messages []db.Message, byTgID map[int64]db.Message, aliases map[string]string) []Thread {
+	rootOf := func(m db.Message) db.Message {
+		seen := map[int64]bool{}
+		for m.ReplyToTgID != 0 && !seen[m.TgMessageID] {
+			seen[m.TgMessageID] = true
+			parent, ok := byTgID[m.ReplyToTgID]
+			if !ok {
+				break
+			}
+			m = parent
+		}
+		return m
+	}
+
+	type acc struct {
+		root        db.Message
+		messages    int
+		authors     map[string]bool
+		first, last time.Time
+	}
+	trees := map[int64]*acc{}
+	var order []int64
+	for _, m := range messages {
+		if m.TgMessageID == 0 {
+			continue
+		}
+		root := rootOf(m)
+		t, ok := trees[root.TgMessageID]
+		if !ok {
+			t = &acc{root: root, authors: map[string]bool{}, first: m.Timestamp, last: m.Timestamp}
+			trees[root.TgMessageID] = t
+			order = append(order, root.TgMessageID)
+		}
+		t.messages++
+		if aliases[m.UserHash] != "" {
+			t.authors[m.UserHash] = true
+		}
+		if m.Timestamp.Before(t.first) {
+			t.first = m.Timestamp
+		}
+		if m.Timestamp.After(t.last) {
+			t.last = m.Timestamp
+		}
+	}
+
+	var out []Thread
+	for _, id := range order {
+		t := trees[id]
+		if t.messages < 2 {
+			continue
+		}
+		out = append(out, Thread{
+			RootTgMessageID: t.root.TgMessageID,
+			Snippet:         snippet(t.root.Text),
+			Messages:        t.messages,
+			Participants:    len(t.authors),
+			Span:            t.last.Sub(t.first),
+		})
+	}
+	slices.SortStableFunc(out, func(a, b Thread) int { return b.Messages - a.Messages })
+	return top(out)
+}
+
+// clusters links members who exchanged at least clusterMinReplies replies
+// and returns the connected groups of three or more, largest first.
+func clusters(pairs map[[2]string]int) []Cluster {
+	mutual := map[[2]string]int{}
+	for k, n := range pairs {
+		a, b := k[0], k[1]
+		if b < a {
+			a, b = b, a
+		}
+		mutual[[2]string{a, b}] += n
+	}
+
+	parent := map[string]string{}
+	var find func(string) string
+	find = func(x string) string {
+		if p, ok := parent[x]; ok && p != x {
+			parent[x] = find(p)
+			return parent[x]
+		}
+		parent[x] = x
+		return x
+	}
+	for k, n := range mutual {
+		if n >= clusterMinReplies {
+			parent[find(k[0])] = find(k[1])
+		}
+	}
+
+	groups := map[string]*Cluster{}
+	for k, n := range mutual {
+		if n < clusterMinReplies {
+			continue
+		}
+		root := find(k[0])
+		c, ok := groups[root]
+		if !ok {
+			c = &Cluster{}
+			groups[root] = c
+		}
+		c.Replies += n
+	}
+	for alias := range parent {
+		if c, ok := groups[find(alias)]; ok {
+			c.Aliases = append(c.Aliases, alias)
+		}
+	}
+
+	var out []Cluster
+	for _, c := range groups {
+		if len(c.Aliases) < 3 {
+			continue
+		}
+		slices.SortFunc(c.Aliases, compareAliases)
+		out = append(out, *c)
+	}
+	slices.SortFunc(out, func(a, b Cluster) int {
+		if len(a.Aliases) != len(b.Aliases) {
+			return len(b.Aliases) - len(a.Aliases)
+		}
+		if a.Replies != b.Replies {
+			return b.Replies - a.Replies
+		}
+		return compareAliases(a.Aliases[0], b.Aliases[0])
+	})
+	return top(out)
+}
+
+// compareAliases orders aliases like У2 before У10.
+func compareAliases(a, b string) int {
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	return strings.Compare(a, b)
+}
+
+// isQuestion reports whether the text asks something.
+func isQuestion(text string) bool {
+	return strings.Contains(text, "?")
+}
+
+// snippet is the start of a message, on one line.
+func snippet(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= snippetRunes {
+		return text
+	}
+	return strings.TrimSpace(string([]rune(text)[:snippetRunes])) + "…"
+}
+
+func top[T any](s []T) []T {
+	if len(s) > topN {
+		return s[:topN]
+	}
+	return s
+}
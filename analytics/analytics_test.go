@@ -0,0 +1,107 @@
+package analytics
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
+)
+
+// spec is one message of a test chat: {tgID, replyTo, hash, text}.
+type spec = struct {
+	tg, replyTo int64
+	hash, text  string
+}
+
+// chat builds messages a minute apart from start.
+func chat(start time.Time, specs ...spec) []db.Message {
+	msgs := make([]db.Message, len(specs))
+	for i, s := range specs {
+		msgs[i] = db.Message{
+			GroupID: -100, UserHash: s.hash, Text: s.text,
+			Timestamp:   start.Add(time.Duration(i) * time.Minute),
+			TgMessageID: s.tg, ReplyToTgID: s.replyTo,
+		}
+	}
+	return msgs
+}
+
+func TestCompute(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	start := now.Add(-3 * time.Hour)
+	msgs := chat(start,
+		spec{1, 0, "aaaa", "что думаете про релиз?"},
+		spec{2, 1, "bbbb", "катим вечером"},
+		spec{3, 2, "cccc", "поддерживаю"},
+		spec{4, 3, "aaaa", "ок"},
+		spec{5, 0, "dddd", "кто-нибудь видел логи?"}, // never answered
+		spec{6, 4, "bbbb", "тогда решено"},
+		spec{7, 6, "cccc", "и тесты прогнать"},
+		spec{8, 7, "bbbb", "само собой"},
+		spec{9, 0, "aaaa", "а обед когда?"},
+	)
+	// Too recent to count as unanswered yet.
+	msgs[8].Timestamp = now.Add(-10 * time.Minute)
+	aliases := map[string]string{"aaaa": "У1", "bbbb": "У2", "cccc": "У3", "dddd": "У4"}
+
+	r := Compute(msgs, aliases, now)
+
+	if r.Messages != 9 || r.Participants != 4 {
+		t.Errorf("totals = %d messages, %d participants", r.Messages, r.Participants)
+	}
+	if len(r.Members) != 4 || r.Members[0].Alias != "У1" || r.Members[0].Messages != 3 || r.Members[1].Alias != "У2" {
+		t.Errorf("members = %+v, want У1 (3) then У2 (3)", r.Members)
+	}
+	if b := r.Members[1]; b.RepliesSent != 3 || b.RepliesReceived != 2 {
+		t.Errorf("У2 = %+v, want 3 replies sent and 2 received", b)
+	}
+	if len(r.Pairs) != 4 || r.Pairs[0] != (Pair{From: "У2", To: "У1", Replies: 2}) || r.Pairs[1] != (Pair{From: "У3", To: "У2", Replies: 2}) {
+		t.Errorf("pairs = %+v, want У2 → У1 and У3 → У2 twice each first", r.Pairs)
+	}
+	if len(r.Threads) != 1 || r.Threads[0].RootTgMessageID != 1 || r.Threads[0].Messages != 7 || r.Threads[0].Participants != 3 {
+		t.Errorf("threads = %+v, want the 7-message thread under 1", r.Threads)
+	}
+	if r.Replies != 6 || r.MedianLatency != time.Minute || r.P90Latency != 2*time.Minute {
+		t.Errorf("latency over %d replies: median %v, p90 %v", r.Replies, r.MedianLatency, r.P90Latency)
+	}
+	if r.UnansweredTotal != 1 || r.Unanswered[0].TgMessageID != 5 || r.Unanswered[0].Alias != "У4" {
+		t.Errorf("unanswered = %d %+v, want only message 5", r.UnansweredTotal, r.Unanswered)
+	}
+	if len(r.Clusters) != 1 || strings.Join(r.Clusters[0].Aliases, ",") != "У1,У2,У3" {
+		t.Errorf("clusters = %+v, want У1, У2, У3", r.Clusters)
+	}
+}
+
+func TestFormatNeverShowsHashes(t *testing.T) {
+	now := time.Now()
+	msgs := chat(now.Add(-2*time.Hour),
+		spec{1, 0, "a3f2b1c4", "есть **вопрос**?"},
+		spec{2, 0, "9e8d7c6b", "и у меня [вопрос]?"},
+		spec{3, 2, "a3f2b1c4", "какой?"},
+	)
+	r := Compute(msgs, map[string]string{"a3f2b1c4": "У1", "9e8d7c6b": "У2"}, now)
+
+	md := r.Markdown(i18n.English, func(id int64) string { return fmt.Sprintf("https://t.me/c/1/%d", id) })
+	text := r.Text(i18n.Russian)
+	for _, out := range []string{md, text} {
+		if strings.Contains(out, "a3f2b1c4") || strings.Contains(out, "9e8d7c6b") {
+			t.Fatalf("report leaks a user hash:\n%s", out)
+		}
+	}
+	if !strings.Contains(md, "[«есть вопрос?»](https://t.me/c/1/1)") {
+		t.Errorf("unanswered question not quoted and linked:\n%s", md)
+	}
+	if !strings.Contains(md, "«и у меня (вопрос)?»") {
+		t.Errorf("thread root not made Markdown-safe:\n%s", md)
+	}
+	if !strings.HasPrefix(text, "📊 Статистика\n") || strings.Contains(text, "](") {
+		t.Errorf("plain text report:\n%s", text)
+	}
+
+	if empty := (Report{}).Text(i18n.English); !strings.Contains(empty, "No messages") {
+		t.Errorf("empty report = %q", empty)
+	}
+}
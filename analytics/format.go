@@ -0,0 +1,106 @@
+package analytics
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/tgutil"
+)
+
+// Markdown renders the report as a Markdown section for a group post. link
+// returns the link to a message in the group, or "" when there is none.
+func (r Report) Markdown(lang i18n.Lang, link func(tgMessageID int64) string) string {
+	return r.format(lang, true, link)
+}
+
+// Text renders the report as plain text, for the admin DM.
+func (r Report) Text(lang i18n.Lang) string {
+	return r.format(lang, false, func(int64) string { return "" })
+}
+
+func (r Report) format(lang i18n.Lang, md bool, link func(int64) string) string {
+	var sb strings.Builder
+	header := i18n.T(lang, i18n.StatsHeader)
+	if md {
+		header = "**" + header + "**"
+	}
+	sb.WriteString(header + "\n")
+	if r.Messages == 0 {
+		sb.WriteString(i18n.T(lang, i18n.StatsEmpty))
+		return sb.String()
+	}
+	sb.WriteString(i18n.T(lang, i18n.StatsTotals, r.Messages, r.Participants) + "\n")
+
+	// quote renders a snippet, linked to its message when possible.
+	quote := func(s string, tgID int64) string {
+		if !md {
+			return "«" + s + "»"
+		}
+		s = markdownSafe(s)
+		if l := link(tgID); l != "" {
+			return fmt.Sprintf("[«%s»](%s)", s, l)
+		}
+		return "«" + s + "»"
+	}
+	section := func(key i18n.Key, args ...any) {
+		sb.WriteString("\n" + i18n.T(lang, key, args...) + "\n")
+	}
+
+	if len(r.Members) > 0 {
+		section(i18n.StatsMembers)
+		for _, m := range r.Members {
+			sb.WriteString("• " + i18n.T(lang, i18n.StatsMemberLine, m.Alias, m.Messages, m.RepliesSent, m.RepliesReceived) + "\n")
+		}
+	}
+	if len(r.Pairs) > 0 {
+		section(i18n.StatsPairs)
+		for _, p := range r.Pairs {
+			fmt.Fprintf(&sb, "• %s → %s: %d\n", p.From, p.To, p.Replies)
+		}
+	}
+	if len(r.Threads) > 0 {
+		section(i18n.StatsThreads)
+		for _, t := range r.Threads {
+			sb.WriteString("• " + i18n.T(lang, i18n.StatsThreadLine,
+				quote(t.Snippet, t.RootTgMessageID), t.Messages, t.Participants, formatDuration(lang, t.Span)) + "\n")
+		}
+	}
+	if r.Replies > 0 {
+		section(i18n.StatsLatency, formatDuration(lang, r.MedianLatency), formatDuration(lang, r.P90Latency), r.Replies)
+	}
+	if r.UnansweredTotal > 0 {
+		section(i18n.StatsUnanswered, r.UnansweredTotal)
+		for _, q := range r.Unanswered {
+			alias := q.Alias
+			if alias == "" {
+				alias = "anon"
+			}
+			sb.WriteString("• " + i18n.T(lang, i18n.StatsQuestionLine, alias, quote(q.Snippet, q.TgMessageID), formatDuration(lang, q.Age)) + "\n")
+		}
+	}
+	if len(r.Clusters) > 0 {
+		section(i18n.StatsClusters)
+		for _, c := range r.Clusters {
+			sb.WriteString("• " + i18n.T(lang, i18n.StatsClusterLine, strings.Join(c.Aliases, ", "), c.Replies) + "\n")
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// formatDuration renders d coarsely: seconds or minutes under an hour, hours
+// and minutes above.
+func formatDuration(lang i18n.Lang, d time.Duration) string {
+	if d < time.Hour {
+		return tgutil.FormatDuration(lang, d)
+	}
+	minutes := int(d.Minutes())
+	return i18n.T(lang, i18n.DurationHours, minutes/60, minutes%60)
+}
+
+// markdownSafe drops the characters that would turn a quoted message into
+// Markdown markup.
+func markdownSafe(s string) string {
+	return strings.NewReplacer("*", "", "_", "", "`", "", "[", "(", "]", ")").Replace(s)
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"telegram_summarize_bot/config"
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/metrics"
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Database maintenance",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		var err error
+		cfg, err = config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		return nil
+	},
+}
+
+var rotateKeyCmd = &cobra.Command{
+	Use:   "rotate-key",
+	Short: "Re-encrypt stored chat content under the current DB_ENCRYPTION_KEY",
+	Long: `Re-encrypts every stored row that is in plaintext or under an old key:
+message text, digest subscriber chat IDs, stored summaries and digests, and
+the items and events extracted with them. Keep the old key in
+DB_ENCRYPTION_OLD_KEYS until this has run; stop the bot first, or run it again
+afterwards to pick up rows the bot wrote under the old key meanwhile.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRotateKey(cmd.Context(), cfg)
+	},
+}
+
+var rotateSaltCmd = &cobra.Command{
+	Use:   "rotate-salt",
+	Short: "Start a new epoch of the salt behind anonymous user hashes",
+	Long: `Retires the current user hash salt and starts a new one, so members get
+new hashes. Older rows are relinked to a member's new hash when they are next
+seen, as long as the retired salt is kept (RETENTION_DAYS). A running bot picks
+the new salt up within an hour.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRotateSalt(cmd.Context(), cfg)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(dbCmd)
+	dbCmd.AddCommand(rotateKeyCmd)
+	dbCmd.AddCommand(rotateSaltCmd)
+}
+
+// openDatabase opens the bot's database, encrypting message text and
+// subscription chat IDs when a key is configured. Without a key it refuses a
+// database that already holds encrypted values, which could not be read.
+func openDatabase(ctx context.Context, cfg *config.Config, m *metrics.Metrics) (*db.DB, error) {
+	database, err := db.New(cfg.DBPath, m)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.DBEncryptionKey == "" {
+		encrypted, err := database.HasEncryptedData(ctx)
+		if err == nil && encrypted {
+			err = fmt.Errorf("database holds encrypted data but DB_ENCRYPTION_KEY (or DB_ENCRYPTION_KEY_FILE) is not set")
+		}
+		if err != nil {
+			_ = database.Close()
+			return nil, err
+		}
+		return database, nil
+	}
+	c, err := db.NewCipher(cfg.DBEncryptionKey, cfg.DBEncryptionOldKeys...)
+	if err != nil {
+		_ = database.Close()
+		return nil, err
+	}
+	database.SetCipher(c)
+	return database, nil
+}
+
+func runRotateKey(ctx context.Context, cfg *config.Config) error {
+	if cfg.DBEncryptionKey == "" {
+		return fmt.Errorf("DB_ENCRYPTION_KEY (or DB_ENCRYPTION_KEY_FILE) is not set")
+	}
+	database, err := openDatabase(ctx, cfg, metrics.New())
+	if err != nil {
+		return fmt.Errorf("open database %q: %w", cfg.DBPath, err)
+	}
+	defer func() { _ = database.Close() }()
+
+	for _, table := range db.EncryptedTables() {
+		n, err := database.RotateKey(ctx, table)
+		if err != nil {
+			return fmt.Errorf("rotate key (%d %s rows re-encrypted before the failure): %w", n, table, err)
+		}
+		fmt.Printf("Re-encrypted %d %s rows.\n", n, table)
+	}
+	return nil
+}
+
+func runRotateSalt(ctx context.Context, cfg *config.Config) error {
+	database, err := openDatabase(ctx, cfg, metrics.New())
+	if err != nil {
+		return fmt.Errorf("open database %q: %w", cfg.DBPath, err)
+	}
+	defer func() { _ = database.Close() }()
+
+	next, err := database.RotateUserHashSalt(ctx)
+	if err != nil {
+		return fmt.Errorf("rotate salt: %w", err)
+	}
+	fmt.Printf("Rotated user hash salt to epoch %d.\n", next.Epoch)
+	return nil
+}
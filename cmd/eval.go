@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"telegram_summarize_bot/config"
+	"telegram_summarize_bot/eval"
+	"telegram_summarize_bot/provider"
+)
+
+var (
+	evalFixturesDir string
+	evalConfigPaths []string
+	evalReplay      bool
+)
+
+var evalCmd = &cobra.Command{
+	Use:   "eval",
+	Short: "Summarize recorded conversation fixtures and check the results",
+	Long: `Runs the topic-summary pipeline over every *.jsonl fixture in --fixtures
+and checks structural invariants: every message clustered once, topic links
+pointing into their own topic, topic count within topic_max, no leaked user
+hashes. Pass --config twice to compare two prompt/model configurations side
+by side. With --replay (or "replay": true in a config) each fixture's
+recorded <name>.responses.jsonl is served instead of calling the LLM.
+
+Exits non-zero when any fixture fails.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fixtures, err := eval.LoadFixtures(evalFixturesDir)
+		if err != nil {
+			return err
+		}
+
+		configs := make([]eval.Config, 0, len(evalConfigPaths))
+		for _, path := range evalConfigPaths {
+			c, err := eval.LoadConfig(path)
+			if err != nil {
+				return err
+			}
+			configs = append(configs, c)
+		}
+		if len(configs) == 0 {
+			configs = append(configs, eval.Config{Name: "default"})
+		}
+
+		var client provider.LLMClient
+		for i := range configs {
+			configs[i].Replay = configs[i].Replay || evalReplay
+			if configs[i].Replay || client != nil {
+				continue
+			}
+			// A live run uses the bot's provider and its settings as defaults.
+			c, err := config.Load()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			if client, err = provider.New(c, nil); err != nil {
+				return fmt.Errorf("failed to initialize LLM provider: %w", err)
+			}
+			for j := range configs {
+				if configs[j].Model == "" {
+					configs[j].Model = c.Model
+				}
+				if configs[j].TopicMax == 0 {
+					configs[j].TopicMax = c.TopicMax
+				}
+				if configs[j].ReplyThreads == nil {
+					configs[j].ReplyThreads = &c.ReplyThreads
+				}
+			}
+		}
+
+		results := make([][]eval.Result, len(configs))
+		failed := 0
+		for i, c := range configs {
+			results[i] = eval.Run(cmd.Context(), c, client, fixtures)
+			for _, r := range results[i] {
+				if !r.Passed() {
+					failed++
+				}
+			}
+		}
+		fmt.Println(eval.FormatReport(configs, results))
+
+		if failed > 0 {
+			return fmt.Errorf("%d of %d runs failed", failed, len(configs)*len(fixtures))
+		}
+		return nil
+	},
+}
+
+func init() {
+	evalCmd.Flags().StringVar(&evalFixturesDir, "fixtures", "", "directory of *.jsonl conversation fixtures (required)")
+	evalCmd.Flags().StringArrayVar(&evalConfigPaths, "config", nil, "JSON prompt/model configuration; repeat to compare")
+	evalCmd.Flags().BoolVar(&evalReplay, "replay", false, "serve recorded responses instead of calling the LLM")
+	_ = evalCmd.MarkFlagRequired("fixtures")
+	rootCmd.AddCommand(evalCmd)
+}
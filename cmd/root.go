@@ -10,7 +10,6 @@ import (
 
 	"github.com/spf13/cobra"
 	"telegram_summarize_bot/config"
-	"telegram_summarize_bot/db"
 	"telegram_summarize_bot/handlers"
 	"telegram_summarize_bot/logger"
 	"telegram_summarize_bot/metrics"
@@ -71,7 +70,7 @@ func resolveVision(cfg *config.Config, llmClient provider.LLMClient) (enabled bo
 func runBot(ctx context.Context, cfg *config.Config) error {
 	m := metrics.New()
 
-	database, err := db.New(cfg.DBPath, m)
+	database, err := openDatabase(ctx, cfg, m)
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
@@ -96,6 +95,7 @@ func runBot(ctx context.Context, cfg *config.Config) error {
 		Int("topic_max", cfg.TopicMax).
 		Int("rate_limit_sec", cfg.RateLimitSec).
 		Str("model", cfg.Model).
+		Bool("db_encryption", cfg.DBEncryptionKey != "").
 		Msg("Configuration loaded")
 
 	llmClient, err := provider.New(cfg, database)
@@ -104,7 +104,8 @@ func runBot(ctx context.Context, cfg *config.Config) error {
 	}
 
 	sum := summarizer.New(llmClient, cfg.Model, m, cfg.ReplyThreads).
-		WithReplyThreadDepth(cfg.ReplyThreadContextDepth)
+		WithReplyThreadDepth(cfg.ReplyThreadContextDepth).
+		WithRedactor(summarizer.NewRedactor(cfg.PIIPatterns), cfg.PIIRedaction)
 
 	initCtx, initCancel := context.WithTimeout(ctx, 10*time.Second)
 	tgBot, err := handlers.NewBot(initCtx, cfg, database, sum, m, llmClient)
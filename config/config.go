@@ -3,11 +3,13 @@ package config
 import (
 	"fmt"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
+	"telegram_summarize_bot/i18n"
 	"telegram_summarize_bot/logger"
 )
 
@@ -18,6 +20,7 @@ const (
 	LLMModeCompletions LLMMode = "completions" // OpenAI Chat Completions API (default)
 	LLMModeResponses   LLMMode = "responses"   // OpenAI Responses API
 	LLMModeOAuth       LLMMode = "oauth"       // OpenAI Codex subscription via OAuth
+	LLMModeReplay      LLMMode = "replay"      // serve responses from a recorded LLM_CASSETTE
 )
 
 const defaultOAuthClientID = "app_EMoamEEZ73f0CkXaXp7hrann" // Codex CLI well-known client ID
@@ -32,28 +35,69 @@ const (
 	VisionEnabledFalse VisionEnabled = "false" // force off
 )
 
+// StructuredOutput selects how clustering and summary calls get the model to
+// answer in their JSON schema.
+type StructuredOutput string
+
+const (
+	// StructuredJSONSchema uses the API's native schema support: response_format
+	// json_schema on Chat Completions, text.format on the Responses API.
+	StructuredJSONSchema StructuredOutput = "json_schema"
+	// StructuredTool forces a call to a tool taking the schema as parameters,
+	// for Chat Completions providers with tools but no response_format. The
+	// Responses API uses text.format either way.
+	StructuredTool StructuredOutput = "tool"
+	// StructuredOff sends no schema; the prompt alone asks for JSON.
+	StructuredOff StructuredOutput = "off"
+)
+
 type Config struct {
 	BotToken                 string
 	LLMMode                  LLMMode
 	LLMToken                 string
 	LLMEndpoint              string
+	LLMCassette              string // recorded LLM calls: appended to in API modes, served in replay mode
+	LLMCassetteRedact        bool   // keep message text out of recorded requests
+	LLMStructuredOutput      StructuredOutput
+	LLMCacheControl          bool // send cache_control markers (completions mode)
 	Model                    string
 	SummaryHours             int
 	RetentionDays            int
+	UserHashRotateDays       int // rotate the user hash salt this often; 0 = never
 	MaxMessages              int
 	TopicMax                 int
 	RateLimitSec             int
+	RateLimitBurst           int  // requests a group may make back to back
+	RateLimitUserSec         int  // per-user refill interval; 0 disables per-user limits
+	RateLimitUserBurst       int  // requests a user may make back to back
+	RateLimitCostSummary     int  // tokens a full summary or catch-up takes
+	RateLimitCostReply       int  // tokens a reply summary takes
+	RateLimitCostURL         int  // tokens a reply summary with links takes
+	RateLimitQueue           bool // queue limited requests instead of refusing them
+	RateLimitQueueMaxSec     int  // longest a queued request waits before it is refused
+	JobWorkers               int  // summary jobs run at once
+	JobMaxAttempts           int  // runs a failing summary job gets before it is given up
+	StreamTLDR               bool // show the TL;DR in the status message as it is written
 	DBPath                   string
+	DBEncryptionKey          string   // encrypts stored message text; empty => plaintext
+	DBEncryptionOldKeys      []string // retired keys still accepted for reading
 	AllowedGroups            []int64
 	AdminUserIDs             []int64
 	DailySummaryHour         int
 	ReplyThreads             bool
+	PIIRedaction             bool         // redact PII from chat content sent to the LLM
+	PIIPatterns              []PIIPattern // custom redaction rules on top of the built-in ones
+	DigestStats              bool         // add conversation statistics to the daily digest
+	ActionItems              bool         // extract decisions, action items and open questions with each summary
+	CalendarEvents           bool         // extract calendar events with each summary, offered as .ics files
 	ReplyThreadContextDepth  int
 	URLMaxChars              int
 	ReplyMinChars            int
 	ReplyChainMaxDepth       int
 	ReplyChainMaxLinks       int
 	ReplyChainMaxImages      int
+	ReplyTreeMaxDepth        int
+	ReplyTreeMaxMessages     int
 	OAuthTokenDir            string
 	OAuthClientID            string
 	OAuthCodexVersion        string
@@ -67,6 +111,12 @@ type Config struct {
 	LLMHTTPTimeoutSec        int
 	CodexQuotaTTLSec         int
 	ModelContextTokens       int // optional override for context-window utilization; 0 => auto
+	// Language is the summary language for groups without their own setting
+	// and the language of the admin DM UI (auto => Russian there).
+	Language i18n.Lang
+	// Timezone resolves relative dates ("tomorrow at 7") for groups without
+	// their own timezone; UTC by default.
+	Timezone *time.Location
 }
 
 func Load() (*Config, error) {
@@ -100,6 +150,22 @@ func Load() (*Config, error) {
 		}
 	}
 
+	llmCassette := strings.TrimSpace(os.Getenv("LLM_CASSETTE"))
+	llmCassetteRedact := false
+	if v := strings.TrimSpace(strings.ToLower(os.Getenv("LLM_CASSETTE_REDACT"))); v == "true" || v == "1" || v == "yes" || v == "on" {
+		llmCassetteRedact = true
+	}
+
+	structuredOutput := StructuredJSONSchema
+	if v := strings.TrimSpace(strings.ToLower(os.Getenv("LLM_STRUCTURED_OUTPUT"))); v != "" {
+		switch StructuredOutput(v) {
+		case StructuredJSONSchema, StructuredTool, StructuredOff:
+			structuredOutput = StructuredOutput(v)
+		default:
+			return nil, fmt.Errorf("config: unknown LLM_STRUCTURED_OUTPUT: %q (valid: json_schema, tool, off)", v)
+		}
+	}
+
 	// Validate and set defaults based on mode
 	switch llmMode {
 	case LLMModeCompletions:
@@ -120,8 +186,19 @@ func Load() (*Config, error) {
 		if llmEndpoint == "" {
 			llmEndpoint = "https://api.openai.com/v1"
 		}
+	case LLMModeReplay:
+		if llmCassette == "" {
+			return nil, &ConfigError{Field: "LLM_CASSETTE"}
+		}
 	default:
-		return nil, fmt.Errorf("config: unknown LLM_MODE: %q (valid: completions, responses, oauth)", llmMode)
+		return nil, fmt.Errorf("config: unknown LLM_MODE: %q (valid: completions, responses, oauth, replay)", llmMode)
+	}
+
+	// OpenRouter forwards cache_control markers; other OpenAI-compatible
+	// endpoints may reject them, so they are opt-in there.
+	llmCacheControl := strings.Contains(llmEndpoint, "openrouter.ai")
+	if v := strings.TrimSpace(strings.ToLower(os.Getenv("LLM_CACHE_CONTROL"))); v != "" {
+		llmCacheControl = v == "true" || v == "1" || v == "yes" || v == "on"
 	}
 
 	model := os.Getenv("MODEL")
@@ -134,6 +211,11 @@ func Load() (*Config, error) {
 		dbPath = "./data/bot.db"
 	}
 
+	dbEncryptionKey, dbEncryptionOldKeys, err := loadDBEncryptionKeys()
+	if err != nil {
+		return nil, err
+	}
+
 	oauthTokenDir := os.Getenv("OAUTH_TOKEN_DIR")
 	if oauthTokenDir == "" {
 		oauthTokenDir = "./data"
@@ -168,6 +250,27 @@ func Load() (*Config, error) {
 		replyThreads = false
 	}
 
+	piiRedaction := false
+	if v := strings.TrimSpace(strings.ToLower(os.Getenv("PII_REDACTION"))); v == "true" || v == "1" || v == "yes" || v == "on" {
+		piiRedaction = true
+	}
+	digestStats := false
+	if v := strings.TrimSpace(strings.ToLower(os.Getenv("DIGEST_STATS"))); v == "true" || v == "1" || v == "yes" || v == "on" {
+		digestStats = true
+	}
+	actionItems := false
+	if v := strings.TrimSpace(strings.ToLower(os.Getenv("ACTION_ITEMS"))); v == "true" || v == "1" || v == "yes" || v == "on" {
+		actionItems = true
+	}
+	calendarEvents := false
+	if v := strings.TrimSpace(strings.ToLower(os.Getenv("CALENDAR_EVENTS"))); v == "true" || v == "1" || v == "yes" || v == "on" {
+		calendarEvents = true
+	}
+	piiPatterns, err := parsePIIPatterns(os.Getenv("PII_PATTERNS"))
+	if err != nil {
+		return nil, err
+	}
+
 	visionEnabled := VisionEnabledAuto
 	switch strings.TrimSpace(strings.ToLower(os.Getenv("VISION_ENABLED"))) {
 	case "true", "1", "yes", "on":
@@ -176,6 +279,35 @@ func Load() (*Config, error) {
 		visionEnabled = VisionEnabledFalse
 	}
 
+	rateLimitSec := envIntOr("RATE_LIMIT_SEC", 60)
+	rateLimitQueue := false
+	if v := strings.TrimSpace(strings.ToLower(os.Getenv("RATE_LIMIT_QUEUE"))); v == "true" || v == "1" || v == "yes" || v == "on" {
+		rateLimitQueue = true
+	}
+
+	streamTLDR := false
+	if v := strings.TrimSpace(strings.ToLower(os.Getenv("STREAM_TLDR"))); v == "true" || v == "1" || v == "yes" || v == "on" {
+		streamTLDR = true
+	}
+
+	language := i18n.Default
+	if v := os.Getenv("BOT_LANGUAGE"); strings.TrimSpace(v) != "" {
+		parsed, ok := i18n.Parse(v)
+		if !ok {
+			return nil, fmt.Errorf("config: unknown BOT_LANGUAGE: %q (valid: ru, en, auto)", v)
+		}
+		language = parsed
+	}
+
+	timezone := time.UTC
+	if v := strings.TrimSpace(os.Getenv("TIMEZONE")); v != "" {
+		loc, err := time.LoadLocation(v)
+		if err != nil {
+			return nil, fmt.Errorf("config: unknown TIMEZONE: %q (want an IANA name like Europe/Moscow)", v)
+		}
+		timezone = loc
+	}
+
 	visionSteering := true
 	if v := strings.TrimSpace(strings.ToLower(os.Getenv("VISION_STEERING"))); v == "false" || v == "0" || v == "no" || v == "off" {
 		visionSteering = false
@@ -186,23 +318,48 @@ func Load() (*Config, error) {
 		LLMMode:                  llmMode,
 		LLMToken:                 llmToken,
 		LLMEndpoint:              llmEndpoint,
+		LLMCassette:              llmCassette,
+		LLMCassetteRedact:        llmCassetteRedact,
+		LLMStructuredOutput:      structuredOutput,
+		LLMCacheControl:          llmCacheControl,
 		Model:                    model,
 		SummaryHours:             envIntOr("SUMMARY_HOURS", 24),
 		RetentionDays:            envIntOr("RETENTION_DAYS", 7),
+		UserHashRotateDays:       envIntOr("USER_HASH_ROTATE_DAYS", 0),
 		MaxMessages:              envIntOr("MAX_MESSAGES", 250),
 		TopicMax:                 envIntOr("TOPIC_MAX", 5),
-		RateLimitSec:             envIntOr("RATE_LIMIT_SEC", 60),
+		RateLimitSec:             rateLimitSec,
+		RateLimitBurst:           envIntOr("RATE_LIMIT_BURST", 1),
+		RateLimitUserSec:         envIntOr("RATE_LIMIT_USER_SEC", rateLimitSec),
+		RateLimitUserBurst:       envIntOr("RATE_LIMIT_USER_BURST", 1),
+		RateLimitCostSummary:     envIntOr("RATE_LIMIT_COST_SUMMARY", 1),
+		RateLimitCostReply:       envIntOr("RATE_LIMIT_COST_REPLY", 1),
+		RateLimitCostURL:         envIntOr("RATE_LIMIT_COST_URL", 1),
+		RateLimitQueue:           rateLimitQueue,
+		RateLimitQueueMaxSec:     envIntOr("RATE_LIMIT_QUEUE_MAX_SEC", 300),
+		JobWorkers:               envIntOr("JOB_WORKERS", 2),
+		JobMaxAttempts:           envIntOr("JOB_MAX_ATTEMPTS", 3),
+		StreamTLDR:               streamTLDR,
 		DBPath:                   dbPath,
+		DBEncryptionKey:          dbEncryptionKey,
+		DBEncryptionOldKeys:      dbEncryptionOldKeys,
 		AllowedGroups:            allowedGroups,
 		AdminUserIDs:             adminUserIDs,
 		DailySummaryHour:         dailySummaryHour,
 		ReplyThreads:             replyThreads,
+		PIIRedaction:             piiRedaction,
+		PIIPatterns:              piiPatterns,
+		DigestStats:              digestStats,
+		ActionItems:              actionItems,
+		CalendarEvents:           calendarEvents,
 		ReplyThreadContextDepth:  envIntOr("REPLY_THREAD_CONTEXT_DEPTH", 3),
 		URLMaxChars:              envIntOr("URL_MAX_CHARS", 64000),
 		ReplyMinChars:            envIntOr("REPLY_SUMMARIZE_MIN_CHARS", 1000),
 		ReplyChainMaxDepth:       envIntOr("REPLY_CHAIN_MAX_DEPTH", 25),
 		ReplyChainMaxLinks:       envIntOr("REPLY_CHAIN_MAX_LINKS", 5),
 		ReplyChainMaxImages:      envIntOr("REPLY_CHAIN_MAX_IMAGES", 8),
+		ReplyTreeMaxDepth:        envIntOr("REPLY_TREE_MAX_DEPTH", 10),
+		ReplyTreeMaxMessages:     envIntOr("REPLY_TREE_MAX_MESSAGES", 80),
 		OAuthTokenDir:            oauthTokenDir,
 		OAuthClientID:            oauthClientID,
 		OAuthCodexVersion:        oauthCodexVersion,
@@ -216,6 +373,8 @@ func Load() (*Config, error) {
 		LLMHTTPTimeoutSec:        envIntOr("LLM_HTTP_TIMEOUT_SEC", 180),
 		CodexQuotaTTLSec:         envIntOr("CODEX_QUOTA_TTL_SEC", 900),
 		ModelContextTokens:       envIntOr("MODEL_CONTEXT_TOKENS", 0),
+		Language:                 language,
+		Timezone:                 timezone,
 	}, nil
 }
 
@@ -237,10 +396,57 @@ func (c *Config) SummaryDuration() time.Duration {
 	return time.Duration(c.SummaryHours) * time.Hour
 }
 
+// GroupSettings are the summary settings a group can override. The global
+// values come from Config.GroupDefaults; per-group overrides are stored in
+// the DB (see db.GroupSettingsOverrides).
+type GroupSettings struct {
+	TopicMax       int
+	SummaryHours   int
+	MaxMessages    int
+	RateLimitSec   int
+	ReplyThreads   bool
+	PIIRedaction   bool
+	DigestStats    bool
+	ActionItems    bool
+	CalendarEvents bool
+}
+
+// GroupDefaults returns the global (env) values of the per-group settings.
+func (c *Config) GroupDefaults() GroupSettings {
+	return GroupSettings{
+		TopicMax:       c.TopicMax,
+		SummaryHours:   c.SummaryHours,
+		MaxMessages:    c.MaxMessages,
+		RateLimitSec:   c.RateLimitSec,
+		ReplyThreads:   c.ReplyThreads,
+		PIIRedaction:   c.PIIRedaction,
+		DigestStats:    c.DigestStats,
+		ActionItems:    c.ActionItems,
+		CalendarEvents: c.CalendarEvents,
+	}
+}
+
+func (s GroupSettings) SummaryDuration() time.Duration {
+	return time.Duration(s.SummaryHours) * time.Hour
+}
+
+func (s GroupSettings) RateLimit() time.Duration {
+	return time.Duration(s.RateLimitSec) * time.Second
+}
+
 func (c *Config) RetentionDuration() time.Duration {
 	return time.Duration(c.RetentionDays) * 24 * time.Hour
 }
 
+// UserHashRotateInterval is how often the user hash salt is rotated; 0 means
+// never.
+func (c *Config) UserHashRotateInterval() time.Duration {
+	if c.UserHashRotateDays <= 0 {
+		return 0
+	}
+	return time.Duration(c.UserHashRotateDays) * 24 * time.Hour
+}
+
 // ImageCacheDuration is the retention window for cached image descriptions.
 func (c *Config) ImageCacheDuration() time.Duration {
 	return time.Duration(c.ImageCacheDays) * 24 * time.Hour
@@ -284,6 +490,75 @@ func envIntOr(key string, def int) int {
 	return def
 }
 
+// loadDBEncryptionKeys reads the message-encryption secrets: the current one
+// from DB_ENCRYPTION_KEY or the first line of DB_ENCRYPTION_KEY_FILE, and
+// retired ones from DB_ENCRYPTION_OLD_KEYS (comma-separated) plus the file's
+// remaining lines.
+func loadDBEncryptionKeys() (current string, old []string, err error) {
+	current = strings.TrimSpace(os.Getenv("DB_ENCRYPTION_KEY"))
+	if path := strings.TrimSpace(os.Getenv("DB_ENCRYPTION_KEY_FILE")); path != "" {
+		if current != "" {
+			return "", nil, fmt.Errorf("config: set DB_ENCRYPTION_KEY or DB_ENCRYPTION_KEY_FILE, not both")
+		}
+		// #nosec G304 -- the path is the operator's own configuration
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", nil, fmt.Errorf("config: read DB_ENCRYPTION_KEY_FILE: %w", err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(line); line == "" {
+				continue
+			}
+			if current == "" {
+				current = line
+			} else {
+				old = append(old, line)
+			}
+		}
+		if current == "" {
+			return "", nil, fmt.Errorf("config: DB_ENCRYPTION_KEY_FILE %q is empty", path)
+		}
+	}
+	for _, key := range strings.Split(os.Getenv("DB_ENCRYPTION_OLD_KEYS"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			old = append(old, key)
+		}
+	}
+	if current == "" && len(old) > 0 {
+		return "", nil, fmt.Errorf("config: DB_ENCRYPTION_OLD_KEYS needs a current DB_ENCRYPTION_KEY")
+	}
+	return current, old, nil
+}
+
+// PIIPattern is a custom PII redaction rule: matches of Pattern are replaced
+// with "[Name_n]" placeholders.
+type PIIPattern struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// piiPatternName is the optional "NAME=" prefix of a PII_PATTERNS entry.
+var piiPatternName = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9_]*)=(.+)$`)
+
+// parsePIIPatterns parses PII_PATTERNS: whitespace-separated regular
+// expressions, each optionally prefixed with "NAME=" to label its
+// placeholders (PII otherwise). Use \s for a space inside a pattern.
+func parsePIIPatterns(value string) ([]PIIPattern, error) {
+	var patterns []PIIPattern
+	for _, field := range strings.Fields(value) {
+		name, expr := "PII", field
+		if m := piiPatternName.FindStringSubmatch(field); m != nil {
+			name, expr = strings.ToUpper(m[1]), m[2]
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("config: invalid PII_PATTERNS entry %q: %w", field, err)
+		}
+		patterns = append(patterns, PIIPattern{Name: name, Pattern: re})
+	}
+	return patterns, nil
+}
+
 func parseIDList(value string) []int64 {
 	if value == "" {
 		return nil
@@ -2,8 +2,13 @@ package config
 
 import (
 	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
+
+	"telegram_summarize_bot/i18n"
 )
 
 // allEnvKeys lists every environment variable that Load() reads.
@@ -13,24 +18,50 @@ var allEnvKeys = []string{
 	"LLM_MODE",
 	"LLM_TOKEN",
 	"LLM_ENDPOINT",
+	"LLM_CASSETTE",
+	"LLM_CASSETTE_REDACT",
+	"LLM_STRUCTURED_OUTPUT",
+	"LLM_CACHE_CONTROL",
 	"OPENROUTER_API_KEY",
 	"OPENROUTER_URL",
 	"MODEL",
 	"DB_PATH",
+	"DB_ENCRYPTION_KEY",
+	"DB_ENCRYPTION_KEY_FILE",
+	"DB_ENCRYPTION_OLD_KEYS",
 	"ALLOWED_GROUPS",
 	"ADMIN_USER_IDS",
 	"ALERT_USER_IDS",
 	"SUMMARY_HOURS",
 	"RETENTION_DAYS",
+	"USER_HASH_ROTATE_DAYS",
 	"MAX_MESSAGES",
 	"TOPIC_MAX",
 	"RATE_LIMIT_SEC",
+	"RATE_LIMIT_BURST",
+	"RATE_LIMIT_USER_SEC",
+	"RATE_LIMIT_USER_BURST",
+	"RATE_LIMIT_COST_SUMMARY",
+	"RATE_LIMIT_COST_REPLY",
+	"RATE_LIMIT_COST_URL",
+	"RATE_LIMIT_QUEUE",
+	"RATE_LIMIT_QUEUE_MAX_SEC",
+	"JOB_WORKERS",
+	"JOB_MAX_ATTEMPTS",
+	"STREAM_TLDR",
 	"DAILY_SUMMARY_HOUR",
 	"REPLY_THREADS",
+	"PII_REDACTION",
+	"DIGEST_STATS",
+	"ACTION_ITEMS",
+	"CALENDAR_EVENTS",
+	"PII_PATTERNS",
 	"URL_MAX_CHARS",
 	"OAUTH_TOKEN_DIR",
 	"OAUTH_CLIENT_ID",
 	"OAUTH_CODEX_VERSION",
+	"BOT_LANGUAGE",
+	"TIMEZONE",
 }
 
 func clearEnv(t *testing.T) {
@@ -131,11 +162,26 @@ func TestLoad_Defaults(t *testing.T) {
 		{"DBPath", cfg.DBPath, "./data/bot.db"},
 		{"SummaryHours", cfg.SummaryHours, 24},
 		{"RetentionDays", cfg.RetentionDays, 7},
+		{"UserHashRotateDays", cfg.UserHashRotateDays, 0},
 		{"MaxMessages", cfg.MaxMessages, 250},
 		{"TopicMax", cfg.TopicMax, 5},
 		{"RateLimitSec", cfg.RateLimitSec, 60},
+		{"RateLimitBurst", cfg.RateLimitBurst, 1},
+		{"RateLimitUserSec", cfg.RateLimitUserSec, 60},
+		{"RateLimitUserBurst", cfg.RateLimitUserBurst, 1},
+		{"RateLimitCostURL", cfg.RateLimitCostURL, 1},
+		{"RateLimitQueue", cfg.RateLimitQueue, false},
+		{"RateLimitQueueMaxSec", cfg.RateLimitQueueMaxSec, 300},
+		{"JobWorkers", cfg.JobWorkers, 2},
+		{"JobMaxAttempts", cfg.JobMaxAttempts, 3},
+		{"StreamTLDR", cfg.StreamTLDR, false},
 		{"DailySummaryHour", cfg.DailySummaryHour, 7},
 		{"ReplyThreads", cfg.ReplyThreads, true},
+		{"PIIRedaction", cfg.PIIRedaction, false},
+		{"DigestStats", cfg.DigestStats, false},
+		{"ActionItems", cfg.ActionItems, false},
+		{"CalendarEvents", cfg.CalendarEvents, false},
+		{"Timezone", cfg.Timezone, time.UTC},
 		{"URLMaxChars", cfg.URLMaxChars, 64000},
 		{"OAuthTokenDir", cfg.OAuthTokenDir, "./data"},
 		{"OAuthClientID", cfg.OAuthClientID, defaultOAuthClientID},
@@ -166,6 +212,7 @@ func TestLoad_CustomValues(t *testing.T) {
 	t.Setenv("ADMIN_USER_IDS", "111,222")
 	t.Setenv("SUMMARY_HOURS", "12")
 	t.Setenv("RETENTION_DAYS", "3")
+	t.Setenv("USER_HASH_ROTATE_DAYS", "30")
 	t.Setenv("MAX_MESSAGES", "500")
 	t.Setenv("TOPIC_MAX", "10")
 	t.Setenv("RATE_LIMIT_SEC", "30")
@@ -200,6 +247,9 @@ func TestLoad_CustomValues(t *testing.T) {
 	if cfg.RetentionDays != 3 {
 		t.Errorf("RetentionDays = %d", cfg.RetentionDays)
 	}
+	if cfg.UserHashRotateInterval() != 30*24*time.Hour {
+		t.Errorf("UserHashRotateInterval = %v", cfg.UserHashRotateInterval())
+	}
 	if cfg.MaxMessages != 500 {
 		t.Errorf("MaxMessages = %d", cfg.MaxMessages)
 	}
@@ -209,6 +259,9 @@ func TestLoad_CustomValues(t *testing.T) {
 	if cfg.RateLimitSec != 30 {
 		t.Errorf("RateLimitSec = %d", cfg.RateLimitSec)
 	}
+	if cfg.RateLimitUserSec != 30 {
+		t.Errorf("RateLimitUserSec = %d, want RATE_LIMIT_SEC", cfg.RateLimitUserSec)
+	}
 	if cfg.DailySummaryHour != 15 {
 		t.Errorf("DailySummaryHour = %d", cfg.DailySummaryHour)
 	}
@@ -299,6 +352,31 @@ func TestLoad_OAuthModeCustomClientID(t *testing.T) {
 	}
 }
 
+func TestLoad_ReplayMode(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("BOT_TOKEN", "test-token")
+	t.Setenv("LLM_MODE", "replay")
+
+	_, err := Load()
+	var cfgErr *ConfigError
+	if !errors.As(err, &cfgErr) || cfgErr.Field != "LLM_CASSETTE" {
+		t.Fatalf("err = %v, want missing LLM_CASSETTE", err)
+	}
+
+	t.Setenv("LLM_CASSETTE", "./data/day.cassette.jsonl")
+	t.Setenv("LLM_CASSETTE_REDACT", "true")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.LLMMode != LLMModeReplay || cfg.LLMToken != "" {
+		t.Errorf("LLMMode = %s, LLMToken = %q; want replay without a token", cfg.LLMMode, cfg.LLMToken)
+	}
+	if cfg.LLMCassette != "./data/day.cassette.jsonl" || !cfg.LLMCassetteRedact {
+		t.Errorf("LLMCassette = %q, LLMCassetteRedact = %v", cfg.LLMCassette, cfg.LLMCassetteRedact)
+	}
+}
+
 func TestLoad_InvalidMode(t *testing.T) {
 	clearEnv(t)
 	t.Setenv("BOT_TOKEN", "test-token")
@@ -311,6 +389,110 @@ func TestLoad_InvalidMode(t *testing.T) {
 	}
 }
 
+func TestLoad_Language(t *testing.T) {
+	clearEnv(t)
+	setRequired(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Language != i18n.Russian {
+		t.Fatalf("default Language = %q, want ru", cfg.Language)
+	}
+
+	t.Setenv("BOT_LANGUAGE", "AUTO")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Language != i18n.Auto {
+		t.Fatalf("Language = %q, want auto", cfg.Language)
+	}
+
+	t.Setenv("BOT_LANGUAGE", "de")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for unknown BOT_LANGUAGE")
+	}
+}
+
+func TestLoad_Timezone(t *testing.T) {
+	clearEnv(t)
+	setRequired(t)
+
+	t.Setenv("TIMEZONE", "Europe/Moscow")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Timezone.String() != "Europe/Moscow" {
+		t.Fatalf("Timezone = %v, want Europe/Moscow", cfg.Timezone)
+	}
+
+	t.Setenv("TIMEZONE", "Mars/Olympus")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for unknown TIMEZONE")
+	}
+}
+
+func TestLoad_StructuredOutput(t *testing.T) {
+	clearEnv(t)
+	setRequired(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.LLMStructuredOutput != StructuredJSONSchema {
+		t.Fatalf("default LLMStructuredOutput = %q, want json_schema", cfg.LLMStructuredOutput)
+	}
+
+	t.Setenv("LLM_STRUCTURED_OUTPUT", "Tool")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.LLMStructuredOutput != StructuredTool {
+		t.Fatalf("LLMStructuredOutput = %q, want tool", cfg.LLMStructuredOutput)
+	}
+
+	t.Setenv("LLM_STRUCTURED_OUTPUT", "grammar")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for unknown LLM_STRUCTURED_OUTPUT")
+	}
+}
+
+func TestLoad_CacheControl(t *testing.T) {
+	clearEnv(t)
+	setRequired(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.LLMCacheControl {
+		t.Fatal("LLMCacheControl should default on for the OpenRouter endpoint")
+	}
+
+	t.Setenv("LLM_ENDPOINT", "http://litellm:4000/v1")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.LLMCacheControl {
+		t.Fatal("LLMCacheControl should default off for other endpoints")
+	}
+
+	t.Setenv("LLM_CACHE_CONTROL", "on")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.LLMCacheControl {
+		t.Fatal("LLM_CACHE_CONTROL=on should turn the markers on")
+	}
+}
+
 func TestLoad_AdminUserIDsFallback(t *testing.T) {
 	clearEnv(t)
 	setRequired(t)
@@ -476,3 +658,116 @@ func TestReplyThreads(t *testing.T) {
 		})
 	}
 }
+
+// --- DigestStats ---
+
+func TestLoad_DigestStats(t *testing.T) {
+	tests := []struct {
+		val  string
+		want bool
+	}{
+		{"", false},
+		{"on", true},
+		{" TRUE ", true},
+		{"1", true},
+		{"off", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.val, func(t *testing.T) {
+			clearEnv(t)
+			setRequired(t)
+			t.Setenv("DIGEST_STATS", tt.val)
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.DigestStats != tt.want || cfg.GroupDefaults().DigestStats != tt.want {
+				t.Errorf("DigestStats = %v, want %v (input %q)", cfg.DigestStats, tt.want, tt.val)
+			}
+		})
+	}
+}
+
+// --- PII redaction ---
+
+func TestLoad_PIIPatterns(t *testing.T) {
+	clearEnv(t)
+	setRequired(t)
+	t.Setenv("PII_REDACTION", "on")
+	t.Setenv("PII_PATTERNS", `ticket=ACME-\d+  \d{4}\s?\d{6}`)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.PIIRedaction {
+		t.Error("PIIRedaction should be on")
+	}
+	if len(cfg.PIIPatterns) != 2 {
+		t.Fatalf("PIIPatterns = %+v, want 2", cfg.PIIPatterns)
+	}
+	if p := cfg.PIIPatterns[0]; p.Name != "TICKET" || !p.Pattern.MatchString("see ACME-42") {
+		t.Errorf("first pattern = %s %v, want TICKET matching ACME-42", p.Name, p.Pattern)
+	}
+	if p := cfg.PIIPatterns[1]; p.Name != "PII" || !p.Pattern.MatchString("4510 123456") {
+		t.Errorf("second pattern = %s %v, want unnamed PII", p.Name, p.Pattern)
+	}
+	if got := cfg.GroupDefaults().PIIRedaction; !got {
+		t.Error("GroupDefaults should carry PIIRedaction")
+	}
+
+	t.Setenv("PII_PATTERNS", "bad=(")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for an invalid PII_PATTERNS regex")
+	}
+}
+
+func TestLoad_DBEncryptionKeys(t *testing.T) {
+	clearEnv(t)
+	setRequired(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.DBEncryptionKey != "" || cfg.DBEncryptionOldKeys != nil {
+		t.Fatalf("encryption should be off by default, got %q %v", cfg.DBEncryptionKey, cfg.DBEncryptionOldKeys)
+	}
+
+	t.Setenv("DB_ENCRYPTION_KEY", "new-secret")
+	t.Setenv("DB_ENCRYPTION_OLD_KEYS", " old-1 ,old-2,")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.DBEncryptionKey != "new-secret" || !reflect.DeepEqual(cfg.DBEncryptionOldKeys, []string{"old-1", "old-2"}) {
+		t.Errorf("keys = %q %v, want new-secret [old-1 old-2]", cfg.DBEncryptionKey, cfg.DBEncryptionOldKeys)
+	}
+
+	// A key file holds the current key first, then old ones.
+	path := filepath.Join(t.TempDir(), "db.key")
+	if err := os.WriteFile(path, []byte("\nfile-new\nfile-old\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DB_ENCRYPTION_KEY_FILE", path)
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error when both DB_ENCRYPTION_KEY and DB_ENCRYPTION_KEY_FILE are set")
+	}
+	t.Setenv("DB_ENCRYPTION_KEY", "")
+	t.Setenv("DB_ENCRYPTION_OLD_KEYS", "")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.DBEncryptionKey != "file-new" || !reflect.DeepEqual(cfg.DBEncryptionOldKeys, []string{"file-old"}) {
+		t.Errorf("keys from file = %q %v, want file-new [file-old]", cfg.DBEncryptionKey, cfg.DBEncryptionOldKeys)
+	}
+
+	t.Setenv("DB_ENCRYPTION_KEY_FILE", "")
+	t.Setenv("DB_ENCRYPTION_OLD_KEYS", "old-1")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for old keys without a current key")
+	}
+}
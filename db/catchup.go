@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// GetLastCatchup returns when the user (by group-scoped UserHash) last got a
+// catch-up summary for the group, or nil if never.
+func (db *DB) GetLastCatchup(ctx context.Context, groupID int64, userHash string) (*time.Time, error) {
+	var t time.Time
+	err := db.conn.QueryRowContext(ctx,
+		`SELECT last_catchup FROM user_catchups WHERE group_id = ? AND user_hash = ?`,
+		groupID, userHash,
+	).Scan(&t)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// SetLastCatchup records the upper bound of the user's latest catch-up.
+func (db *DB) SetLastCatchup(ctx context.Context, groupID int64, userHash string, t time.Time) error {
+	_, err := db.conn.ExecContext(ctx,
+		`INSERT INTO user_catchups (group_id, user_hash, last_catchup) VALUES (?, ?, ?)
+		 ON CONFLICT(group_id, user_hash) DO UPDATE SET last_catchup = excluded.last_catchup`,
+		groupID, userHash, t,
+	)
+	return err
+}
+
+// CleanupOldCatchups drops catch-up marks older than olderThan; by then the
+// messages they point past have been cleaned up too.
+func (db *DB) CleanupOldCatchups(ctx context.Context, olderThan time.Duration) (int64, error) {
+	res, err := db.conn.ExecContext(ctx,
+		`DELETE FROM user_catchups WHERE last_catchup < ?`,
+		time.Now().Add(-olderThan),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
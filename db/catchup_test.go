@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLastCatchup(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	got, err := db.GetLastCatchup(ctx, -100, "aaaa1111")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Fatalf("expected nil for a user without catch-ups, got %v", got)
+	}
+
+	old := time.Now().Add(-30 * 24 * time.Hour).UTC()
+	recent := time.Now().Add(-time.Hour).UTC()
+	if err := db.SetLastCatchup(ctx, -100, "aaaa1111", old); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetLastCatchup(ctx, -100, "aaaa1111", recent); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetLastCatchup(ctx, -100, "bbbb2222", old); err != nil {
+		t.Fatal(err)
+	}
+	got, err = db.GetLastCatchup(ctx, -100, "aaaa1111")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || !got.Equal(recent) {
+		t.Fatalf("last catch-up = %v, want %v", got, recent)
+	}
+	if other, err := db.GetLastCatchup(ctx, -200, "aaaa1111"); err != nil || other != nil {
+		t.Fatalf("catch-up leaked to another group: %v, %v", other, err)
+	}
+
+	purged, err := db.CleanupOldCatchups(ctx, 7*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 1 {
+		t.Fatalf("purged = %d, want 1", purged)
+	}
+}
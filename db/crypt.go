@@ -0,0 +1,304 @@
+package db
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// encryptedPrefix starts every encrypted column value:
+// "enc:<key id>:<base64 nonce+ciphertext>". Whether a row is encrypted is
+// recorded in its own key_id column, never guessed from the value, since chat
+// text can look like anything; plaintext rows (key_id NULL) and encrypted ones
+// live side by side until rotate-key rewrites the old rows.
+const encryptedPrefix = "enc:"
+
+// ErrNoCipherKey is returned when a stored value is encrypted under a key the
+// DB wasn't given.
+var ErrNoCipherKey = errors.New("stored data is encrypted with an unknown key")
+
+// Cipher encrypts chat content and subscribers' chat IDs at rest with
+// AES-256-GCM (see encryptedTables). New values are sealed with the current
+// key; values under any of the old keys can still be read, so keys rotate
+// without downtime (see RotateKey).
+type Cipher struct {
+	current string
+	aeads   map[string]cipher.AEAD // by key id
+}
+
+// NewCipher derives AES keys from the current secret and any old ones still
+// needed for reading.
+func NewCipher(current string, old ...string) (*Cipher, error) {
+	if current == "" {
+		return nil, errors.New("db: empty encryption key")
+	}
+	c := &Cipher{aeads: make(map[string]cipher.AEAD)}
+	for i, secret := range append([]string{current}, old...) {
+		id, aead, err := deriveAEAD(secret)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			c.current = id
+		}
+		c.aeads[id] = aead
+	}
+	return c, nil
+}
+
+// deriveAEAD stretches secret into an AES-256 key with HKDF. The key id is a
+// fingerprint of the derived key, so the same secret always gets the same id
+// and no id has to be configured.
+func deriveAEAD(secret string) (string, cipher.AEAD, error) {
+	key, err := hkdf.Key(sha256.New, []byte(secret), nil, "telegram_summarize_bot message text", 32)
+	if err != nil {
+		return "", nil, fmt.Errorf("db: derive encryption key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", nil, err
+	}
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:4]), aead, nil
+}
+
+// KeyID returns the id of the key new values are sealed with.
+func (c *Cipher) KeyID() string {
+	return c.current
+}
+
+// seal encrypts s under the current key; column is bound in as associated
+// data so a value can't be moved to another column. Empty values stay empty.
+func (c *Cipher) seal(column, s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	aead := c.aeads[c.current]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(s)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(s), []byte(column))
+	return encryptedPrefix + c.current + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// open decrypts a value sealed by seal under any known key.
+func (c *Cipher) open(column, s string) (string, error) {
+	id, payload, ok := strings.Cut(strings.TrimPrefix(s, encryptedPrefix), ":")
+	if !ok {
+		return "", fmt.Errorf("db: malformed encrypted %s", column)
+	}
+	aead, ok := c.aeads[id]
+	if !ok {
+		return "", fmt.Errorf("%w (key id %s)", ErrNoCipherKey, id)
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(payload)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("db: malformed encrypted %s", column)
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(column))
+	if err != nil {
+		return "", fmt.Errorf("db: decrypt %s: %w", column, err)
+	}
+	return string(plain), nil
+}
+
+// SetCipher has the DB encrypt the columns listed in encryptedTables with c
+// when writing and decrypt them on read. Without a cipher values are written
+// in plaintext; encrypted ones then fail to read.
+func (db *DB) SetCipher(c *Cipher) {
+	db.cipher = c
+}
+
+// writeKeyID is the key_id to store alongside values sealed by sealColumn:
+// the current key's id, or NULL when values are written in plaintext.
+func (db *DB) writeKeyID() sql.NullString {
+	if db.cipher == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: db.cipher.current, Valid: true}
+}
+
+// sealColumn encrypts a column value for writing when a cipher is set. The
+// row's key_id must be written as writeKeyID.
+func (db *DB) sealColumn(column, s string) (string, error) {
+	if db.cipher == nil {
+		return s, nil
+	}
+	return db.cipher.seal(column, s)
+}
+
+// openColumn returns a stored column value in plaintext; keyID is the row's
+// key_id, NULL for a value stored in plaintext.
+func (db *DB) openColumn(column, s string, keyID sql.NullString) (string, error) {
+	if !keyID.Valid || s == "" {
+		return s, nil
+	}
+	if db.cipher == nil {
+		return "", fmt.Errorf("%w (key id %s)", ErrNoCipherKey, keyID.String)
+	}
+	return db.cipher.open(column, s)
+}
+
+// openMessage decrypts msg's text and forwarded_from in place.
+func (db *DB) openMessage(msg *Message, keyID sql.NullString) error {
+	var err error
+	if msg.Text, err = db.openColumn("text", msg.Text, keyID); err != nil {
+		return err
+	}
+	msg.ForwardedFrom, err = db.openColumn("forwarded_from", msg.ForwardedFrom, keyID)
+	return err
+}
+
+// encryptedTables lists, in rotation order, the tables with sealed columns.
+// Each has a key_id column naming the key its row was sealed with, NULL for
+// plaintext.
+var encryptedTables = []struct {
+	name    string
+	columns []string
+}{
+	{"messages", []string{"text", "forwarded_from"}},
+	{"digest_subscriptions", []string{"chat_id"}},
+	{"summary_items", []string{"text", "owner"}},
+	{"summary_topics", []string{"title"}},
+	{"summary_events", []string{"title", "place"}},
+	{"digests", []string{"summary"}},
+}
+
+// EncryptedTables returns the tables RotateKey covers, in the order to rotate
+// them.
+func EncryptedTables() []string {
+	names := make([]string, len(encryptedTables))
+	for i, t := range encryptedTables {
+		names[i] = t.name
+	}
+	return names
+}
+
+// HasEncryptedData reports whether any stored row is encrypted, so the bot
+// can refuse to start without a key rather than fail every summary and
+// digest.
+func (db *DB) HasEncryptedData(ctx context.Context) (bool, error) {
+	for _, t := range encryptedTables {
+		var has bool
+		if err := db.conn.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM `+t.name+` WHERE key_id IS NOT NULL)`,
+		).Scan(&has); err != nil || has {
+			return has, err
+		}
+	}
+	return false, nil
+}
+
+// rotateBatchSize bounds how many rows RotateKey holds in memory at once.
+const rotateBatchSize = 500
+
+// RotateKey re-encrypts, under the cipher's current key, every row of the
+// table (one of EncryptedTables) that is in plaintext or under an old key,
+// and returns how many rows it rewrote. Rows are read a batch at a time and
+// rewritten in one transaction per batch, so an interrupted rotation can
+// simply be run again.
+func (db *DB) RotateKey(ctx context.Context, table string) (int64, error) {
+	if db.cipher == nil {
+		return 0, errors.New("db: no encryption key configured")
+	}
+	var columns []string
+	for _, t := range encryptedTables {
+		if t.name == table {
+			columns = t.columns
+		}
+	}
+	if columns == nil {
+		return 0, fmt.Errorf("db: %s has no encrypted columns", table)
+	}
+
+	type row struct {
+		rowID  int64
+		values []sql.NullString
+		keyID  sql.NullString
+	}
+	query := `SELECT rowid, ` + strings.Join(columns, ", ") + `, key_id FROM ` + table + `
+		WHERE rowid > ? AND (key_id IS NULL OR key_id <> ?)
+		ORDER BY rowid LIMIT ?`
+	update := `UPDATE ` + table + ` SET ` + strings.Join(columns, " = ?, ") + ` = ?, key_id = ? WHERE rowid = ?`
+
+	var rotated, lastID int64
+	for {
+		// The batch is read in full before writing: with a single pooled
+		// connection, updating while rows are open would deadlock.
+		rows, err := db.conn.QueryContext(ctx, query, lastID, db.cipher.current, rotateBatchSize)
+		if err != nil {
+			return rotated, err
+		}
+		var batch []row
+		for rows.Next() {
+			r := row{values: make([]sql.NullString, len(columns))}
+			dest := []any{&r.rowID}
+			for i := range r.values {
+				dest = append(dest, &r.values[i])
+			}
+			if err := rows.Scan(append(dest, &r.keyID)...); err != nil {
+				_ = rows.Close()
+				return rotated, err
+			}
+			batch = append(batch, r)
+		}
+		_ = rows.Close()
+		if err := rows.Err(); err != nil {
+			return rotated, err
+		}
+		if len(batch) == 0 {
+			return rotated, nil
+		}
+		lastID = batch[len(batch)-1].rowID
+
+		tx, err := db.conn.BeginTx(ctx, nil)
+		if err != nil {
+			return rotated, err
+		}
+		for _, r := range batch {
+			args := make([]any, 0, len(columns)+2)
+			for i, v := range r.values {
+				if !v.Valid {
+					args = append(args, nil)
+					continue
+				}
+				sealed, err := db.resealColumn(columns[i], v.String, r.keyID)
+				if err != nil {
+					_ = tx.Rollback()
+					return rotated, fmt.Errorf("%s row %d: %w", table, r.rowID, err)
+				}
+				args = append(args, sealed)
+			}
+			if _, err := tx.ExecContext(ctx, update, append(args, db.writeKeyID(), r.rowID)...); err != nil {
+				_ = tx.Rollback()
+				return rotated, err
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return rotated, err
+		}
+		rotated += int64(len(batch))
+	}
+}
+
+func (db *DB) resealColumn(column, s string, keyID sql.NullString) (string, error) {
+	plain, err := db.openColumn(column, s, keyID)
+	if err != nil {
+		return "", err
+	}
+	return db.sealColumn(column, plain)
+}
@@ -0,0 +1,357 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustCipher(t *testing.T, current string, old ...string) *Cipher {
+	t.Helper()
+	c, err := NewCipher(current, old...)
+	if err != nil {
+		t.Fatalf("NewCipher: %v", err)
+	}
+	return c
+}
+
+// rawMessage returns a message's text and forwarded_from as stored.
+func rawMessage(t *testing.T, db *DB, id int64) (text, forwardedFrom string) {
+	t.Helper()
+	if err := db.conn.QueryRow(`SELECT text, COALESCE(forwarded_from, '') FROM messages WHERE id = ?`, id).Scan(&text, &forwardedFrom); err != nil {
+		t.Fatal(err)
+	}
+	return text, forwardedFrom
+}
+
+func TestCipherSealOpen(t *testing.T) {
+	c := mustCipher(t, "secret")
+	sealed, err := c.seal("text", "привет, мир")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(sealed, encryptedPrefix+c.KeyID()+":") || strings.Contains(sealed, "привет") {
+		t.Fatalf("sealed value %q doesn't look encrypted", sealed)
+	}
+	if got, err := c.open("text", sealed); err != nil || got != "привет, мир" {
+		t.Fatalf("open = %q, %v", got, err)
+	}
+	if _, err := c.open("forwarded_from", sealed); err == nil {
+		t.Fatal("a value sealed for one column must not open as another")
+	}
+	if again, _ := c.seal("text", "привет, мир"); again == sealed {
+		t.Fatal("sealing twice should use a fresh nonce")
+	}
+	if empty, _ := c.seal("text", ""); empty != "" {
+		t.Fatalf("empty value sealed to %q", empty)
+	}
+	if mustCipher(t, "secret").KeyID() != c.KeyID() || mustCipher(t, "other").KeyID() == c.KeyID() {
+		t.Fatal("key ids should follow the secret")
+	}
+}
+
+func TestPlaintextShapedLikeCiphertext(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	now := time.Now().UTC()
+
+	// A member posts a real sealed value while encryption is off.
+	lookalike, err := mustCipher(t, "someone else's").seal("text", "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := db.AddMessageReturningID(ctx, &Message{GroupID: -100, UserHash: "aa", Text: lookalike, ForwardedFrom: lookalike, Timestamp: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if has, err := db.HasEncryptedData(ctx); err != nil || has {
+		t.Fatalf("HasEncryptedData = %t, %v; want false", has, err)
+	}
+	msgs, err := db.GetMessages(ctx, -100, now.Add(-time.Hour), 10)
+	if err != nil || len(msgs) != 1 || msgs[0].Text != lookalike || msgs[0].ForwardedFrom != lookalike {
+		t.Fatalf("GetMessages = %+v, %v; want the text as posted", msgs, err)
+	}
+
+	// Turning encryption on encrypts it like any other plaintext.
+	db.SetCipher(mustCipher(t, "secret"))
+	if n, err := db.RotateKey(ctx, "messages"); err != nil || n != 1 {
+		t.Fatalf("RotateKey = %d, %v; want 1", n, err)
+	}
+	if text, _ := rawMessage(t, db, id); text == lookalike {
+		t.Fatal("lookalike left in plaintext by rotation")
+	}
+	msgs, err = db.GetMessages(ctx, -100, now.Add(-time.Hour), 10)
+	if err != nil || len(msgs) != 1 || msgs[0].Text != lookalike {
+		t.Fatalf("GetMessages after rotation = %+v, %v", msgs, err)
+	}
+}
+
+func TestEncryptedMessagesRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	db.SetCipher(mustCipher(t, "secret"))
+	now := time.Now().UTC()
+
+	id, err := db.AddMessageReturningID(ctx, &Message{GroupID: -100, UserHash: "aa", Text: "секретный план", ForwardedFrom: "Иван", Timestamp: now, TgMessageID: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, fwd := rawMessage(t, db, id)
+	if strings.Contains(text, "план") || strings.Contains(fwd, "Иван") {
+		t.Fatalf("stored in plaintext: %q / %q", text, fwd)
+	}
+
+	msgs, err := db.GetMessages(ctx, -100, now.Add(-time.Hour), 10)
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("GetMessages = %v, %v", msgs, err)
+	}
+	if msgs[0].Text != "секретный план" || msgs[0].ForwardedFrom != "Иван" {
+		t.Fatalf("decrypted = %+v", msgs[0])
+	}
+	byTg, err := db.GetMessageByTgID(ctx, -100, 7)
+	if err != nil || byTg == nil || byTg.Text != "секретный план" {
+		t.Fatalf("GetMessageByTgID = %+v, %v", byTg, err)
+	}
+	byIDs, err := db.GetMessagesByIDs(ctx, -100, []int64{id})
+	if err != nil || len(byIDs) != 1 || byIDs[0].Text != "секретный план" {
+		t.Fatalf("GetMessagesByIDs = %+v, %v", byIDs, err)
+	}
+
+	if _, err := db.AddMessageReturningID(ctx, &Message{GroupID: -100, UserHash: "bb", Text: "согласен", Timestamp: now, TgMessageID: 8, ReplyToTgID: 7}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without the key the text can't be read, and isn't returned as ciphertext.
+	db.SetCipher(nil)
+	if _, err := db.GetMessageByTgID(ctx, -100, 7); !errors.Is(err, ErrNoCipherKey) {
+		t.Fatalf("GetMessageByTgID without key: err = %v, want ErrNoCipherKey", err)
+	}
+	if msgs, err := db.GetMessages(ctx, -100, now.Add(-time.Hour), 10); !errors.Is(err, ErrNoCipherKey) || msgs != nil {
+		t.Fatalf("GetMessages without key = %+v, %v; want ErrNoCipherKey", msgs, err)
+	}
+	if msgs, err := db.GetMessagesFrom(ctx, -100, now.Add(-time.Hour), 10); !errors.Is(err, ErrNoCipherKey) || msgs != nil {
+		t.Fatalf("GetMessagesFrom without key = %+v, %v; want ErrNoCipherKey", msgs, err)
+	}
+	if msgs, err := db.GetRepliesTo(ctx, -100, []int64{7}); !errors.Is(err, ErrNoCipherKey) || msgs != nil {
+		t.Fatalf("GetRepliesTo without key = %+v, %v; want ErrNoCipherKey", msgs, err)
+	}
+}
+
+func TestHasEncryptedData(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	now := time.Now().UTC()
+
+	// Plaintext that merely starts like a sealed value doesn't count.
+	if err := db.AddMessage(ctx, &Message{GroupID: -100, UserHash: "aa", Text: "enc: не шифр", Timestamp: now}); err != nil {
+		t.Fatal(err)
+	}
+	if has, err := db.HasEncryptedData(ctx); err != nil || has {
+		t.Fatalf("HasEncryptedData with plaintext = %t, %v", has, err)
+	}
+
+	db.SetCipher(mustCipher(t, "secret"))
+	if err := db.AddMessage(ctx, &Message{GroupID: -100, UserHash: "aa", Text: "секрет", Timestamp: now}); err != nil {
+		t.Fatal(err)
+	}
+	db.SetCipher(nil)
+	if has, err := db.HasEncryptedData(ctx); err != nil || !has {
+		t.Fatalf("HasEncryptedData = %t, %v; want true", has, err)
+	}
+}
+
+func TestRotateKey(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	now := time.Now().UTC()
+	ts := now.Add(-time.Minute)
+	add := func(text, fwd string) int64 {
+		t.Helper()
+		ts = ts.Add(time.Second)
+		id, err := db.AddMessageReturningID(ctx, &Message{GroupID: -100, UserHash: "aa", Text: text, ForwardedFrom: fwd, Timestamp: ts})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+
+	plainID := add("было открыто", "")
+	db.SetCipher(mustCipher(t, "old"))
+	oldID := add("под старым ключом", "Пётр")
+	add("", "")
+
+	// Encrypt the plaintext and move the old key's rows, the empty one too,
+	// to the new one.
+	newCipher := mustCipher(t, "new", "old")
+	db.SetCipher(newCipher)
+	n, err := db.RotateKey(ctx, "messages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Fatalf("rotated %d messages, want 3", n)
+	}
+	for _, id := range []int64{plainID, oldID} {
+		text, _ := rawMessage(t, db, id)
+		if !strings.HasPrefix(text, encryptedPrefix+newCipher.KeyID()+":") {
+			t.Errorf("message %d not under the new key: %q", id, text)
+		}
+	}
+	if n, err := db.RotateKey(ctx, "messages"); err != nil || n != 0 {
+		t.Fatalf("second rotation = %d, %v; want nothing left to do", n, err)
+	}
+
+	// The old key is no longer needed.
+	db.SetCipher(mustCipher(t, "new"))
+	msgs, err := db.GetMessages(ctx, -100, now.Add(-time.Hour), 10)
+	if err != nil || len(msgs) != 3 {
+		t.Fatalf("GetMessages = %v, %v", msgs, err)
+	}
+	if msgs[0].Text != "было открыто" || msgs[1].Text != "под старым ключом" || msgs[1].ForwardedFrom != "Пётр" {
+		t.Fatalf("after rotation = %+v", msgs)
+	}
+}
+
+func TestEncryptedSubscriptionChatID(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	// A subscription from before encryption keeps its plain chat ID.
+	if err := db.SetDigestSubscription(ctx, DigestSubscription{GroupID: -100, UserHash: "aaaa1111", ChatID: 7, Hour: 9}); err != nil {
+		t.Fatal(err)
+	}
+	oldCipher := mustCipher(t, "old")
+	db.SetCipher(oldCipher)
+	if err := db.SetDigestSubscription(ctx, DigestSubscription{GroupID: -100, UserHash: "bbbb2222", ChatID: 123456789, Hour: 9}); err != nil {
+		t.Fatal(err)
+	}
+	raw := func(userHash string) string {
+		t.Helper()
+		var chatID string
+		if err := db.conn.QueryRow(`SELECT chat_id FROM digest_subscriptions WHERE user_hash = ?`, userHash).Scan(&chatID); err != nil {
+			t.Fatal(err)
+		}
+		return chatID
+	}
+	if stored := raw("bbbb2222"); !strings.HasPrefix(stored, encryptedPrefix+oldCipher.KeyID()+":") || strings.Contains(stored, "123456789") {
+		t.Fatalf("chat_id stored as %q, want encrypted", stored)
+	}
+	subs, err := db.GetDigestSubscriptionsAt(ctx, 9, 0)
+	if err != nil || len(subs) != 2 || subs[0].ChatID+subs[1].ChatID != 7+123456789 {
+		t.Fatalf("subscriptions = %+v, %v", subs, err)
+	}
+
+	db.SetCipher(nil)
+	if has, err := db.HasEncryptedData(ctx); err != nil || !has {
+		t.Fatalf("HasEncryptedData = %t, %v; want true for an encrypted chat ID", has, err)
+	}
+	if _, err := db.GetDigestSubscription(ctx, -100, "bbbb2222"); !errors.Is(err, ErrNoCipherKey) {
+		t.Fatalf("GetDigestSubscription without key: %v; want ErrNoCipherKey", err)
+	}
+
+	newCipher := mustCipher(t, "new", "old")
+	db.SetCipher(newCipher)
+	if n, err := db.RotateKey(ctx, "digest_subscriptions"); err != nil || n != 2 {
+		t.Fatalf("RotateKey = %d, %v; want 2", n, err)
+	}
+	for _, userHash := range []string{"aaaa1111", "bbbb2222"} {
+		if stored := raw(userHash); !strings.HasPrefix(stored, encryptedPrefix+newCipher.KeyID()+":") {
+			t.Errorf("subscription %s not under the new key: %q", userHash, stored)
+		}
+	}
+	if n, err := db.RotateKey(ctx, "digest_subscriptions"); err != nil || n != 0 {
+		t.Fatalf("second rotation = %d, %v; want nothing left to do", n, err)
+	}
+	db.SetCipher(mustCipher(t, "new"))
+	if sub, err := db.GetDigestSubscription(ctx, -100, "bbbb2222"); err != nil || sub == nil || sub.ChatID != 123456789 {
+		t.Fatalf("after rotation = %+v, %v", sub, err)
+	}
+}
+
+func TestEncryptedSummaryItems(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	db.SetCipher(mustCipher(t, "old"))
+
+	if _, err := db.SaveSummary(ctx, StoredSummary{GroupID: -100, Lang: "ru", Items: []SummaryItem{
+		{Kind: ItemAction, Text: "позвонить Ивану", Owner: "У1", Due: "пятница", TgMessageID: 5},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	var text, owner string
+	if err := db.conn.QueryRow(`SELECT text, owner FROM summary_items`).Scan(&text, &owner); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(text, "Ивану") || owner == "У1" {
+		t.Fatalf("item stored in plaintext: %q / %q", text, owner)
+	}
+
+	db.SetCipher(nil)
+	if _, err := db.GetSummaryItems(ctx, -100, ItemAction, time.Now().Add(-time.Hour)); !errors.Is(err, ErrNoCipherKey) {
+		t.Fatalf("GetSummaryItems without key: %v; want ErrNoCipherKey", err)
+	}
+
+	db.SetCipher(mustCipher(t, "new", "old"))
+	if n, err := db.RotateKey(ctx, "summary_items"); err != nil || n != 1 {
+		t.Fatalf("RotateKey = %d, %v; want 1", n, err)
+	}
+	db.SetCipher(mustCipher(t, "new"))
+	items, err := db.GetSummaryItems(ctx, -100, ItemAction, time.Now().Add(-time.Hour))
+	if err != nil || len(items) != 1 || items[0].Text != "позвонить Ивану" || items[0].Owner != "У1" || items[0].Due != "пятница" {
+		t.Fatalf("items after rotation = %+v, %v", items, err)
+	}
+}
+
+func TestEncryptedSummariesAndDigests(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	db.SetCipher(mustCipher(t, "secret"))
+	start := time.Date(2026, 3, 13, 19, 0, 0, 0, time.UTC)
+
+	id, err := db.SaveSummary(ctx, StoredSummary{
+		GroupID: -100, Lang: "ru",
+		Topics: []SummaryTopic{{Title: "Переезд Ивана", MessageIDs: []int64{1}}},
+		Events: []SummaryEvent{{Title: "Ужин у Ивана", Place: "Ленина, 5", Start: start, End: start.Add(time.Hour), TgMessageID: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.PutDailyDigest(ctx, DailyDigest{GroupID: -100, WindowEnd: "2026-03-13T08:00", Lang: "ru", Summary: "Иван переезжает", SummaryID: id, CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range []string{
+		`SELECT title FROM summary_topics`,
+		`SELECT title || place FROM summary_events`,
+		`SELECT summary FROM digests`,
+	} {
+		var stored string
+		if err := db.conn.QueryRow(q).Scan(&stored); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(stored, "Иван") || strings.Contains(stored, "Ленина") {
+			t.Errorf("%s: stored in plaintext: %q", q, stored)
+		}
+	}
+
+	s, err := db.GetSummary(ctx, id)
+	if err != nil || s == nil || s.Topics[0].Title != "Переезд Ивана" || s.Events[0].Title != "Ужин у Ивана" || s.Events[0].Place != "Ленина, 5" {
+		t.Fatalf("GetSummary = %+v, %v", s, err)
+	}
+	d, err := db.GetDailyDigest(ctx, -100, "2026-03-13T08:00")
+	if err != nil || d == nil || d.Summary != "Иван переезжает" {
+		t.Fatalf("GetDailyDigest = %+v, %v", d, err)
+	}
+
+	db.SetCipher(nil)
+	if _, err := db.GetSummary(ctx, id); !errors.Is(err, ErrNoCipherKey) {
+		t.Fatalf("GetSummary without key: %v; want ErrNoCipherKey", err)
+	}
+	if _, err := db.GetDailyDigest(ctx, -100, "2026-03-13T08:00"); !errors.Is(err, ErrNoCipherKey) {
+		t.Fatalf("GetDailyDigest without key: %v; want ErrNoCipherKey", err)
+	}
+	if has, err := db.HasEncryptedData(ctx); err != nil || !has {
+		t.Fatalf("HasEncryptedData = %t, %v; want true", has, err)
+	}
+}
@@ -3,7 +3,6 @@ package db
 import (
 	"context"
 	"crypto/hmac"
-	"crypto/rand"
 	"crypto/sha256"
 	"database/sql"
 	"encoding/binary"
@@ -24,6 +23,7 @@ type DB struct {
 	conn    *sql.DB
 	dbPath  string
 	metrics *metrics.Metrics
+	cipher  *Cipher // optional; nil => message text is stored in plaintext
 }
 
 type Message struct {
@@ -90,41 +90,6 @@ func HashString(s string, groupID int64, salt []byte) string {
 	return hex.EncodeToString(mac.Sum(nil))[:8]
 }
 
-// GetUserHashSalt loads the HMAC salt from bot_config, generating and persisting one on first call.
-func (db *DB) GetUserHashSalt(ctx context.Context) ([]byte, error) {
-	hexSalt, err := db.getOrCreateConfig(ctx, "user_hash_salt", func() string {
-		b := make([]byte, 32)
-		if _, err := rand.Read(b); err != nil {
-			panic("crypto/rand unavailable: " + err.Error())
-		}
-		return hex.EncodeToString(b)
-	})
-	if err != nil {
-		return nil, err
-	}
-	return hex.DecodeString(hexSalt)
-}
-
-func (db *DB) getOrCreateConfig(ctx context.Context, key string, defaultFn func() string) (string, error) {
-	var val string
-	err := db.conn.QueryRowContext(ctx, `SELECT value FROM bot_config WHERE key = ?`, key).Scan(&val)
-	if err == nil {
-		return val, nil
-	}
-	if err != sql.ErrNoRows {
-		return "", fmt.Errorf("failed to query bot_config %q: %w", key, err)
-	}
-	val = defaultFn()
-	if _, err := db.conn.ExecContext(ctx, `INSERT OR IGNORE INTO bot_config (key, value) VALUES (?, ?)`, key, val); err != nil {
-		return "", fmt.Errorf("failed to insert bot_config %q: %w", key, err)
-	}
-	// Re-read in case another writer raced us.
-	if err := db.conn.QueryRowContext(ctx, `SELECT value FROM bot_config WHERE key = ?`, key).Scan(&val); err != nil {
-		return "", fmt.Errorf("failed to re-read bot_config %q: %w", key, err)
-	}
-	return val, nil
-}
-
 type KnownGroup struct {
 	GroupID  int64
 	Title    string
@@ -148,6 +113,7 @@ type GroupSummaryInstructions struct {
 	Instructions string
 	UpdatedAt    time.Time
 	UpdatedBy    int64
+	Version      int // latest history version; see GroupSummaryInstructionsVersion
 }
 
 func New(dbPath string, m *metrics.Metrics) (*DB, error) {
@@ -270,6 +236,128 @@ func (db *DB) migrate() error {
 			updated_at   DATETIME NOT NULL,
 			updated_by   INTEGER NOT NULL
 		)`,
+		`CREATE TABLE IF NOT EXISTS group_summary_instruction_versions (
+			id           INTEGER PRIMARY KEY AUTOINCREMENT,
+			group_id     INTEGER  NOT NULL,
+			version      INTEGER  NOT NULL,
+			instructions TEXT     NOT NULL DEFAULT '',
+			created_at   DATETIME NOT NULL,
+			created_by   INTEGER  NOT NULL DEFAULT 0,
+			UNIQUE(group_id, version)
+		)`,
+		// Seed version 1 from instructions saved before history existed.
+		`INSERT INTO group_summary_instruction_versions (group_id, version, instructions, created_at, created_by)
+		 SELECT gsi.group_id, 1, gsi.instructions, gsi.updated_at, gsi.updated_by
+		 FROM group_summary_instructions gsi
+		 WHERE NOT EXISTS (SELECT 1 FROM group_summary_instruction_versions v WHERE v.group_id = gsi.group_id)`,
+		`CREATE TABLE IF NOT EXISTS group_languages (
+			group_id   INTEGER PRIMARY KEY,
+			language   TEXT     NOT NULL,
+			updated_at DATETIME NOT NULL,
+			updated_by INTEGER  NOT NULL DEFAULT 0
+		)`,
+		`CREATE TABLE IF NOT EXISTS group_timezones (
+			group_id   INTEGER PRIMARY KEY,
+			timezone   TEXT     NOT NULL,
+			updated_at DATETIME NOT NULL,
+			updated_by INTEGER  NOT NULL DEFAULT 0
+		)`,
+		`CREATE TABLE IF NOT EXISTS group_settings (
+			group_id       INTEGER PRIMARY KEY,
+			topic_max      INTEGER,
+			summary_hours  INTEGER,
+			max_messages   INTEGER,
+			rate_limit_sec INTEGER,
+			reply_threads  INTEGER,
+			updated_at     DATETIME NOT NULL,
+			updated_by     INTEGER  NOT NULL DEFAULT 0
+		)`,
+		`CREATE TABLE IF NOT EXISTS user_catchups (
+			group_id     INTEGER  NOT NULL,
+			user_hash    TEXT     NOT NULL,
+			last_catchup DATETIME NOT NULL,
+			PRIMARY KEY (group_id, user_hash)
+		)`,
+		`CREATE TABLE IF NOT EXISTS user_hash_salts (
+			epoch      INTEGER  PRIMARY KEY,
+			salt       TEXT     NOT NULL,
+			created_at DATETIME NOT NULL,
+			retired_at DATETIME
+		)`,
+		`CREATE TABLE IF NOT EXISTS user_opt_outs (
+			group_id   INTEGER  NOT NULL,
+			user_hash  TEXT     NOT NULL,
+			created_at DATETIME NOT NULL,
+			PRIMARY KEY (group_id, user_hash)
+		)`,
+		// chat_id is the private chat digests are DMed to, i.e. the member's
+		// user ID: the bot can't deliver without it. It is deleted with the
+		// subscription and, with a cipher set, stored encrypted (TEXT in an
+		// INTEGER column, which SQLite keeps as is) with key_id naming the key;
+		// without one it is stored in plaintext.
+		`CREATE TABLE IF NOT EXISTS digest_subscriptions (
+			group_id   INTEGER  NOT NULL,
+			user_hash  TEXT     NOT NULL,
+			chat_id    INTEGER  NOT NULL,
+			hour       INTEGER  NOT NULL,
+			minute     INTEGER  NOT NULL,
+			last_sent  DATETIME,
+			created_at DATETIME NOT NULL,
+			PRIMARY KEY (group_id, user_hash)
+		)`,
+		// Digests were once shared per UTC day; daily_digests held those.
+		`DROP TABLE IF EXISTS daily_digests`,
+		`CREATE TABLE IF NOT EXISTS digests (
+			group_id   INTEGER  NOT NULL,
+			window_end TEXT     NOT NULL,
+			lang       TEXT     NOT NULL,
+			summary    TEXT     NOT NULL,
+			summary_id INTEGER  NOT NULL DEFAULT 0,
+			created_at DATETIME NOT NULL,
+			PRIMARY KEY (group_id, window_end)
+		)`,
+		`CREATE TABLE IF NOT EXISTS summaries (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			group_id   INTEGER  NOT NULL,
+			lang       TEXT     NOT NULL,
+			created_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS summary_topics (
+			summary_id  INTEGER NOT NULL REFERENCES summaries(id) ON DELETE CASCADE,
+			topic_index INTEGER NOT NULL,
+			title       TEXT    NOT NULL,
+			message_ids TEXT    NOT NULL,
+			PRIMARY KEY (summary_id, topic_index)
+		)`,
+		`CREATE TABLE IF NOT EXISTS summary_items (
+			summary_id    INTEGER NOT NULL REFERENCES summaries(id) ON DELETE CASCADE,
+			item_index    INTEGER NOT NULL,
+			kind          TEXT    NOT NULL,
+			text          TEXT    NOT NULL,
+			owner         TEXT    NOT NULL DEFAULT '',
+			due           TEXT    NOT NULL DEFAULT '',
+			tg_message_id INTEGER,
+			PRIMARY KEY (summary_id, item_index)
+		)`,
+		`CREATE TABLE IF NOT EXISTS summary_events (
+			summary_id    INTEGER  NOT NULL REFERENCES summaries(id) ON DELETE CASCADE,
+			event_index   INTEGER  NOT NULL,
+			title         TEXT     NOT NULL,
+			place         TEXT     NOT NULL DEFAULT '',
+			starts_at     DATETIME NOT NULL,
+			ends_at       DATETIME NOT NULL,
+			all_day       INTEGER  NOT NULL DEFAULT 0,
+			timezone      TEXT     NOT NULL,
+			tg_message_id INTEGER,
+			PRIMARY KEY (summary_id, event_index)
+		)`,
+		`CREATE TABLE IF NOT EXISTS summary_feedback (
+			summary_id INTEGER  NOT NULL REFERENCES summaries(id) ON DELETE CASCADE,
+			user_hash  TEXT     NOT NULL,
+			vote       INTEGER  NOT NULL,
+			created_at DATETIME NOT NULL,
+			PRIMARY KEY (summary_id, user_hash)
+		)`,
 		`CREATE TABLE IF NOT EXISTS message_photos (
 			id             INTEGER PRIMARY KEY AUTOINCREMENT,
 			message_id     INTEGER NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
@@ -303,6 +391,23 @@ func (db *DB) migrate() error {
 			total_tokens      INTEGER  NOT NULL
 		)`,
 		`CREATE INDEX IF NOT EXISTS idx_token_usage_ts ON token_usage(ts)`,
+		`CREATE TABLE IF NOT EXISTS jobs (
+			id            INTEGER PRIMARY KEY AUTOINCREMENT,
+			kind          TEXT     NOT NULL,
+			group_id      INTEGER  NOT NULL,
+			dedupe_key    TEXT     NOT NULL DEFAULT '',
+			payload       TEXT     NOT NULL DEFAULT '',
+			status_msg_id INTEGER  NOT NULL DEFAULT 0,
+			lang          TEXT     NOT NULL DEFAULT '',
+			state         TEXT     NOT NULL,
+			attempts      INTEGER  NOT NULL DEFAULT 0,
+			resumed       INTEGER  NOT NULL DEFAULT 0,
+			next_run_at   DATETIME NOT NULL,
+			last_error    TEXT     NOT NULL DEFAULT '',
+			created_at    DATETIME NOT NULL,
+			updated_at    DATETIME NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_jobs_state_next ON jobs(state, next_run_at)`,
 	}
 
 	for _, q := range queries {
@@ -317,6 +422,19 @@ func (db *DB) migrate() error {
 		{"known_groups", "username", "TEXT NOT NULL DEFAULT ''"},
 		{"messages", "tg_message_id", "INTEGER"},
 		{"messages", "reply_to_tg_id", "INTEGER"},
+		{"summaries", "model", "TEXT NOT NULL DEFAULT ''"},
+		{"summaries", "instructions_version", "INTEGER NOT NULL DEFAULT 0"},
+		{"group_settings", "pii_redaction", "INTEGER"},
+		{"group_settings", "digest_stats", "INTEGER"},
+		{"group_settings", "action_items", "INTEGER"},
+		{"group_settings", "calendar_events", "INTEGER"},
+		{"user_opt_outs", "salt_epoch", "INTEGER NOT NULL DEFAULT 0"},
+		{"messages", "key_id", "TEXT"},
+		{"digest_subscriptions", "key_id", "TEXT"},
+		{"summary_items", "key_id", "TEXT"},
+		{"summary_topics", "key_id", "TEXT"},
+		{"summary_events", "key_id", "TEXT"},
+		{"digests", "key_id", "TEXT"},
 	}
 	for _, m := range additiveMigrations {
 		if err := db.addColumnIfNotExists(m.table, m.column, m.colDef); err != nil {
@@ -331,6 +449,13 @@ func (db *DB) migrate() error {
 		return err
 	}
 
+	// Reply-tree walks look up a message's replies by parent.
+	if _, err := db.conn.Exec(`CREATE INDEX IF NOT EXISTS idx_messages_reply
+		ON messages(group_id, reply_to_tg_id)
+		WHERE reply_to_tg_id IS NOT NULL`); err != nil {
+		return err
+	}
+
 	// PII removal: drop user_id and username, add user_hash.
 	for _, col := range []string{"user_id", "username"} {
 		if err := db.dropColumnIfExists("messages", col); err != nil {
@@ -440,10 +565,18 @@ func (db *DB) AddMessage(ctx context.Context, msg *Message) error {
 // (0, nil) when the row was a duplicate (dedup index on group_id+tg_message_id).
 func (db *DB) AddMessageReturningID(ctx context.Context, msg *Message) (int64, error) {
 	defer db.metrics.DBAdd.Start()()
+	text, err := db.sealColumn("text", msg.Text)
+	if err != nil {
+		return 0, err
+	}
+	forwardedFrom, err := db.sealColumn("forwarded_from", msg.ForwardedFrom)
+	if err != nil {
+		return 0, err
+	}
 	res, err := db.conn.ExecContext(ctx,
-		`INSERT OR IGNORE INTO messages (group_id, user_hash, text, timestamp, forwarded_from, tg_message_id, reply_to_tg_id) VALUES (?, ?, ?, ?, ?, ?, ?)`,
-		msg.GroupID, msg.UserHash, msg.Text, msg.Timestamp, msg.ForwardedFrom,
-		nullableInt64(msg.TgMessageID), nullableInt64(msg.ReplyToTgID),
+		`INSERT OR IGNORE INTO messages (group_id, user_hash, text, timestamp, forwarded_from, tg_message_id, reply_to_tg_id, key_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		msg.GroupID, msg.UserHash, text, msg.Timestamp, forwardedFrom,
+		nullableInt64(msg.TgMessageID), nullableInt64(msg.ReplyToTgID), db.writeKeyID(),
 	)
 	if err != nil {
 		return 0, err
@@ -626,7 +759,7 @@ func (db *DB) CleanupOldImageDescriptions(ctx context.Context, olderThan time.Du
 func (db *DB) GetMessages(ctx context.Context, groupID int64, since time.Time, limit int) ([]Message, error) {
 	defer db.metrics.DBGet.Start()()
 	rows, err := db.conn.QueryContext(ctx,
-		`SELECT id, group_id, user_hash, text, timestamp, forwarded_from, tg_message_id, reply_to_tg_id
+		`SELECT id, group_id, user_hash, text, timestamp, forwarded_from, tg_message_id, reply_to_tg_id, key_id
 		 FROM messages
 		 WHERE group_id = ? AND timestamp > ?
 		 ORDER BY timestamp DESC
@@ -641,15 +774,18 @@ func (db *DB) GetMessages(ctx context.Context, groupID int64, since time.Time, l
 	var messages []Message
 	for rows.Next() {
 		var msg Message
-		var forwardedFrom sql.NullString
+		var forwardedFrom, keyID sql.NullString
 		var tgMessageID, replyToTgID sql.NullInt64
-		if err := rows.Scan(&msg.ID, &msg.GroupID, &msg.UserHash, &msg.Text, &msg.Timestamp, &forwardedFrom, &tgMessageID, &replyToTgID); err != nil {
+		if err := rows.Scan(&msg.ID, &msg.GroupID, &msg.UserHash, &msg.Text, &msg.Timestamp, &forwardedFrom, &tgMessageID, &replyToTgID, &keyID); err != nil {
 			logger.Error().Err(err).Msg("failed to scan message")
 			continue
 		}
 		msg.ForwardedFrom = forwardedFrom.String
 		msg.TgMessageID = tgMessageID.Int64
 		msg.ReplyToTgID = replyToTgID.Int64
+		if err := db.openMessage(&msg, keyID); err != nil {
+			return nil, fmt.Errorf("message %d: %w", msg.ID, err)
+		}
 		messages = append(messages, msg)
 	}
 
@@ -665,6 +801,43 @@ func (db *DB) GetMessages(ctx context.Context, groupID int64, since time.Time, l
 	return messages, nil
 }
 
+// GetMessagesFrom returns the group's messages from the given time onwards
+// (inclusive), oldest first, capped at the first limit of them.
+func (db *DB) GetMessagesFrom(ctx context.Context, groupID int64, from time.Time, limit int) ([]Message, error) {
+	defer db.metrics.DBGet.Start()()
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT id, group_id, user_hash, text, timestamp, forwarded_from, tg_message_id, reply_to_tg_id, key_id
+		 FROM messages
+		 WHERE group_id = ? AND timestamp >= ?
+		 ORDER BY timestamp, id
+		 LIMIT ?`,
+		groupID, from, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var messages []Message
+	for rows.Next() {
+		var msg Message
+		var forwardedFrom, keyID sql.NullString
+		var tgMessageID, replyToTgID sql.NullInt64
+		if err := rows.Scan(&msg.ID, &msg.GroupID, &msg.UserHash, &msg.Text, &msg.Timestamp, &forwardedFrom, &tgMessageID, &replyToTgID, &keyID); err != nil {
+			logger.Error().Err(err).Msg("failed to scan message")
+			continue
+		}
+		msg.ForwardedFrom = forwardedFrom.String
+		msg.TgMessageID = tgMessageID.Int64
+		msg.ReplyToTgID = replyToTgID.Int64
+		if err := db.openMessage(&msg, keyID); err != nil {
+			return nil, fmt.Errorf("message %d: %w", msg.ID, err)
+		}
+		messages = append(messages, msg)
+	}
+	return messages, rows.Err()
+}
+
 // GetMessageByTgID returns the stored message with the given Telegram message_id
 // in the group, or (nil, nil) when it is absent (e.g. retention-pruned, or never
 // ingested). Uses the idx_messages_dedup index on (group_id, tg_message_id).
@@ -675,14 +848,14 @@ func (db *DB) GetMessageByTgID(ctx context.Context, groupID, tgMessageID int64)
 	defer db.metrics.DBGet.Start()()
 
 	var msg Message
-	var forwardedFrom sql.NullString
+	var forwardedFrom, keyID sql.NullString
 	var dbTgID, replyToTgID sql.NullInt64
 	err := db.conn.QueryRowContext(ctx,
-		`SELECT id, group_id, user_hash, text, timestamp, forwarded_from, tg_message_id, reply_to_tg_id
+		`SELECT id, group_id, user_hash, text, timestamp, forwarded_from, tg_message_id, reply_to_tg_id, key_id
 		 FROM messages
 		 WHERE group_id = ? AND tg_message_id = ?`,
 		groupID, tgMessageID,
-	).Scan(&msg.ID, &msg.GroupID, &msg.UserHash, &msg.Text, &msg.Timestamp, &forwardedFrom, &dbTgID, &replyToTgID)
+	).Scan(&msg.ID, &msg.GroupID, &msg.UserHash, &msg.Text, &msg.Timestamp, &forwardedFrom, &dbTgID, &replyToTgID, &keyID)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	}
@@ -692,9 +865,57 @@ func (db *DB) GetMessageByTgID(ctx context.Context, groupID, tgMessageID int64)
 	msg.ForwardedFrom = forwardedFrom.String
 	msg.TgMessageID = dbTgID.Int64
 	msg.ReplyToTgID = replyToTgID.Int64
+	if err := db.openMessage(&msg, keyID); err != nil {
+		return nil, err
+	}
 	return &msg, nil
 }
 
+// GetRepliesTo returns the stored messages in the group that reply to any of
+// the given Telegram message_ids, oldest first. Uses the idx_messages_reply
+// index on (group_id, reply_to_tg_id).
+func (db *DB) GetRepliesTo(ctx context.Context, groupID int64, tgMessageIDs []int64) ([]Message, error) {
+	if len(tgMessageIDs) == 0 {
+		return nil, nil
+	}
+	defer db.metrics.DBGet.Start()()
+
+	args := make([]any, 0, len(tgMessageIDs)+1)
+	args = append(args, groupID)
+	for _, id := range tgMessageIDs {
+		args = append(args, id)
+	}
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT id, group_id, user_hash, text, timestamp, forwarded_from, tg_message_id, reply_to_tg_id, key_id
+		 FROM messages
+		 WHERE group_id = ? AND reply_to_tg_id IN (`+strings.TrimSuffix(strings.Repeat("?,", len(tgMessageIDs)), ",")+`)
+		 ORDER BY timestamp, id`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var messages []Message
+	for rows.Next() {
+		var msg Message
+		var forwardedFrom, keyID sql.NullString
+		var tgMessageID, replyToTgID sql.NullInt64
+		if err := rows.Scan(&msg.ID, &msg.GroupID, &msg.UserHash, &msg.Text, &msg.Timestamp, &forwardedFrom, &tgMessageID, &replyToTgID, &keyID); err != nil {
+			return nil, err
+		}
+		msg.ForwardedFrom = forwardedFrom.String
+		msg.TgMessageID = tgMessageID.Int64
+		msg.ReplyToTgID = replyToTgID.Int64
+		if err := db.openMessage(&msg, keyID); err != nil {
+			return nil, fmt.Errorf("message %d: %w", msg.ID, err)
+		}
+		messages = append(messages, msg)
+	}
+	return messages, rows.Err()
+}
+
 func (db *DB) CleanupOldMessages(ctx context.Context, olderThan time.Duration) (int64, error) {
 	cutoff := time.Now().Add(-olderThan)
 	result, err := db.conn.ExecContext(ctx,
@@ -801,9 +1022,11 @@ func (db *DB) UpdateLastDailySummary(ctx context.Context, groupID int64, t time.
 func (db *DB) GetGroupSummaryInstructions(ctx context.Context, groupID int64) (*GroupSummaryInstructions, error) {
 	var item GroupSummaryInstructions
 	err := db.conn.QueryRowContext(ctx,
-		`SELECT group_id, instructions, updated_at, updated_by FROM group_summary_instructions WHERE group_id = ?`,
+		`SELECT gsi.group_id, gsi.instructions, gsi.updated_at, gsi.updated_by,
+		        COALESCE((SELECT MAX(v.version) FROM group_summary_instruction_versions v WHERE v.group_id = gsi.group_id), 0)
+		 FROM group_summary_instructions gsi WHERE gsi.group_id = ?`,
 		groupID,
-	).Scan(&item.GroupID, &item.Instructions, &item.UpdatedAt, &item.UpdatedBy)
+	).Scan(&item.GroupID, &item.Instructions, &item.UpdatedAt, &item.UpdatedBy, &item.Version)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -813,33 +1036,66 @@ func (db *DB) GetGroupSummaryInstructions(ctx context.Context, groupID int64) (*
 	return &item, nil
 }
 
+// SetGroupSummaryInstructions replaces the group's instructions and records the
+// new text as the next version in the history. Saving text identical to the
+// latest version does not add a duplicate version.
 func (db *DB) SetGroupSummaryInstructions(ctx context.Context, groupID, updatedBy int64, instructions string) error {
 	instructions = strings.TrimSpace(instructions)
 	if instructions == "" {
-		return db.ClearGroupSummaryInstructions(ctx, groupID)
+		return db.ClearGroupSummaryInstructions(ctx, groupID, updatedBy)
 	}
 	if len([]rune(instructions)) > MaxGroupSummaryInstructionsLength {
 		return fmt.Errorf("summary instructions exceed %d characters", MaxGroupSummaryInstructionsLength)
 	}
 
-	_, err := db.conn.ExecContext(ctx,
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := time.Now()
+	if _, err := tx.ExecContext(ctx,
 		`INSERT INTO group_summary_instructions (group_id, instructions, updated_at, updated_by)
 		 VALUES (?, ?, ?, ?)
 		 ON CONFLICT(group_id) DO UPDATE SET
 			instructions = excluded.instructions,
 			updated_at = excluded.updated_at,
 			updated_by = excluded.updated_by`,
-		groupID, instructions, time.Now(), updatedBy,
-	)
-	return err
+		groupID, instructions, now, updatedBy,
+	); err != nil {
+		return err
+	}
+	if err := appendInstructionsVersion(ctx, tx, groupID, updatedBy, instructions, now); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (db *DB) ClearGroupSummaryInstructions(ctx context.Context, groupID int64) error {
-	_, err := db.conn.ExecContext(ctx,
+// ClearGroupSummaryInstructions removes the group's instructions. The clear is
+// recorded as an empty version so it can be undone by restoring an earlier one.
+func (db *DB) ClearGroupSummaryInstructions(ctx context.Context, groupID, clearedBy int64) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx,
 		`DELETE FROM group_summary_instructions WHERE group_id = ?`,
 		groupID,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n > 0 {
+		if err := appendInstructionsVersion(ctx, tx, groupID, clearedBy, "", time.Now()); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
 func (db *DB) UpsertKnownGroup(ctx context.Context, groupID int64, title, username string) error {
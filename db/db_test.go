@@ -2,8 +2,10 @@ package db
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -1009,7 +1011,7 @@ func TestGroupSummaryInstructions(t *testing.T) {
 	})
 
 	t.Run("clear deletes", func(t *testing.T) {
-		if err := db.ClearGroupSummaryInstructions(ctx, -100); err != nil {
+		if err := db.ClearGroupSummaryInstructions(ctx, -100, 43); err != nil {
 			t.Fatal(err)
 		}
 		got, err := db.GetGroupSummaryInstructions(ctx, -100)
@@ -1372,3 +1374,68 @@ func TestErrorLog(t *testing.T) {
 		}
 	})
 }
+
+func TestGetRepliesTo(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	now := time.Now().UTC()
+
+	for _, m := range []Message{
+		{GroupID: -100, UserHash: "a", Text: "второй ответ", Timestamp: now, TgMessageID: 3, ReplyToTgID: 1},
+		{GroupID: -100, UserHash: "b", Text: "первый ответ", Timestamp: now.Add(-time.Minute), TgMessageID: 2, ReplyToTgID: 1},
+		{GroupID: -100, UserHash: "c", Text: "ответ на ответ", Timestamp: now, TgMessageID: 4, ReplyToTgID: 2},
+		{GroupID: -100, UserHash: "d", Text: "без ответа", Timestamp: now, TgMessageID: 5},
+		{GroupID: -200, UserHash: "e", Text: "в другой группе", Timestamp: now, TgMessageID: 2, ReplyToTgID: 1},
+	} {
+		if err := db.AddMessage(ctx, &m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	replies, err := db.GetRepliesTo(ctx, -100, []int64{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replies) != 2 || replies[0].TgMessageID != 2 || replies[1].TgMessageID != 3 {
+		t.Fatalf("replies to 1 = %+v, want 2 then 3", replies)
+	}
+	if replies[0].Text != "первый ответ" || replies[0].ReplyToTgID != 1 {
+		t.Errorf("reply not fully loaded: %+v", replies[0])
+	}
+
+	replies, err = db.GetRepliesTo(ctx, -100, []int64{2, 3})
+	if err != nil || len(replies) != 1 || replies[0].TgMessageID != 4 {
+		t.Fatalf("replies to 2, 3 = %+v, %v", replies, err)
+	}
+	if replies, err := db.GetRepliesTo(ctx, -100, nil); err != nil || replies != nil {
+		t.Fatalf("no parents: %+v, %v", replies, err)
+	}
+}
+
+func TestGetMessagesFrom(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	anchor := time.Now().UTC().Add(-time.Hour)
+
+	for i, offset := range []time.Duration{-time.Minute, 0, time.Minute, 2 * time.Minute, 3 * time.Minute} {
+		if err := db.AddMessage(ctx, &Message{GroupID: -100, UserHash: "a", Text: fmt.Sprintf("m%d", i), Timestamp: anchor.Add(offset)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.AddMessage(ctx, &Message{GroupID: -200, UserHash: "b", Text: "other", Timestamp: anchor}); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, err := db.GetMessagesFrom(ctx, -100, anchor, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, m := range msgs {
+		got = append(got, m.Text)
+	}
+	// Inclusive of the anchor, oldest first, capped from the start.
+	if want := []string{"m1", "m2", "m3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetMessagesFrom = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,219 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// DigestSubscription is a member's request to get a group's daily digest by
+// private message. It is keyed by the group-scoped UserHash; ChatID is the
+// private chat the digest goes to, which for a DM is the member's user ID. It
+// is only kept while subscribed; it is encrypted at rest when the DB has a
+// cipher and otherwise stored as is, linking UserHash to the member.
+type DigestSubscription struct {
+	GroupID  int64
+	UserHash string
+	ChatID   int64
+	Hour     int // UTC hour 0-23
+	Minute   int // UTC minute 0-59
+	LastSent *time.Time
+}
+
+// DailyDigest is a group's generated digest of the 24 hours up to WindowEnd,
+// shared by the group post and the subscriber DMs due at that minute.
+type DailyDigest struct {
+	GroupID   int64
+	WindowEnd string // UTC minute the window ends at, see DigestWindowEnd
+	Lang      string
+	Summary   string // Markdown, without a header
+	SummaryID int64  // stored topic structure (see SaveSummary); 0 if none
+	CreatedAt time.Time
+}
+
+// DigestWindowEnd is the DailyDigest.WindowEnd key for a digest delivered at
+// t: its UTC minute, so deliveries due at the same scheduler tick share one
+// digest and no other delivery does.
+func DigestWindowEnd(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04")
+}
+
+// SetDigestSubscription creates or updates the user's subscription for the
+// group. Changing the time keeps the last delivery, so a digest already sent
+// today isn't repeated.
+func (db *DB) SetDigestSubscription(ctx context.Context, s DigestSubscription) error {
+	chatID, err := db.sealColumn("chat_id", strconv.FormatInt(s.ChatID, 10))
+	if err != nil {
+		return err
+	}
+	_, err = db.conn.ExecContext(ctx,
+		`INSERT INTO digest_subscriptions (group_id, user_hash, chat_id, key_id, hour, minute, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(group_id, user_hash) DO UPDATE SET
+			chat_id = excluded.chat_id,
+			key_id = excluded.key_id,
+			hour = excluded.hour,
+			minute = excluded.minute`,
+		s.GroupID, s.UserHash, chatID, db.writeKeyID(), s.Hour, s.Minute, time.Now(),
+	)
+	return err
+}
+
+// GetDigestSubscription returns the user's subscription for the group, or nil
+// if they aren't subscribed.
+func (db *DB) GetDigestSubscription(ctx context.Context, groupID int64, userHash string) (*DigestSubscription, error) {
+	s := DigestSubscription{GroupID: groupID, UserHash: userHash}
+	var chatID string
+	var keyID sql.NullString
+	var lastSent sql.NullTime
+	err := db.conn.QueryRowContext(ctx,
+		`SELECT chat_id, key_id, hour, minute, last_sent FROM digest_subscriptions WHERE group_id = ? AND user_hash = ?`,
+		groupID, userHash,
+	).Scan(&chatID, &keyID, &s.Hour, &s.Minute, &lastSent)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if s.ChatID, err = db.openChatID(chatID, keyID); err != nil {
+		return nil, err
+	}
+	if lastSent.Valid {
+		s.LastSent = &lastSent.Time
+	}
+	return &s, nil
+}
+
+// DeleteDigestSubscription removes the user's subscription for the group and
+// reports whether there was one.
+func (db *DB) DeleteDigestSubscription(ctx context.Context, groupID int64, userHash string) (bool, error) {
+	res, err := db.conn.ExecContext(ctx,
+		`DELETE FROM digest_subscriptions WHERE group_id = ? AND user_hash = ?`,
+		groupID, userHash,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetDigestSubscriptionsAt returns the subscriptions scheduled for hour:minute
+// UTC.
+func (db *DB) GetDigestSubscriptionsAt(ctx context.Context, hour, minute int) ([]DigestSubscription, error) {
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT group_id, user_hash, chat_id, key_id, hour, minute, last_sent
+		 FROM digest_subscriptions WHERE hour = ? AND minute = ?
+		 ORDER BY group_id`,
+		hour, minute,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var subs []DigestSubscription
+	for rows.Next() {
+		var s DigestSubscription
+		var chatID string
+		var keyID sql.NullString
+		var lastSent sql.NullTime
+		if err := rows.Scan(&s.GroupID, &s.UserHash, &chatID, &keyID, &s.Hour, &s.Minute, &lastSent); err != nil {
+			return nil, err
+		}
+		var err error
+		if s.ChatID, err = db.openChatID(chatID, keyID); err != nil {
+			return nil, err
+		}
+		if lastSent.Valid {
+			s.LastSent = &lastSent.Time
+		}
+		subs = append(subs, s)
+	}
+	return subs, rows.Err()
+}
+
+// openChatID decrypts and parses a stored subscription chat_id. Rows written
+// without a key hold the plain integer.
+func (db *DB) openChatID(s string, keyID sql.NullString) (int64, error) {
+	plain, err := db.openColumn("chat_id", s, keyID)
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.ParseInt(plain, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("db: malformed subscription chat_id: %w", err)
+	}
+	return id, nil
+}
+
+// MarkDigestSent records a delivery to the subscriber.
+func (db *DB) MarkDigestSent(ctx context.Context, groupID int64, userHash string, t time.Time) error {
+	_, err := db.conn.ExecContext(ctx,
+		`UPDATE digest_subscriptions SET last_sent = ? WHERE group_id = ? AND user_hash = ?`,
+		t, groupID, userHash,
+	)
+	return err
+}
+
+// GetDailyDigest returns the group's digest for the window ending at
+// windowEnd, or nil if none has been generated yet.
+func (db *DB) GetDailyDigest(ctx context.Context, groupID int64, windowEnd string) (*DailyDigest, error) {
+	d := DailyDigest{GroupID: groupID, WindowEnd: windowEnd}
+	var keyID sql.NullString
+	err := db.conn.QueryRowContext(ctx,
+		`SELECT lang, summary, summary_id, created_at, key_id FROM digests WHERE group_id = ? AND window_end = ?`,
+		groupID, windowEnd,
+	).Scan(&d.Lang, &d.Summary, &d.SummaryID, &d.CreatedAt, &keyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if d.Summary, err = db.openColumn("summary", d.Summary, keyID); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// PutDailyDigest stores (or replaces) the group's digest for d.WindowEnd.
+func (db *DB) PutDailyDigest(ctx context.Context, d DailyDigest) error {
+	summary, err := db.sealColumn("summary", d.Summary)
+	if err != nil {
+		return err
+	}
+	_, err = db.conn.ExecContext(ctx,
+		`INSERT OR REPLACE INTO digests (group_id, window_end, lang, summary, summary_id, created_at, key_id) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		d.GroupID, d.WindowEnd, d.Lang, summary, d.SummaryID, d.CreatedAt, db.writeKeyID(),
+	)
+	return err
+}
+
+// CleanupOldDailyDigests drops digests generated more than olderThan ago.
+func (db *DB) CleanupOldDailyDigests(ctx context.Context, olderThan time.Duration) (int64, error) {
+	res, err := db.conn.ExecContext(ctx,
+		`DELETE FROM digests WHERE created_at < ?`,
+		time.Now().Add(-olderThan),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// GetKnownGroupTitle returns the group's last seen title, or "" if unknown.
+func (db *DB) GetKnownGroupTitle(ctx context.Context, groupID int64) (string, error) {
+	var title string
+	err := db.conn.QueryRowContext(ctx,
+		`SELECT title FROM known_groups WHERE group_id = ?`, groupID,
+	).Scan(&title)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return title, err
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDigestSubscriptions(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	if err := db.SetDigestSubscription(ctx, DigestSubscription{GroupID: -100, UserHash: "aaaa1111", ChatID: 7, Hour: 8}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetDigestSubscription(ctx, DigestSubscription{GroupID: -200, UserHash: "bbbb2222", ChatID: 7, Hour: 9, Minute: 30}); err != nil {
+		t.Fatal(err)
+	}
+	sent := time.Now().UTC()
+	if err := db.MarkDigestSent(ctx, -100, "aaaa1111", sent); err != nil {
+		t.Fatal(err)
+	}
+
+	// Moving the time keeps the last delivery.
+	if err := db.SetDigestSubscription(ctx, DigestSubscription{GroupID: -100, UserHash: "aaaa1111", ChatID: 7, Hour: 9, Minute: 30}); err != nil {
+		t.Fatal(err)
+	}
+	subs, err := db.GetDigestSubscriptionsAt(ctx, 9, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subs) != 2 || subs[0].GroupID != -200 || subs[1].GroupID != -100 {
+		t.Fatalf("subscriptions at 09:30 = %+v", subs)
+	}
+	if subs[1].LastSent == nil || !subs[1].LastSent.Equal(sent) {
+		t.Fatalf("last sent = %v, want %v", subs[1].LastSent, sent)
+	}
+	if subs, err := db.GetDigestSubscriptionsAt(ctx, 8, 0); err != nil || len(subs) != 0 {
+		t.Fatalf("subscriptions at 08:00 = %+v, %v; want none", subs, err)
+	}
+
+	removed, err := db.DeleteDigestSubscription(ctx, -100, "aaaa1111")
+	if err != nil || !removed {
+		t.Fatalf("delete = %v, %v; want removed", removed, err)
+	}
+	if removed, _ := db.DeleteDigestSubscription(ctx, -100, "aaaa1111"); removed {
+		t.Fatal("second delete should report nothing removed")
+	}
+	if sub, err := db.GetDigestSubscription(ctx, -100, "aaaa1111"); err != nil || sub != nil {
+		t.Fatalf("subscription after delete = %+v, %v", sub, err)
+	}
+}
+
+func TestDailyDigests(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	windowEnd := DigestWindowEnd(time.Date(2026, 3, 10, 23, 59, 42, 0, time.UTC))
+	if windowEnd != "2026-03-10T23:59" {
+		t.Fatalf("DigestWindowEnd = %q", windowEnd)
+	}
+
+	if d, err := db.GetDailyDigest(ctx, -100, windowEnd); err != nil || d != nil {
+		t.Fatalf("digest before put = %+v, %v", d, err)
+	}
+	if err := db.PutDailyDigest(ctx, DailyDigest{GroupID: -100, WindowEnd: windowEnd, Lang: "ru", Summary: "first", CreatedAt: time.Now().Add(-72 * time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.PutDailyDigest(ctx, DailyDigest{GroupID: -100, WindowEnd: windowEnd, Lang: "en", Summary: "second", CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	d, err := db.GetDailyDigest(ctx, -100, windowEnd)
+	if err != nil || d == nil || d.Summary != "second" || d.Lang != "en" {
+		t.Fatalf("digest = %+v, %v; want the replacement", d, err)
+	}
+
+	if err := db.PutDailyDigest(ctx, DailyDigest{GroupID: -100, WindowEnd: "2026-03-07T23:59", Lang: "ru", Summary: "old", CreatedAt: time.Now().Add(-72 * time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	purged, err := db.CleanupOldDailyDigests(ctx, 48*time.Hour)
+	if err != nil || purged != 1 {
+		t.Fatalf("purged = %d, %v; want 1", purged, err)
+	}
+}
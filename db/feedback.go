@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// Votes on a posted summary.
+const (
+	VoteUp   = 1
+	VoteDown = -1
+)
+
+// SetSummaryFeedback records the user's (by group-scoped UserHash) vote on a
+// summary, replacing any earlier vote of theirs.
+func (db *DB) SetSummaryFeedback(ctx context.Context, summaryID int64, userHash string, vote int) error {
+	_, err := db.conn.ExecContext(ctx,
+		`INSERT INTO summary_feedback (summary_id, user_hash, vote, created_at) VALUES (?, ?, ?, ?)
+		 ON CONFLICT(summary_id, user_hash) DO UPDATE SET vote = excluded.vote, created_at = excluded.created_at`,
+		summaryID, userHash, vote, time.Now(),
+	)
+	return err
+}
+
+// SummaryRating is the vote tally of one posted summary.
+type SummaryRating struct {
+	SummaryID           int64
+	GroupID             int64
+	Model               string
+	InstructionsVersion int
+	CreatedAt           time.Time
+	Up, Down            int
+}
+
+// GetSummaryRatings returns the tallies of summaries created since the given
+// time that got at least one vote, oldest first.
+func (db *DB) GetSummaryRatings(ctx context.Context, since time.Time) ([]SummaryRating, error) {
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT s.id, s.group_id, s.model, s.instructions_version, s.created_at,
+		        SUM(CASE WHEN f.vote > 0 THEN 1 ELSE 0 END),
+		        SUM(CASE WHEN f.vote < 0 THEN 1 ELSE 0 END)
+		 FROM summaries s JOIN summary_feedback f ON f.summary_id = s.id
+		 WHERE s.created_at >= ?
+		 GROUP BY s.id
+		 ORDER BY s.created_at, s.id`,
+		since,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var ratings []SummaryRating
+	for rows.Next() {
+		var r SummaryRating
+		if err := rows.Scan(&r.SummaryID, &r.GroupID, &r.Model, &r.InstructionsVersion, &r.CreatedAt, &r.Up, &r.Down); err != nil {
+			return nil, err
+		}
+		ratings = append(ratings, r)
+	}
+	return ratings, rows.Err()
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSummaryRatings(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	rated, err := db.SaveSummary(ctx, StoredSummary{GroupID: -100, Lang: "ru", Model: "gpt-a", InstructionsVersion: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.SaveSummary(ctx, StoredSummary{GroupID: -100, Lang: "ru", Model: "gpt-a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range []struct {
+		user string
+		vote int
+	}{{"aaaa1111", VoteUp}, {"bbbb2222", VoteDown}, {"bbbb2222", VoteUp}, {"cccc3333", VoteDown}} {
+		if err := db.SetSummaryFeedback(ctx, rated, v.user, v.vote); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ratings, err := db.GetSummaryRatings(ctx, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ratings) != 1 {
+		t.Fatalf("ratings = %+v; want only the voted summary", ratings)
+	}
+	r := ratings[0]
+	if r.SummaryID != rated || r.GroupID != -100 || r.Model != "gpt-a" || r.InstructionsVersion != 2 || r.Up != 2 || r.Down != 1 {
+		t.Fatalf("rating = %+v; want 2 up (a changed vote counts once), 1 down", r)
+	}
+
+	if later, err := db.GetSummaryRatings(ctx, time.Now().Add(time.Hour)); err != nil || len(later) != 0 {
+		t.Fatalf("ratings after cutoff = %+v, %v", later, err)
+	}
+
+	if _, err := db.CleanupOldSummaries(ctx, -time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	var votes int
+	if err := db.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM summary_feedback`).Scan(&votes); err != nil || votes != 0 {
+		t.Fatalf("orphaned feedback = %d, %v", votes, err)
+	}
+}
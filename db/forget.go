@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// ForgetUser deletes everything stored about the user (by group-scoped
+// UserHash) in the group: their messages with attached photos, the summary
+// items and calendar events extracted from those messages, catch-up mark,
+// digest subscription and summary votes. Votes are also deleted under
+// retiredHashes, the user's hashes under retired salts, since votes aren't
+// relinked (see RelinkUserHash). Cached image descriptions of their photos
+// are dropped too unless another stored message still shows the same image.
+// It returns how many messages were deleted.
+func (db *DB) ForgetUser(ctx context.Context, groupID int64, userHash string, retiredHashes ...string) (int64, error) {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Collect the user's images before the cascade removes their photo rows.
+	rows, err := tx.QueryContext(ctx,
+		`SELECT DISTINCT p.file_unique_id FROM message_photos p
+		 JOIN messages m ON m.id = p.message_id
+		 WHERE m.group_id = ? AND m.user_hash = ?`,
+		groupID, userHash,
+	)
+	if err != nil {
+		return 0, err
+	}
+	var images []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			_ = rows.Close()
+			return 0, err
+		}
+		images = append(images, id)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	// Items and events point at their source message only by Telegram ID, so
+	// they go while the messages can still be matched.
+	for _, table := range []string{"summary_items", "summary_events"} {
+		if _, err := tx.ExecContext(ctx,
+			`DELETE FROM `+table+`
+			 WHERE summary_id IN (SELECT id FROM summaries WHERE group_id = ?1)
+			   AND tg_message_id IN (SELECT tg_message_id FROM messages
+			                         WHERE group_id = ?1 AND user_hash = ?2 AND tg_message_id IS NOT NULL)`,
+			groupID, userHash,
+		); err != nil {
+			return 0, err
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM messages WHERE group_id = ? AND user_hash = ?`, groupID, userHash)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, id := range images {
+		if _, err := tx.ExecContext(ctx,
+			`DELETE FROM image_descriptions WHERE file_unique_id = ?
+			 AND NOT EXISTS (SELECT 1 FROM message_photos WHERE file_unique_id = ?)`,
+			id, id,
+		); err != nil {
+			return 0, err
+		}
+	}
+
+	for _, q := range []string{
+		`DELETE FROM user_catchups WHERE group_id = ? AND user_hash = ?`,
+		`DELETE FROM digest_subscriptions WHERE group_id = ? AND user_hash = ?`,
+		`DELETE FROM summary_feedback WHERE user_hash = ?2
+		 AND summary_id IN (SELECT id FROM summaries WHERE group_id = ?1)`,
+	} {
+		if _, err := tx.ExecContext(ctx, q, groupID, userHash); err != nil {
+			return 0, err
+		}
+	}
+	for _, h := range retiredHashes {
+		if _, err := tx.ExecContext(ctx,
+			`DELETE FROM summary_feedback WHERE user_hash = ?
+			 AND summary_id IN (SELECT id FROM summaries WHERE group_id = ?)`,
+			h, groupID,
+		); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// SetUserOptOut records whether the user (by group-scoped UserHash under the
+// current salt) opted out of having their messages stored in the group.
+func (db *DB) SetUserOptOut(ctx context.Context, groupID int64, userHash string, optOut bool) error {
+	if !optOut {
+		_, err := db.conn.ExecContext(ctx,
+			`DELETE FROM user_opt_outs WHERE group_id = ? AND user_hash = ?`,
+			groupID, userHash,
+		)
+		return err
+	}
+	_, err := db.conn.ExecContext(ctx,
+		`INSERT OR IGNORE INTO user_opt_outs (group_id, user_hash, salt_epoch, created_at)
+		 VALUES (?, ?, (SELECT COALESCE(MAX(epoch), 0) FROM user_hash_salts), ?)`,
+		groupID, userHash, time.Now(),
+	)
+	return err
+}
+
+// IsUserOptedOut reports whether the user opted out of message storage in
+// the group.
+func (db *DB) IsUserOptedOut(ctx context.Context, groupID int64, userHash string) (bool, error) {
+	var n int
+	err := db.conn.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM user_opt_outs WHERE group_id = ? AND user_hash = ?`,
+		groupID, userHash,
+	).Scan(&n)
+	return n > 0, err
+}
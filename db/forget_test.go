@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestForgetUser(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	now := time.Now().UTC()
+
+	var tgID int64
+	add := func(groupID int64, hash, text string, photos ...string) {
+		t.Helper()
+		tgID++
+		id, err := db.AddMessageReturningID(ctx, &Message{GroupID: groupID, UserHash: hash, Text: text, Timestamp: now, TgMessageID: tgID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var records []PhotoRecord
+		for _, p := range photos {
+			records = append(records, PhotoRecord{FileUniqueID: p, FileID: "f-" + p})
+		}
+		if err := db.AddMessagePhotos(ctx, id, records); err != nil {
+			t.Fatal(err)
+		}
+	}
+	describe := func(id string) {
+		t.Helper()
+		if err := db.PutImageDescription(ctx, ImageDescription{FileUniqueID: id, Description: id, CreatedAt: now, LastUsedAt: now}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	add(-100, "aaaa1111", "моё", "mine", "shared")
+	add(-100, "aaaa1111", "ещё моё")
+	add(-100, "bbbb2222", "чужое", "shared")
+	add(-200, "aaaa1111", "в другой группе")
+	for _, id := range []string{"mine", "shared"} {
+		describe(id)
+	}
+	if err := db.SetLastCatchup(ctx, -100, "aaaa1111", now); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetDigestSubscription(ctx, DigestSubscription{GroupID: -100, UserHash: "aaaa1111", ChatID: 7, Hour: 8}); err != nil {
+		t.Fatal(err)
+	}
+	summaryID, err := db.SaveSummary(ctx, StoredSummary{GroupID: -100, Lang: "ru", Items: []SummaryItem{
+		{Kind: ItemAction, Text: "моё дело", Owner: "У1", TgMessageID: 2},
+		{Kind: ItemAction, Text: "чужое дело", Owner: "У2", TgMessageID: 3},
+		{Kind: ItemDecision, Text: "без источника"},
+	}, Events: []SummaryEvent{
+		{Title: "Встреча у меня", Start: now, End: now.Add(time.Hour), TgMessageID: 2},
+		{Title: "Созвон", Start: now, End: now.Add(time.Hour), TgMessageID: 3},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetSummaryFeedback(ctx, summaryID, "aaaa1111", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, err := db.ForgetUser(ctx, -100, "aaaa1111")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 2 {
+		t.Fatalf("deleted = %d, want 2", deleted)
+	}
+
+	msgs, err := db.GetMessages(ctx, -100, now.Add(-time.Hour), 10)
+	if err != nil || len(msgs) != 1 || msgs[0].UserHash != "bbbb2222" {
+		t.Fatalf("remaining messages = %+v, %v", msgs, err)
+	}
+	if other, _ := db.GetMessages(ctx, -200, now.Add(-time.Hour), 10); len(other) != 1 {
+		t.Fatalf("forgetting reached another group: %+v", other)
+	}
+	var photos int
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM message_photos`).Scan(&photos); err != nil || photos != 1 {
+		t.Fatalf("photos left = %d, %v; want only the other member's", photos, err)
+	}
+	if d, _ := db.GetImageDescription(ctx, "mine"); d != nil {
+		t.Errorf("description of an image nobody else posted was kept: %+v", d)
+	}
+	if d, _ := db.GetImageDescription(ctx, "shared"); d == nil {
+		t.Error("description of an image still in the chat was dropped")
+	}
+	if c, _ := db.GetLastCatchup(ctx, -100, "aaaa1111"); c != nil {
+		t.Errorf("catch-up mark kept: %v", c)
+	}
+	if s, _ := db.GetDigestSubscription(ctx, -100, "aaaa1111"); s != nil {
+		t.Errorf("digest subscription kept: %+v", s)
+	}
+	items, err := db.GetSummaryItems(ctx, -100, ItemAction, now.Add(-time.Hour))
+	if err != nil || len(items) != 1 || items[0].Text != "чужое дело" {
+		t.Errorf("action items left = %+v, %v; want only the other member's", items, err)
+	}
+	if items, _ := db.GetSummaryItems(ctx, -100, ItemDecision, now.Add(-time.Hour)); len(items) != 1 {
+		t.Errorf("item without a source was dropped: %+v", items)
+	}
+	if s, err := db.GetSummary(ctx, summaryID); err != nil || s == nil || len(s.Events) != 1 || s.Events[0].Title != "Созвон" {
+		t.Errorf("events left = %+v, %v; want only the other member's", s, err)
+	}
+	var votes int
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM summary_feedback`).Scan(&votes); err != nil || votes != 0 {
+		t.Errorf("votes left = %d, %v", votes, err)
+	}
+}
+
+func TestUserOptOut(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	for _, optOut := range []bool{true, true, false} {
+		if err := db.SetUserOptOut(ctx, -100, "aaaa1111", optOut); err != nil {
+			t.Fatal(err)
+		}
+		got, err := db.IsUserOptedOut(ctx, -100, "aaaa1111")
+		if err != nil || got != optOut {
+			t.Fatalf("after SetUserOptOut(%v): opted out = %v, %v", optOut, got, err)
+		}
+	}
+	if err := db.SetUserOptOut(ctx, -100, "aaaa1111", true); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := db.IsUserOptedOut(ctx, -200, "aaaa1111"); got {
+		t.Fatal("opt-out leaked to another group")
+	}
+}
@@ -0,0 +1,37 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// GetGroupLanguage returns the group's summary language code, or "" when none
+// is set (callers then use the configured default).
+func (db *DB) GetGroupLanguage(ctx context.Context, groupID int64) (string, error) {
+	var lang string
+	err := db.conn.QueryRowContext(ctx,
+		`SELECT language FROM group_languages WHERE group_id = ?`,
+		groupID,
+	).Scan(&lang)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return lang, err
+}
+
+// SetGroupLanguage stores the group's summary language code. Validation is the
+// caller's job (see i18n.Parse).
+func (db *DB) SetGroupLanguage(ctx context.Context, groupID, updatedBy int64, lang string) error {
+	_, err := db.conn.ExecContext(ctx,
+		`INSERT INTO group_languages (group_id, language, updated_at, updated_by)
+		 VALUES (?, ?, ?, ?)
+		 ON CONFLICT(group_id) DO UPDATE SET
+			language = excluded.language,
+			updated_at = excluded.updated_at,
+			updated_by = excluded.updated_by`,
+		groupID, lang, time.Now(), updatedBy,
+	)
+	return err
+}
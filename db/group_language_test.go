@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGroupLanguage(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	got, err := db.GetGroupLanguage(ctx, -100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "" {
+		t.Fatalf("expected empty language for unset group, got %q", got)
+	}
+
+	if err := db.SetGroupLanguage(ctx, -100, 42, "en"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetGroupLanguage(ctx, -100, 43, "auto"); err != nil {
+		t.Fatal(err)
+	}
+	got, err = db.GetGroupLanguage(ctx, -100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "auto" {
+		t.Fatalf("expected auto, got %q", got)
+	}
+
+	other, err := db.GetGroupLanguage(ctx, -200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other != "" {
+		t.Fatalf("language leaked to another group: %q", other)
+	}
+}
@@ -0,0 +1,157 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"telegram_summarize_bot/config"
+)
+
+// GroupSettingsOverrides holds a group's overrides of the global summary
+// settings. A nil field means "use the global value".
+type GroupSettingsOverrides struct {
+	TopicMax       *int
+	SummaryHours   *int
+	MaxMessages    *int
+	RateLimitSec   *int
+	ReplyThreads   *bool
+	PIIRedaction   *bool
+	DigestStats    *bool
+	ActionItems    *bool
+	CalendarEvents *bool
+}
+
+// IsEmpty reports whether no setting is overridden.
+func (o GroupSettingsOverrides) IsEmpty() bool {
+	return o.TopicMax == nil && o.SummaryHours == nil && o.MaxMessages == nil &&
+		o.RateLimitSec == nil && o.ReplyThreads == nil && o.PIIRedaction == nil &&
+		o.DigestStats == nil && o.ActionItems == nil && o.CalendarEvents == nil
+}
+
+// Apply returns base with the non-nil overrides applied.
+func (o GroupSettingsOverrides) Apply(base config.GroupSettings) config.GroupSettings {
+	if o.TopicMax != nil {
+		base.TopicMax = *o.TopicMax
+	}
+	if o.SummaryHours != nil {
+		base.SummaryHours = *o.SummaryHours
+	}
+	if o.MaxMessages != nil {
+		base.MaxMessages = *o.MaxMessages
+	}
+	if o.RateLimitSec != nil {
+		base.RateLimitSec = *o.RateLimitSec
+	}
+	if o.ReplyThreads != nil {
+		base.ReplyThreads = *o.ReplyThreads
+	}
+	if o.PIIRedaction != nil {
+		base.PIIRedaction = *o.PIIRedaction
+	}
+	if o.DigestStats != nil {
+		base.DigestStats = *o.DigestStats
+	}
+	if o.ActionItems != nil {
+		base.ActionItems = *o.ActionItems
+	}
+	if o.CalendarEvents != nil {
+		base.CalendarEvents = *o.CalendarEvents
+	}
+	return base
+}
+
+// GetGroupSettingsOverrides returns the group's overrides; all fields are nil
+// when the group has none.
+func (db *DB) GetGroupSettingsOverrides(ctx context.Context, groupID int64) (GroupSettingsOverrides, error) {
+	var topicMax, summaryHours, maxMessages, rateLimitSec, replyThreads, piiRedaction, digestStats, actionItems, calendarEvents sql.NullInt64
+	err := db.conn.QueryRowContext(ctx,
+		`SELECT topic_max, summary_hours, max_messages, rate_limit_sec, reply_threads, pii_redaction, digest_stats, action_items, calendar_events
+		 FROM group_settings WHERE group_id = ?`,
+		groupID,
+	).Scan(&topicMax, &summaryHours, &maxMessages, &rateLimitSec, &replyThreads, &piiRedaction, &digestStats, &actionItems, &calendarEvents)
+	if errors.Is(err, sql.ErrNoRows) {
+		return GroupSettingsOverrides{}, nil
+	}
+	if err != nil {
+		return GroupSettingsOverrides{}, err
+	}
+
+	var o GroupSettingsOverrides
+	o.TopicMax = nullIntPtr(topicMax)
+	o.SummaryHours = nullIntPtr(summaryHours)
+	o.MaxMessages = nullIntPtr(maxMessages)
+	o.RateLimitSec = nullIntPtr(rateLimitSec)
+	o.ReplyThreads = nullBoolPtr(replyThreads)
+	o.PIIRedaction = nullBoolPtr(piiRedaction)
+	o.DigestStats = nullBoolPtr(digestStats)
+	o.ActionItems = nullBoolPtr(actionItems)
+	o.CalendarEvents = nullBoolPtr(calendarEvents)
+	return o, nil
+}
+
+// SetGroupSettingsOverrides replaces the group's overrides. Validation is the
+// caller's job.
+func (db *DB) SetGroupSettingsOverrides(ctx context.Context, groupID, updatedBy int64, o GroupSettingsOverrides) error {
+	_, err := db.conn.ExecContext(ctx,
+		`INSERT INTO group_settings (group_id, topic_max, summary_hours, max_messages, rate_limit_sec, reply_threads, pii_redaction, digest_stats, action_items, calendar_events, updated_at, updated_by)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(group_id) DO UPDATE SET
+			topic_max = excluded.topic_max,
+			summary_hours = excluded.summary_hours,
+			max_messages = excluded.max_messages,
+			rate_limit_sec = excluded.rate_limit_sec,
+			reply_threads = excluded.reply_threads,
+			pii_redaction = excluded.pii_redaction,
+			digest_stats = excluded.digest_stats,
+			action_items = excluded.action_items,
+			calendar_events = excluded.calendar_events,
+			updated_at = excluded.updated_at,
+			updated_by = excluded.updated_by`,
+		groupID, intPtrArg(o.TopicMax), intPtrArg(o.SummaryHours), intPtrArg(o.MaxMessages),
+		intPtrArg(o.RateLimitSec), boolPtrArg(o.ReplyThreads), boolPtrArg(o.PIIRedaction),
+		boolPtrArg(o.DigestStats), boolPtrArg(o.ActionItems), boolPtrArg(o.CalendarEvents), time.Now(), updatedBy,
+	)
+	return err
+}
+
+// GroupSettings returns the group's effective settings: base with the group's
+// overrides applied. On a lookup error base is returned alongside the error.
+func (db *DB) GroupSettings(ctx context.Context, groupID int64, base config.GroupSettings) (config.GroupSettings, error) {
+	o, err := db.GetGroupSettingsOverrides(ctx, groupID)
+	if err != nil {
+		return base, err
+	}
+	return o.Apply(base), nil
+}
+
+func nullIntPtr(n sql.NullInt64) *int {
+	if !n.Valid {
+		return nil
+	}
+	v := int(n.Int64)
+	return &v
+}
+
+func intPtrArg(p *int) any {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
+
+func nullBoolPtr(n sql.NullInt64) *bool {
+	if !n.Valid {
+		return nil
+	}
+	v := n.Int64 != 0
+	return &v
+}
+
+func boolPtrArg(p *bool) any {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"telegram_summarize_bot/config"
+)
+
+func TestGroupSettingsOverrides(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	base := config.GroupSettings{TopicMax: 5, SummaryHours: 24, MaxMessages: 250, RateLimitSec: 60, ReplyThreads: true}
+
+	o, err := db.GetGroupSettingsOverrides(ctx, -100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !o.IsEmpty() {
+		t.Fatalf("expected no overrides for unset group, got %+v", o)
+	}
+
+	topics, hours, off, on := 8, 6, false, true
+	if err := db.SetGroupSettingsOverrides(ctx, -100, 42, GroupSettingsOverrides{TopicMax: &topics, SummaryHours: &hours, ReplyThreads: &off, PIIRedaction: &on, DigestStats: &on, ActionItems: &on, CalendarEvents: &on}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := db.GroupSettings(ctx, -100, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := config.GroupSettings{TopicMax: 8, SummaryHours: 6, MaxMessages: 250, RateLimitSec: 60, ReplyThreads: false, PIIRedaction: true, DigestStats: true, ActionItems: true, CalendarEvents: true}
+	if got != want {
+		t.Fatalf("effective settings = %+v, want %+v", got, want)
+	}
+
+	// Replacing the row clears fields that are now nil.
+	if err := db.SetGroupSettingsOverrides(ctx, -100, 43, GroupSettingsOverrides{TopicMax: &topics}); err != nil {
+		t.Fatal(err)
+	}
+	o, err = db.GetGroupSettingsOverrides(ctx, -100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.TopicMax == nil || *o.TopicMax != 8 || o.SummaryHours != nil || o.ReplyThreads != nil || o.PIIRedaction != nil || o.DigestStats != nil || o.ActionItems != nil || o.CalendarEvents != nil {
+		t.Fatalf("unexpected overrides after replace: %+v", o)
+	}
+
+	other, err := db.GroupSettings(ctx, -200, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other != base {
+		t.Fatalf("overrides leaked to another group: %+v", other)
+	}
+}
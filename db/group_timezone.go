@@ -0,0 +1,37 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// GetGroupTimezone returns the group's IANA timezone name, or "" when none is
+// set (callers then use the configured default).
+func (db *DB) GetGroupTimezone(ctx context.Context, groupID int64) (string, error) {
+	var tz string
+	err := db.conn.QueryRowContext(ctx,
+		`SELECT timezone FROM group_timezones WHERE group_id = ?`,
+		groupID,
+	).Scan(&tz)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return tz, err
+}
+
+// SetGroupTimezone stores the group's IANA timezone name. Validation is the
+// caller's job (see time.LoadLocation).
+func (db *DB) SetGroupTimezone(ctx context.Context, groupID, updatedBy int64, tz string) error {
+	_, err := db.conn.ExecContext(ctx,
+		`INSERT INTO group_timezones (group_id, timezone, updated_at, updated_by)
+		 VALUES (?, ?, ?, ?)
+		 ON CONFLICT(group_id) DO UPDATE SET
+			timezone = excluded.timezone,
+			updated_at = excluded.updated_at,
+			updated_by = excluded.updated_by`,
+		groupID, tz, time.Now(), updatedBy,
+	)
+	return err
+}
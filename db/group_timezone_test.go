@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGroupTimezone(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	got, err := db.GetGroupTimezone(ctx, -100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "" {
+		t.Fatalf("expected empty timezone for unset group, got %q", got)
+	}
+
+	if err := db.SetGroupTimezone(ctx, -100, 42, "Europe/Moscow"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetGroupTimezone(ctx, -100, 43, "Asia/Tbilisi"); err != nil {
+		t.Fatal(err)
+	}
+	got, err = db.GetGroupTimezone(ctx, -100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Asia/Tbilisi" {
+		t.Fatalf("expected Asia/Tbilisi, got %q", got)
+	}
+
+	other, err := db.GetGroupTimezone(ctx, -200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other != "" {
+		t.Fatalf("timezone leaked to another group: %q", other)
+	}
+}
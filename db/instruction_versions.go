@@ -0,0 +1,154 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// maxInstructionVersions bounds the per-group history; older versions are
+// pruned when a new one is appended.
+const maxInstructionVersions = 50
+
+// ErrInstructionsVersionNotFound is returned when restoring a version that is
+// not (or no longer) in the group's history.
+var ErrInstructionsVersionNotFound = errors.New("summary instructions version not found")
+
+// GroupSummaryInstructionsVersion is one entry in a group's instructions
+// history. An empty Instructions marks a clear.
+type GroupSummaryInstructionsVersion struct {
+	GroupID      int64
+	Version      int
+	Instructions string
+	CreatedAt    time.Time
+	CreatedBy    int64
+}
+
+// appendInstructionsVersion records text as the group's next version inside tx,
+// unless it equals the latest version already stored.
+func appendInstructionsVersion(ctx context.Context, tx *sql.Tx, groupID, createdBy int64, text string, now time.Time) error {
+	var latest sql.NullString
+	err := tx.QueryRowContext(ctx,
+		`SELECT instructions FROM group_summary_instruction_versions
+		 WHERE group_id = ? ORDER BY version DESC LIMIT 1`,
+		groupID,
+	).Scan(&latest)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if latest.Valid && latest.String == text {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO group_summary_instruction_versions (group_id, version, instructions, created_at, created_by)
+		 SELECT ?, COALESCE(MAX(version), 0) + 1, ?, ?, ?
+		 FROM group_summary_instruction_versions WHERE group_id = ?`,
+		groupID, text, now, createdBy, groupID,
+	); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		`DELETE FROM group_summary_instruction_versions
+		 WHERE group_id = ? AND version <= (
+			SELECT MAX(version) - ? FROM group_summary_instruction_versions WHERE group_id = ?)`,
+		groupID, maxInstructionVersions, groupID,
+	)
+	return err
+}
+
+// ListGroupSummaryInstructionsVersions returns up to limit versions for the
+// group, newest first.
+func (db *DB) ListGroupSummaryInstructionsVersions(ctx context.Context, groupID int64, limit int) ([]GroupSummaryInstructionsVersion, error) {
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT group_id, version, instructions, created_at, created_by
+		 FROM group_summary_instruction_versions
+		 WHERE group_id = ?
+		 ORDER BY version DESC
+		 LIMIT ?`,
+		groupID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var versions []GroupSummaryInstructionsVersion
+	for rows.Next() {
+		var v GroupSummaryInstructionsVersion
+		if err := rows.Scan(&v.GroupID, &v.Version, &v.Instructions, &v.CreatedAt, &v.CreatedBy); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// GetGroupSummaryInstructionsVersion returns a single version, or (nil, nil)
+// when it does not exist.
+func (db *DB) GetGroupSummaryInstructionsVersion(ctx context.Context, groupID int64, version int) (*GroupSummaryInstructionsVersion, error) {
+	var v GroupSummaryInstructionsVersion
+	err := db.conn.QueryRowContext(ctx,
+		`SELECT group_id, version, instructions, created_at, created_by
+		 FROM group_summary_instruction_versions
+		 WHERE group_id = ? AND version = ?`,
+		groupID, version,
+	).Scan(&v.GroupID, &v.Version, &v.Instructions, &v.CreatedAt, &v.CreatedBy)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// PreviousGroupSummaryInstructionsVersion returns the newest stored version
+// older than version, or (nil, nil) when none is left. It need not be
+// version-1: older versions are pruned.
+func (db *DB) PreviousGroupSummaryInstructionsVersion(ctx context.Context, groupID int64, version int) (*GroupSummaryInstructionsVersion, error) {
+	var v GroupSummaryInstructionsVersion
+	err := db.conn.QueryRowContext(ctx,
+		`SELECT group_id, version, instructions, created_at, created_by
+		 FROM group_summary_instruction_versions
+		 WHERE group_id = ? AND version < ?
+		 ORDER BY version DESC LIMIT 1`,
+		groupID, version,
+	).Scan(&v.GroupID, &v.Version, &v.Instructions, &v.CreatedAt, &v.CreatedBy)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// LatestGroupSummaryInstructionsVersion returns the group's current version
+// number (a clear counts as a version), or 0 when it has no history.
+func (db *DB) LatestGroupSummaryInstructionsVersion(ctx context.Context, groupID int64) (int, error) {
+	var version int
+	err := db.conn.QueryRowContext(ctx,
+		`SELECT COALESCE(MAX(version), 0) FROM group_summary_instruction_versions WHERE group_id = ?`,
+		groupID,
+	).Scan(&version)
+	return version, err
+}
+
+// RestoreGroupSummaryInstructionsVersion makes an earlier version current
+// again. The restore is itself recorded as a new version, so it can be undone
+// the same way.
+func (db *DB) RestoreGroupSummaryInstructionsVersion(ctx context.Context, groupID int64, version int, restoredBy int64) error {
+	v, err := db.GetGroupSummaryInstructionsVersion(ctx, groupID, version)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		return ErrInstructionsVersionNotFound
+	}
+	if v.Instructions == "" {
+		return db.ClearGroupSummaryInstructions(ctx, groupID, restoredBy)
+	}
+	return db.SetGroupSummaryInstructions(ctx, groupID, restoredBy, v.Instructions)
+}
@@ -0,0 +1,143 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestGroupSummaryInstructionsVersions(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	if err := db.SetGroupSummaryInstructions(ctx, -100, 1, "первая"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetGroupSummaryInstructions(ctx, -100, 2, "вторая"); err != nil {
+		t.Fatal(err)
+	}
+	// Saving identical text must not add a duplicate version.
+	if err := db.SetGroupSummaryInstructions(ctx, -100, 2, "вторая"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := db.GetGroupSummaryInstructions(ctx, -100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.Version != 2 {
+		t.Fatalf("expected current version 2, got %+v", got)
+	}
+
+	t.Run("clear is recorded and reversible", func(t *testing.T) {
+		if err := db.ClearGroupSummaryInstructions(ctx, -100, 3); err != nil {
+			t.Fatal(err)
+		}
+		versions, err := db.ListGroupSummaryInstructionsVersions(ctx, -100, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(versions) != 3 {
+			t.Fatalf("expected 3 versions, got %+v", versions)
+		}
+		if versions[0].Version != 3 || versions[0].Instructions != "" || versions[0].CreatedBy != 3 {
+			t.Fatalf("expected clear tombstone as newest version, got %+v", versions[0])
+		}
+		if versions[2].Instructions != "первая" {
+			t.Fatalf("expected oldest version last, got %+v", versions[2])
+		}
+
+		if err := db.RestoreGroupSummaryInstructionsVersion(ctx, -100, 2, 4); err != nil {
+			t.Fatal(err)
+		}
+		got, err := db.GetGroupSummaryInstructions(ctx, -100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got == nil || got.Instructions != "вторая" || got.Version != 4 || got.UpdatedBy != 4 {
+			t.Fatalf("expected restored instructions as version 4, got %+v", got)
+		}
+	})
+
+	t.Run("restoring a clear clears again", func(t *testing.T) {
+		if err := db.RestoreGroupSummaryInstructionsVersion(ctx, -100, 3, 5); err != nil {
+			t.Fatal(err)
+		}
+		got, err := db.GetGroupSummaryInstructions(ctx, -100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != nil {
+			t.Fatalf("expected instructions cleared, got %+v", got)
+		}
+		if latest, err := db.LatestGroupSummaryInstructionsVersion(ctx, -100); err != nil || latest != 5 {
+			t.Fatalf("latest version = %d, %v; want the clear's 5", latest, err)
+		}
+	})
+
+	t.Run("clearing nothing records nothing", func(t *testing.T) {
+		if err := db.ClearGroupSummaryInstructions(ctx, -300, 1); err != nil {
+			t.Fatal(err)
+		}
+		versions, err := db.ListGroupSummaryInstructionsVersions(ctx, -300, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(versions) != 0 {
+			t.Fatalf("expected no versions, got %+v", versions)
+		}
+		if latest, err := db.LatestGroupSummaryInstructionsVersion(ctx, -300); err != nil || latest != 0 {
+			t.Fatalf("latest version = %d, %v; want 0", latest, err)
+		}
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		err := db.RestoreGroupSummaryInstructionsVersion(ctx, -100, 99, 1)
+		if !errors.Is(err, ErrInstructionsVersionNotFound) {
+			t.Fatalf("expected ErrInstructionsVersionNotFound, got %v", err)
+		}
+	})
+}
+
+func TestGroupSummaryInstructionsVersionsPruned(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	for i := range maxInstructionVersions + 5 {
+		if err := db.SetGroupSummaryInstructions(ctx, -100, 1, string(rune('a'+i%26))+string(rune('0'+i/26))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	versions, err := db.ListGroupSummaryInstructionsVersions(ctx, -100, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != maxInstructionVersions {
+		t.Fatalf("expected %d versions after pruning, got %d", maxInstructionVersions, len(versions))
+	}
+	if versions[0].Version != maxInstructionVersions+5 {
+		t.Fatalf("expected newest version %d, got %d", maxInstructionVersions+5, versions[0].Version)
+	}
+}
+
+func TestPreviousGroupSummaryInstructionsVersion(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	for _, text := range []string{"первая", "вторая", "третья"} {
+		if err := db.SetGroupSummaryInstructions(ctx, -100, 1, text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.conn.Exec(`DELETE FROM group_summary_instruction_versions WHERE version = 2`); err != nil {
+		t.Fatal(err)
+	}
+
+	prev, err := db.PreviousGroupSummaryInstructionsVersion(ctx, -100, 3)
+	if err != nil || prev == nil || prev.Version != 1 || prev.Instructions != "первая" {
+		t.Fatalf("previous of v3 = %+v, %v; want v1", prev, err)
+	}
+	if prev, err := db.PreviousGroupSummaryInstructionsVersion(ctx, -100, 1); err != nil || prev != nil {
+		t.Fatalf("previous of v1 = %+v, %v; want none", prev, err)
+	}
+}
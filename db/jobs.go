@@ -0,0 +1,201 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Job states. A job is queued until a worker claims it, running while it
+// works, and then done or — once its retries ran out — failed.
+const (
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+// Job is a unit of summarization work that outlives the process that queued
+// it: a restart resumes it where the chat can see it, in StatusMsgID.
+type Job struct {
+	ID          int64
+	Kind        string
+	GroupID     int64
+	Key         string // at most one queued or running job per non-empty key
+	Payload     string // kind-specific JSON
+	StatusMsgID int64  // the chat message showing the job's progress; 0 if none yet
+	Lang        string // language of the status message
+	State       string
+	Attempts    int       // failed runs so far
+	Resumed     bool      // interrupted by a shutdown at least once
+	NextRunAt   time.Time // earliest time a queued job may run
+	LastError   string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+const jobColumns = `id, kind, group_id, dedupe_key, payload, status_msg_id, lang, state, attempts, resumed, next_run_at, last_error, created_at, updated_at`
+
+func scanJob(row interface{ Scan(...any) error }) (*Job, error) {
+	var j Job
+	if err := row.Scan(&j.ID, &j.Kind, &j.GroupID, &j.Key, &j.Payload, &j.StatusMsgID, &j.Lang,
+		&j.State, &j.Attempts, &j.Resumed, &j.NextRunAt, &j.LastError, &j.CreatedAt, &j.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// EnqueueJob stores j in state j.State (queued if empty), runnable from
+// j.NextRunAt (now if zero), and returns its ID. It returns 0 without error
+// when a queued or running job already has j's non-empty Key.
+func (db *DB) EnqueueJob(ctx context.Context, j Job) (int64, error) {
+	now := time.Now()
+	if j.State == "" {
+		j.State = JobQueued
+	}
+	if j.NextRunAt.IsZero() {
+		j.NextRunAt = now
+	}
+	res, err := db.conn.ExecContext(ctx,
+		`INSERT INTO jobs (kind, group_id, dedupe_key, payload, status_msg_id, lang, state, attempts, resumed, next_run_at, last_error, created_at, updated_at)
+		 SELECT ?, ?, ?, ?, ?, ?, ?, 0, 0, ?, '', ?, ?
+		 WHERE ? = '' OR NOT EXISTS (
+			SELECT 1 FROM jobs WHERE dedupe_key = ? AND state IN ('queued', 'running'))`,
+		j.Kind, j.GroupID, j.Key, j.Payload, j.StatusMsgID, j.Lang, j.State, j.NextRunAt, now, now,
+		j.Key, j.Key,
+	)
+	if err != nil {
+		return 0, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// GetJob returns the job, or nil if there is none with that ID.
+func (db *DB) GetJob(ctx context.Context, id int64) (*Job, error) {
+	j, err := scanJob(db.conn.QueryRowContext(ctx, `SELECT `+jobColumns+` FROM jobs WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return j, err
+}
+
+// ClaimJob marks the oldest queued job that is due at now as running and
+// returns it, or nil when none is due.
+func (db *DB) ClaimJob(ctx context.Context, now time.Time) (*Job, error) {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var id int64
+	err = tx.QueryRowContext(ctx,
+		`SELECT id FROM jobs WHERE state = 'queued' AND next_run_at <= ? ORDER BY next_run_at, id LIMIT 1`, now,
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE jobs SET state = 'running', updated_at = ? WHERE id = ?`, time.Now(), id,
+	); err != nil {
+		return nil, err
+	}
+	j, err := scanJob(tx.QueryRowContext(ctx, `SELECT `+jobColumns+` FROM jobs WHERE id = ?`, id))
+	if err != nil {
+		return nil, err
+	}
+	return j, tx.Commit()
+}
+
+// SetJobStatus records the job's status message and its language.
+func (db *DB) SetJobStatus(ctx context.Context, id, statusMsgID int64, lang string) error {
+	_, err := db.conn.ExecContext(ctx,
+		`UPDATE jobs SET status_msg_id = ?, lang = ?, updated_at = ? WHERE id = ?`,
+		statusMsgID, lang, time.Now(), id,
+	)
+	return err
+}
+
+// CompleteJob marks the job done.
+func (db *DB) CompleteJob(ctx context.Context, id int64) error {
+	return db.setJobState(ctx, id, JobDone)
+}
+
+// RetryJob records a failed run and queues the job again from at.
+func (db *DB) RetryJob(ctx context.Context, id int64, at time.Time, errMsg string) error {
+	_, err := db.conn.ExecContext(ctx,
+		`UPDATE jobs SET state = 'queued', attempts = attempts + 1, next_run_at = ?, last_error = ?, updated_at = ? WHERE id = ?`,
+		at, errMsg, time.Now(), id,
+	)
+	return err
+}
+
+// PostponeJob queues a running job again from at without counting the run
+// as a failure, for a job that couldn't start yet.
+func (db *DB) PostponeJob(ctx context.Context, id int64, at time.Time) error {
+	_, err := db.conn.ExecContext(ctx,
+		`UPDATE jobs SET state = 'queued', next_run_at = ?, updated_at = ? WHERE id = ?`,
+		at, time.Now(), id,
+	)
+	return err
+}
+
+// FailJob records a failed run and gives up on the job.
+func (db *DB) FailJob(ctx context.Context, id int64, errMsg string) error {
+	_, err := db.conn.ExecContext(ctx,
+		`UPDATE jobs SET state = 'failed', attempts = attempts + 1, last_error = ?, updated_at = ? WHERE id = ?`,
+		errMsg, time.Now(), id,
+	)
+	return err
+}
+
+// CheckpointJob puts a running job interrupted by a shutdown back in the
+// queue, without counting the run as a failure, to be resumed.
+func (db *DB) CheckpointJob(ctx context.Context, id int64) error {
+	_, err := db.conn.ExecContext(ctx,
+		`UPDATE jobs SET state = 'queued', resumed = 1, updated_at = ? WHERE id = ? AND state = 'running'`,
+		time.Now(), id,
+	)
+	return err
+}
+
+// CheckpointRunningJobs is CheckpointJob for every running job: at shutdown
+// for workers that didn't stop in time, and at startup for jobs a crash left
+// running.
+func (db *DB) CheckpointRunningJobs(ctx context.Context) (int64, error) {
+	res, err := db.conn.ExecContext(ctx,
+		`UPDATE jobs SET state = 'queued', resumed = 1, updated_at = ? WHERE state = 'running'`,
+		time.Now(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// CleanupOldJobs drops done and failed jobs last updated more than olderThan
+// ago.
+func (db *DB) CleanupOldJobs(ctx context.Context, olderThan time.Duration) (int64, error) {
+	res, err := db.conn.ExecContext(ctx,
+		`DELETE FROM jobs WHERE state IN ('done', 'failed') AND updated_at < ?`,
+		time.Now().Add(-olderThan),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (db *DB) setJobState(ctx context.Context, id int64, state string) error {
+	_, err := db.conn.ExecContext(ctx,
+		`UPDATE jobs SET state = ?, updated_at = ? WHERE id = ?`, state, time.Now(), id,
+	)
+	return err
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestJobLifecycle(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	id, err := db.EnqueueJob(ctx, Job{Kind: "digest", GroupID: -100, Key: "digest:-100", Payload: `{}`})
+	if err != nil || id == 0 {
+		t.Fatalf("EnqueueJob = %d, %v", id, err)
+	}
+	if dup, err := db.EnqueueJob(ctx, Job{Kind: "digest", GroupID: -100, Key: "digest:-100"}); err != nil || dup != 0 {
+		t.Fatalf("duplicate EnqueueJob = %d, %v; want 0 while the first is queued", dup, err)
+	}
+
+	job, err := db.ClaimJob(ctx, time.Now())
+	if err != nil || job == nil || job.ID != id || job.State != JobRunning {
+		t.Fatalf("ClaimJob = %+v, %v; want the queued job running", job, err)
+	}
+	if again, err := db.ClaimJob(ctx, time.Now()); err != nil || again != nil {
+		t.Fatalf("second ClaimJob = %+v, %v; want nothing left", again, err)
+	}
+
+	if err := db.SetJobStatus(ctx, id, 55, "en"); err != nil {
+		t.Fatal(err)
+	}
+	retryAt := time.Now().Add(time.Minute)
+	if err := db.RetryJob(ctx, id, retryAt, "boom"); err != nil {
+		t.Fatal(err)
+	}
+	if due, err := db.ClaimJob(ctx, time.Now()); err != nil || due != nil {
+		t.Fatalf("ClaimJob before the retry time = %+v, %v; want nothing", due, err)
+	}
+	job, err = db.ClaimJob(ctx, retryAt)
+	if err != nil || job == nil {
+		t.Fatalf("ClaimJob at the retry time = %+v, %v", job, err)
+	}
+	if job.Attempts != 1 || job.LastError != "boom" || job.StatusMsgID != 55 || job.Lang != "en" {
+		t.Fatalf("retried job = %+v", job)
+	}
+
+	if err := db.CompleteJob(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if id2, err := db.EnqueueJob(ctx, Job{Kind: "digest", GroupID: -100, Key: "digest:-100"}); err != nil || id2 == 0 {
+		t.Fatalf("EnqueueJob after the first is done = %d, %v; want a new job", id2, err)
+	}
+}
+
+func TestCheckpointRunningJobs(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	running, _ := db.EnqueueJob(ctx, Job{Kind: "summary", GroupID: -100, State: JobRunning})
+	queued, _ := db.EnqueueJob(ctx, Job{Kind: "summary", GroupID: -100})
+
+	n, err := db.CheckpointRunningJobs(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("CheckpointRunningJobs = %d, %v; want 1", n, err)
+	}
+	job, _ := db.GetJob(ctx, running)
+	if job.State != JobQueued || !job.Resumed || job.Attempts != 0 {
+		t.Fatalf("checkpointed job = %+v; want queued, resumed, no failed attempt", job)
+	}
+	if job, _ := db.GetJob(ctx, queued); job.Resumed {
+		t.Fatal("a job that wasn't running should not be marked resumed")
+	}
+}
+
+func TestFailAndCleanupJobs(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	id, _ := db.EnqueueJob(ctx, Job{Kind: "summary", GroupID: -100})
+	if err := db.FailJob(ctx, id, "gave up"); err != nil {
+		t.Fatal(err)
+	}
+	job, _ := db.GetJob(ctx, id)
+	if job.State != JobFailed || job.Attempts != 1 {
+		t.Fatalf("failed job = %+v", job)
+	}
+	keep, _ := db.EnqueueJob(ctx, Job{Kind: "summary", GroupID: -100})
+
+	n, err := db.CleanupOldJobs(ctx, -time.Minute)
+	if err != nil || n != 1 {
+		t.Fatalf("CleanupOldJobs = %d, %v; want the failed job dropped", n, err)
+	}
+	if job, _ := db.GetJob(ctx, keep); job == nil {
+		t.Fatal("a queued job should survive cleanup")
+	}
+}
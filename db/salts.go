@@ -0,0 +1,270 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// HashSalt is one epoch of the HMAC salt behind UserHash. Rotating the salt
+// starts a new epoch, so a member's hash changes and activity can't be linked
+// across epochs by the hash alone. A retired salt is kept for a grace period
+// so hashes from before the rotation can still be relinked to the new ones
+// (see RelinkUserHash).
+type HashSalt struct {
+	Epoch     int64
+	Salt      []byte
+	CreatedAt time.Time
+	RetiredAt *time.Time // nil for the current salt
+}
+
+func newSaltHex() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// GetUserHashSalt returns the current HMAC salt, generating one on first call.
+func (db *DB) GetUserHashSalt(ctx context.Context) ([]byte, error) {
+	current, _, err := db.GetUserHashSalts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return current.Salt, nil
+}
+
+// GetUserHashSalts returns the current salt and the retired ones still kept,
+// newest first. On first use it adopts the salt older versions kept in
+// bot_config as epoch 0, or generates one.
+func (db *DB) GetUserHashSalts(ctx context.Context) (HashSalt, []HashSalt, error) {
+	if err := db.seedUserHashSalt(ctx); err != nil {
+		return HashSalt{}, nil, err
+	}
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT epoch, salt, created_at, retired_at FROM user_hash_salts ORDER BY epoch DESC`)
+	if err != nil {
+		return HashSalt{}, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var (
+		current HashSalt
+		retired []HashSalt
+		found   bool
+	)
+	for rows.Next() {
+		var (
+			s         HashSalt
+			saltHex   string
+			retiredAt sql.NullTime
+		)
+		if err := rows.Scan(&s.Epoch, &saltHex, &s.CreatedAt, &retiredAt); err != nil {
+			return HashSalt{}, nil, err
+		}
+		if s.Salt, err = hex.DecodeString(saltHex); err != nil {
+			return HashSalt{}, nil, fmt.Errorf("user hash salt %d: %w", s.Epoch, err)
+		}
+		if retiredAt.Valid {
+			s.RetiredAt = &retiredAt.Time
+			retired = append(retired, s)
+		} else if !found {
+			current, found = s, true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return HashSalt{}, nil, err
+	}
+	if !found {
+		return HashSalt{}, nil, errors.New("no current user hash salt")
+	}
+	return current, retired, nil
+}
+
+// seedUserHashSalt creates epoch 0 when there is no salt yet.
+func (db *DB) seedUserHashSalt(ctx context.Context) error {
+	var n int
+	if err := db.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM user_hash_salts`).Scan(&n); err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Keep hashes from before salt epochs existed valid.
+	var salt string
+	err = tx.QueryRowContext(ctx, `SELECT value FROM bot_config WHERE key = 'user_hash_salt'`).Scan(&salt)
+	if errors.Is(err, sql.ErrNoRows) {
+		salt, err = newSaltHex()
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT OR IGNORE INTO user_hash_salts (epoch, salt, created_at) VALUES (0, ?, ?)`,
+		salt, time.Now()); err != nil {
+		return err
+	}
+	// The salt now lives only in user_hash_salts, where purging a retired
+	// one really removes it.
+	if _, err := tx.ExecContext(ctx, `DELETE FROM bot_config WHERE key = 'user_hash_salt'`); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RotateUserHashSalt retires the current salt and starts a new epoch, which
+// it returns. Digest subscriptions, which know the member's chat, are
+// rehashed right away; everything else keeps its old hash until the member is
+// next seen (see RelinkUserHash) or ages out.
+func (db *DB) RotateUserHashSalt(ctx context.Context) (HashSalt, error) {
+	current, _, err := db.GetUserHashSalts(ctx)
+	if err != nil {
+		return HashSalt{}, err
+	}
+	saltHex, err := newSaltHex()
+	if err != nil {
+		return HashSalt{}, err
+	}
+	next := HashSalt{Epoch: current.Epoch + 1, CreatedAt: time.Now()}
+	next.Salt, _ = hex.DecodeString(saltHex)
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return HashSalt{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE user_hash_salts SET retired_at = ? WHERE epoch = ? AND retired_at IS NULL`,
+		next.CreatedAt, current.Epoch); err != nil {
+		return HashSalt{}, err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO user_hash_salts (epoch, salt, created_at) VALUES (?, ?, ?)`,
+		next.Epoch, saltHex, next.CreatedAt); err != nil {
+		return HashSalt{}, err
+	}
+
+	// Subscriptions are DMed, so their chat is the member's user ID.
+	rows, err := tx.QueryContext(ctx, `SELECT group_id, user_hash, chat_id, key_id FROM digest_subscriptions`)
+	if err != nil {
+		return HashSalt{}, err
+	}
+	type sub struct {
+		groupID, chatID int64
+		userHash        string
+	}
+	var subs []sub
+	for rows.Next() {
+		var s sub
+		var chatID string
+		var keyID sql.NullString
+		if err := rows.Scan(&s.groupID, &s.userHash, &chatID, &keyID); err != nil {
+			_ = rows.Close()
+			return HashSalt{}, err
+		}
+		if s.chatID, err = db.openChatID(chatID, keyID); err != nil {
+			_ = rows.Close()
+			return HashSalt{}, err
+		}
+		subs = append(subs, s)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return HashSalt{}, err
+	}
+	for _, s := range subs {
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE digest_subscriptions SET user_hash = ? WHERE group_id = ? AND user_hash = ?`,
+			UserHash(s.chatID, s.groupID, next.Salt), s.groupID, s.userHash); err != nil {
+			return HashSalt{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return HashSalt{}, err
+	}
+	return next, nil
+}
+
+// PurgeRetiredSalts deletes salts retired longer than olderThan ago, after
+// which hashes made with them can no longer be relinked. A salt is kept while
+// an opt-out made under it hasn't been relinked yet, so opting out survives
+// rotation however long the member stays silent.
+func (db *DB) PurgeRetiredSalts(ctx context.Context, olderThan time.Duration) (int64, error) {
+	res, err := db.conn.ExecContext(ctx,
+		`DELETE FROM user_hash_salts
+		 WHERE retired_at IS NOT NULL AND retired_at < ?
+		   AND epoch NOT IN (SELECT salt_epoch FROM user_opt_outs)`,
+		time.Now().Add(-olderThan),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// RelinkUserHash moves what the member needs to keep working from oldHashes
+// (their hashes under retired salts) to newHash, their hash under the current
+// salt of the given epoch: their messages, which age out with the retired
+// salt anyway, catch-up mark, digest subscription and opt-out. Where the
+// member already has a row under newHash too, that one is kept. Summary votes
+// stay under the old hash, so they can't be linked across epochs and age out
+// with their summaries.
+func (db *DB) RelinkUserHash(ctx context.Context, groupID int64, newHash string, epoch int64, oldHashes []string) error {
+	if len(oldHashes) == 0 {
+		return nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(oldHashes)), ",")
+	old := make([]any, len(oldHashes))
+	for i, h := range oldHashes {
+		old[i] = h
+	}
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	exec := func(query string, args ...any) error {
+		_, err := tx.ExecContext(ctx, query, append(args, old...)...)
+		return err
+	}
+	if err := exec(`UPDATE messages SET user_hash = ? WHERE group_id = ? AND user_hash IN (`+placeholders+`)`,
+		newHash, groupID); err != nil {
+		return err
+	}
+	for _, table := range []string{"user_catchups", "digest_subscriptions"} {
+		if err := exec(`UPDATE OR IGNORE `+table+` SET user_hash = ? WHERE group_id = ? AND user_hash IN (`+placeholders+`)`,
+			newHash, groupID); err != nil {
+			return err
+		}
+		if err := exec(`DELETE FROM `+table+` WHERE group_id = ? AND user_hash IN (`+placeholders+`)`,
+			groupID); err != nil {
+			return err
+		}
+	}
+	if err := exec(`UPDATE OR IGNORE user_opt_outs SET user_hash = ?, salt_epoch = ? WHERE group_id = ? AND user_hash IN (`+placeholders+`)`,
+		newHash, epoch, groupID); err != nil {
+		return err
+	}
+	if err := exec(`DELETE FROM user_opt_outs WHERE group_id = ? AND user_hash IN (`+placeholders+`)`,
+		groupID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,223 @@
+package db
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+	"time"
+)
+
+func TestUserHashSaltAdoptsLegacySalt(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	legacy := "00112233445566778899aabbccddeeff00112233445566778899aabbccddeeff"
+	if _, err := db.conn.Exec(`INSERT INTO bot_config (key, value) VALUES ('user_hash_salt', ?)`, legacy); err != nil {
+		t.Fatal(err)
+	}
+	current, retired, err := db.GetUserHashSalts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.Epoch != 0 || len(retired) != 0 {
+		t.Fatalf("current epoch %d with %d retired, want epoch 0 alone", current.Epoch, len(retired))
+	}
+	if got := hex.EncodeToString(current.Salt); got != legacy {
+		t.Errorf("salt %s, want the legacy one", got)
+	}
+	var n int
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM bot_config WHERE key = 'user_hash_salt'`).Scan(&n); err != nil || n != 0 {
+		t.Errorf("legacy salt left in bot_config: %d, %v", n, err)
+	}
+}
+
+func TestRotateUserHashSalt(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	first, err := db.GetUserHashSalt(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetDigestSubscription(ctx, DigestSubscription{GroupID: -100, UserHash: UserHash(7, -100, first), ChatID: 7, Hour: 8}); err != nil {
+		t.Fatal(err)
+	}
+	// An encrypted chat ID is decrypted to recompute the hash.
+	db.SetCipher(mustCipher(t, "secret"))
+	if err := db.SetDigestSubscription(ctx, DigestSubscription{GroupID: -100, UserHash: UserHash(8, -100, first), ChatID: 8, Hour: 8}); err != nil {
+		t.Fatal(err)
+	}
+
+	next, err := db.RotateUserHashSalt(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Epoch != 1 || string(next.Salt) == string(first) {
+		t.Fatalf("rotated to epoch %d, salt changed = %v", next.Epoch, string(next.Salt) != string(first))
+	}
+	current, retired, err := db.GetUserHashSalts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.Epoch != 1 || string(current.Salt) != string(next.Salt) {
+		t.Errorf("current epoch %d, want the rotated salt", current.Epoch)
+	}
+	if len(retired) != 1 || retired[0].Epoch != 0 || retired[0].RetiredAt == nil {
+		t.Fatalf("retired = %+v, want epoch 0", retired)
+	}
+
+	for _, userID := range []int64{7, 8} {
+		if s, _ := db.GetDigestSubscription(ctx, -100, UserHash(userID, -100, next.Salt)); s == nil || s.ChatID != userID {
+			t.Errorf("digest subscription of %d not rehashed under the new salt: %+v", userID, s)
+		}
+	}
+	if s, _ := db.GetDigestSubscription(ctx, -100, UserHash(7, -100, first)); s != nil {
+		t.Error("digest subscription still under the retired salt")
+	}
+}
+
+func TestRelinkUserHash(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	now := time.Now().UTC()
+
+	const oldHash, newHash = "aaaa1111", "cccc3333"
+	for _, m := range []Message{
+		{GroupID: -100, UserHash: oldHash, Text: "до ротации", Timestamp: now.Add(-time.Minute)},
+		{GroupID: -100, UserHash: newHash, Text: "после ротации", Timestamp: now},
+		{GroupID: -200, UserHash: oldHash, Text: "в другой группе", Timestamp: now},
+	} {
+		if _, err := db.AddMessageReturningID(ctx, &m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Both hashes have a catch-up mark; the one under the new hash wins.
+	if err := db.SetLastCatchup(ctx, -100, oldHash, now.Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetLastCatchup(ctx, -100, newHash, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetUserOptOut(ctx, -100, oldHash, true); err != nil {
+		t.Fatal(err)
+	}
+	summaryID, err := db.SaveSummary(ctx, StoredSummary{GroupID: -100, Lang: "ru"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetSummaryFeedback(ctx, summaryID, oldHash, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.RelinkUserHash(ctx, -100, newHash, 1, []string{oldHash}); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, err := db.GetMessages(ctx, -100, now.Add(-time.Hour), 10)
+	if err != nil || len(msgs) != 2 {
+		t.Fatalf("messages = %+v, %v", msgs, err)
+	}
+	for _, m := range msgs {
+		if m.UserHash != newHash {
+			t.Errorf("message %q still under %s", m.Text, m.UserHash)
+		}
+	}
+	if other, _ := db.GetMessages(ctx, -200, now.Add(-time.Hour), 10); len(other) != 1 || other[0].UserHash != oldHash {
+		t.Errorf("relinking reached another group: %+v", other)
+	}
+	if c, _ := db.GetLastCatchup(ctx, -100, newHash); c == nil || !c.Equal(now) {
+		t.Errorf("catch-up mark = %v, want the one under the new hash", c)
+	}
+	if c, _ := db.GetLastCatchup(ctx, -100, oldHash); c != nil {
+		t.Errorf("catch-up mark under the old hash kept: %v", c)
+	}
+	if out, _ := db.IsUserOptedOut(ctx, -100, newHash); !out {
+		t.Error("opt-out not carried over to the new hash")
+	}
+	var epoch int64
+	if err := db.conn.QueryRow(`SELECT salt_epoch FROM user_opt_outs WHERE user_hash = ?`, newHash).Scan(&epoch); err != nil || epoch != 1 {
+		t.Errorf("opt-out salt epoch = %d, %v; want 1", epoch, err)
+	}
+}
+
+func TestRelinkUserHashLeavesFeedback(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	const oldHash, newHash = "aaaa1111", "cccc3333"
+	before, err := db.SaveSummary(ctx, StoredSummary{GroupID: -100, Lang: "ru"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetSummaryFeedback(ctx, before, oldHash, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.RelinkUserHash(ctx, -100, newHash, 1, []string{oldHash}); err != nil {
+		t.Fatal(err)
+	}
+	after, err := db.SaveSummary(ctx, StoredSummary{GroupID: -100, Lang: "ru"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetSummaryFeedback(ctx, after, newHash, -1); err != nil {
+		t.Fatal(err)
+	}
+
+	// No vote from before the rotation joins the member's current hash.
+	var joined int
+	if err := db.conn.QueryRow(
+		`SELECT COUNT(*) FROM summary_feedback a JOIN summary_feedback b ON a.user_hash = b.user_hash
+		 WHERE a.summary_id = ? AND b.summary_id = ?`, before, after,
+	).Scan(&joined); err != nil || joined != 0 {
+		t.Fatalf("votes joined across the rotation = %d, %v", joined, err)
+	}
+	var hash string
+	if err := db.conn.QueryRow(`SELECT user_hash FROM summary_feedback WHERE summary_id = ?`, before).Scan(&hash); err != nil || hash != oldHash {
+		t.Fatalf("pre-rotation vote under %q, %v; want it left under the old hash", hash, err)
+	}
+
+	// Forgetting the member still reaches the vote under the retired hash.
+	if _, err := db.ForgetUser(ctx, -100, newHash, oldHash); err != nil {
+		t.Fatal(err)
+	}
+	var left int
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM summary_feedback`).Scan(&left); err != nil || left != 0 {
+		t.Fatalf("votes left after forgetting = %d, %v", left, err)
+	}
+}
+
+func TestPurgeRetiredSalts(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	if _, err := db.GetUserHashSalt(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// An opt-out made under epoch 0 keeps that salt.
+	if err := db.SetUserOptOut(ctx, -100, "aaaa1111", true); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if _, err := db.RotateUserHashSalt(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if purged, err := db.PurgeRetiredSalts(ctx, time.Hour); err != nil || purged != 0 {
+		t.Fatalf("purged %d recently retired salts, %v", purged, err)
+	}
+	purged, err := db.PurgeRetiredSalts(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 1 {
+		t.Errorf("purged %d salts, want only epoch 1", purged)
+	}
+	current, retired, err := db.GetUserHashSalts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.Epoch != 2 || len(retired) != 1 || retired[0].Epoch != 0 {
+		t.Errorf("current epoch %d, retired %+v; want 2 with epoch 0 kept", current.Epoch, retired)
+	}
+}
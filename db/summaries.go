@@ -0,0 +1,362 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SummaryTopic is a posted summary's topic with its cluster membership, kept
+// so the topic can be expanded later.
+type SummaryTopic struct {
+	Title      string
+	MessageIDs []int64 // Message.ID values, chronological
+}
+
+// Kinds of SummaryItem.
+const (
+	ItemDecision = "decision"
+	ItemAction   = "action"
+	ItemQuestion = "question"
+)
+
+// SummaryItem is a decision, action item or open question extracted with a
+// posted summary. Owner is the member's alias within that summary, never a
+// hash; SummaryID and CreatedAt are filled in when reading.
+type SummaryItem struct {
+	SummaryID   int64
+	Kind        string
+	Text        string
+	Owner       string
+	Due         string
+	TgMessageID int64 // source message; 0 if unknown
+	CreatedAt   time.Time
+}
+
+// SummaryEvent is a calendar event extracted with a posted summary. Start and
+// End carry the group's timezone at extraction time; an all-day event starts
+// at midnight and ends at the midnight after its last day.
+type SummaryEvent struct {
+	Title       string
+	Place       string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+	TgMessageID int64 // source message; 0 if unknown
+}
+
+// StoredSummary is a posted summary's topic structure, with the model and
+// instructions version that produced it for the quality report.
+type StoredSummary struct {
+	ID                  int64
+	GroupID             int64
+	Lang                string
+	Model               string
+	InstructionsVersion int // 0 when the group had no instructions
+	Topics              []SummaryTopic
+	Items               []SummaryItem
+	Events              []SummaryEvent
+	CreatedAt           time.Time
+}
+
+// SaveSummary stores a posted summary with its topics and returns its ID.
+// s.ID and s.CreatedAt are ignored.
+func (db *DB) SaveSummary(ctx context.Context, s StoredSummary) (int64, error) {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO summaries (group_id, lang, model, instructions_version, created_at) VALUES (?, ?, ?, ?, ?)`,
+		s.GroupID, s.Lang, s.Model, s.InstructionsVersion, time.Now(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	for i, t := range s.Topics {
+		ids, err := json.Marshal(t.MessageIDs)
+		if err != nil {
+			return 0, err
+		}
+		title, err := db.sealColumn("title", t.Title)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO summary_topics (summary_id, topic_index, title, message_ids, key_id) VALUES (?, ?, ?, ?, ?)`,
+			id, i, title, string(ids), db.writeKeyID(),
+		); err != nil {
+			return 0, err
+		}
+	}
+	for i, it := range s.Items {
+		// Items quote and name members, so they are sealed like messages.
+		text, err := db.sealColumn("text", it.Text)
+		if err != nil {
+			return 0, err
+		}
+		owner, err := db.sealColumn("owner", it.Owner)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO summary_items (summary_id, item_index, kind, text, owner, due, tg_message_id, key_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			id, i, it.Kind, text, owner, it.Due, nullableInt64(it.TgMessageID), db.writeKeyID(),
+		); err != nil {
+			return 0, err
+		}
+	}
+	for i, ev := range s.Events {
+		// Redacted names and places are restored before saving, so events
+		// are sealed too.
+		title, err := db.sealColumn("title", ev.Title)
+		if err != nil {
+			return 0, err
+		}
+		place, err := db.sealColumn("place", ev.Place)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO summary_events (summary_id, event_index, title, place, starts_at, ends_at, all_day, timezone, tg_message_id, key_id)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			id, i, title, place, ev.Start.UTC(), ev.End.UTC(), ev.AllDay, ev.Start.Location().String(), nullableInt64(ev.TgMessageID), db.writeKeyID(),
+		); err != nil {
+			return 0, err
+		}
+	}
+	return id, tx.Commit()
+}
+
+// GetSummaryItems returns the group's items of the given kind from summaries
+// created since then, newest summary first and in extraction order within
+// one summary.
+func (db *DB) GetSummaryItems(ctx context.Context, groupID int64, kind string, since time.Time) ([]SummaryItem, error) {
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT i.summary_id, i.kind, i.text, i.owner, i.due, i.tg_message_id, i.key_id, s.created_at
+		 FROM summary_items i JOIN summaries s ON s.id = i.summary_id
+		 WHERE s.group_id = ? AND i.kind = ? AND s.created_at >= ?
+		 ORDER BY s.created_at DESC, i.summary_id DESC, i.item_index`,
+		groupID, kind, since,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var items []SummaryItem
+	for rows.Next() {
+		var it SummaryItem
+		var tgMessageID sql.NullInt64
+		var keyID sql.NullString
+		if err := rows.Scan(&it.SummaryID, &it.Kind, &it.Text, &it.Owner, &it.Due, &tgMessageID, &keyID, &it.CreatedAt); err != nil {
+			return nil, err
+		}
+		it.TgMessageID = tgMessageID.Int64
+		var err error
+		if it.Text, err = db.openColumn("text", it.Text, keyID); err != nil {
+			return nil, fmt.Errorf("summary %d item: %w", it.SummaryID, err)
+		}
+		if it.Owner, err = db.openColumn("owner", it.Owner, keyID); err != nil {
+			return nil, fmt.Errorf("summary %d item: %w", it.SummaryID, err)
+		}
+		items = append(items, it)
+	}
+	return items, rows.Err()
+}
+
+// GetSummary returns a stored summary with its topics and events in order, or
+// nil if it doesn't exist (or was cleaned up).
+func (db *DB) GetSummary(ctx context.Context, id int64) (*StoredSummary, error) {
+	s := StoredSummary{ID: id}
+	err := db.conn.QueryRowContext(ctx,
+		`SELECT group_id, lang, model, instructions_version, created_at FROM summaries WHERE id = ?`, id,
+	).Scan(&s.GroupID, &s.Lang, &s.Model, &s.InstructionsVersion, &s.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT title, message_ids, key_id FROM summary_topics WHERE summary_id = ? ORDER BY topic_index`, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var t SummaryTopic
+		var ids string
+		var keyID sql.NullString
+		if err := rows.Scan(&t.Title, &ids, &keyID); err != nil {
+			return nil, err
+		}
+		if t.Title, err = db.openColumn("title", t.Title, keyID); err != nil {
+			return nil, fmt.Errorf("summary %d topic: %w", id, err)
+		}
+		if err := json.Unmarshal([]byte(ids), &t.MessageIDs); err != nil {
+			return nil, fmt.Errorf("summary %d topic %q: %w", id, t.Title, err)
+		}
+		s.Topics = append(s.Topics, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if s.Events, err = db.getSummaryEvents(ctx, id); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (db *DB) getSummaryEvents(ctx context.Context, summaryID int64) ([]SummaryEvent, error) {
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT title, place, starts_at, ends_at, all_day, timezone, tg_message_id, key_id
+		 FROM summary_events WHERE summary_id = ? ORDER BY event_index`, summaryID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var events []SummaryEvent
+	for rows.Next() {
+		var ev SummaryEvent
+		var tz string
+		var tgMessageID sql.NullInt64
+		var keyID sql.NullString
+		if err := rows.Scan(&ev.Title, &ev.Place, &ev.Start, &ev.End, &ev.AllDay, &tz, &tgMessageID, &keyID); err != nil {
+			return nil, err
+		}
+		var err error
+		if ev.Title, err = db.openColumn("title", ev.Title, keyID); err != nil {
+			return nil, fmt.Errorf("summary %d event: %w", summaryID, err)
+		}
+		if ev.Place, err = db.openColumn("place", ev.Place, keyID); err != nil {
+			return nil, fmt.Errorf("summary %d event: %w", summaryID, err)
+		}
+		// A zone that no longer loads (tzdata changed under us) degrades to
+		// UTC rather than losing the event.
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			loc = time.UTC
+		}
+		ev.Start, ev.End = ev.Start.In(loc), ev.End.In(loc)
+		ev.TgMessageID = tgMessageID.Int64
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
+
+// CleanupOldSummaryTopics deletes the topic clusters of summaries created more
+// than olderThan ago, once their messages are gone, keeping the summaries
+// themselves for the quality report.
+func (db *DB) CleanupOldSummaryTopics(ctx context.Context, olderThan time.Duration) (int64, error) {
+	res, err := db.conn.ExecContext(ctx,
+		`DELETE FROM summary_topics
+		 WHERE summary_id IN (SELECT id FROM summaries WHERE created_at < ?)`,
+		time.Now().Add(-olderThan),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// CleanupOldSummaryItems deletes the extracted items of summaries created
+// more than olderThan ago: like the topics, they retell messages that are
+// gone by then.
+func (db *DB) CleanupOldSummaryItems(ctx context.Context, olderThan time.Duration) (int64, error) {
+	res, err := db.conn.ExecContext(ctx,
+		`DELETE FROM summary_items
+		 WHERE summary_id IN (SELECT id FROM summaries WHERE created_at < ?)`,
+		time.Now().Add(-olderThan),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// CleanupOldSummaryEvents deletes the calendar events of summaries created
+// more than olderThan ago, along with the rest of what they retell.
+func (db *DB) CleanupOldSummaryEvents(ctx context.Context, olderThan time.Duration) (int64, error) {
+	res, err := db.conn.ExecContext(ctx,
+		`DELETE FROM summary_events
+		 WHERE summary_id IN (SELECT id FROM summaries WHERE created_at < ?)`,
+		time.Now().Add(-olderThan),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// CleanupOldSummaries deletes stored summaries (with their topics and
+// feedback) created more than olderThan ago.
+func (db *DB) CleanupOldSummaries(ctx context.Context, olderThan time.Duration) (int64, error) {
+	res, err := db.conn.ExecContext(ctx,
+		`DELETE FROM summaries WHERE created_at < ?`,
+		time.Now().Add(-olderThan),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// GetMessagesByIDs returns the group's messages with the given IDs in
+// chronological order. IDs that no longer exist (retention-pruned) or belong
+// to another group are skipped.
+func (db *DB) GetMessagesByIDs(ctx context.Context, groupID int64, ids []int64) ([]Message, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	defer db.metrics.DBGet.Start()()
+
+	args := make([]any, 0, len(ids)+1)
+	args = append(args, groupID)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT id, group_id, user_hash, text, timestamp, forwarded_from, tg_message_id, reply_to_tg_id, key_id
+		 FROM messages
+		 WHERE group_id = ? AND id IN (?`+strings.Repeat(",?", len(ids)-1)+`)
+		 ORDER BY timestamp, id`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var messages []Message
+	for rows.Next() {
+		var msg Message
+		var forwardedFrom, keyID sql.NullString
+		var tgMessageID, replyToTgID sql.NullInt64
+		if err := rows.Scan(&msg.ID, &msg.GroupID, &msg.UserHash, &msg.Text, &msg.Timestamp, &forwardedFrom, &tgMessageID, &replyToTgID, &keyID); err != nil {
+			return nil, err
+		}
+		msg.ForwardedFrom = forwardedFrom.String
+		msg.TgMessageID = tgMessageID.Int64
+		msg.ReplyToTgID = replyToTgID.Int64
+		if err := db.openMessage(&msg, keyID); err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+	return messages, rows.Err()
+}
@@ -0,0 +1,173 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStoredSummaries(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	var ids []int64
+	for i, text := range []string{"первое", "второе", "третье"} {
+		id, err := db.AddMessageReturningID(ctx, &Message{
+			GroupID: -100, UserHash: "aaaa1111", Text: text,
+			Timestamp: time.Now().Add(time.Duration(i-3) * time.Minute),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	other, err := db.AddMessageReturningID(ctx, &Message{GroupID: -200, UserHash: "bbbb2222", Text: "чужое", Timestamp: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	summaryID, err := db.SaveSummary(ctx, StoredSummary{
+		GroupID: -100, Lang: "ru", Model: "gpt-test", InstructionsVersion: 3,
+		Topics: []SummaryTopic{
+			{Title: "Релиз", MessageIDs: []int64{ids[2], ids[0], other}},
+			{Title: "Оффтоп", MessageIDs: []int64{ids[1]}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := db.GetSummary(ctx, summaryID)
+	if err != nil || s == nil {
+		t.Fatalf("GetSummary = %+v, %v", s, err)
+	}
+	if s.GroupID != -100 || s.Lang != "ru" || s.Model != "gpt-test" || s.InstructionsVersion != 3 || len(s.Topics) != 2 || s.Topics[1].Title != "Оффтоп" {
+		t.Fatalf("stored summary = %+v", s)
+	}
+
+	msgs, err := db.GetMessagesByIDs(ctx, -100, s.Topics[0].MessageIDs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 2 || msgs[0].Text != "первое" || msgs[1].Text != "третье" {
+		t.Fatalf("topic messages = %+v; want own group's, chronological", msgs)
+	}
+
+	if missing, err := db.GetSummary(ctx, summaryID+1); err != nil || missing != nil {
+		t.Fatalf("missing summary = %+v, %v", missing, err)
+	}
+	if purged, err := db.CleanupOldSummaryTopics(ctx, -time.Minute); err != nil || purged != 2 {
+		t.Fatalf("purged topics = %d, %v; want 2", purged, err)
+	}
+	if s, err := db.GetSummary(ctx, summaryID); err != nil || s == nil || len(s.Topics) != 0 {
+		t.Fatalf("summary after topic cleanup = %+v, %v; want kept without topics", s, err)
+	}
+	if purged, err := db.CleanupOldSummaries(ctx, -time.Minute); err != nil || purged != 1 {
+		t.Fatalf("purged = %d, %v; want 1", purged, err)
+	}
+	var topics int
+	if err := db.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM summary_topics`).Scan(&topics); err != nil || topics != 0 {
+		t.Fatalf("orphaned topics = %d, %v", topics, err)
+	}
+}
+
+func TestSummaryItems(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	first, err := db.SaveSummary(ctx, StoredSummary{
+		GroupID: -100, Lang: "ru",
+		Items: []SummaryItem{
+			{Kind: ItemDecision, Text: "Релиз в пятницу", TgMessageID: 11},
+			{Kind: ItemAction, Text: "Собрать сборку", Owner: "У2", Due: "четверг", TgMessageID: 12},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := db.SaveSummary(ctx, StoredSummary{
+		GroupID: -100, Lang: "ru",
+		Items: []SummaryItem{
+			{Kind: ItemAction, Text: "Написать анонс"},
+			{Kind: ItemAction, Text: "Обновить доку", Owner: "У1"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.SaveSummary(ctx, StoredSummary{GroupID: -200, Lang: "ru", Items: []SummaryItem{{Kind: ItemAction, Text: "чужое"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	items, err := db.GetSummaryItems(ctx, -100, ItemAction, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, it := range items {
+		got = append(got, it.Text)
+	}
+	if len(items) != 3 || items[0].SummaryID != second || items[2].SummaryID != first ||
+		got[0] != "Написать анонс" || got[1] != "Обновить доку" || got[2] != "Собрать сборку" {
+		t.Fatalf("action items = %+v; want the newest summary first, in order", items)
+	}
+	if it := items[2]; it.Owner != "У2" || it.Due != "четверг" || it.TgMessageID != 12 || it.CreatedAt.IsZero() {
+		t.Fatalf("stored item = %+v", it)
+	}
+	if items[0].TgMessageID != 0 {
+		t.Fatalf("item without a source = %+v", items[0])
+	}
+
+	if later, err := db.GetSummaryItems(ctx, -100, ItemAction, time.Now().Add(time.Hour)); err != nil || len(later) != 0 {
+		t.Fatalf("items after the window = %+v, %v", later, err)
+	}
+	if purged, err := db.CleanupOldSummaryItems(ctx, -time.Minute); err != nil || purged != 5 {
+		t.Fatalf("purged items = %d, %v; want 5", purged, err)
+	}
+}
+
+func TestSummaryEvents(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2026, 3, 12, 19, 0, 0, 0, moscow)
+	day := time.Date(2026, 3, 20, 0, 0, 0, 0, moscow)
+	id, err := db.SaveSummary(ctx, StoredSummary{
+		GroupID: -100, Lang: "ru",
+		Events: []SummaryEvent{
+			{Title: "Встреча", Place: "Кофейня", Start: start, End: start.Add(time.Hour), TgMessageID: 11},
+			{Title: "Дедлайн", Start: day, End: day.AddDate(0, 0, 1), AllDay: true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := db.GetSummary(ctx, id)
+	if err != nil || stored == nil {
+		t.Fatalf("GetSummary = %+v, %v", stored, err)
+	}
+	if len(stored.Events) != 2 {
+		t.Fatalf("events = %+v", stored.Events)
+	}
+	ev := stored.Events[0]
+	if ev.Title != "Встреча" || ev.Place != "Кофейня" || ev.TgMessageID != 11 || ev.AllDay ||
+		!ev.Start.Equal(start) || !ev.End.Equal(start.Add(time.Hour)) || ev.Start.Location().String() != "Europe/Moscow" {
+		t.Fatalf("stored event = %+v", ev)
+	}
+	// All-day events keep their calendar date in the group's timezone.
+	if ev := stored.Events[1]; !ev.AllDay || ev.Start.Format("2006-01-02 15:04") != "2026-03-20 00:00" || ev.TgMessageID != 0 {
+		t.Fatalf("all-day event = %+v", ev)
+	}
+
+	if purged, err := db.CleanupOldSummaryEvents(ctx, -time.Minute); err != nil || purged != 2 {
+		t.Fatalf("purged events = %d, %v; want 2", purged, err)
+	}
+	if stored, err := db.GetSummary(ctx, id); err != nil || len(stored.Events) != 0 {
+		t.Fatalf("events after cleanup = %+v, %v", stored, err)
+	}
+}
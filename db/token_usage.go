@@ -28,9 +28,11 @@ type TokenUsageTotals struct {
 
 // TokenUsageGroup is a labeled aggregation row (by model or operation).
 type TokenUsageGroup struct {
-	Label       string
-	TotalTokens int64
-	Calls       int64
+	Label        string
+	PromptTokens int64
+	CachedTokens int64
+	TotalTokens  int64
+	Calls        int64
 }
 
 // InsertTokenUsage records token usage for a single LLM call.
@@ -59,7 +61,8 @@ func (db *DB) SumTokenUsageSince(ctx context.Context, since time.Time) (TokenUsa
 // TokenUsageByModelSince returns total tokens grouped by model since a time.
 func (db *DB) TokenUsageByModelSince(ctx context.Context, since time.Time) ([]TokenUsageGroup, error) {
 	return db.scanGroups(ctx,
-		`SELECT model, COALESCE(SUM(total_tokens), 0), COUNT(*) FROM token_usage
+		`SELECT model, COALESCE(SUM(prompt_tokens), 0), COALESCE(SUM(cached_tokens), 0),
+		        COALESCE(SUM(total_tokens), 0), COUNT(*) FROM token_usage
 		 WHERE ts >= ? AND operation != ? GROUP BY model ORDER BY SUM(total_tokens) DESC`,
 		since)
 }
@@ -67,7 +70,8 @@ func (db *DB) TokenUsageByModelSince(ctx context.Context, since time.Time) ([]To
 // TokenUsageByOperationSince returns total tokens grouped by operation since a time.
 func (db *DB) TokenUsageByOperationSince(ctx context.Context, since time.Time) ([]TokenUsageGroup, error) {
 	return db.scanGroups(ctx,
-		`SELECT operation, COALESCE(SUM(total_tokens), 0), COUNT(*) FROM token_usage
+		`SELECT operation, COALESCE(SUM(prompt_tokens), 0), COALESCE(SUM(cached_tokens), 0),
+		        COALESCE(SUM(total_tokens), 0), COUNT(*) FROM token_usage
 		 WHERE ts >= ? AND operation != ? GROUP BY operation ORDER BY SUM(total_tokens) DESC`,
 		since)
 }
@@ -82,7 +86,7 @@ func (db *DB) scanGroups(ctx context.Context, query string, since time.Time) ([]
 	var groups []TokenUsageGroup
 	for rows.Next() {
 		var g TokenUsageGroup
-		if err := rows.Scan(&g.Label, &g.TotalTokens, &g.Calls); err != nil {
+		if err := rows.Scan(&g.Label, &g.PromptTokens, &g.CachedTokens, &g.TotalTokens, &g.Calls); err != nil {
 			continue
 		}
 		groups = append(groups, g)
@@ -56,6 +56,9 @@ func TestTokenUsageAggregation(t *testing.T) {
 		if g.Label == provider.OpProbe {
 			t.Errorf("probe should be excluded, got %+v", byOp)
 		}
+		if g.Label == provider.OpCluster && (g.PromptTokens != 200 || g.CachedTokens != 40) {
+			t.Errorf("cluster prompt/cached = %d/%d, want 200/40", g.PromptTokens, g.CachedTokens)
+		}
 	}
 }
 
@@ -0,0 +1,143 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/summarizer"
+)
+
+// Invariants checked on every summary.
+const (
+	// InvariantClusteredOnce: the model put every message in exactly one
+	// topic, and so does the final summary.
+	InvariantClusteredOnce = "clustered_once"
+	// InvariantTopicLinks: each topic links to its own first message.
+	InvariantTopicLinks = "topic_links"
+	// InvariantTopicMax: neither the model nor the summary exceeds TopicMax.
+	InvariantTopicMax = "topic_max"
+	// InvariantNoUserHashes: no participant's user hash leaks into the text.
+	InvariantNoUserHashes = "no_user_hashes"
+)
+
+// Violation is one failed invariant.
+type Violation struct {
+	Invariant string
+	Detail    string
+}
+
+func (v Violation) String() string {
+	return v.Invariant + ": " + v.Detail
+}
+
+// rawClusters mirrors the clustering response the model is asked for.
+type rawClusters struct {
+	Topics []struct {
+		MessageIndexes []int `json:"message_indexes"`
+	} `json:"topics"`
+}
+
+// check runs every invariant on summary, the pipeline's result for f, and on
+// clusterResponse, the model's raw clustering answer it was built from.
+func check(f Fixture, topicMax int, summary *summarizer.StructuredSummary, clusterResponse string) []Violation {
+	var out []Violation
+	add := func(invariant, format string, args ...any) {
+		out = append(out, Violation{Invariant: invariant, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	// The model's own clustering, before the summarizer repairs it.
+	var raw rawClusters
+	if err := parseJSONObject(clusterResponse, &raw); err != nil {
+		add(InvariantClusteredOnce, "clustering response: %v", err)
+	} else {
+		seen := make(map[int]int, len(f.Messages))
+		outOfRange := 0
+		for _, t := range raw.Topics {
+			for _, idx := range t.MessageIndexes {
+				if idx < 0 || idx >= len(f.Messages) {
+					outOfRange++
+					continue
+				}
+				seen[idx]++
+			}
+		}
+		missing, repeated := 0, 0
+		for idx := range f.Messages {
+			switch n := seen[idx]; {
+			case n == 0:
+				missing++
+			case n > 1:
+				repeated++
+			}
+		}
+		if missing > 0 || repeated > 0 || outOfRange > 0 {
+			add(InvariantClusteredOnce, "model left %d of %d messages out, put %d in several topics, gave %d bad indexes",
+				missing, len(f.Messages), repeated, outOfRange)
+		}
+		if len(raw.Topics) > topicMax {
+			add(InvariantTopicMax, "model returned %d topics, max %d", len(raw.Topics), topicMax)
+		}
+	}
+
+	byID := make(map[int64]db.Message, len(f.Messages))
+	for _, m := range f.Messages {
+		byID[m.ID] = m
+	}
+	owners := make(map[int64]int, len(f.Messages))
+	for _, t := range summary.Topics {
+		for _, id := range t.MessageIDs {
+			owners[id]++
+		}
+	}
+	for _, m := range f.Messages {
+		if n := owners[m.ID]; n != 1 {
+			add(InvariantClusteredOnce, "message %d is in %d summary topics", m.ID, n)
+		}
+	}
+
+	if len(summary.Topics) > topicMax {
+		add(InvariantTopicMax, "summary has %d topics, max %d", len(summary.Topics), topicMax)
+	}
+
+	for i, t := range summary.Topics {
+		first := int64(0)
+		own := false
+		for _, id := range t.MessageIDs {
+			tgID := byID[id].TgMessageID
+			if first == 0 {
+				first = tgID
+			}
+			own = own || (tgID != 0 && tgID == t.FirstTgMessageID)
+		}
+		switch {
+		case t.FirstTgMessageID == 0 && first != 0:
+			add(InvariantTopicLinks, "topic %d %q has no link", i+1, t.Title)
+		case t.FirstTgMessageID != 0 && !own:
+			add(InvariantTopicLinks, "topic %d %q links to message %d outside the topic", i+1, t.Title, t.FirstTgMessageID)
+		}
+	}
+
+	text := summarizer.FormatTelegramSummary(summary, f.Messages[0].GroupID)
+	leaked := make(map[string]bool)
+	for _, m := range f.Messages {
+		if m.UserHash != "" && !leaked[m.UserHash] && strings.Contains(text, m.UserHash) {
+			leaked[m.UserHash] = true
+			add(InvariantNoUserHashes, "user hash %s appears in the summary", m.UserHash)
+		}
+	}
+
+	return out
+}
+
+// parseJSONObject decodes the outermost {...} of a model response, the way
+// the summarizer reads it.
+func parseJSONObject(content string, target any) error {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start == -1 || end < start {
+		return fmt.Errorf("no JSON object")
+	}
+	return json.Unmarshal([]byte(content[start:end+1]), target)
+}
@@ -0,0 +1,92 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"telegram_summarize_bot/provider"
+)
+
+// replayClient serves a fixture's recorded responses in call order per
+// operation, so a run needs no network. A recorded bad response followed by a
+// good one exercises the summarizer's retry path.
+//
+// It is deliberately not a provider cassette (provider.NewReplayClient). A
+// cassette matches each request by a hash of its exact prompt, so it replays
+// one recorded day faithfully but misses as soon as a prompt changes — and
+// comparing prompt changes is what evals are for. Fixture responses are
+// keyed by operation only, stay valid across prompt edits, and are small
+// enough to write by hand to pin down a failure mode.
+type replayClient struct {
+	mu        sync.Mutex
+	responses map[string][]string
+}
+
+func newReplayClient(responses map[string][]string) *replayClient {
+	queued := make(map[string][]string, len(responses))
+	for op, contents := range responses {
+		queued[op] = append([]string(nil), contents...)
+	}
+	return &replayClient{responses: queued}
+}
+
+func (c *replayClient) Complete(_ context.Context, req provider.CompletionRequest) (provider.CompletionResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	queue := c.responses[req.Operation]
+	if len(queue) == 0 {
+		return provider.CompletionResponse{}, &provider.APIError{
+			HTTPStatusCode: 400, // not retryable: nothing more to replay
+			Message:        fmt.Sprintf("no recorded %q response left", req.Operation),
+		}
+	}
+	c.responses[req.Operation] = queue[1:]
+	return provider.CompletionResponse{Content: queue[0], FinishReason: "stop", HTTPStatusCode: 200}, nil
+}
+
+// call is one observed LLM call.
+type call struct {
+	operation string
+	content   string
+	err       error
+	tokens    int
+	elapsed   time.Duration
+}
+
+// observingClient records every call made through it, so the checks can see
+// the model's raw answers before the summarizer sanitizes them.
+type observingClient struct {
+	inner provider.LLMClient
+
+	mu    sync.Mutex
+	calls []call
+}
+
+func (c *observingClient) Complete(ctx context.Context, req provider.CompletionRequest) (provider.CompletionResponse, error) {
+	start := time.Now()
+	resp, err := c.inner.Complete(ctx, req)
+	c.mu.Lock()
+	c.calls = append(c.calls, call{
+		operation: req.Operation,
+		content:   resp.Content,
+		err:       err,
+		tokens:    resp.Usage.TotalTokens,
+		elapsed:   time.Since(start),
+	})
+	c.mu.Unlock()
+	return resp, err
+}
+
+// lastContent returns the last successful response to op, or "".
+func (c *observingClient) lastContent(op string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := len(c.calls) - 1; i >= 0; i-- {
+		if c.calls[i].operation == op && c.calls[i].err == nil {
+			return c.calls[i].content
+		}
+	}
+	return ""
+}
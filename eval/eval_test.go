@@ -0,0 +1,69 @@
+package eval
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/summarizer"
+)
+
+func TestRunReplayedFixtures(t *testing.T) {
+	fixtures, err := LoadFixtures("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) != 2 || fixtures[0].Name != "release-day" || fixtures[1].Name != "sloppy-model" {
+		t.Fatalf("fixtures = %+v", fixtures)
+	}
+
+	results := Run(context.Background(), Config{Name: "baseline", Replay: true}, nil, fixtures)
+
+	clean := results[0]
+	if !clean.Passed() || clean.Topics != 2 || clean.Calls != 2 {
+		t.Fatalf("release-day = %+v; want a clean pass with 2 topics in 2 calls", clean)
+	}
+
+	sloppy := results[1]
+	if sloppy.Err != nil || sloppy.Calls != 3 {
+		t.Fatalf("sloppy-model = %+v; want success after one clustering retry", sloppy)
+	}
+	got := make(map[string]bool)
+	for _, v := range sloppy.Violations {
+		got[v.Invariant] = true
+	}
+	if !got[InvariantClusteredOnce] || !got[InvariantNoUserHashes] || got[InvariantTopicLinks] || got[InvariantTopicMax] {
+		t.Fatalf("sloppy-model violations = %v", sloppy.Violations)
+	}
+
+	report := FormatReport([]Config{{Name: "baseline", Replay: true}, {Name: "strict", TopicMax: 1, Replay: true}},
+		[][]Result{results, Run(context.Background(), Config{Name: "strict", TopicMax: 1, Replay: true}, nil, fixtures)})
+	for _, want := range []string{"A = baseline", "B = strict", "release-day", "passed", "1/2", "0/2", "B release-day: topic_max"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report lacks %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestRunWithoutRecordedResponses(t *testing.T) {
+	f := Fixture{Name: "live-only", Messages: []db.Message{{ID: 1, Text: "привет"}}}
+	if r := Run(context.Background(), Config{Replay: true}, nil, []Fixture{f})[0]; r.Err == nil {
+		t.Fatalf("replay without responses should fail, got %+v", r)
+	}
+}
+
+func TestCheckTopicLinks(t *testing.T) {
+	f := Fixture{Messages: []db.Message{
+		{ID: 1, GroupID: fixtureGroupID, TgMessageID: 11},
+		{ID: 2, GroupID: fixtureGroupID, TgMessageID: 12},
+	}}
+	summary := &summarizer.StructuredSummary{Topics: []summarizer.TopicSummary{
+		{Title: "Чужая ссылка", MessageIDs: []int64{1}, FirstTgMessageID: 12},
+		{Title: "Без ссылки", MessageIDs: []int64{2}},
+	}}
+	violations := check(f, 5, summary, `{"topics":[{"message_indexes":[0]},{"message_indexes":[1]}]}`)
+	if len(violations) != 2 || violations[0].Invariant != InvariantTopicLinks || violations[1].Invariant != InvariantTopicLinks {
+		t.Fatalf("violations = %v", violations)
+	}
+}
@@ -0,0 +1,191 @@
+// Package eval runs the topic-summary pipeline over recorded conversation
+// fixtures and checks structural invariants of the result, so prompt and
+// model changes can be compared offline (see the "eval" command).
+package eval
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
+)
+
+// fixtureGroupID is the group fixture messages belong to unless they say
+// otherwise: a supergroup ID, so topic links can be built and checked.
+const fixtureGroupID = -1001234567890
+
+// responsesSuffix names the recorded-responses file next to a fixture:
+// chat.jsonl is replayed from chat.responses.jsonl.
+const responsesSuffix = ".responses.jsonl"
+
+// Fixture is one recorded conversation.
+type Fixture struct {
+	Name     string
+	Messages []db.Message
+	// photos and descriptions stand in for Telegram and the vision model:
+	// fixtures carry each photo's description instead of its bytes.
+	photos       map[int64][]db.PhotoRecord
+	descriptions map[string]string
+	// Responses are the recorded completions by operation (provider.Op*), in
+	// call order; nil when the fixture has no responses file.
+	Responses map[string][]string
+}
+
+// fixtureMessage is one line of a fixture file: a db.Message plus the
+// descriptions of its photos.
+type fixtureMessage struct {
+	ID            int64          `json:"id"`
+	GroupID       int64          `json:"group_id"`
+	UserHash      string         `json:"user_hash"`
+	Text          string         `json:"text"`
+	Timestamp     time.Time      `json:"timestamp"`
+	ForwardedFrom string         `json:"forwarded_from"`
+	TgMessageID   int64          `json:"tg_message_id"`
+	ReplyToTgID   int64          `json:"reply_to_tg_id"`
+	Photos        []fixturePhoto `json:"photos"`
+}
+
+type fixturePhoto struct {
+	FileUniqueID string `json:"file_unique_id"`
+	Description  string `json:"description"`
+}
+
+// recordedResponse is one line of a responses file.
+type recordedResponse struct {
+	Operation string `json:"operation"`
+	Content   string `json:"content"`
+}
+
+// LoadFixtures loads every *.jsonl fixture in dir, by name.
+func LoadFixtures(dir string) ([]Fixture, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var fixtures []Fixture
+	for _, path := range paths {
+		if strings.HasSuffix(path, responsesSuffix) {
+			continue
+		}
+		f, err := LoadFixture(path)
+		if err != nil {
+			return nil, err
+		}
+		fixtures = append(fixtures, f)
+	}
+	if len(fixtures) == 0 {
+		return nil, fmt.Errorf("no fixtures in %s", dir)
+	}
+	return fixtures, nil
+}
+
+// LoadFixture loads one fixture file and, when present, its recorded
+// responses. Messages without an ID are numbered by line.
+func LoadFixture(path string) (Fixture, error) {
+	f := Fixture{
+		Name:         strings.TrimSuffix(filepath.Base(path), ".jsonl"),
+		photos:       make(map[int64][]db.PhotoRecord),
+		descriptions: make(map[string]string),
+	}
+	err := readJSONLines(path, func(line int, raw []byte) error {
+		var m fixtureMessage
+		if err := json.Unmarshal(raw, &m); err != nil {
+			return err
+		}
+		if m.ID == 0 {
+			m.ID = int64(line)
+		}
+		if m.GroupID == 0 {
+			m.GroupID = fixtureGroupID
+		}
+		f.Messages = append(f.Messages, db.Message{
+			ID:            m.ID,
+			GroupID:       m.GroupID,
+			UserHash:      m.UserHash,
+			Text:          m.Text,
+			Timestamp:     m.Timestamp,
+			ForwardedFrom: m.ForwardedFrom,
+			TgMessageID:   m.TgMessageID,
+			ReplyToTgID:   m.ReplyToTgID,
+		})
+		for _, p := range m.Photos {
+			f.photos[m.ID] = append(f.photos[m.ID], db.PhotoRecord{MessageID: m.ID, FileUniqueID: p.FileUniqueID})
+			f.descriptions[p.FileUniqueID] = p.Description
+		}
+		return nil
+	})
+	if err != nil {
+		return Fixture{}, err
+	}
+	if len(f.Messages) == 0 {
+		return Fixture{}, fmt.Errorf("%s: no messages", path)
+	}
+
+	responsesPath := strings.TrimSuffix(path, ".jsonl") + responsesSuffix
+	if _, err := os.Stat(responsesPath); err == nil {
+		f.Responses = make(map[string][]string)
+		err := readJSONLines(responsesPath, func(_ int, raw []byte) error {
+			var r recordedResponse
+			if err := json.Unmarshal(raw, &r); err != nil {
+				return err
+			}
+			f.Responses[r.Operation] = append(f.Responses[r.Operation], r.Content)
+			return nil
+		})
+		if err != nil {
+			return Fixture{}, err
+		}
+	}
+	return f, nil
+}
+
+// readJSONLines calls fn with each non-blank line of path and its 1-based
+// number among them.
+func readJSONLines(path string, fn func(line int, raw []byte) error) error {
+	file, err := os.Open(path) // #nosec G304 -- fixture paths come from the operator
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		raw := scanner.Bytes()
+		if len(strings.TrimSpace(string(raw))) == 0 {
+			continue
+		}
+		line++
+		if err := fn(line, raw); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// GetPhotosForMessages implements summarizer.PhotoLookup.
+func (f Fixture) GetPhotosForMessages(_ context.Context, messageIDs []int64) (map[int64][]db.PhotoRecord, error) {
+	out := make(map[int64][]db.PhotoRecord)
+	for _, id := range messageIDs {
+		if photos, ok := f.photos[id]; ok {
+			out[id] = photos
+		}
+	}
+	return out, nil
+}
+
+// Describe implements summarizer.ImageDescriber with the recorded description.
+func (f Fixture) Describe(_ context.Context, photo db.PhotoRecord, _ string, _ i18n.Lang) (string, error) {
+	return f.descriptions[photo.FileUniqueID], nil
+}
@@ -0,0 +1,150 @@
+package eval
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// FormatReport renders the results side by side, one column per config: a
+// row per fixture, then totals, then each failure's details. results[i] are
+// configs[i]'s results, in the same fixture order.
+func FormatReport(configs []Config, results [][]Result) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Eval: %d fixtures\n", len(results[0]))
+	for i, c := range configs {
+		parts := []string{c.Name}
+		if c.Model != "" {
+			parts = append(parts, c.Model)
+		}
+		if c.Replay {
+			parts = append(parts, "replay")
+		}
+		parts = append(parts, fmt.Sprintf("topic_max %d", c.topicMax()))
+		fmt.Fprintf(&sb, "  %s = %s\n", columnName(i), strings.Join(parts, " · "))
+	}
+	sb.WriteString("\n")
+
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	header := []string{"fixture"}
+	for i := range configs {
+		header = append(header, columnName(i))
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+
+	var details []string
+	for fi := range results[0] {
+		row := []string{results[0][fi].Fixture}
+		for ci := range configs {
+			r := results[ci][fi]
+			row = append(row, resultCell(r))
+			prefix := fmt.Sprintf("%s %s: ", columnName(ci), r.Fixture)
+			if r.Err != nil {
+				details = append(details, prefix+"error: "+r.Err.Error())
+			}
+			for _, v := range r.Violations {
+				details = append(details, prefix+v.String())
+			}
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	fmt.Fprintln(tw)
+
+	totals := []struct {
+		label string
+		value func([]Result) string
+	}{
+		{"passed", func(rs []Result) string {
+			passed := 0
+			for _, r := range rs {
+				if r.Passed() {
+					passed++
+				}
+			}
+			return fmt.Sprintf("%d/%d", passed, len(rs))
+		}},
+		{"violations", func(rs []Result) string {
+			n := 0
+			for _, r := range rs {
+				n += len(r.Violations)
+			}
+			return fmt.Sprint(n)
+		}},
+		{"errors", func(rs []Result) string {
+			n := 0
+			for _, r := range rs {
+				if r.Err != nil {
+					n++
+				}
+			}
+			return fmt.Sprint(n)
+		}},
+		{"avg topics", func(rs []Result) string {
+			topics, ok := 0, 0
+			for _, r := range rs {
+				if r.Err == nil {
+					topics += r.Topics
+					ok++
+				}
+			}
+			if ok == 0 {
+				return "—"
+			}
+			return fmt.Sprintf("%.1f", float64(topics)/float64(ok))
+		}},
+		{"LLM calls", func(rs []Result) string {
+			n := 0
+			for _, r := range rs {
+				n += r.Calls
+			}
+			return fmt.Sprint(n)
+		}},
+		{"tokens", func(rs []Result) string {
+			n := 0
+			for _, r := range rs {
+				n += r.Tokens
+			}
+			return fmt.Sprint(n)
+		}},
+		{"time", func(rs []Result) string {
+			var d time.Duration
+			for _, r := range rs {
+				d += r.Elapsed
+			}
+			return d.Round(100 * time.Millisecond).String()
+		}},
+	}
+	for _, t := range totals {
+		row := []string{t.label}
+		for ci := range configs {
+			row = append(row, t.value(results[ci]))
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	_ = tw.Flush()
+
+	if len(details) > 0 {
+		sb.WriteString("\nFailures\n")
+		for _, d := range details {
+			sb.WriteString("  " + d + "\n")
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// columnName labels configs A, B, C…
+func columnName(i int) string {
+	return string(rune('A' + i))
+}
+
+func resultCell(r Result) string {
+	switch {
+	case r.Err != nil:
+		return "✗ error"
+	case len(r.Violations) > 0:
+		return fmt.Sprintf("✗ %d topics · %d calls", r.Topics, r.Calls)
+	default:
+		return fmt.Sprintf("✓ %d topics · %d calls", r.Topics, r.Calls)
+	}
+}
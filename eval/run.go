@@ -0,0 +1,124 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/metrics"
+	"telegram_summarize_bot/provider"
+	"telegram_summarize_bot/summarizer"
+)
+
+// defaultTopicMax matches the bot's TOPIC_MAX default.
+const defaultTopicMax = 5
+
+// Config is one prompt/model configuration under evaluation.
+type Config struct {
+	Name         string `json:"name"`
+	Model        string `json:"model"`        // empty => MODEL
+	Instructions string `json:"instructions"` // group summary instructions
+	TopicMax     int    `json:"topic_max"`    // 0 => 5
+	ReplyThreads *bool  `json:"reply_threads"`
+	Lang         string `json:"lang"` // ru, en or auto (default)
+	// Replay serves each fixture's recorded responses instead of calling the
+	// configured provider.
+	Replay bool `json:"replay"`
+}
+
+// LoadConfig reads a Config from a JSON file; the name defaults to the
+// file's base name.
+func LoadConfig(path string) (Config, error) {
+	raw, err := os.ReadFile(path) // #nosec G304 -- config paths come from the operator
+	if err != nil {
+		return Config{}, err
+	}
+	var c Config
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if c.Name == "" {
+		c.Name = strings.TrimSuffix(filepath.Base(path), ".json")
+	}
+	return c, nil
+}
+
+func (c Config) topicMax() int {
+	if c.TopicMax <= 0 {
+		return defaultTopicMax
+	}
+	return c.TopicMax
+}
+
+// Result is one fixture's outcome under one Config.
+type Result struct {
+	Fixture    string
+	Topics     int
+	Calls      int // LLM calls, retries included
+	Tokens     int
+	Elapsed    time.Duration
+	Violations []Violation
+	Err        error // the pipeline failed; no invariants were checked
+}
+
+// Passed reports whether the pipeline succeeded with no violations.
+func (r Result) Passed() bool {
+	return r.Err == nil && len(r.Violations) == 0
+}
+
+// Run summarizes every fixture under c and checks the results. client is the
+// live provider; it is unused (and may be nil) when c.Replay is set.
+func Run(ctx context.Context, c Config, client provider.LLMClient, fixtures []Fixture) []Result {
+	results := make([]Result, 0, len(fixtures))
+	for _, f := range fixtures {
+		results = append(results, runFixture(ctx, c, client, f))
+	}
+	return results
+}
+
+func runFixture(ctx context.Context, c Config, client provider.LLMClient, f Fixture) Result {
+	res := Result{Fixture: f.Name}
+	if c.Replay {
+		if f.Responses == nil {
+			res.Err = errors.New("no recorded responses")
+			return res
+		}
+		client = newReplayClient(f.Responses)
+	}
+	if client == nil {
+		res.Err = errors.New("no LLM client")
+		return res
+	}
+
+	lang, ok := i18n.Parse(c.Lang)
+	if !ok {
+		lang = i18n.Auto
+	}
+	observer := &observingClient{inner: client}
+	sum := summarizer.New(observer, c.Model, metrics.New(), true).
+		WithImageDescriber(f, f, 1)
+	if c.ReplyThreads != nil {
+		ctx = summarizer.WithReplyThreads(ctx, *c.ReplyThreads)
+	}
+
+	start := time.Now()
+	summary, err := sum.SummarizeByTopics(ctx, f.Messages, c.topicMax(), c.Instructions, lang)
+	res.Elapsed = time.Since(start)
+	res.Calls = len(observer.calls)
+	for _, call := range observer.calls {
+		res.Tokens += call.tokens
+	}
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	res.Topics = len(summary.Topics)
+	res.Violations = check(f, c.topicMax(), summary, observer.lastContent(provider.OpCluster))
+	return res
+}
@@ -8,9 +8,11 @@ import (
 
 	"telegram_summarize_bot/config"
 	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
 	"telegram_summarize_bot/logger"
 	"telegram_summarize_bot/metrics"
 	"telegram_summarize_bot/provider"
+	"telegram_summarize_bot/summarizer"
 
 	"github.com/mymmrac/telego"
 )
@@ -22,11 +24,16 @@ type Deps interface {
 	EditMessage(ctx context.Context, chatID, messageID int64, text string) error
 	EditWithRetry(ctx context.Context, chatID, msgID int64, text string)
 	EditFormattedWithRetry(ctx context.Context, chatID, msgID int64, text string)
+	// HandleSummaryCallback handles a group summary's "expand", feedback or
+	// calendar button press, including answering the callback query.
+	HandleSummaryCallback(ctx context.Context, cq *telego.CallbackQuery)
 }
 
-// SummaryService abstracts the summarizer for URL summarization.
+// SummaryService abstracts the summarizer for URL summarization and digest
+// previews.
 type SummaryService interface {
-	SummarizeURL(ctx context.Context, pageURL string, content string, instructions string) (string, error)
+	SummarizeURL(ctx context.Context, pageURL string, content string, instructions string, lang i18n.Lang) (string, error)
+	SummarizeByTopics(ctx context.Context, messages []db.Message, topicMax int, additionalInstructions string, lang i18n.Lang) (*summarizer.StructuredSummary, error)
 }
 
 // RateLimiterIface abstracts the rate limiter.
@@ -54,6 +61,7 @@ type Admin struct {
 
 	mu                         sync.Mutex
 	pendingSummaryInstructions map[int64]int64
+	pendingSettings            map[int64]pendingSetting
 }
 
 // New creates a new Admin handler.
@@ -68,6 +76,7 @@ func New(deps Deps, database *db.DB, m *metrics.Metrics, cfg *config.Config, sum
 		telegram:                   tg,
 		llm:                        llm,
 		pendingSummaryInstructions: make(map[int64]int64),
+		pendingSettings:            make(map[int64]pendingSetting),
 	}
 }
 
@@ -93,6 +102,9 @@ func (a *Admin) Handle(ctx context.Context, update telego.Update) bool {
 	if a.handlePendingSummaryInstructions(ctx, msg, cmd) {
 		return true
 	}
+	if a.handlePendingSetting(ctx, msg, cmd) {
+		return true
+	}
 
 	switch cmd {
 	case "/status":
@@ -105,6 +117,18 @@ func (a *Admin) Handle(ctx context.Context, update telego.Update) bool {
 		a.handleInstructions(ctx, msg.Chat.ID)
 	case "/usage":
 		a.handleUsage(ctx, msg.Chat.ID)
+	case "/quality":
+		a.handleQuality(ctx, msg.Chat.ID, fields[1:])
+	case "/preview":
+		a.handlePreview(ctx, msg.Chat.ID, msg.Text)
+	case "/language":
+		a.handleLanguage(ctx, msg.Chat.ID, msg.From.ID, fields[1:])
+	case "/settings":
+		a.handleSettings(ctx, msg.Chat.ID, fields[1:])
+	case "/stats":
+		a.handleStats(ctx, msg.Chat.ID, fields[1:])
+	case "/forget":
+		a.handleForget(ctx, msg.Chat.ID, fields[1:])
 	case "/help":
 		a.handleHelp(ctx, msg.Chat.ID)
 	default:
@@ -122,5 +146,11 @@ func (a *Admin) handleReset(ctx context.Context, chatID int64) {
 	if err := a.db.ClearAllMetrics(ctx); err != nil {
 		logger.Error().Err(err).Msg("failed to clear persisted metrics")
 	}
-	a.deps.SendMessage(ctx, chatID, "Метрики сброшены.")
+	a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.AdminMetricsReset))
+}
+
+// lang is the admin DM UI language (BOT_LANGUAGE; auto falls back to the
+// default).
+func (a *Admin) lang() i18n.Lang {
+	return a.cfg.Language
 }
@@ -2,6 +2,7 @@ package admin
 
 import (
 	"context"
+	"fmt"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -10,7 +11,10 @@ import (
 	"github.com/mymmrac/telego"
 	"telegram_summarize_bot/config"
 	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
 	"telegram_summarize_bot/metrics"
+	"telegram_summarize_bot/provider"
+	"telegram_summarize_bot/summarizer"
 )
 
 type fakeDeps struct {
@@ -18,6 +22,7 @@ type fakeDeps struct {
 	formattedText []string
 	editTexts     []string
 	nextID        int64
+	summaryData   []string
 }
 
 func (f *fakeDeps) SendMessage(_ context.Context, chatID int64, text string) int64 {
@@ -43,6 +48,10 @@ func (f *fakeDeps) EditFormattedWithRetry(_ context.Context, chatID, msgID int64
 	f.editTexts = append(f.editTexts, text)
 }
 
+func (f *fakeDeps) HandleSummaryCallback(_ context.Context, cq *telego.CallbackQuery) {
+	f.summaryData = append(f.summaryData, cq.Data)
+}
+
 type fakeTelegram struct {
 	sentTexts []string
 	nextID    int
@@ -61,15 +70,34 @@ func (f *fakeTelegram) AnswerCallbackQuery(_ context.Context, _ *telego.AnswerCa
 type fakeSummarizer struct {
 	summary string
 	err     error
+
+	topicsCtx          context.Context
+	topicsMessages     []db.Message
+	topicsInstructions string
+	topicsLang         i18n.Lang
 }
 
-func (f *fakeSummarizer) SummarizeURL(_ context.Context, _, _, _ string) (string, error) {
+func (f *fakeSummarizer) SummarizeURL(_ context.Context, _, _, _ string, _ i18n.Lang) (string, error) {
 	if f.err != nil {
 		return "", f.err
 	}
 	return f.summary, nil
 }
 
+func (f *fakeSummarizer) SummarizeByTopics(ctx context.Context, messages []db.Message, _ int, instructions string, lang i18n.Lang) (*summarizer.StructuredSummary, error) {
+	f.topicsCtx = ctx
+	f.topicsLang = lang
+	f.topicsMessages = messages
+	f.topicsInstructions = instructions
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &summarizer.StructuredSummary{
+		TLDR:   "итог",
+		Topics: []summarizer.TopicSummary{{Title: "Тема", Summary: f.summary, MessageCount: len(messages)}},
+	}, nil
+}
+
 func newTestAdmin(t *testing.T) (*Admin, *db.DB, *fakeDeps) {
 	t.Helper()
 
@@ -339,6 +367,24 @@ func TestHandleCallbackQuery_NonAdmin(t *testing.T) {
 	}
 }
 
+func TestHandleCallbackQuery_SummaryButtonsRoutedForAnyUser(t *testing.T) {
+	a, database, deps := newTestAdmin(t)
+	defer func() { _ = database.Close() }()
+
+	for _, data := range []string{ExpandCallbackPrefix + "5:1", FeedbackCallbackPrefix + "5:up"} {
+		a.HandleCallbackQuery(context.Background(), &telego.CallbackQuery{
+			ID:      "123",
+			From:    telego.User{ID: 123}, // not an admin
+			Data:    data,
+			Message: &telego.Message{Chat: telego.Chat{ID: -100}},
+		})
+	}
+
+	if len(deps.summaryData) != 2 || deps.summaryData[0] != "exp:5:1" || deps.summaryData[1] != "fb:5:up" {
+		t.Fatalf("summary callbacks not delegated: %v", deps.summaryData)
+	}
+}
+
 func TestExtractURL(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -532,3 +578,553 @@ func TestHandle_InstructionsCancelAndSlashCommandDoesNotSave(t *testing.T) {
 // formatDuration and splitMessage moved/removed: duration formatting is now
 // tgutil.FormatDuration (tested in tgutil/format_test.go) and the unused
 // splitMessage helper was deleted.
+
+func TestHandle_InstructionsHistoryDiffAndRestore(t *testing.T) {
+	a, database, deps := newTestAdmin(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+	chat := telego.Chat{ID: 999, Type: "private"}
+	if err := database.AddAllowedGroup(ctx, -100123, 999); err != nil {
+		t.Fatalf("AddAllowedGroup error: %v", err)
+	}
+	if err := database.SetGroupSummaryInstructions(ctx, -100123, 999, "выделяй решения"); err != nil {
+		t.Fatalf("SetGroupSummaryInstructions error: %v", err)
+	}
+	if err := database.SetGroupSummaryInstructions(ctx, -100123, 999, "пиши стихами"); err != nil {
+		t.Fatalf("SetGroupSummaryInstructions error: %v", err)
+	}
+
+	callback := func(data string) {
+		a.HandleCallbackQuery(ctx, &telego.CallbackQuery{
+			ID:      data,
+			From:    telego.User{ID: 999},
+			Data:    data,
+			Message: &telego.InaccessibleMessage{Chat: chat},
+		})
+	}
+
+	tg := a.telegram.(*fakeTelegram)
+	callback("inst:hist:-100123")
+	if len(tg.sentTexts) != 1 || !strings.Contains(tg.sentTexts[0], "*v2*") || !strings.Contains(tg.sentTexts[0], "*v1*") {
+		t.Fatalf("expected history listing both versions, got: %v", tg.sentTexts)
+	}
+
+	callback("inst:diff:-100123:2")
+	if len(tg.sentTexts) != 2 {
+		t.Fatalf("expected diff message, got: %v", tg.sentTexts)
+	}
+	diff := tg.sentTexts[1]
+	if !strings.Contains(diff, "- выделяй решения") || !strings.Contains(diff, "+ пиши стихами") {
+		t.Fatalf("unexpected diff: %q", diff)
+	}
+
+	callback("inst:rest:-100123:1")
+	got, err := database.GetGroupSummaryInstructions(ctx, -100123)
+	if err != nil {
+		t.Fatalf("GetGroupSummaryInstructions error: %v", err)
+	}
+	if got == nil || got.Instructions != "выделяй решения" || got.Version != 3 {
+		t.Fatalf("expected v1 restored as v3, got %+v", got)
+	}
+	if len(deps.sentTexts) != 1 || !strings.Contains(deps.sentTexts[0], "восстановлены из версии 1") {
+		t.Fatalf("expected restore confirmation, got: %v", deps.sentTexts)
+	}
+}
+
+func TestHandle_InstructionsDiffPrunedPredecessor(t *testing.T) {
+	a, database, _ := newTestAdmin(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+	if err := database.AddAllowedGroup(ctx, -100123, 999); err != nil {
+		t.Fatalf("AddAllowedGroup error: %v", err)
+	}
+	// Enough versions that the first ones are pruned.
+	var latest int
+	for i := 1; ; i++ {
+		if err := database.SetGroupSummaryInstructions(ctx, -100123, 999, fmt.Sprintf("правило %d", i)); err != nil {
+			t.Fatalf("SetGroupSummaryInstructions error: %v", err)
+		}
+		if v, _ := database.GetGroupSummaryInstructionsVersion(ctx, -100123, 1); v == nil {
+			latest = i
+			break
+		}
+	}
+	oldest := latest
+	for v := latest; v > 0; v-- {
+		if got, _ := database.GetGroupSummaryInstructionsVersion(ctx, -100123, v); got == nil {
+			break
+		}
+		oldest = v
+	}
+
+	tg := a.telegram.(*fakeTelegram)
+	a.showInstructionsDiff(ctx, 999, -100123, oldest)
+	diff := tg.sentTexts[len(tg.sentTexts)-1]
+	if !strings.Contains(diff, "Более ранние версии удалены") || !strings.Contains(diff, fmt.Sprintf("+ правило %d", oldest)) {
+		t.Fatalf("diff of the oldest kept version = %q; want the whole text, flagged", diff)
+	}
+
+	a.showInstructionsDiff(ctx, 999, -100123, oldest+1)
+	diff = tg.sentTexts[len(tg.sentTexts)-1]
+	if !strings.Contains(diff, fmt.Sprintf("- правило %d", oldest)) || !strings.Contains(diff, fmt.Sprintf("+ правило %d", oldest+1)) ||
+		strings.Contains(diff, "удалены") {
+		t.Fatalf("diff against a kept predecessor = %q", diff)
+	}
+}
+
+func TestLineDiff(t *testing.T) {
+	got := lineDiff("a\nb\nc", "a\nx\nc\nd")
+	want := "  a\n- b\n+ x\n  c\n+ d"
+	if got != want {
+		t.Fatalf("lineDiff =\n%s\nwant\n%s", got, want)
+	}
+	if got := lineDiff("", "new"); got != "+ new" {
+		t.Fatalf("lineDiff from empty = %q", got)
+	}
+}
+
+func TestHandle_PreviewUsesDraftAndLeavesGroupStateAlone(t *testing.T) {
+	a, database, deps := newTestAdmin(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+	a.cfg.SummaryHours = 24
+	a.cfg.MaxMessages = 100
+	a.rateLimiter = &fakeRateLimiter{allowed: true}
+	sum := &fakeSummarizer{summary: "обсуждали релиз"}
+	a.summarizer = sum
+
+	if err := database.AddAllowedGroup(ctx, -100123, 999); err != nil {
+		t.Fatalf("AddAllowedGroup error: %v", err)
+	}
+	if err := database.SetGroupSummaryInstructions(ctx, -100123, 999, "выделяй решения"); err != nil {
+		t.Fatalf("SetGroupSummaryInstructions error: %v", err)
+	}
+	if err := database.AddMessage(ctx, &db.Message{GroupID: -100123, UserHash: "aaaa1111", Text: "релиз в пятницу", Timestamp: time.Now()}); err != nil {
+		t.Fatalf("AddMessage error: %v", err)
+	}
+
+	a.Handle(ctx, telego.Update{Message: &telego.Message{
+		Chat: telego.Chat{ID: 999, Type: "private"},
+		From: &telego.User{ID: 999},
+		Text: "/preview -100123 пиши кратко\nи по делу",
+	}})
+
+	if sum.topicsInstructions != "пиши кратко\nи по делу" {
+		t.Fatalf("expected draft instructions, got %q", sum.topicsInstructions)
+	}
+	if len(sum.topicsMessages) != 1 {
+		t.Fatalf("expected 1 message summarized, got %d", len(sum.topicsMessages))
+	}
+	if op, _ := provider.OperationFromContext(sum.topicsCtx); op != provider.OpPreview {
+		t.Fatalf("expected preview operation in context, got %q", op)
+	}
+	if len(deps.editTexts) != 1 || !strings.Contains(deps.editTexts[0], "Предпросмотр") || !strings.Contains(deps.editTexts[0], "черновик") {
+		t.Fatalf("expected preview digest edit, got: %v", deps.editTexts)
+	}
+
+	got, err := database.GetGroupSummaryInstructions(ctx, -100123)
+	if err != nil {
+		t.Fatalf("GetGroupSummaryInstructions error: %v", err)
+	}
+	if got == nil || got.Instructions != "выделяй решения" {
+		t.Fatalf("draft must not be saved, got %+v", got)
+	}
+	last, err := database.GetLastSummarizeTime(ctx, -100123)
+	if err != nil {
+		t.Fatalf("GetLastSummarizeTime error: %v", err)
+	}
+	if last != nil {
+		t.Fatalf("preview must not update last_summarize, got %v", last)
+	}
+}
+
+func TestHandle_PreviewButtonUsesCurrentInstructions(t *testing.T) {
+	a, database, deps := newTestAdmin(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+	a.cfg.SummaryHours = 24
+	a.cfg.MaxMessages = 100
+	a.rateLimiter = &fakeRateLimiter{allowed: true}
+	sum := &fakeSummarizer{summary: "ok"}
+	a.summarizer = sum
+
+	if err := database.AddAllowedGroup(ctx, -100123, 999); err != nil {
+		t.Fatalf("AddAllowedGroup error: %v", err)
+	}
+	if err := database.SetGroupSummaryInstructions(ctx, -100123, 999, "выделяй решения"); err != nil {
+		t.Fatalf("SetGroupSummaryInstructions error: %v", err)
+	}
+	if err := database.AddMessage(ctx, &db.Message{GroupID: -100123, UserHash: "aaaa1111", Text: "привет", Timestamp: time.Now()}); err != nil {
+		t.Fatalf("AddMessage error: %v", err)
+	}
+
+	a.HandleCallbackQuery(ctx, &telego.CallbackQuery{
+		ID:      "cb",
+		From:    telego.User{ID: 999},
+		Data:    "inst:prev:-100123",
+		Message: &telego.InaccessibleMessage{Chat: telego.Chat{ID: 999, Type: "private"}},
+	})
+
+	if sum.topicsInstructions != "выделяй решения" {
+		t.Fatalf("expected current instructions, got %q", sum.topicsInstructions)
+	}
+	if len(deps.editTexts) != 1 || !strings.Contains(deps.editTexts[0], "v1") {
+		t.Fatalf("expected preview labelled with current version, got: %v", deps.editTexts)
+	}
+}
+
+func TestHandle_PreviewRateLimited(t *testing.T) {
+	a, database, deps := newTestAdmin(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+	a.cfg.SummaryHours = 24
+	a.cfg.MaxMessages = 100
+	sum := &fakeSummarizer{}
+	a.summarizer = sum
+
+	if err := database.AddAllowedGroup(ctx, -100123, 999); err != nil {
+		t.Fatalf("AddAllowedGroup error: %v", err)
+	}
+	if err := database.AddMessage(ctx, &db.Message{GroupID: -100123, UserHash: "aaaa1111", Text: "привет", Timestamp: time.Now()}); err != nil {
+		t.Fatalf("AddMessage error: %v", err)
+	}
+
+	a.Handle(ctx, telego.Update{Message: &telego.Message{
+		Chat: telego.Chat{ID: 999, Type: "private"},
+		From: &telego.User{ID: 999},
+		Text: "/preview -100123",
+	}})
+
+	if sum.topicsMessages != nil {
+		t.Fatal("summarizer must not run when rate-limited")
+	}
+	if len(deps.sentTexts) != 1 || !strings.Contains(deps.sentTexts[0], "Подождите") {
+		t.Fatalf("expected rate limit message, got: %v", deps.sentTexts)
+	}
+}
+
+func TestHandle_LanguageShowsAndSetsGroupLanguage(t *testing.T) {
+	a, database, deps := newTestAdmin(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+	if err := database.AddAllowedGroup(ctx, -100123, 999); err != nil {
+		t.Fatalf("AddAllowedGroup error: %v", err)
+	}
+	send := func(text string) {
+		a.Handle(ctx, telego.Update{Message: &telego.Message{
+			Chat: telego.Chat{ID: 999, Type: "private"},
+			From: &telego.User{ID: 999},
+			Text: text,
+		}})
+	}
+
+	send("/language -100123")
+	if len(deps.sentTexts) != 1 || !strings.Contains(deps.sentTexts[0], ": ru") {
+		t.Fatalf("expected current language ru, got: %v", deps.sentTexts)
+	}
+
+	send("/language -100123 auto")
+	got, err := database.GetGroupLanguage(ctx, -100123)
+	if err != nil {
+		t.Fatalf("GetGroupLanguage error: %v", err)
+	}
+	if got != "auto" {
+		t.Fatalf("stored language = %q, want auto", got)
+	}
+
+	send("/language -100123 fr")
+	if last := deps.sentTexts[len(deps.sentTexts)-1]; last != i18n.T(i18n.Russian, i18n.LanguageBadValue) {
+		t.Fatalf("expected bad value reply, got %q", last)
+	}
+
+	send("/language -100999 en")
+	if last := deps.sentTexts[len(deps.sentTexts)-1]; !strings.Contains(last, "-100999") {
+		t.Fatalf("expected not-allowed reply for unknown group, got %q", last)
+	}
+}
+
+func TestHandle_PreviewUsesGroupLanguage(t *testing.T) {
+	a, database, _ := newTestAdmin(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+	a.cfg.SummaryHours = 24
+	a.cfg.MaxMessages = 100
+	a.rateLimiter = &fakeRateLimiter{allowed: true}
+	sum := &fakeSummarizer{summary: "ok"}
+	a.summarizer = sum
+
+	if err := database.AddAllowedGroup(ctx, -100123, 999); err != nil {
+		t.Fatalf("AddAllowedGroup error: %v", err)
+	}
+	if err := database.SetGroupLanguage(ctx, -100123, 999, "auto"); err != nil {
+		t.Fatalf("SetGroupLanguage error: %v", err)
+	}
+	if err := database.AddMessage(ctx, &db.Message{GroupID: -100123, UserHash: "aaaa1111", Text: "shipping the release on Friday", Timestamp: time.Now()}); err != nil {
+		t.Fatalf("AddMessage error: %v", err)
+	}
+
+	a.Handle(ctx, telego.Update{Message: &telego.Message{
+		Chat: telego.Chat{ID: 999, Type: "private"},
+		From: &telego.User{ID: 999},
+		Text: "/preview -100123",
+	}})
+
+	if sum.topicsLang != i18n.English {
+		t.Fatalf("auto language should resolve to en from the messages, got %q", sum.topicsLang)
+	}
+}
+
+func TestHandle_SettingsEditToggleAndReset(t *testing.T) {
+	a, database, deps := newTestAdmin(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+	a.cfg.TopicMax = 5
+	a.cfg.SummaryHours = 24
+	a.cfg.MaxMessages = 250
+	a.cfg.RateLimitSec = 60
+	a.cfg.ReplyThreads = true
+	tg := a.telegram.(*fakeTelegram)
+	chat := telego.Chat{ID: 999, Type: "private"}
+	user := &telego.User{ID: 999}
+	callback := func(data string) {
+		a.HandleCallbackQuery(ctx, &telego.CallbackQuery{
+			ID: "cb", From: telego.User{ID: 999}, Data: data,
+			Message: &telego.InaccessibleMessage{Chat: chat},
+		})
+	}
+
+	if err := database.AddAllowedGroup(ctx, -100123, 999); err != nil {
+		t.Fatalf("AddAllowedGroup error: %v", err)
+	}
+
+	a.Handle(ctx, telego.Update{Message: &telego.Message{Text: "/settings -100123", Chat: chat, From: user}})
+	if len(tg.sentTexts) != 1 || !strings.Contains(tg.sentTexts[0], "Тем в сводке: *5* \\(глобально\\)") {
+		t.Fatalf("expected settings view with global values, got: %v", tg.sentTexts)
+	}
+
+	callback("set:edit:-100123:topic_max")
+	if len(deps.sentTexts) != 1 || !strings.Contains(deps.sentTexts[0], "от 1 до 20") {
+		t.Fatalf("expected edit prompt, got: %v", deps.sentTexts)
+	}
+	a.Handle(ctx, telego.Update{Message: &telego.Message{Text: "50", Chat: chat, From: user}})
+	if last := deps.sentTexts[len(deps.sentTexts)-1]; !strings.Contains(last, "Нужно целое число") {
+		t.Fatalf("expected out-of-range reply, got %q", last)
+	}
+	a.Handle(ctx, telego.Update{Message: &telego.Message{Text: "8", Chat: chat, From: user}})
+
+	callback("set:tog:-100123")
+
+	o, err := database.GetGroupSettingsOverrides(ctx, -100123)
+	if err != nil {
+		t.Fatalf("GetGroupSettingsOverrides error: %v", err)
+	}
+	if o.TopicMax == nil || *o.TopicMax != 8 || o.ReplyThreads == nil || *o.ReplyThreads {
+		t.Fatalf("unexpected overrides: %+v", o)
+	}
+	if last := tg.sentTexts[len(tg.sentTexts)-1]; !strings.Contains(last, "Тем в сводке: *8* \\(для группы\\)") ||
+		!strings.Contains(last, "Ветки ответов: *выкл* \\(для группы\\)") {
+		t.Fatalf("expected overrides in refreshed view, got %q", last)
+	}
+
+	callback("set:reset:-100123:topic_max")
+	o, err = database.GetGroupSettingsOverrides(ctx, -100123)
+	if err != nil {
+		t.Fatalf("GetGroupSettingsOverrides error: %v", err)
+	}
+	if o.TopicMax != nil || o.ReplyThreads == nil {
+		t.Fatalf("reset should clear only topic_max, got %+v", o)
+	}
+
+	callback("set:tog:-100123:pii_redaction")
+	o, err = database.GetGroupSettingsOverrides(ctx, -100123)
+	if err != nil {
+		t.Fatalf("GetGroupSettingsOverrides error: %v", err)
+	}
+	if o.PIIRedaction == nil || !*o.PIIRedaction || o.ReplyThreads == nil || *o.ReplyThreads {
+		t.Fatalf("toggle should turn on only PII redaction, got %+v", o)
+	}
+	if last := tg.sentTexts[len(tg.sentTexts)-1]; !strings.Contains(last, "Скрывать личные данные: *вкл* \\(для группы\\)") {
+		t.Fatalf("expected PII redaction override in refreshed view, got %q", last)
+	}
+	callback("set:reset:-100123:pii_redaction")
+	o, err = database.GetGroupSettingsOverrides(ctx, -100123)
+	if err != nil {
+		t.Fatalf("GetGroupSettingsOverrides error: %v", err)
+	}
+	if o.PIIRedaction != nil || o.ReplyThreads == nil {
+		t.Fatalf("reset should clear only pii_redaction, got %+v", o)
+	}
+}
+
+func TestHandle_SettingsUnknownGroup(t *testing.T) {
+	a, database, deps := newTestAdmin(t)
+	defer func() { _ = database.Close() }()
+
+	a.Handle(context.Background(), telego.Update{Message: &telego.Message{
+		Text: "/settings -100999",
+		Chat: telego.Chat{ID: 999, Type: "private"},
+		From: &telego.User{ID: 999},
+	}})
+	if len(deps.sentTexts) != 1 || !strings.Contains(deps.sentTexts[0], "-100999") {
+		t.Fatalf("expected not-allowed reply, got: %v", deps.sentTexts)
+	}
+}
+
+func TestHandle_QualityReport(t *testing.T) {
+	a, database, deps := newTestAdmin(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+	if err := database.UpsertKnownGroup(ctx, -100123, "Dev chat", ""); err != nil {
+		t.Fatal(err)
+	}
+	vote := func(s db.StoredSummary, votes ...int) {
+		id, err := database.SaveSummary(ctx, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, v := range votes {
+			if err := database.SetSummaryFeedback(ctx, id, fmt.Sprintf("user%04d", i), v); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	vote(db.StoredSummary{GroupID: -100123, Lang: "ru", Model: "gpt-a"}, db.VoteUp, db.VoteDown)
+	vote(db.StoredSummary{GroupID: -100123, Lang: "ru", Model: "gpt-b", InstructionsVersion: 2}, db.VoteUp, db.VoteUp, db.VoteUp)
+	vote(db.StoredSummary{GroupID: -100456, Lang: "ru", Model: "gpt-a"}) // no votes
+
+	send := func(text string) {
+		a.Handle(ctx, telego.Update{Message: &telego.Message{
+			Chat: telego.Chat{ID: 999, Type: "private"},
+			From: &telego.User{ID: 999},
+			Text: text,
+		}})
+	}
+
+	send("/quality")
+	if len(deps.sentTexts) != 1 {
+		t.Fatalf("expected one report, got: %v", deps.sentTexts)
+	}
+	report := deps.sentTexts[0]
+	for _, want := range []string{
+		"Всего: 80% 👍 (👍 4 · 👎 1 · сводок: 2)",
+		"Dev chat: 80%",
+		"gpt-b: 100% 👍 (👍 3 · 👎 0 · сводок: 1)",
+		"gpt-a: 50%",
+		"Dev chat · без инструкций: 50%",
+		"Dev chat · v2: 100%",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report lacks %q:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "-100456") {
+		t.Errorf("unrated group in report:\n%s", report)
+	}
+
+	send("/quality 365")
+	if len(deps.formattedText) != 1 || !strings.Contains(deps.formattedText[0], "/quality") {
+		t.Fatalf("expected usage reply, got: %v", deps.formattedText)
+	}
+}
+
+func TestHandle_ForgetPurgesMemberAndOptsOut(t *testing.T) {
+	a, database, deps := newTestAdmin(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+	for _, hash := range []string{"a1b2c3d4", "a1b2c3d4", "eeee0000"} {
+		if err := database.AddMessage(ctx, &db.Message{GroupID: -100123, UserHash: hash, Text: "hi", Timestamp: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	send := func(text string) string {
+		a.Handle(ctx, telego.Update{Message: &telego.Message{
+			Chat: telego.Chat{ID: 999, Type: "private"},
+			From: &telego.User{ID: 999},
+			Text: text,
+		}})
+		if len(deps.formattedText) > 0 {
+			last := deps.formattedText[len(deps.formattedText)-1]
+			deps.formattedText = nil
+			return last
+		}
+		return deps.sentTexts[len(deps.sentTexts)-1]
+	}
+
+	if got := send("/forget -100123"); got != i18n.T(i18n.Russian, i18n.AdminForgetUsage) {
+		t.Fatalf("expected usage, got %q", got)
+	}
+	if got := send("/forget -100123 alice"); got != i18n.T(i18n.Russian, i18n.AdminForgetBadHash) {
+		t.Fatalf("expected bad hash reply, got %q", got)
+	}
+	if got := send("/forget -100123 A1B2C3D4"); got != i18n.T(i18n.Russian, i18n.AdminForgetDone, "a1b2c3d4", int64(-100123), int64(2)) {
+		t.Fatalf("unexpected reply %q", got)
+	}
+
+	msgs, err := database.GetMessages(ctx, -100123, time.Now().Add(-time.Hour), 10)
+	if err != nil || len(msgs) != 1 || msgs[0].UserHash != "eeee0000" {
+		t.Fatalf("remaining messages = %+v, %v", msgs, err)
+	}
+	if out, _ := database.IsUserOptedOut(ctx, -100123, "a1b2c3d4"); !out {
+		t.Fatal("the forgotten member should be opted out")
+	}
+}
+
+func TestHandle_Stats(t *testing.T) {
+	a, database, deps := newTestAdmin(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+	a.cfg.SummaryHours = 24
+	a.cfg.RetentionDays = 7
+
+	if err := database.AddAllowedGroup(ctx, -100123, 999); err != nil {
+		t.Fatalf("AddAllowedGroup error: %v", err)
+	}
+	start := time.Now().Add(-2 * time.Hour)
+	for i, m := range []db.Message{
+		{UserHash: "aaaa1111", Text: "кто возьмёт ревью?"},
+		{UserHash: "bbbb2222", Text: "я", ReplyToTgID: 1},
+	} {
+		m.GroupID, m.TgMessageID, m.Timestamp = -100123, int64(i+1), start.Add(time.Duration(i)*time.Minute)
+		if err := database.AddMessage(ctx, &m); err != nil {
+			t.Fatalf("AddMessage error: %v", err)
+		}
+	}
+
+	send := func(text string) {
+		a.Handle(ctx, telego.Update{Message: &telego.Message{
+			Chat: telego.Chat{ID: 999, Type: "private"},
+			From: &telego.User{ID: 999},
+			Text: text,
+		}})
+	}
+
+	send("/stats -100123")
+	if len(deps.sentTexts) != 1 {
+		t.Fatalf("expected one report, got %v", deps.sentTexts)
+	}
+	report := deps.sentTexts[0]
+	for _, want := range []string{"-100123", "24", "У2 → У1: 1"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report missing %q:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "aaaa1111") || strings.Contains(report, "bbbb2222") {
+		t.Errorf("report leaks a user hash:\n%s", report)
+	}
+
+	send("/stats -100123 1000")
+	if len(deps.formattedText) != 1 || !strings.Contains(deps.formattedText[0], "168") {
+		t.Fatalf("expected usage with the retention limit, got %v", deps.formattedText)
+	}
+}
@@ -0,0 +1,58 @@
+package admin
+
+import "strings"
+
+// lineDiff returns a unified-style line diff of before→after: unchanged lines
+// are prefixed with "  ", removed with "- " and added with "+ ". Instructions
+// are capped at a few thousand characters, so the quadratic LCS table is cheap.
+func lineDiff(before, after string) string {
+	a := splitLines(before)
+	b := splitLines(after)
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var sb strings.Builder
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			sb.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			sb.WriteString("- " + a[i] + "\n")
+			i++
+		default:
+			sb.WriteString("+ " + b[j] + "\n")
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		sb.WriteString("- " + a[i] + "\n")
+	}
+	for ; j < len(b); j++ {
+		sb.WriteString("+ " + b[j] + "\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
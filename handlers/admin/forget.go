@@ -0,0 +1,48 @@
+package admin
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/logger"
+)
+
+// userHashRe matches a db.UserHash value.
+var userHashRe = regexp.MustCompile(`^[0-9a-f]{8}$`)
+
+// handleForget handles "/forget <group_id> <hash>", the admin counterpart of
+// a member's "forget me": it deletes everything stored about the member in
+// the group and stops storing their messages. The group doesn't have to be
+// allowed any more, so a removed group can still be purged.
+func (a *Admin) handleForget(ctx context.Context, chatID int64, args []string) {
+	if len(args) != 2 {
+		a.deps.SendFormatted(ctx, chatID, i18n.T(a.lang(), i18n.AdminForgetUsage))
+		return
+	}
+	groupID, ok := parseInstructionGroupID(args[0])
+	if !ok {
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.AdminBadGroupID))
+		return
+	}
+	userHash := strings.ToLower(args[1])
+	if !userHashRe.MatchString(userHash) {
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.AdminForgetBadHash))
+		return
+	}
+
+	if err := a.db.SetUserOptOut(ctx, groupID, userHash, true); err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to record opt-out")
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.ForgetFailed))
+		return
+	}
+	deleted, err := a.db.ForgetUser(ctx, groupID, userHash)
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to forget user")
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.ForgetFailed))
+		return
+	}
+	logger.Info().Int64("group_id", groupID).Int64("deleted", deleted).Msg("forgot user on admin request")
+	a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.AdminForgetDone, userHash, groupID, deleted))
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 
+	"telegram_summarize_bot/i18n"
 	"telegram_summarize_bot/logger"
 	"telegram_summarize_bot/summarizer"
 )
@@ -21,12 +22,12 @@ func (a *Admin) handleGroups(ctx context.Context, chatID, userID int64, args []s
 	switch subCmd {
 	case "add":
 		if len(args) < 2 {
-			a.deps.SendFormatted(ctx, chatID, "Использование: `/groups add <group_id>`")
+			a.deps.SendFormatted(ctx, chatID, i18n.T(a.lang(), i18n.AdminGroupsAddUsage))
 			return
 		}
 		groupID, err := strconv.ParseInt(args[1], 10, 64)
 		if err != nil {
-			a.deps.SendMessage(ctx, chatID, "Неверный ID группы.")
+			a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.AdminBadGroupID))
 			return
 		}
 		// Best-effort title lookup; don't block add if group is unknown.
@@ -44,24 +45,24 @@ func (a *Admin) handleGroups(ctx context.Context, chatID, userID int64, args []s
 		}
 		if err := a.db.AddAllowedGroup(ctx, groupID, userID); err != nil {
 			logger.Error().Err(err).Msg("failed to add allowed group")
-			a.deps.SendMessage(ctx, chatID, "Ошибка добавления группы.")
+			a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.AdminGroupsAddError))
 			return
 		}
-		a.deps.SendMessage(ctx, chatID, fmt.Sprintf("✅ %s добавлена.", title))
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.AdminGroupsAdded, title))
 	case "remove":
 		if len(args) < 2 {
-			a.deps.SendFormatted(ctx, chatID, "Использование: `/groups remove <group_id>`")
+			a.deps.SendFormatted(ctx, chatID, i18n.T(a.lang(), i18n.AdminGroupsRemoveUsage))
 			return
 		}
 		groupID, err := strconv.ParseInt(args[1], 10, 64)
 		if err != nil {
-			a.deps.SendMessage(ctx, chatID, "Неверный ID группы.")
+			a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.AdminBadGroupID))
 			return
 		}
 		groups, err := a.db.GetKnownGroups(ctx)
 		if err != nil {
 			logger.Error().Err(err).Msg("failed to get known groups")
-			a.deps.SendMessage(ctx, chatID, "Ошибка получения списка групп.")
+			a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.AdminGroupsError))
 			return
 		}
 		var foundTitle string
@@ -72,18 +73,18 @@ func (a *Admin) handleGroups(ctx context.Context, chatID, userID int64, args []s
 			}
 		}
 		if foundTitle == "" {
-			a.deps.SendMessage(ctx, chatID, fmt.Sprintf("Группа %d не найдена в списке известных групп.", groupID))
+			a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.AdminGroupsUnknown, groupID))
 			a.sendGroupsList(ctx, chatID)
 			return
 		}
 		if err := a.db.RemoveAllowedGroup(ctx, groupID); err != nil {
 			logger.Error().Err(err).Msg("failed to remove allowed group")
-			a.deps.SendMessage(ctx, chatID, "Ошибка удаления группы.")
+			a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.AdminGroupsRemoveError))
 			return
 		}
-		a.deps.SendMessage(ctx, chatID, fmt.Sprintf("❌ %s удалена.", foundTitle))
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.AdminGroupsRemoved, foundTitle))
 	default:
-		a.deps.SendFormatted(ctx, chatID, "Неизвестная подкоманда\\. Используйте: `/groups`, `/groups add <id>`, `/groups remove <id>`")
+		a.deps.SendFormatted(ctx, chatID, i18n.T(a.lang(), i18n.AdminGroupsBadSubcommand))
 	}
 }
 
@@ -91,16 +92,16 @@ func (a *Admin) sendGroupsList(ctx context.Context, chatID int64) {
 	groups, err := a.db.GetKnownGroups(ctx)
 	if err != nil {
 		logger.Error().Err(err).Msg("failed to get known groups")
-		a.deps.SendMessage(ctx, chatID, "Ошибка получения списка групп.")
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.AdminGroupsError))
 		return
 	}
 	if len(groups) == 0 {
-		a.deps.SendMessage(ctx, chatID, "Нет известных групп.")
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.AdminGroupsNone))
 		return
 	}
 
 	var sb strings.Builder
-	sb.WriteString("📋 *Известные группы:*\n\n")
+	sb.WriteString(i18n.T(a.lang(), i18n.AdminGroupsListHeader))
 	for _, g := range groups {
 		status := "❌"
 		if g.Allowed {
@@ -119,6 +120,6 @@ func (a *Admin) sendGroupsList(ctx context.Context, chatID int64) {
 		}
 		fmt.Fprintf(&sb, "%s %s \\(%s\\)\n", status, title, summarizer.EscapeMarkdown(fmt.Sprintf("%d", g.GroupID)))
 	}
-	sb.WriteString("\nДля управления:\n• `/groups add <group_id>`\n• `/groups remove <group_id>`")
+	sb.WriteString(i18n.T(a.lang(), i18n.AdminGroupsListFooter))
 	a.deps.SendFormatted(ctx, chatID, sb.String())
 }
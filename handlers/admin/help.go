@@ -1,17 +1,11 @@
 package admin
 
-import "context"
+import (
+	"context"
+
+	"telegram_summarize_bot/i18n"
+)
 
 func (a *Admin) handleHelp(ctx context.Context, chatID int64) {
-	helpText := "*Команды администратора*\n\n" +
-		"`/help` — показать это сообщение\n" +
-		"`/status` — статус бота и метрики\n" +
-		"`/reset` — сбросить все метрики\n" +
-		"`/groups` — список разрешённых групп\n" +
-		"`/groups add <group_id>` — добавить группу\n" +
-		"`/groups remove <group_id>` — удалить группу\n" +
-		"`/instructions` — настроить дополнительные инструкции суммаризации для группы\n" +
-		"`/usage` — использование токенов и квоты Codex\n\n" +
-		"*Суммаризация URL:*\nОтправьте ссылку — бот загрузит страницу и вернёт краткое содержание\\."
-	a.deps.SendFormatted(ctx, chatID, helpText)
+	a.deps.SendFormatted(ctx, chatID, i18n.T(a.lang(), i18n.AdminHelp))
 }
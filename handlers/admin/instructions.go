@@ -2,11 +2,13 @@ package admin
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 
 	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
 	"telegram_summarize_bot/logger"
 	"telegram_summarize_bot/summarizer"
 
@@ -16,17 +18,32 @@ import (
 
 const (
 	instructionsCallbackPrefix = "inst:"
+	// instructionsHistoryLimit is how many versions the history view lists.
+	instructionsHistoryLimit = 8
+	// instructionsSnippetRunes truncates each version's text in the history view.
+	instructionsSnippetRunes = 80
 )
 
 func (a *Admin) handleInstructions(ctx context.Context, chatID int64) {
+	rows, ok := a.allowedGroupRows(ctx, chatID, "inst:grp:")
+	if !ok {
+		return
+	}
+	a.sendKeyboard(ctx, chatID, i18n.T(a.lang(), i18n.InstPickGroup), rows)
+}
+
+// allowedGroupRows builds a one-button-per-row group picker whose callback
+// data is callbackPrefix followed by the group ID. It reports false (after
+// telling the admin why) when there is nothing to pick.
+func (a *Admin) allowedGroupRows(ctx context.Context, chatID int64, callbackPrefix string) ([][]telego.InlineKeyboardButton, bool) {
 	groups, err := a.db.GetKnownGroups(ctx)
 	if err != nil {
-		logger.Error().Err(err).Msg("failed to get known groups for instructions")
-		a.deps.SendMessage(ctx, chatID, "Ошибка получения списка групп.")
-		return
+		logger.Error().Err(err).Msg("failed to get known groups for group picker")
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.AdminGroupsError))
+		return nil, false
 	}
 
-	var rows []telego.InlineKeyboardButton
+	var rows [][]telego.InlineKeyboardButton
 	for _, g := range groups {
 		if !g.Allowed {
 			continue
@@ -35,21 +52,16 @@ func (a *Admin) handleInstructions(ctx context.Context, chatID int64) {
 		if title == "" {
 			title = fmt.Sprintf("%d", g.GroupID)
 		}
-		rows = append(rows, telego.InlineKeyboardButton{
+		rows = append(rows, []telego.InlineKeyboardButton{{
 			Text:         title,
-			CallbackData: fmt.Sprintf("inst:grp:%d", g.GroupID),
-		})
+			CallbackData: fmt.Sprintf("%s%d", callbackPrefix, g.GroupID),
+		}})
 	}
 	if len(rows) == 0 {
-		a.deps.SendMessage(ctx, chatID, "Нет разрешённых групп.")
-		return
-	}
-
-	keyboardRows := make([][]telego.InlineKeyboardButton, 0, len(rows))
-	for _, row := range rows {
-		keyboardRows = append(keyboardRows, []telego.InlineKeyboardButton{row})
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.InstNoGroups))
+		return nil, false
 	}
-	a.sendInstructionsKeyboard(ctx, chatID, "Выберите группу для настройки инструкций суммаризации:", keyboardRows)
+	return rows, true
 }
 
 func (a *Admin) handleInstructionsCallback(ctx context.Context, cq *telego.CallbackQuery) {
@@ -62,7 +74,7 @@ func (a *Admin) handleInstructionsCallback(ctx context.Context, cq *telego.Callb
 	switch {
 	case data == "cancel":
 		a.clearPendingInstructions(chatID)
-		a.deps.SendMessage(ctx, chatID, "Настройка инструкций отменена.")
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.InstCancelled))
 	case strings.HasPrefix(data, "grp:"):
 		groupID, ok := parseInstructionGroupID(strings.TrimPrefix(data, "grp:"))
 		if !ok {
@@ -81,10 +93,7 @@ func (a *Admin) handleInstructionsCallback(ctx context.Context, cq *telego.Callb
 			return
 		}
 		a.setPendingInstructions(chatID, groupID)
-		a.deps.SendMessage(ctx, chatID, fmt.Sprintf(
-			"Отправьте новые дополнительные инструкции для группы %d одним сообщением. Максимум %d символов. Для отмены: /cancel",
-			groupID, db.MaxGroupSummaryInstructionsLength,
-		))
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.InstEditPrompt, groupID, db.MaxGroupSummaryInstructionsLength))
 	case strings.HasPrefix(data, "clear:"):
 		groupID, ok := parseInstructionGroupID(strings.TrimPrefix(data, "clear:"))
 		if !ok {
@@ -93,13 +102,60 @@ func (a *Admin) handleInstructionsCallback(ctx context.Context, cq *telego.Callb
 		if !a.ensureInstructionsGroupAllowed(ctx, chatID, groupID) {
 			return
 		}
-		if err := a.db.ClearGroupSummaryInstructions(ctx, groupID); err != nil {
+		if err := a.db.ClearGroupSummaryInstructions(ctx, groupID, cq.From.ID); err != nil {
 			logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to clear group summary instructions")
-			a.deps.SendMessage(ctx, chatID, "Ошибка удаления инструкций.")
+			a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.InstClearError))
+			return
+		}
+		a.clearPendingInstructions(chatID)
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.InstCleared, groupID))
+	case strings.HasPrefix(data, "prev:"):
+		groupID, ok := parseInstructionGroupID(strings.TrimPrefix(data, "prev:"))
+		if !ok {
+			return
+		}
+		if !a.ensureInstructionsGroupAllowed(ctx, chatID, groupID) {
+			return
+		}
+		a.previewCurrentInstructions(ctx, chatID, groupID)
+	case strings.HasPrefix(data, "hist:"):
+		groupID, ok := parseInstructionGroupID(strings.TrimPrefix(data, "hist:"))
+		if !ok {
+			return
+		}
+		if !a.ensureInstructionsGroupAllowed(ctx, chatID, groupID) {
+			return
+		}
+		a.showInstructionsHistory(ctx, chatID, groupID)
+	case strings.HasPrefix(data, "diff:"):
+		groupID, version, ok := parseInstructionGroupVersion(strings.TrimPrefix(data, "diff:"))
+		if !ok {
+			return
+		}
+		if !a.ensureInstructionsGroupAllowed(ctx, chatID, groupID) {
+			return
+		}
+		a.showInstructionsDiff(ctx, chatID, groupID, version)
+	case strings.HasPrefix(data, "rest:"):
+		groupID, version, ok := parseInstructionGroupVersion(strings.TrimPrefix(data, "rest:"))
+		if !ok {
+			return
+		}
+		if !a.ensureInstructionsGroupAllowed(ctx, chatID, groupID) {
+			return
+		}
+		err := a.db.RestoreGroupSummaryInstructionsVersion(ctx, groupID, version, cq.From.ID)
+		if errors.Is(err, db.ErrInstructionsVersionNotFound) {
+			a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.InstVersionMissing, version))
+			return
+		}
+		if err != nil {
+			logger.Error().Err(err).Int64("group_id", groupID).Int("version", version).Msg("failed to restore group summary instructions")
+			a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.InstRestoreError))
 			return
 		}
 		a.clearPendingInstructions(chatID)
-		a.deps.SendMessage(ctx, chatID, fmt.Sprintf("Инструкции для группы %d очищены.", groupID))
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.InstRestored, groupID, version))
 	}
 }
 
@@ -107,11 +163,11 @@ func (a *Admin) ensureInstructionsGroupAllowed(ctx context.Context, chatID, grou
 	allowed, err := a.db.IsGroupAllowed(ctx, groupID)
 	if err != nil {
 		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to check group allowlist for instructions")
-		a.deps.SendMessage(ctx, chatID, "Ошибка проверки группы.")
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.AdminGroupCheckError))
 		return false
 	}
 	if !allowed {
-		a.deps.SendMessage(ctx, chatID, fmt.Sprintf("Группа %d не разрешена для бота.", groupID))
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.AdminGroupNotAllowed, groupID))
 		return false
 	}
 	return true
@@ -121,27 +177,121 @@ func (a *Admin) showInstructionsGroup(ctx context.Context, chatID, groupID int64
 	item, err := a.db.GetGroupSummaryInstructions(ctx, groupID)
 	if err != nil {
 		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to get group summary instructions")
-		a.deps.SendMessage(ctx, chatID, "Ошибка получения инструкций.")
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.InstLoadError))
 		return
 	}
 
-	text := fmt.Sprintf("*Инструкции для группы %s:*\n\n", summarizer.EscapeMarkdown(fmt.Sprintf("%d", groupID)))
+	groupLabel := summarizer.EscapeMarkdown(fmt.Sprintf("%d", groupID))
+	var text string
 	if item == nil || strings.TrimSpace(item.Instructions) == "" {
-		text += "_Не заданы\\._"
+		text = i18n.T(a.lang(), i18n.InstViewEmpty, groupLabel)
 	} else {
-		text += summarizer.EscapeMarkdown(item.Instructions)
+		text = i18n.T(a.lang(), i18n.InstViewHeader, groupLabel, item.Version) +
+			summarizer.EscapeMarkdown(item.Instructions)
 	}
 
 	keyboard := [][]telego.InlineKeyboardButton{
 		{
 			{Text: "Edit", CallbackData: fmt.Sprintf("inst:edit:%d", groupID)},
 			{Text: "Clear", CallbackData: fmt.Sprintf("inst:clear:%d", groupID)},
+			{Text: "History", CallbackData: fmt.Sprintf("inst:hist:%d", groupID)},
 		},
 		{
+			{Text: "Preview", CallbackData: fmt.Sprintf("inst:prev:%d", groupID)},
 			{Text: "Cancel", CallbackData: "inst:cancel"},
 		},
 	}
-	a.sendInstructionsKeyboard(ctx, chatID, text, keyboard)
+	a.sendKeyboard(ctx, chatID, text, keyboard)
+}
+
+// showInstructionsHistory lists the latest versions with a diff button for
+// each and a restore button for every version but the current one.
+func (a *Admin) showInstructionsHistory(ctx context.Context, chatID, groupID int64) {
+	versions, err := a.db.ListGroupSummaryInstructionsVersions(ctx, groupID, instructionsHistoryLimit)
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to list group summary instructions versions")
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.InstHistoryError))
+		return
+	}
+	if len(versions) == 0 {
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.InstHistoryEmpty, groupID))
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(i18n.T(a.lang(), i18n.InstHistoryHeader, summarizer.EscapeMarkdown(fmt.Sprintf("%d", groupID))))
+	keyboard := make([][]telego.InlineKeyboardButton, 0, len(versions)+1)
+	for i, v := range versions {
+		snippet := i18n.T(a.lang(), i18n.InstHistoryCleared)
+		if v.Instructions != "" {
+			snippet = summarizer.EscapeMarkdown(truncateRunes(v.Instructions, instructionsSnippetRunes))
+		}
+		current := ""
+		if i == 0 {
+			current = i18n.T(a.lang(), i18n.InstHistoryCurrent)
+		}
+		fmt.Fprintf(&sb, "\n*v%d*%s · %s\n%s\n", v.Version, current,
+			summarizer.EscapeMarkdown(v.CreatedAt.UTC().Format("2006-01-02 15:04 UTC")), snippet)
+
+		row := []telego.InlineKeyboardButton{
+			{Text: fmt.Sprintf("Diff v%d", v.Version), CallbackData: fmt.Sprintf("inst:diff:%d:%d", groupID, v.Version)},
+		}
+		if i > 0 {
+			row = append(row, telego.InlineKeyboardButton{
+				Text: fmt.Sprintf("Restore v%d", v.Version), CallbackData: fmt.Sprintf("inst:rest:%d:%d", groupID, v.Version),
+			})
+		}
+		keyboard = append(keyboard, row)
+	}
+	keyboard = append(keyboard, []telego.InlineKeyboardButton{{Text: "Cancel", CallbackData: "inst:cancel"}})
+	a.sendKeyboard(ctx, chatID, sb.String(), keyboard)
+}
+
+// showInstructionsDiff shows what version changed relative to its predecessor.
+func (a *Admin) showInstructionsDiff(ctx context.Context, chatID, groupID int64, version int) {
+	cur, err := a.db.GetGroupSummaryInstructionsVersion(ctx, groupID, version)
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Int("version", version).Msg("failed to get group summary instructions version")
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.InstVersionError))
+		return
+	}
+	if cur == nil {
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.InstVersionMissing, version))
+		return
+	}
+	// The predecessor may have been pruned; diff against the nearest version
+	// still stored, and say so.
+	prev, err := a.db.PreviousGroupSummaryInstructionsVersion(ctx, groupID, version)
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Int("version", version).Msg("failed to get previous group summary instructions version")
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.InstVersionError))
+		return
+	}
+	var before, base string
+	switch {
+	case prev != nil:
+		before = prev.Instructions
+		if prev.Version != version-1 {
+			base = i18n.T(a.lang(), i18n.InstDiffBase, prev.Version)
+		}
+	case version > 1:
+		base = i18n.T(a.lang(), i18n.InstDiffNoBase)
+	}
+
+	diff := lineDiff(before, cur.Instructions)
+	if diff == "" {
+		diff = i18n.T(a.lang(), i18n.InstDiffNoChanges)
+	}
+	text := i18n.T(a.lang(), i18n.InstDiffHeader, version, summarizer.EscapeMarkdown(fmt.Sprintf("%d", groupID))) + base +
+		"```diff\n" + escapeCodeBlock(diff) + "\n```"
+
+	keyboard := [][]telego.InlineKeyboardButton{
+		{
+			{Text: fmt.Sprintf("Restore v%d", version), CallbackData: fmt.Sprintf("inst:rest:%d:%d", groupID, version)},
+			{Text: "History", CallbackData: fmt.Sprintf("inst:hist:%d", groupID)},
+		},
+	}
+	a.sendKeyboard(ctx, chatID, text, keyboard)
 }
 
 func (a *Admin) handlePendingSummaryInstructions(ctx context.Context, msg *telego.Message, cmd string) bool {
@@ -152,7 +302,7 @@ func (a *Admin) handlePendingSummaryInstructions(ctx context.Context, msg *teleg
 
 	if cmd == "/cancel" {
 		a.clearPendingInstructions(msg.Chat.ID)
-		a.deps.SendMessage(ctx, msg.Chat.ID, "Настройка инструкций отменена.")
+		a.deps.SendMessage(ctx, msg.Chat.ID, i18n.T(a.lang(), i18n.InstCancelled))
 		return true
 	}
 	if strings.HasPrefix(cmd, "/") {
@@ -161,16 +311,16 @@ func (a *Admin) handlePendingSummaryInstructions(ctx context.Context, msg *teleg
 
 	if err := a.db.SetGroupSummaryInstructions(ctx, groupID, msg.From.ID, msg.Text); err != nil {
 		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to save group summary instructions")
-		a.deps.SendMessage(ctx, msg.Chat.ID, "Ошибка сохранения инструкций: "+err.Error())
+		a.deps.SendMessage(ctx, msg.Chat.ID, i18n.T(a.lang(), i18n.InstSaveError, err.Error()))
 		return true
 	}
 
 	a.clearPendingInstructions(msg.Chat.ID)
-	a.deps.SendMessage(ctx, msg.Chat.ID, fmt.Sprintf("Инструкции для группы %d сохранены.", groupID))
+	a.deps.SendMessage(ctx, msg.Chat.ID, i18n.T(a.lang(), i18n.InstSaved, groupID))
 	return true
 }
 
-func (a *Admin) sendInstructionsKeyboard(ctx context.Context, chatID int64, text string, rows [][]telego.InlineKeyboardButton) {
+func (a *Admin) sendKeyboard(ctx context.Context, chatID int64, text string, rows [][]telego.InlineKeyboardButton) {
 	defer a.metrics.TelegramSend.Start()()
 	keyboard := &telego.InlineKeyboardMarkup{InlineKeyboard: rows}
 	_, err := a.telegram.SendMessage(ctx,
@@ -179,7 +329,7 @@ func (a *Admin) sendInstructionsKeyboard(ctx context.Context, chatID int64, text
 			WithReplyMarkup(keyboard),
 	)
 	if err != nil {
-		logger.Error().Err(err).Int64("chat_id", chatID).Msg("failed to send instructions keyboard")
+		logger.Error().Err(err).Int64("chat_id", chatID).Msg("failed to send keyboard")
 		a.metrics.RecordError("telegram_send", err.Error())
 	}
 }
@@ -187,6 +337,7 @@ func (a *Admin) sendInstructionsKeyboard(ctx context.Context, chatID int64, text
 func (a *Admin) setPendingInstructions(chatID, groupID int64) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
+	delete(a.pendingSettings, chatID)
 	a.pendingSummaryInstructions[chatID] = groupID
 }
 
@@ -208,6 +359,39 @@ func parseInstructionGroupID(raw string) (int64, bool) {
 	return groupID, err == nil
 }
 
+// parseInstructionGroupVersion parses "<group_id>:<version>" callback data.
+func parseInstructionGroupVersion(raw string) (int64, int, bool) {
+	groupRaw, versionRaw, ok := strings.Cut(raw, ":")
+	if !ok {
+		return 0, 0, false
+	}
+	groupID, ok := parseInstructionGroupID(groupRaw)
+	if !ok {
+		return 0, 0, false
+	}
+	version, err := strconv.Atoi(versionRaw)
+	if err != nil || version <= 0 {
+		return 0, 0, false
+	}
+	return groupID, version, true
+}
+
+// escapeCodeBlock escapes text for a MarkdownV2 pre block, where only
+// backslashes and backticks are special.
+func escapeCodeBlock(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "`", "\\`").Replace(s)
+}
+
+// truncateRunes shortens s to at most n runes, adding an ellipsis when cut and
+// flattening newlines so the snippet stays on one line.
+func truncateRunes(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > n {
+		return string(r[:n]) + "…"
+	}
+	return s
+}
+
 func callbackChatID(cq *telego.CallbackQuery) int64 {
 	if cq.Message == nil {
 		return 0
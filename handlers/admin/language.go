@@ -0,0 +1,74 @@
+package admin
+
+import (
+	"context"
+	"time"
+
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/logger"
+)
+
+// groupLanguage returns the group's summary language, or the configured
+// default when the group has none. The result may be i18n.Auto.
+func (a *Admin) groupLanguage(ctx context.Context, groupID int64) i18n.Lang {
+	raw, err := a.db.GetGroupLanguage(ctx, groupID)
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to get group language")
+	}
+	if lang, ok := i18n.Parse(raw); ok {
+		return lang
+	}
+	return a.cfg.Language.OrDefault()
+}
+
+// groupTimezone returns the group's timezone, falling back to the configured
+// default (UTC when there is none).
+func (a *Admin) groupTimezone(ctx context.Context, groupID int64) *time.Location {
+	raw, err := a.db.GetGroupTimezone(ctx, groupID)
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to get group timezone")
+	}
+	if raw != "" {
+		if loc, err := time.LoadLocation(raw); err == nil {
+			return loc
+		}
+	}
+	if a.cfg.Timezone != nil {
+		return a.cfg.Timezone
+	}
+	return time.UTC
+}
+
+// handleLanguage handles "/language <group_id> [ru|en|auto]": without a value
+// it shows the group's summary language, otherwise it sets it.
+func (a *Admin) handleLanguage(ctx context.Context, chatID, userID int64, args []string) {
+	if len(args) == 0 {
+		a.deps.SendFormatted(ctx, chatID, i18n.T(a.lang(), i18n.AdminLanguageUsage))
+		return
+	}
+	groupID, ok := parseInstructionGroupID(args[0])
+	if !ok {
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.AdminBadGroupID))
+		return
+	}
+	if !a.ensureInstructionsGroupAllowed(ctx, chatID, groupID) {
+		return
+	}
+
+	if len(args) == 1 {
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.AdminLanguageCurrent, groupID, a.groupLanguage(ctx, groupID)))
+		return
+	}
+
+	lang, ok := i18n.Parse(args[1])
+	if !ok {
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.LanguageBadValue))
+		return
+	}
+	if err := a.db.SetGroupLanguage(ctx, groupID, userID, string(lang)); err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to set group language")
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.LanguageSaveError))
+		return
+	}
+	a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.AdminLanguageSet, groupID, lang))
+}
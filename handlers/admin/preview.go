@@ -0,0 +1,118 @@
+package admin
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/logger"
+	"telegram_summarize_bot/provider"
+	"telegram_summarize_bot/summarizer"
+	"telegram_summarize_bot/tgutil"
+
+	telegramify "github.com/barbashov/telegramify-markdown-go"
+)
+
+// handlePreview handles "/preview <group_id> [draft instructions]". Without a
+// draft the group's current instructions are used; with one, the draft is
+// tried without being saved.
+func (a *Admin) handlePreview(ctx context.Context, chatID int64, text string) {
+	fields := strings.Fields(text)
+	if len(fields) < 2 {
+		a.deps.SendFormatted(ctx, chatID, i18n.T(a.lang(), i18n.PreviewUsage))
+		return
+	}
+	groupID, ok := parseInstructionGroupID(fields[1])
+	if !ok {
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.AdminBadGroupID))
+		return
+	}
+	if !a.ensureInstructionsGroupAllowed(ctx, chatID, groupID) {
+		return
+	}
+
+	// Keep the draft's original line breaks: cut the command and group ID off
+	// the raw text instead of re-joining fields.
+	draft := strings.TrimSpace(text)
+	for _, f := range fields[:2] {
+		draft = strings.TrimSpace(strings.TrimPrefix(draft, f))
+	}
+	if draft != "" {
+		if len([]rune(draft)) > db.MaxGroupSummaryInstructionsLength {
+			a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.PreviewDraftTooLong, db.MaxGroupSummaryInstructionsLength))
+			return
+		}
+		a.runPreview(ctx, chatID, groupID, draft, i18n.T(a.lang(), i18n.PreviewLabelDraft))
+		return
+	}
+	a.previewCurrentInstructions(ctx, chatID, groupID)
+}
+
+// previewCurrentInstructions previews the digest with the group's saved
+// instructions.
+func (a *Admin) previewCurrentInstructions(ctx context.Context, chatID, groupID int64) {
+	item, err := a.db.GetGroupSummaryInstructions(ctx, groupID)
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to get group summary instructions for preview")
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.InstLoadError))
+		return
+	}
+	if item == nil {
+		a.runPreview(ctx, chatID, groupID, "", i18n.T(a.lang(), i18n.PreviewLabelNone))
+		return
+	}
+	a.runPreview(ctx, chatID, groupID, item.Instructions, i18n.T(a.lang(), i18n.PreviewLabelCurrent, item.Version))
+}
+
+// runPreview summarizes the group's recent messages with the given
+// instructions and sends the digest to the admin's DM only. It reads the
+// messages directly rather than through the group's summarize path, so
+// last_summarize and the group's rate limit stay untouched; the admin's own
+// chat is rate-limited instead. Token usage is recorded as provider.OpPreview.
+func (a *Admin) runPreview(ctx context.Context, chatID, groupID int64, instructions, instructionsLabel string) {
+	settings, err := a.db.GroupSettings(ctx, groupID, a.cfg.GroupDefaults())
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("preview: failed to get group settings")
+	}
+	since := time.Now().Add(-settings.SummaryDuration())
+	messages, err := a.db.GetMessages(ctx, groupID, since, settings.MaxMessages)
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("preview: failed to get messages")
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.MessagesError))
+		return
+	}
+	if len(messages) == 0 {
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.PreviewNoMessages, groupID, settings.SummaryHours))
+		return
+	}
+
+	if !a.rateLimiter.Allow(chatID) {
+		a.metrics.RateLimit.Record(0)
+		remaining := a.rateLimiter.RemainingTime(chatID)
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.RateLimitWaitDM, tgutil.FormatDuration(a.lang(), remaining)))
+		return
+	}
+
+	statusMsgID := a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.PreviewPreparing, len(messages)))
+
+	lang := a.groupLanguage(ctx, groupID).Resolve(summarizer.MessageTexts(messages)...)
+	sumCtx := summarizer.WithGroupSettings(provider.WithOperation(ctx, provider.OpPreview), settings, a.groupTimezone(ctx, groupID))
+	summary, err := a.summarizer.SummarizeByTopics(sumCtx, messages, settings.TopicMax, instructions, lang)
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("preview: failed to summarize")
+		a.deps.EditWithRetry(ctx, chatID, statusMsgID, i18n.T(a.lang(), i18n.SummarizeFailed))
+		return
+	}
+
+	header := i18n.T(a.lang(), i18n.PreviewHeader, groupID, instructionsLabel) + "\n\n"
+	chunks := telegramify.Split(telegramify.Markdownify(header+summarizer.FormatTelegramSummary(summary, groupID)), 4096)
+	if len(chunks) == 0 {
+		return
+	}
+	a.deps.EditFormattedWithRetry(ctx, chatID, statusMsgID, chunks[0])
+	for _, chunk := range chunks[1:] {
+		a.deps.SendFormatted(ctx, chatID, chunk)
+	}
+}
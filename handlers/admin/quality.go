@@ -0,0 +1,194 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/logger"
+)
+
+// The /quality window, in days. Summaries are kept for 90 days.
+const (
+	qualityDefaultDays = 30
+	qualityMaxDays     = 90
+)
+
+// qualityDateLayout formats days in the quality report.
+const qualityDateLayout = "02.01"
+
+// handleQuality handles "/quality [days]": the 👍/👎 approval rate of posted
+// summaries overall, per group, per model, per group instructions version and
+// per week.
+func (a *Admin) handleQuality(ctx context.Context, chatID int64, args []string) {
+	days := qualityDefaultDays
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 || n > qualityMaxDays {
+			a.deps.SendFormatted(ctx, chatID, i18n.T(a.lang(), i18n.QualityUsage, qualityMaxDays))
+			return
+		}
+		days = n
+	}
+
+	ratings, err := a.db.GetSummaryRatings(ctx, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to get summary ratings")
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.QualityLoadError))
+		return
+	}
+
+	titles := make(map[int64]string)
+	for _, r := range ratings {
+		if _, ok := titles[r.GroupID]; ok {
+			continue
+		}
+		title, err := a.db.GetKnownGroupTitle(ctx, r.GroupID)
+		if err != nil {
+			logger.Error().Err(err).Int64("group_id", r.GroupID).Msg("failed to get group title")
+		}
+		if title == "" {
+			title = strconv.FormatInt(r.GroupID, 10)
+		}
+		titles[r.GroupID] = title
+	}
+
+	a.deps.SendMessage(ctx, chatID, formatQualityReport(a.lang(), days, ratings, titles))
+}
+
+// qualityTally accumulates votes for one report line.
+type qualityTally struct {
+	label       string
+	up, down    int
+	summaries   int
+	first, last time.Time
+}
+
+func (t *qualityTally) add(r db.SummaryRating) {
+	if t.summaries == 0 || r.CreatedAt.Before(t.first) {
+		t.first = r.CreatedAt
+	}
+	if r.CreatedAt.After(t.last) {
+		t.last = r.CreatedAt
+	}
+	t.up += r.Up
+	t.down += r.Down
+	t.summaries++
+}
+
+func (t *qualityTally) line(lang i18n.Lang) string {
+	return i18n.T(lang, i18n.QualityLine, t.label, t.up*100/(t.up+t.down), t.up, t.down, t.summaries)
+}
+
+// qualityBreakdown groups ratings by key, keeping first-seen order.
+type qualityBreakdown struct {
+	order   []string
+	tallies map[string]*qualityTally
+}
+
+func (b *qualityBreakdown) add(key, label string, r db.SummaryRating) {
+	if b.tallies == nil {
+		b.tallies = make(map[string]*qualityTally)
+	}
+	t, ok := b.tallies[key]
+	if !ok {
+		t = &qualityTally{label: label}
+		b.tallies[key] = t
+		b.order = append(b.order, key)
+	}
+	t.add(r)
+}
+
+func (b *qualityBreakdown) list() []*qualityTally {
+	out := make([]*qualityTally, len(b.order))
+	for i, key := range b.order {
+		out[i] = b.tallies[key]
+	}
+	return out
+}
+
+// byVotes orders tallies with the most votes first.
+func byVotes(tallies []*qualityTally) []*qualityTally {
+	sort.SliceStable(tallies, func(i, j int) bool {
+		return tallies[i].up+tallies[i].down > tallies[j].up+tallies[j].down
+	})
+	return tallies
+}
+
+// formatQualityReport renders the ratings (oldest first) as plain text.
+func formatQualityReport(lang i18n.Lang, days int, ratings []db.SummaryRating, titles map[int64]string) string {
+	if len(ratings) == 0 {
+		return i18n.T(lang, i18n.QualityEmpty, days)
+	}
+
+	total := qualityTally{label: i18n.T(lang, i18n.QualityTotal)}
+	var groups, models, weeks qualityBreakdown
+	for _, r := range ratings {
+		total.add(r)
+		groups.add(strconv.FormatInt(r.GroupID, 10), titles[r.GroupID], r)
+
+		model := r.Model
+		if model == "" {
+			model = "—"
+		}
+		models.add(model, model, r)
+
+		week := weekStart(r.CreatedAt)
+		weeks.add(week.Format(time.DateOnly), i18n.T(lang, i18n.QualityWeek, week.Format(qualityDateLayout)), r)
+	}
+
+	// Versions are listed by group and in version order, so each group's
+	// instruction changes read as a timeline.
+	byGroupVersion := append([]db.SummaryRating(nil), ratings...)
+	sort.SliceStable(byGroupVersion, func(i, j int) bool {
+		ri, rj := byGroupVersion[i], byGroupVersion[j]
+		if titles[ri.GroupID] != titles[rj.GroupID] {
+			return titles[ri.GroupID] < titles[rj.GroupID]
+		}
+		if ri.GroupID != rj.GroupID {
+			return ri.GroupID < rj.GroupID
+		}
+		return ri.InstructionsVersion < rj.InstructionsVersion
+	})
+	var versions qualityBreakdown
+	for _, r := range byGroupVersion {
+		version := i18n.T(lang, i18n.QualityNoInstructions)
+		if r.InstructionsVersion > 0 {
+			version = "v" + strconv.Itoa(r.InstructionsVersion)
+		}
+		versions.add(fmt.Sprintf("%d:%d", r.GroupID, r.InstructionsVersion), titles[r.GroupID]+" · "+version, r)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(i18n.T(lang, i18n.QualityHeader, days) + "\n")
+	sb.WriteString(total.line(lang) + "\n")
+
+	writeSection := func(header i18n.Key, tallies []*qualityTally, withDates bool) {
+		sb.WriteString("\n" + i18n.T(lang, header) + "\n")
+		for _, t := range tallies {
+			line := "  " + t.line(lang)
+			if withDates {
+				line += fmt.Sprintf("  [%s–%s]", t.first.UTC().Format(qualityDateLayout), t.last.UTC().Format(qualityDateLayout))
+			}
+			sb.WriteString(line + "\n")
+		}
+	}
+	writeSection(i18n.QualityByGroup, byVotes(groups.list()), false)
+	writeSection(i18n.QualityByModel, byVotes(models.list()), false)
+	writeSection(i18n.QualityByVersion, versions.list(), true)
+	writeSection(i18n.QualityByWeek, weeks.list(), false)
+
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// weekStart returns the UTC Monday starting t's week.
+func weekStart(t time.Time) time.Time {
+	t = t.UTC()
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.UTC)
+}
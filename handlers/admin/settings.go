@@ -0,0 +1,325 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"telegram_summarize_bot/config"
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/logger"
+	"telegram_summarize_bot/summarizer"
+
+	"github.com/mymmrac/telego"
+)
+
+const settingsCallbackPrefix = "set:"
+
+// intSetting describes one numeric per-group setting shown by /settings.
+type intSetting struct {
+	key      string
+	label    i18n.Key
+	min, max int
+	value    func(config.GroupSettings) int
+	override func(*db.GroupSettingsOverrides) **int
+}
+
+// intSettings lists the numeric overridable settings in display order; the
+// on/off ones are boolSettings.
+var intSettings = []intSetting{
+	{
+		key: "topic_max", label: i18n.SettingTopicMax, min: 1, max: 20,
+		value:    func(s config.GroupSettings) int { return s.TopicMax },
+		override: func(o *db.GroupSettingsOverrides) **int { return &o.TopicMax },
+	},
+	{
+		key: "summary_hours", label: i18n.SettingSummaryHours, min: 1, max: 720,
+		value:    func(s config.GroupSettings) int { return s.SummaryHours },
+		override: func(o *db.GroupSettingsOverrides) **int { return &o.SummaryHours },
+	},
+	{
+		key: "max_messages", label: i18n.SettingMaxMessages, min: 10, max: 5000,
+		value:    func(s config.GroupSettings) int { return s.MaxMessages },
+		override: func(o *db.GroupSettingsOverrides) **int { return &o.MaxMessages },
+	},
+	{
+		key: "rate_limit_sec", label: i18n.SettingRateLimitSec, min: 0, max: 86400,
+		value:    func(s config.GroupSettings) int { return s.RateLimitSec },
+		override: func(o *db.GroupSettingsOverrides) **int { return &o.RateLimitSec },
+	},
+}
+
+// boolSetting describes one on/off per-group setting shown by /settings as a
+// toggle.
+type boolSetting struct {
+	key      string
+	label    i18n.Key
+	value    func(config.GroupSettings) bool
+	override func(*db.GroupSettingsOverrides) **bool
+}
+
+var boolSettings = []boolSetting{
+	{
+		key: "reply_threads", label: i18n.SettingReplyThreads,
+		value:    func(s config.GroupSettings) bool { return s.ReplyThreads },
+		override: func(o *db.GroupSettingsOverrides) **bool { return &o.ReplyThreads },
+	},
+	{
+		key: "pii_redaction", label: i18n.SettingPIIRedaction,
+		value:    func(s config.GroupSettings) bool { return s.PIIRedaction },
+		override: func(o *db.GroupSettingsOverrides) **bool { return &o.PIIRedaction },
+	},
+	{
+		key: "digest_stats", label: i18n.SettingDigestStats,
+		value:    func(s config.GroupSettings) bool { return s.DigestStats },
+		override: func(o *db.GroupSettingsOverrides) **bool { return &o.DigestStats },
+	},
+	{
+		key: "action_items", label: i18n.SettingActionItems,
+		value:    func(s config.GroupSettings) bool { return s.ActionItems },
+		override: func(o *db.GroupSettingsOverrides) **bool { return &o.ActionItems },
+	},
+	{
+		key: "calendar_events", label: i18n.SettingEvents,
+		value:    func(s config.GroupSettings) bool { return s.CalendarEvents },
+		override: func(o *db.GroupSettingsOverrides) **bool { return &o.CalendarEvents },
+	},
+}
+
+// pendingSetting is a numeric setting awaiting its new value from the admin.
+type pendingSetting struct {
+	groupID int64
+	setting intSetting
+}
+
+func findIntSetting(key string) (intSetting, bool) {
+	for _, s := range intSettings {
+		if s.key == key {
+			return s, true
+		}
+	}
+	return intSetting{}, false
+}
+
+// findBoolSetting looks up a toggle. Keyboards sent before there was more
+// than one toggle name none, meaning reply threading.
+func findBoolSetting(key string) (boolSetting, bool) {
+	if key == "" {
+		return boolSettings[0], true
+	}
+	for _, s := range boolSettings {
+		if s.key == key {
+			return s, true
+		}
+	}
+	return boolSetting{}, false
+}
+
+// handleSettings handles "/settings [group_id]": without a group it shows a
+// group picker, otherwise the group's settings keyboard.
+func (a *Admin) handleSettings(ctx context.Context, chatID int64, args []string) {
+	if len(args) == 0 {
+		rows, ok := a.allowedGroupRows(ctx, chatID, settingsCallbackPrefix+"grp:")
+		if !ok {
+			return
+		}
+		a.sendKeyboard(ctx, chatID, i18n.T(a.lang(), i18n.SettingsPickGroup), rows)
+		return
+	}
+	groupID, ok := parseInstructionGroupID(args[0])
+	if !ok {
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.AdminBadGroupID))
+		return
+	}
+	if !a.ensureInstructionsGroupAllowed(ctx, chatID, groupID) {
+		return
+	}
+	a.showGroupSettings(ctx, chatID, groupID)
+}
+
+func (a *Admin) handleSettingsCallback(ctx context.Context, cq *telego.CallbackQuery) {
+	chatID := callbackChatID(cq)
+	if chatID == 0 {
+		return
+	}
+
+	data := strings.TrimPrefix(cq.Data, settingsCallbackPrefix)
+	action, rest, _ := strings.Cut(data, ":")
+	if action == "cancel" {
+		a.clearPendingSetting(chatID)
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.SettingsCancelled))
+		return
+	}
+
+	groupRaw, key, _ := strings.Cut(rest, ":")
+	groupID, ok := parseInstructionGroupID(groupRaw)
+	if !ok {
+		return
+	}
+	if !a.ensureInstructionsGroupAllowed(ctx, chatID, groupID) {
+		return
+	}
+
+	switch action {
+	case "grp":
+		a.showGroupSettings(ctx, chatID, groupID)
+	case "edit":
+		setting, ok := findIntSetting(key)
+		if !ok {
+			return
+		}
+		a.setPendingSetting(chatID, pendingSetting{groupID: groupID, setting: setting})
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.SettingsEditPrompt,
+			i18n.T(a.lang(), setting.label), groupID, setting.min, setting.max, setting.value(a.cfg.GroupDefaults())))
+	case "tog":
+		setting, ok := findBoolSetting(key)
+		if !ok {
+			return
+		}
+		if a.updateGroupOverrides(ctx, chatID, groupID, cq.From.ID, func(o *db.GroupSettingsOverrides) {
+			enabled := !setting.value(o.Apply(a.cfg.GroupDefaults()))
+			*setting.override(o) = &enabled
+		}) {
+			a.showGroupSettings(ctx, chatID, groupID)
+		}
+	case "reset":
+		if a.updateGroupOverrides(ctx, chatID, groupID, cq.From.ID, func(o *db.GroupSettingsOverrides) {
+			if setting, ok := findBoolSetting(key); ok && key != "" {
+				*setting.override(o) = nil
+				return
+			}
+			if setting, ok := findIntSetting(key); ok {
+				*setting.override(o) = nil
+			}
+		}) {
+			a.showGroupSettings(ctx, chatID, groupID)
+		}
+	}
+}
+
+// updateGroupOverrides applies change to the group's stored overrides and
+// saves them, reporting failures to the admin.
+func (a *Admin) updateGroupOverrides(ctx context.Context, chatID, groupID, userID int64, change func(*db.GroupSettingsOverrides)) bool {
+	o, err := a.db.GetGroupSettingsOverrides(ctx, groupID)
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to get group settings overrides")
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.SettingsLoadError))
+		return false
+	}
+	change(&o)
+	if err := a.db.SetGroupSettingsOverrides(ctx, groupID, userID, o); err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to save group settings overrides")
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.SettingsSaveError))
+		return false
+	}
+	return true
+}
+
+// showGroupSettings lists each setting's effective value and whether it comes
+// from the global config or the group's override, with a button per setting
+// and a Reset button for each override.
+func (a *Admin) showGroupSettings(ctx context.Context, chatID, groupID int64) {
+	o, err := a.db.GetGroupSettingsOverrides(ctx, groupID)
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to get group settings overrides")
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.SettingsLoadError))
+		return
+	}
+	effective := o.Apply(a.cfg.GroupDefaults())
+
+	var sb strings.Builder
+	sb.WriteString(i18n.T(a.lang(), i18n.SettingsHeader, summarizer.EscapeMarkdown(fmt.Sprintf("%d", groupID))))
+	keyboard := make([][]telego.InlineKeyboardButton, 0, len(intSettings)+len(boolSettings)+1)
+	addRow := func(key string, label i18n.Key, value string, overridden bool, callback string) {
+		source := i18n.T(a.lang(), i18n.SettingsGlobal)
+		if overridden {
+			source = i18n.T(a.lang(), i18n.SettingsOverride)
+		}
+		labelText := i18n.T(a.lang(), label)
+		sb.WriteString(i18n.T(a.lang(), i18n.SettingsLine,
+			summarizer.EscapeMarkdown(labelText), summarizer.EscapeMarkdown(value), summarizer.EscapeMarkdown(source)))
+
+		row := []telego.InlineKeyboardButton{{Text: labelText + ": " + value, CallbackData: callback}}
+		if overridden {
+			row = append(row, telego.InlineKeyboardButton{
+				Text: "Reset", CallbackData: fmt.Sprintf("set:reset:%d:%s", groupID, key),
+			})
+		}
+		keyboard = append(keyboard, row)
+	}
+
+	for _, s := range intSettings {
+		addRow(s.key, s.label, strconv.Itoa(s.value(effective)), *s.override(&o) != nil,
+			fmt.Sprintf("set:edit:%d:%s", groupID, s.key))
+	}
+	for _, s := range boolSettings {
+		value := i18n.T(a.lang(), i18n.SettingsOff)
+		if s.value(effective) {
+			value = i18n.T(a.lang(), i18n.SettingsOn)
+		}
+		addRow(s.key, s.label, value, *s.override(&o) != nil,
+			fmt.Sprintf("set:tog:%d:%s", groupID, s.key))
+	}
+	sb.WriteString(i18n.T(a.lang(), i18n.SettingsFooter))
+
+	keyboard = append(keyboard, []telego.InlineKeyboardButton{{Text: "Cancel", CallbackData: "set:cancel"}})
+	a.sendKeyboard(ctx, chatID, sb.String(), keyboard)
+}
+
+// handlePendingSetting saves the admin's reply to a settings "edit" prompt.
+// Returns false when no value is awaited or the message is another command.
+func (a *Admin) handlePendingSetting(ctx context.Context, msg *telego.Message, cmd string) bool {
+	pending, ok := a.pendingSettingFor(msg.Chat.ID)
+	if !ok {
+		return false
+	}
+
+	if cmd == "/cancel" {
+		a.clearPendingSetting(msg.Chat.ID)
+		a.deps.SendMessage(ctx, msg.Chat.ID, i18n.T(a.lang(), i18n.SettingsCancelled))
+		return true
+	}
+	if strings.HasPrefix(cmd, "/") {
+		return false
+	}
+
+	s := pending.setting
+	value, err := strconv.Atoi(strings.TrimSpace(msg.Text))
+	if err != nil || value < s.min || value > s.max {
+		a.deps.SendMessage(ctx, msg.Chat.ID, i18n.T(a.lang(), i18n.SettingsBadValue, s.min, s.max))
+		return true
+	}
+
+	a.clearPendingSetting(msg.Chat.ID)
+	if a.updateGroupOverrides(ctx, msg.Chat.ID, pending.groupID, msg.From.ID, func(o *db.GroupSettingsOverrides) {
+		*s.override(o) = &value
+	}) {
+		a.deps.SendMessage(ctx, msg.Chat.ID, i18n.T(a.lang(), i18n.SettingsSaved, pending.groupID))
+		a.showGroupSettings(ctx, msg.Chat.ID, pending.groupID)
+	}
+	return true
+}
+
+// setPendingSetting waits for a setting's value; it replaces any pending
+// instructions edit so the next message has a single meaning.
+func (a *Admin) setPendingSetting(chatID int64, p pendingSetting) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.pendingSummaryInstructions, chatID)
+	a.pendingSettings[chatID] = p
+}
+
+func (a *Admin) clearPendingSetting(chatID int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.pendingSettings, chatID)
+}
+
+func (a *Admin) pendingSettingFor(chatID int64) (pendingSetting, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	p, ok := a.pendingSettings[chatID]
+	return p, ok
+}
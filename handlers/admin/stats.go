@@ -0,0 +1,69 @@
+package admin
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"telegram_summarize_bot/analytics"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/logger"
+	"telegram_summarize_bot/summarizer"
+)
+
+// statsMaxMessages caps how many of the window's latest messages /stats
+// reads; it is well above any group's summary cap, as no LLM is involved.
+const statsMaxMessages = 5000
+
+// handleStats handles "/stats <group_id> [hours]": conversation statistics
+// for the group's last hours (the group's summary window by default, at most
+// the retention period). Members appear only as aliases, numbered over the
+// window like in a summary.
+func (a *Admin) handleStats(ctx context.Context, chatID int64, args []string) {
+	maxHours := a.cfg.RetentionDays * 24
+	if len(args) == 0 {
+		a.deps.SendFormatted(ctx, chatID, i18n.T(a.lang(), i18n.StatsUsage, maxHours))
+		return
+	}
+	groupID, ok := parseInstructionGroupID(args[0])
+	if !ok {
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.AdminBadGroupID))
+		return
+	}
+	if !a.ensureInstructionsGroupAllowed(ctx, chatID, groupID) {
+		return
+	}
+
+	settings, err := a.db.GroupSettings(ctx, groupID, a.cfg.GroupDefaults())
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("stats: failed to get group settings")
+	}
+	hours := settings.SummaryHours
+	if len(args) > 1 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 || n > maxHours {
+			a.deps.SendFormatted(ctx, chatID, i18n.T(a.lang(), i18n.StatsUsage, maxHours))
+			return
+		}
+		hours = n
+	}
+
+	now := time.Now()
+	messages, err := a.db.GetMessages(ctx, groupID, now.Add(-time.Duration(hours)*time.Hour), statsMaxMessages)
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("stats: failed to get messages")
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.MessagesError))
+		return
+	}
+
+	title, err := a.db.GetKnownGroupTitle(ctx, groupID)
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to get group title")
+	}
+	if title == "" {
+		title = strconv.FormatInt(groupID, 10)
+	}
+
+	report := analytics.Compute(messages, summarizer.BuildUserAliasMap(messages), now)
+	a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.StatsWindow, title, hours)+"\n"+report.Text(a.lang()))
+}
@@ -37,8 +37,23 @@ func (a *Admin) handleStatus(ctx context.Context, chatID int64) {
 	}
 }
 
-// HandleCallbackQuery processes inline button presses for metric deep-dives.
+// Callback data prefixes of the buttons under group summaries.
+const (
+	ExpandCallbackPrefix   = "exp:" // per-topic "expand"
+	FeedbackCallbackPrefix = "fb:"  // 👍/👎
+	EventCallbackPrefix    = "ics:" // 📅 add an event to the calendar
+)
+
+// HandleCallbackQuery processes inline button presses: group summaries'
+// expand, feedback and calendar buttons (open to every member) and the admin
+// DM keyboards.
 func (a *Admin) HandleCallbackQuery(ctx context.Context, cq *telego.CallbackQuery) {
+	if strings.HasPrefix(cq.Data, ExpandCallbackPrefix) || strings.HasPrefix(cq.Data, FeedbackCallbackPrefix) ||
+		strings.HasPrefix(cq.Data, EventCallbackPrefix) {
+		a.deps.HandleSummaryCallback(ctx, cq)
+		return
+	}
+
 	_ = a.telegram.AnswerCallbackQuery(ctx, &telego.AnswerCallbackQueryParams{
 		CallbackQueryID: cq.ID,
 	})
@@ -59,6 +74,10 @@ func (a *Admin) HandleCallbackQuery(ctx context.Context, cq *telego.CallbackQuer
 		a.handleInstructionsCallback(ctx, cq)
 		return
 	}
+	if strings.HasPrefix(data, settingsCallbackPrefix) {
+		a.handleSettingsCallback(ctx, cq)
+		return
+	}
 
 	if !strings.HasPrefix(data, "lat:") {
 		return
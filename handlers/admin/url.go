@@ -5,6 +5,7 @@ import (
 	"errors"
 
 	"telegram_summarize_bot/fetcher"
+	"telegram_summarize_bot/i18n"
 	"telegram_summarize_bot/logger"
 	"telegram_summarize_bot/tgutil"
 
@@ -26,37 +27,37 @@ func (a *Admin) handleURLSummarize(ctx context.Context, chatID int64, rawURL str
 	if !a.rateLimiter.Allow(chatID) {
 		a.metrics.RateLimit.Record(0)
 		remaining := a.rateLimiter.RemainingTime(chatID)
-		a.deps.SendMessage(ctx, chatID, "Подождите "+tgutil.FormatDuration(remaining)+" перед следующим запросом.")
+		a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.RateLimitWaitDM, tgutil.FormatDuration(a.lang(), remaining)))
 		return
 	}
 
-	statusMsgID := a.deps.SendMessage(ctx, chatID, "Загружаю страницу...")
+	statusMsgID := a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.URLLoading))
 
 	content, err := fetcher.Fetch(ctx, rawURL, a.cfg.URLMaxChars)
 	if err != nil {
 		logger.Error().Err(err).Str("url", rawURL).Msg("failed to fetch URL")
-		msg := "Не удалось загрузить страницу: " + err.Error()
+		msg := i18n.T(a.lang(), i18n.URLFetchFailed, err.Error())
 		if errors.Is(err, fetcher.ErrNoReadableContent) {
-			msg = "Не удалось прочитать страницу — возможно, она требует входа или контент подгружается через JavaScript."
+			msg = i18n.T(a.lang(), i18n.PageUnreadable)
 		}
 		a.deps.EditWithRetry(ctx, chatID, statusMsgID, msg)
 		return
 	}
 
-	if editErr := a.deps.EditMessage(ctx, chatID, statusMsgID, "Суммаризую содержимое..."); editErr != nil {
+	if editErr := a.deps.EditMessage(ctx, chatID, statusMsgID, i18n.T(a.lang(), i18n.URLSummarizing)); editErr != nil {
 		logger.Warn().Err(editErr).Msg("failed to update status message")
 	}
 
-	summary, err := a.summarizer.SummarizeURL(ctx, rawURL, content, "")
+	summary, err := a.summarizer.SummarizeURL(ctx, rawURL, content, "", a.lang())
 	if err != nil {
 		logger.Error().Err(err).Str("url", rawURL).Msg("failed to summarize URL")
-		a.deps.EditWithRetry(ctx, chatID, statusMsgID, "Ошибка суммаризации. Попробуйте позже.")
+		a.deps.EditWithRetry(ctx, chatID, statusMsgID, i18n.T(a.lang(), i18n.SummarizeFailed))
 		return
 	}
 
 	// The summary is Markdown; convert it (plus our header) to Telegram
 	// MarkdownV2 so formatting renders instead of leaking as literal markers.
-	rendered := telegramify.Markdownify("🔗 **Суммаризация URL:**\n\n" + summary)
+	rendered := telegramify.Markdownify(i18n.T(a.lang(), i18n.URLHeader) + "\n\n" + summary)
 	chunks := telegramify.Split(rendered, 4096)
 	if len(chunks) == 0 {
 		return
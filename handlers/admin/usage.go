@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"telegram_summarize_bot/config"
+	"telegram_summarize_bot/i18n"
 	"telegram_summarize_bot/usage"
 )
 
@@ -13,7 +14,7 @@ import (
 func (a *Admin) handleUsage(ctx context.Context, chatID int64) {
 	var quota usage.QuotaResult
 	if a.cfg.LLMMode == config.LLMModeOAuth && a.llm != nil {
-		msgID := a.deps.SendMessage(ctx, chatID, "⏳ Собираю данные об использовании…")
+		msgID := a.deps.SendMessage(ctx, chatID, i18n.T(a.lang(), i18n.AdminUsageCollecting))
 		quota = usage.ResolveCodexQuota(ctx, a.db, a.llm, a.cfg.Model, a.cfg.CodexQuotaTTL())
 		report := usage.Build(ctx, a.db, a.cfg.Model, a.cfg.ModelContextTokens, quota)
 		if msgID != 0 {
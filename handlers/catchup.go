@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/logger"
+	"telegram_summarize_bot/summarizer"
+	"telegram_summarize_bot/tgutil"
+
+	"github.com/mymmrac/telego"
+)
+
+// catchupTimeLayout formats the start of a catch-up window for the user.
+const catchupTimeLayout = "02.01 15:04"
+
+// handleCatchup DMs the invoking user a summary of the group since their last
+// catch-up (tracked per user hash, never by raw user ID), or since the hours
+// or UTC time they pass. The user must have started a private chat with the
+// bot; otherwise they are told so in the group.
+func (b *Bot) handleCatchup(ctx context.Context, update telego.Update, args []string) {
+	msg := update.Message
+	groupID := msg.Chat.ID
+	userID := msg.From.ID
+	lang := b.groupLanguage(ctx, groupID)
+	settings := b.groupSettings(ctx, groupID)
+	userHash := b.userHash(ctx, userID, groupID)
+	now := time.Now()
+
+	var since time.Time
+	if len(args) > 0 {
+		parsed, ok := parseCatchupSince(args[0], now, b.cfg.RetentionDuration())
+		if !ok {
+			b.sendMessageReply(ctx, groupID, int64(msg.MessageID), i18n.T(lang, i18n.CatchupUsage))
+			return
+		}
+		since = parsed
+	} else {
+		last, err := b.db.GetLastCatchup(ctx, groupID, userHash)
+		if err != nil {
+			logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to get last catchup")
+		}
+		since = now.Add(-settings.SummaryDuration())
+		if last != nil {
+			since = *last
+		}
+	}
+	sinceText := since.UTC().Format(catchupTimeLayout)
+
+	statusMsgID, err := b.sendPrivate(ctx, userID, i18n.T(lang, i18n.CatchupCollecting, msg.Chat.Title, sinceText))
+	if err != nil {
+		if isBotBlocked(err) {
+			b.sendMessageReply(ctx, groupID, int64(msg.MessageID), i18n.T(lang, i18n.StartPrivateChat, b.username))
+		}
+		return
+	}
+
+	// Catch-ups are limited per user, so they don't eat the group's tokens.
+	ticket, remaining := b.rateLimiter.TakeUser(groupID, userHash, KindSummary)
+	if ticket == nil {
+		b.metrics.RateLimit.Record(0)
+		b.editWithRetry(ctx, userID, statusMsgID, i18n.T(lang, i18n.RateLimitWaitDM, tgutil.FormatDuration(lang, remaining)))
+		return
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			ticket.Release()
+		}
+	}()
+
+	messages, err := b.db.GetMessages(ctx, groupID, since, settings.MaxMessages)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to get messages")
+		b.editWithRetry(ctx, userID, statusMsgID, i18n.T(lang, i18n.MessagesError))
+		return
+	}
+	if len(messages) == 0 {
+		b.editWithRetry(ctx, userID, statusMsgID, i18n.T(lang, i18n.CatchupNothing, msg.Chat.Title, sinceText))
+		return
+	}
+	lang = lang.Resolve(summarizer.MessageTexts(messages)...)
+
+	logger.Info().Int("count", len(messages)).Int64("group_id", groupID).Msg("Summarizing catchup")
+
+	instructions := b.loadGroupSummaryInstructions(ctx, groupID)
+	summary, err := b.summarizeByTopics(ctx, userID, statusMsgID, messages, settings, instructions, lang)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to summarize catchup")
+		b.editWithRetry(ctx, userID, statusMsgID, i18n.T(lang, i18n.SummarizeFailed))
+		return
+	}
+
+	// Links in the summary point at the group's messages, so they are built
+	// from groupID even though the summary goes to the private chat.
+	header := i18n.T(lang, i18n.CatchupHeader, chatTitlePlaceholder, sinceText)
+	chunks := renderMarkdownWithTitle(header+"\n\n"+summarizer.FormatTelegramSummary(summary, groupID), msg.Chat.Title)
+	if err := b.editFormattedFinal(ctx, userID, statusMsgID, chunks[0]); err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to send catchup")
+		return
+	}
+	for _, chunk := range chunks[1:] {
+		b.sendFormatted(ctx, userID, chunk)
+	}
+	committed = true
+
+	if err := b.db.SetLastCatchup(ctx, groupID, userHash, now); err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to set last catchup")
+	}
+}
+
+// parseCatchupSince parses a catch-up start: a number of hours back (up to
+// the retention period) or an HH:MM time in UTC, taken as yesterday when that
+// time hasn't come yet today.
+func parseCatchupSince(arg string, now time.Time, retention time.Duration) (time.Time, bool) {
+	if hours, err := strconv.Atoi(arg); err == nil {
+		d := time.Duration(hours) * time.Hour
+		if hours <= 0 || d > retention {
+			return time.Time{}, false
+		}
+		return now.Add(-d), true
+	}
+
+	h, m, ok := parseClock(arg)
+	if !ok {
+		return time.Time{}, false
+	}
+	utc := now.UTC()
+	since := time.Date(utc.Year(), utc.Month(), utc.Day(), h, m, 0, 0, time.UTC)
+	if since.After(utc) {
+		since = since.AddDate(0, 0, -1)
+	}
+	return since, true
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/summarizer"
+
+	"github.com/mymmrac/telego"
+	"github.com/mymmrac/telego/telegoapi"
+)
+
+func catchupUpdate(text string) telego.Update {
+	return telego.Update{
+		Message: &telego.Message{
+			MessageID: 100,
+			Text:      text,
+			Chat:      telego.Chat{ID: 42, Type: "group", Title: "Dev chat"},
+			From:      &telego.User{ID: 7, Username: "alice"},
+		},
+	}
+}
+
+func TestHandleCatchup(t *testing.T) {
+	sum := &fakeSummarizer{
+		summary: &summarizer.StructuredSummary{
+			TLDR:   "Договорились о релизе.",
+			Topics: []summarizer.TopicSummary{{Title: "Релиз", Summary: "Катим вечером."}},
+		},
+	}
+	b, database, tg := newTestBot(t, sum)
+	defer func() { _ = database.Close() }()
+	b.cfg.RetentionDays = 7
+	ctx := context.Background()
+
+	if err := database.AddMessage(ctx, &db.Message{
+		GroupID:   42,
+		UserHash:  "a3f2b1c4",
+		Text:      "Катим сегодня",
+		Timestamp: time.Now().Add(-time.Hour),
+	}); err != nil {
+		t.Fatalf("AddMessage error: %v", err)
+	}
+
+	b.handleCommand(ctx, catchupUpdate("@testbot catchup"), "catchup")
+	if sum.calls != 1 {
+		t.Fatalf("summarizer calls = %d, want 1", sum.calls)
+	}
+	for _, chat := range append(tg.sentChats, tg.editChats...) {
+		if chat != 7 {
+			t.Fatalf("catchup should only go to the user's private chat, got chat %d", chat)
+		}
+	}
+	if last := tg.editTexts[len(tg.editTexts)-1]; !strings.Contains(last, "Dev chat") || !strings.Contains(last, "Релиз") {
+		t.Fatalf("unexpected catchup: %q", last)
+	}
+
+	hash := db.UserHash(7, 42, b.currentSalt())
+	last, err := database.GetLastCatchup(ctx, 42, hash)
+	if err != nil || last == nil {
+		t.Fatalf("last catchup not recorded: %v, %v", last, err)
+	}
+
+	// The next catchup starts where the previous one ended.
+	b.rateLimiter = NewRateLimiter(60)
+	b.handleCommand(ctx, catchupUpdate("@testbot catchup"), "catchup")
+	if sum.calls != 1 {
+		t.Fatalf("summarizer called again without new messages")
+	}
+	want := i18n.T(i18n.Russian, i18n.CatchupNothing, "Dev chat", last.UTC().Format(catchupTimeLayout))
+	if got := tg.editTexts[len(tg.editTexts)-1]; got != want {
+		t.Fatalf("empty catchup reply = %q, want %q", got, want)
+	}
+}
+
+func TestHandleCatchupRequiresPrivateChat(t *testing.T) {
+	sum := &fakeSummarizer{}
+	b, database, tg := newTestBot(t, sum)
+	defer func() { _ = database.Close() }()
+	tg.sendErrs = map[int64]error{7: fmt.Errorf("api: %w", &telegoapi.Error{ErrorCode: 403, Description: "Forbidden: bot can't initiate conversation with a user"})}
+	ctx := context.Background()
+
+	b.handleCommand(ctx, catchupUpdate("@testbot catchup"), "catchup")
+	if len(tg.sentChats) != 1 || tg.sentChats[0] != 42 {
+		t.Fatalf("expected one group reply, got chats %v", tg.sentChats)
+	}
+	if got, want := tg.sentTexts[0], i18n.T(i18n.Russian, i18n.StartPrivateChat, "testbot"); got != want {
+		t.Fatalf("reply = %q, want %q", got, want)
+	}
+	if sum.calls != 0 {
+		t.Fatalf("summarizer should not run when the DM fails")
+	}
+}
+
+func TestParseCatchupSince(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 30, 0, 0, time.UTC)
+	week := 7 * 24 * time.Hour
+
+	tests := []struct {
+		arg  string
+		want time.Time
+		ok   bool
+	}{
+		{"6", now.Add(-6 * time.Hour), true},
+		{"168", now.Add(-week), true},
+		{"169", time.Time{}, false},
+		{"0", time.Time{}, false},
+		{"09:15", time.Date(2026, 3, 10, 9, 15, 0, 0, time.UTC), true},
+		{"18:00", time.Date(2026, 3, 9, 18, 0, 0, 0, time.UTC), true},
+		{"24:00", time.Time{}, false},
+		{"yesterday", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseCatchupSince(tt.arg, now, week)
+		if ok != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("parseCatchupSince(%q) = %v, %v; want %v, %v", tt.arg, got, ok, tt.want, tt.ok)
+		}
+	}
+}
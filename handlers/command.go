@@ -25,7 +25,8 @@ func (b *Bot) handleCommand(ctx context.Context, update telego.Update, command s
 		cmd = strings.ToLower(parts[0])
 	}
 
-	// help and schedule stay explicit commands, even when replying.
+	// help, schedule, language, timezone, catchup, (un)subscribe, forget,
+	// remember and todo stay explicit commands, even when replying.
 	switch cmd {
 	case "help":
 		b.handleHelp(ctx, update)
@@ -33,6 +34,30 @@ func (b *Bot) handleCommand(ctx context.Context, update telego.Update, command s
 	case "schedule":
 		b.handleSchedule(ctx, update, parts[1:])
 		return
+	case "language":
+		b.handleLanguage(ctx, update, parts[1:])
+		return
+	case "timezone":
+		b.handleTimezone(ctx, update, parts[1:])
+		return
+	case "catchup":
+		b.handleCatchup(ctx, update, parts[1:])
+		return
+	case "subscribe":
+		b.handleSubscribe(ctx, update, parts[1:])
+		return
+	case "unsubscribe":
+		b.handleUnsubscribe(ctx, update)
+		return
+	case "forget":
+		b.handleForget(ctx, update, parts[1:])
+		return
+	case "remember":
+		b.handleRemember(ctx, update, parts[1:])
+		return
+	case "todo":
+		b.handleTodo(ctx, update)
+		return
 	}
 
 	isSummarizeKeyword := cmd == "summarize" || cmd == "sub" || cmd == "s"
@@ -45,6 +70,10 @@ func (b *Bot) handleCommand(ctx context.Context, update telego.Update, command s
 		if isSummarizeKeyword {
 			steering = strings.TrimSpace(strings.TrimPrefix(steering, parts[0]))
 		}
+		if isSinceKeyword(steering) {
+			b.handleSummarizeSince(ctx, update)
+			return
+		}
 		b.handleSummarizeReply(ctx, update, truncateSteering(steering))
 		return
 	}
@@ -57,6 +86,17 @@ func (b *Bot) handleCommand(ctx context.Context, update telego.Update, command s
 	b.handleHelp(ctx, update)
 }
 
+// isSinceKeyword reports whether the text after the optional summarize keyword
+// of a reply asks for everything since the replied-to message rather than
+// steering a summary of it.
+func isSinceKeyword(s string) bool {
+	switch strings.Join(strings.Fields(strings.ToLower(s)), " ") {
+	case "since", "from here":
+		return true
+	}
+	return false
+}
+
 // truncateSteering bounds a steering prompt to maxSteeringChars runes.
 func truncateSteering(s string) string {
 	if r := []rune(s); len(r) > maxSteeringChars {
@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"telegram_summarize_bot/analytics"
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/logger"
+	"telegram_summarize_bot/summarizer"
+
+	"github.com/mymmrac/telego"
+)
+
+// handleSubscribe subscribes the invoking user to the group's daily digest by
+// DM, at the given UTC time or the group's schedule time. The confirmation is
+// sent privately, which also checks that the bot can reach the user.
+func (b *Bot) handleSubscribe(ctx context.Context, update telego.Update, args []string) {
+	msg := update.Message
+	groupID := msg.Chat.ID
+	lang := b.groupLanguage(ctx, groupID)
+
+	hour, minute := b.defaultDigestTime(ctx, groupID)
+	if len(args) > 0 {
+		h, m, ok := parseClock(args[0])
+		if !ok {
+			b.sendMessageReply(ctx, groupID, int64(msg.MessageID), i18n.T(lang, i18n.SubscribeUsage))
+			return
+		}
+		hour, minute = h, m
+	}
+
+	sub := db.DigestSubscription{
+		GroupID:  groupID,
+		UserHash: b.userHash(ctx, msg.From.ID, groupID),
+		ChatID:   msg.From.ID,
+		Hour:     hour,
+		Minute:   minute,
+	}
+	if err := b.db.SetDigestSubscription(ctx, sub); err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to save digest subscription")
+		b.sendMessageReply(ctx, groupID, int64(msg.MessageID), i18n.T(lang, i18n.SubscribeSaveError))
+		return
+	}
+
+	if _, err := b.sendPrivate(ctx, msg.From.ID, i18n.T(lang, i18n.SubscribeDone, msg.Chat.Title, hour, minute)); err != nil {
+		// A subscription the bot can't deliver is useless; drop it.
+		if _, delErr := b.db.DeleteDigestSubscription(ctx, groupID, sub.UserHash); delErr != nil {
+			logger.Error().Err(delErr).Int64("group_id", groupID).Msg("failed to delete digest subscription")
+		}
+		if isBotBlocked(err) {
+			b.sendMessageReply(ctx, groupID, int64(msg.MessageID), i18n.T(lang, i18n.StartPrivateChat, b.username))
+		}
+	}
+}
+
+// handleUnsubscribe removes the invoking user's digest subscription.
+func (b *Bot) handleUnsubscribe(ctx context.Context, update telego.Update) {
+	msg := update.Message
+	groupID := msg.Chat.ID
+	lang := b.groupLanguage(ctx, groupID)
+
+	removed, err := b.db.DeleteDigestSubscription(ctx, groupID, b.userHash(ctx, msg.From.ID, groupID))
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to delete digest subscription")
+		b.sendMessageReply(ctx, groupID, int64(msg.MessageID), i18n.T(lang, i18n.SubscribeSaveError))
+		return
+	}
+	key := i18n.UnsubscribeDone
+	if !removed {
+		key = i18n.UnsubscribeNone
+	}
+	b.sendMessageReply(ctx, groupID, int64(msg.MessageID), i18n.T(lang, key))
+}
+
+// defaultDigestTime is the group's schedule time, or the configured default
+// hour when the group has no schedule.
+func (b *Bot) defaultDigestTime(ctx context.Context, groupID int64) (hour, minute int) {
+	s, err := b.db.GetGroupSchedule(ctx, groupID)
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to get group schedule")
+	}
+	if s == nil {
+		return b.cfg.DailySummaryHour, 0
+	}
+	return s.Hour, s.Minute
+}
+
+// dailyDigest returns the group's digest of the 24 hours up to now's minute,
+// summarizing them on first use (or always, with refresh) and storing the
+// result so the group post and the subscribers due at the same minute, and a
+// job resumed after a restart, share one summary. Deliveries at other times
+// cover their own 24 hours. It returns
+// nil without error when there is nothing to summarize. onGenerate, if set,
+// is called with the digest language just before a new summary is requested
+// and returns the group message the digest will be posted into (0 if none).
+// A summary of the same messages already in progress is reused.
+func (b *Bot) dailyDigest(ctx context.Context, groupID int64, now time.Time, refresh bool, onGenerate func(i18n.Lang) int64) (*db.DailyDigest, error) {
+	unlock := b.lockDigest(groupID)
+	defer unlock()
+
+	end := now.UTC().Truncate(time.Minute)
+	windowEnd := db.DigestWindowEnd(end)
+	if !refresh {
+		digest, err := b.db.GetDailyDigest(ctx, groupID, windowEnd)
+		if err != nil {
+			logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to get daily digest")
+		} else if digest != nil {
+			return digest, nil
+		}
+	}
+
+	settings := b.groupSettings(ctx, groupID)
+	messages, err := b.db.GetMessages(ctx, groupID, end.Add(-24*time.Hour), settings.MaxMessages)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return nil, nil
+	}
+
+	logger.Info().Int64("group_id", groupID).Int("count", len(messages)).Msg("generating daily digest")
+
+	lang := b.groupLanguage(ctx, groupID).Resolve(summarizer.MessageTexts(messages)...)
+	var msgID int64
+	if onGenerate != nil {
+		msgID = onGenerate(lang)
+	}
+	instructions := b.loadGroupSummaryInstructions(ctx, groupID)
+	key := summaryFlightKey(groupID, 0, messages, settings, lang, instructions)
+
+	var (
+		summary   *summarizer.StructuredSummary
+		summaryID int64
+	)
+	for summary == nil {
+		flight, leader := b.joinFlight(key)
+		if !leader {
+			result, ok := awaitFlight(ctx, flight)
+			if !ok {
+				return nil, ctx.Err()
+			}
+			summary, summaryID = result.summary, result.summaryID
+			continue
+		}
+		summary, err = b.summarizeByTopics(ctx, groupID, msgID, messages, settings, instructions, lang)
+		if err != nil {
+			b.landFlight(key, flight, flightResult{})
+			return nil, err
+		}
+		summaryID = b.saveSummary(ctx, groupID, summary)
+		b.landFlight(key, flight, flightResult{summary: summary, summaryID: summaryID, msgID: msgID})
+	}
+
+	text := summarizer.FormatTelegramSummary(summary, groupID)
+	if settings.DigestStats {
+		// Aliases are built over the same messages as the summary's, so a
+		// member is the same У-number in both.
+		report := analytics.Compute(messages, summarizer.BuildUserAliasMap(messages), now)
+		text += "\n\n" + report.Markdown(lang, func(id int64) string { return summarizer.MessageLink(groupID, id) })
+	}
+	digest := &db.DailyDigest{
+		GroupID:   groupID,
+		WindowEnd: windowEnd,
+		Lang:      string(lang),
+		Summary:   text,
+		SummaryID: summaryID,
+		CreatedAt: time.Now(),
+	}
+	if err := b.db.PutDailyDigest(ctx, *digest); err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to store daily digest")
+	}
+	return digest, nil
+}
+
+// lockDigest locks digest generation for the group and returns the unlock.
+func (b *Bot) lockDigest(groupID int64) func() {
+	b.digestMu.Lock()
+	if b.digestLocks == nil {
+		b.digestLocks = make(map[int64]*sync.Mutex)
+	}
+	mu, ok := b.digestLocks[groupID]
+	if !ok {
+		mu = &sync.Mutex{}
+		b.digestLocks[groupID] = mu
+	}
+	b.digestMu.Unlock()
+
+	mu.Lock()
+	return mu.Unlock
+}
+
+// sendSubscriberDigests DMs the group's digest up to now to subs.
+// Subscribers who blocked the bot are unsubscribed.
+func (b *Bot) sendSubscriberDigests(ctx context.Context, groupID int64, subs []db.DigestSubscription, now time.Time) {
+	digest, err := b.dailyDigest(ctx, groupID, now, false, nil)
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("digest subscriptions: failed to get digest")
+		return
+	}
+	if digest == nil {
+		logger.Info().Int64("group_id", groupID).Msg("digest subscriptions: no messages, skipping")
+		return
+	}
+
+	title, err := b.db.GetKnownGroupTitle(ctx, groupID)
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to get group title")
+	}
+	lang, _ := i18n.Parse(digest.Lang)
+	chunks := renderMarkdownWithTitle(i18n.T(lang, i18n.DigestDMHeader, chatTitlePlaceholder)+"\n\n"+digest.Summary, title)
+
+	for _, sub := range subs {
+		if err := b.sendDigestChunks(ctx, sub.ChatID, chunks); err != nil {
+			if isBotBlocked(err) {
+				logger.Info().Int64("group_id", groupID).Msg("digest subscriber blocked the bot, unsubscribing")
+				if _, err := b.db.DeleteDigestSubscription(ctx, groupID, sub.UserHash); err != nil {
+					logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to delete digest subscription")
+				}
+			}
+			continue
+		}
+		if err := b.db.MarkDigestSent(ctx, groupID, sub.UserHash, now); err != nil {
+			logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to mark digest sent")
+		}
+	}
+}
+
+func (b *Bot) sendDigestChunks(ctx context.Context, chatID int64, chunks []string) error {
+	for _, chunk := range chunks {
+		if err := b.sendPrivateFormatted(ctx, chatID, chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseClock parses an "HH:MM" time of day.
+func parseClock(s string) (hour, minute int, ok bool) {
+	hRaw, mRaw, found := strings.Cut(s, ":")
+	if !found {
+		return 0, 0, false
+	}
+	h, err1 := strconv.Atoi(hRaw)
+	m, err2 := strconv.Atoi(mRaw)
+	if err1 != nil || err2 != nil || h < 0 || h > 23 || m < 0 || m > 59 {
+		return 0, 0, false
+	}
+	return h, m, true
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/summarizer"
+
+	"github.com/mymmrac/telego"
+	"github.com/mymmrac/telego/telegoapi"
+)
+
+func subscribeUpdate(userID int64, text string) telego.Update {
+	return telego.Update{
+		Message: &telego.Message{
+			MessageID: 100,
+			Text:      text,
+			Chat:      telego.Chat{ID: 42, Type: "group", Title: "Dev chat"},
+			From:      &telego.User{ID: userID},
+		},
+	}
+}
+
+func TestHandleSubscribe(t *testing.T) {
+	b, database, tg := newTestBot(t, &fakeSummarizer{})
+	defer func() { _ = database.Close() }()
+	b.cfg.DailySummaryHour = 7
+	ctx := context.Background()
+	hash := db.UserHash(7, 42, b.currentSalt())
+
+	b.handleCommand(ctx, subscribeUpdate(7, "@testbot subscribe"), "subscribe")
+	sub, err := database.GetDigestSubscription(ctx, 42, hash)
+	if err != nil || sub == nil || sub.ChatID != 7 || sub.Hour != 7 || sub.Minute != 0 {
+		t.Fatalf("subscription = %+v, %v; want 07:00 to chat 7", sub, err)
+	}
+	if tg.sentChats[0] != 7 || tg.sentTexts[0] != i18n.T(i18n.Russian, i18n.SubscribeDone, "Dev chat", 7, 0) {
+		t.Fatalf("expected a private confirmation, got %v %q", tg.sentChats, tg.sentTexts)
+	}
+
+	b.handleCommand(ctx, subscribeUpdate(7, "@testbot subscribe 25:00"), "subscribe 25:00")
+	if got := tg.sentTexts[len(tg.sentTexts)-1]; got != i18n.T(i18n.Russian, i18n.SubscribeUsage) {
+		t.Fatalf("bad time reply = %q", got)
+	}
+
+	b.handleCommand(ctx, subscribeUpdate(7, "@testbot unsubscribe"), "unsubscribe")
+	if got := tg.sentTexts[len(tg.sentTexts)-1]; got != i18n.T(i18n.Russian, i18n.UnsubscribeDone) {
+		t.Fatalf("unsubscribe reply = %q", got)
+	}
+	b.handleCommand(ctx, subscribeUpdate(7, "@testbot unsubscribe"), "unsubscribe")
+	if got := tg.sentTexts[len(tg.sentTexts)-1]; got != i18n.T(i18n.Russian, i18n.UnsubscribeNone) {
+		t.Fatalf("second unsubscribe reply = %q", got)
+	}
+}
+
+func TestHandleSubscribeRequiresPrivateChat(t *testing.T) {
+	b, database, tg := newTestBot(t, &fakeSummarizer{})
+	defer func() { _ = database.Close() }()
+	tg.sendErrs = map[int64]error{7: &telegoapi.Error{ErrorCode: 403, Description: "Forbidden: bot was blocked by the user"}}
+	ctx := context.Background()
+
+	b.handleCommand(ctx, subscribeUpdate(7, "@testbot subscribe 08:00"), "subscribe 08:00")
+	if len(tg.sentTexts) != 1 || tg.sentTexts[0] != i18n.T(i18n.Russian, i18n.StartPrivateChat, "testbot") {
+		t.Fatalf("expected a start-the-bot hint, got %q", tg.sentTexts)
+	}
+	if sub, _ := database.GetDigestSubscription(ctx, 42, db.UserHash(7, 42, b.currentSalt())); sub != nil {
+		t.Fatalf("undeliverable subscription kept: %+v", sub)
+	}
+}
+
+func TestDigestSharedBetweenGroupPostAndSubscribers(t *testing.T) {
+	sum := &fakeSummarizer{summary: &summarizer.StructuredSummary{TLDR: "Итог дня"}}
+	b, database, tg := newTestBot(t, sum)
+	defer func() { _ = database.Close() }()
+	ctx := context.Background()
+	now := time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC)
+
+	if err := database.AddMessage(ctx, &db.Message{GroupID: 42, UserHash: "abc123", Text: "решили катить", Timestamp: now.Add(-time.Hour)}); err != nil {
+		t.Fatalf("AddMessage error: %v", err)
+	}
+	if err := database.UpsertKnownGroup(ctx, 42, "Dev chat", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.SetGroupSchedule(ctx, &db.GroupSchedule{GroupID: 42, Enabled: true, Hour: 8}); err != nil {
+		t.Fatal(err)
+	}
+	for _, userID := range []int64{7, 8} {
+		if err := database.SetDigestSubscription(ctx, db.DigestSubscription{
+			GroupID: 42, UserHash: db.UserHash(userID, 42, b.currentSalt()), ChatID: userID, Hour: 8,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// User 8 blocked the bot after subscribing.
+	tg.sendErrs = map[int64]error{8: errors.Join(errors.New("send"), &telegoapi.Error{ErrorCode: 403})}
+
+	due := b.dueDigests(ctx, now)
+	if len(due) != 1 || !due[42].post || len(due[42].subs) != 2 {
+		t.Fatalf("due digests = %+v", due)
+	}
+	b.submitDigestJob(ctx, 42, digestJob{Now: now, Post: due[42].post, Subs: len(due[42].subs) > 0})
+	waitJobs(t, b)
+
+	if sum.calls != 1 {
+		t.Fatalf("summarizer calls = %d, want one shared digest", sum.calls)
+	}
+	var dm string
+	for i, chat := range tg.sentChats {
+		if chat == 7 {
+			dm = tg.sentTexts[i]
+		}
+	}
+	if !strings.Contains(dm, "Dev chat") || !strings.Contains(dm, "Итог дня") {
+		t.Fatalf("subscriber DM = %q", dm)
+	}
+	if sub, _ := database.GetDigestSubscription(ctx, 42, db.UserHash(8, 42, b.currentSalt())); sub != nil {
+		t.Fatal("subscriber who blocked the bot should be unsubscribed")
+	}
+
+	// Everything was delivered today; a later subscriber gets the 24 hours
+	// up to their own time, including what was posted since.
+	if due := b.dueDigests(ctx, now); len(due) != 0 {
+		t.Fatalf("nothing should be due again today, got %+v", due)
+	}
+	if err := database.AddMessage(ctx, &db.Message{GroupID: 42, UserHash: "abc123", Text: "откатили", Timestamp: now.Add(time.Hour)}); err != nil {
+		t.Fatalf("AddMessage error: %v", err)
+	}
+	b.sendSubscriberDigests(ctx, 42, []db.DigestSubscription{{GroupID: 42, UserHash: "later", ChatID: 9}}, now.Add(6*time.Hour))
+	if sum.calls != 2 {
+		t.Fatalf("summarizer calls = %d after a later delivery, want a fresh digest", sum.calls)
+	}
+	if got := sum.topicMessages; len(got) != 2 || got[1].Text != "откатили" {
+		t.Fatalf("later digest summarized %+v, want the newer message too", got)
+	}
+}
+
+func TestDailyDigestStats(t *testing.T) {
+	sum := &fakeSummarizer{summary: &summarizer.StructuredSummary{TLDR: "Итог дня"}}
+	b, database, _ := newTestBot(t, sum)
+	defer func() { _ = database.Close() }()
+	ctx := context.Background()
+	now := time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC)
+
+	for i, m := range []db.Message{
+		{UserHash: "hash-anna", Text: "кто смотрит релиз?"},
+		{UserHash: "hash-boris", Text: "я", ReplyToTgID: 1},
+		{UserHash: "hash-anna", Text: "спасибо", ReplyToTgID: 2},
+	} {
+		m.GroupID, m.TgMessageID = 42, int64(i+1)
+		m.Timestamp = now.Add(-3*time.Hour + time.Duration(i)*time.Minute)
+		if err := database.AddMessage(ctx, &m); err != nil {
+			t.Fatalf("AddMessage error: %v", err)
+		}
+	}
+
+	digest, err := b.dailyDigest(ctx, 42, now, false, nil)
+	if err != nil || digest == nil {
+		t.Fatalf("dailyDigest = %v, %v", digest, err)
+	}
+	if strings.Contains(digest.Summary, i18n.T(i18n.Russian, i18n.StatsHeader)) {
+		t.Fatalf("stats are off by default, got %q", digest.Summary)
+	}
+
+	on := true
+	if err := database.SetGroupSettingsOverrides(ctx, 42, 1, db.GroupSettingsOverrides{DigestStats: &on}); err != nil {
+		t.Fatal(err)
+	}
+	digest, err = b.dailyDigest(ctx, 42, now, true, nil)
+	if err != nil || digest == nil {
+		t.Fatalf("dailyDigest = %v, %v", digest, err)
+	}
+	for _, want := range []string{"Итог дня", i18n.T(i18n.Russian, i18n.StatsHeader), "У1", "У2"} {
+		if !strings.Contains(digest.Summary, want) {
+			t.Errorf("digest missing %q:\n%s", want, digest.Summary)
+		}
+	}
+	if strings.Contains(digest.Summary, "hash-") {
+		t.Errorf("digest leaks a user hash:\n%s", digest.Summary)
+	}
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"telegram_summarize_bot/handlers/admin"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/ics"
+	"telegram_summarize_bot/logger"
+	"telegram_summarize_bot/summarizer"
+
+	"github.com/mymmrac/telego"
+)
+
+// eventFileNameRunes caps the title part of an .ics file name.
+const eventFileNameRunes = 40
+
+// eventRows builds the "📅 Add" buttons of a stored summary's events, numbered
+// like the events section when there are several.
+func eventRows(lang i18n.Lang, summaryID int64, events int) [][]telego.InlineKeyboardButton {
+	var rows [][]telego.InlineKeyboardButton
+	for i := range events {
+		if i%eventButtonsPerRow == 0 {
+			rows = append(rows, nil)
+		}
+		label := i18n.T(lang, i18n.EventButton)
+		if events > 1 {
+			label = i18n.T(lang, i18n.EventButtonN, i+1)
+		}
+		rows[len(rows)-1] = append(rows[len(rows)-1], telego.InlineKeyboardButton{
+			Text:         label,
+			CallbackData: fmt.Sprintf("%s%d:%d", admin.EventCallbackPrefix, summaryID, i),
+		})
+	}
+	return rows
+}
+
+// handleEventCallback answers a "📅 Add" press with the event as an .ics
+// file, posted as a reply to the summary.
+func (b *Bot) handleEventCallback(ctx context.Context, cq *telego.CallbackQuery) {
+	answer := func(text string) {
+		_ = b.telegram.AnswerCallbackQuery(ctx, &telego.AnswerCallbackQueryParams{CallbackQueryID: cq.ID, Text: text})
+	}
+	if cq.Message == nil {
+		answer("")
+		return
+	}
+	chatID := cq.Message.GetChat().ID
+	lang := b.groupLanguage(ctx, chatID)
+
+	summaryID, index, ok := parseEventData(cq.Data)
+	if !ok {
+		answer("")
+		return
+	}
+	stored, err := b.db.GetSummary(ctx, summaryID)
+	if err != nil {
+		logger.Error().Err(err).Int64("summary_id", summaryID).Msg("failed to get stored summary")
+		answer(i18n.T(lang, i18n.EventFailed))
+		return
+	}
+	if stored == nil || stored.GroupID != chatID || index >= len(stored.Events) {
+		answer(i18n.T(lang, i18n.EventUnavailable))
+		return
+	}
+	if l, ok := i18n.Parse(stored.Lang); ok {
+		lang = l
+	}
+	ev := stored.Events[index]
+
+	data := ics.Encode(ics.Event{
+		UID:    fmt.Sprintf("summary-%d-event-%d@%s", summaryID, index, b.username),
+		Title:  ev.Title,
+		Place:  ev.Place,
+		URL:    summarizer.MessageLink(chatID, ev.TgMessageID),
+		Start:  ev.Start,
+		End:    ev.End,
+		AllDay: ev.AllDay,
+	}, time.Now())
+	if err := b.sendDocumentReply(ctx, chatID, int64(cq.Message.GetMessageID()), eventFileName(ev.Title), data, ev.Title); err != nil {
+		answer(i18n.T(lang, i18n.EventFailed))
+		return
+	}
+	answer("")
+}
+
+// parseEventData parses "ics:<summary_id>:<event_index>".
+func parseEventData(data string) (summaryID int64, index int, ok bool) {
+	idRaw, indexRaw, found := strings.Cut(strings.TrimPrefix(data, admin.EventCallbackPrefix), ":")
+	if !found {
+		return 0, 0, false
+	}
+	summaryID, err1 := strconv.ParseInt(idRaw, 10, 64)
+	index, err2 := strconv.Atoi(indexRaw)
+	if err1 != nil || err2 != nil || summaryID <= 0 || index < 0 {
+		return 0, 0, false
+	}
+	return summaryID, index, true
+}
+
+// eventFileName turns an event title into a file name calendar apps and
+// phones accept: letters and digits kept, runs of anything else as one "_".
+func eventFileName(title string) string {
+	var sb strings.Builder
+	n, gap := 0, false
+	for _, r := range title {
+		if n == eventFileNameRunes {
+			break
+		}
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			gap = sb.Len() > 0
+			continue
+		}
+		if gap {
+			sb.WriteRune('_')
+			n++
+			gap = false
+		}
+		sb.WriteRune(r)
+		n++
+	}
+	if sb.Len() == 0 {
+		return "event.ics"
+	}
+	return sb.String() + ".ics"
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/summarizer"
+
+	"github.com/mymmrac/telego"
+)
+
+func TestEventButtonSendsICS(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 3, 12, 19, 0, 0, 0, moscow)
+	sum := &fakeSummarizer{
+		summary: &summarizer.StructuredSummary{
+			TLDR:   "Созвон в четверг.",
+			Topics: []summarizer.TopicSummary{{Title: "Планы", Summary: "Созвон."}},
+			Events: []summarizer.SummaryEvent{{Title: "Созвон по релизу", Place: "Zoom", Start: start, End: start.Add(time.Hour)}},
+			Lang:   i18n.Russian,
+		},
+	}
+	b, database, tg := newTestBot(t, sum)
+	defer func() { _ = database.Close() }()
+	ctx := context.Background()
+	if err := database.AddMessage(ctx, &db.Message{GroupID: 42, UserHash: "a3f2b1c4", Text: "созвон в четверг в 19", Timestamp: time.Now().Add(-time.Minute)}); err != nil {
+		t.Fatalf("AddMessage error: %v", err)
+	}
+
+	b.handleSummarize(ctx, summarizeUpdate(), nil)
+	waitJobs(t, b)
+	markup := tg.editMarkups[len(tg.editMarkups)-1]
+	if markup == nil || len(markup.InlineKeyboard) != 3 {
+		t.Fatalf("expected expand, event and feedback rows, got %+v", markup)
+	}
+	button := markup.InlineKeyboard[1][0]
+	if button.Text != i18n.T(i18n.Russian, i18n.EventButton) || !strings.HasPrefix(button.CallbackData, "ics:") {
+		t.Fatalf("event button = %+v", button)
+	}
+
+	// Routed through the shared callback entry point, open to non-admins.
+	b.admin.HandleCallbackQuery(ctx, expandCallback(button.CallbackData))
+	if len(tg.docs) != 1 || tg.docNames[0] != "Созвон_по_релизу.ics" || tg.docReplyTo[0] != 1 {
+		t.Fatalf("documents = %q %v", tg.docNames, tg.docReplyTo)
+	}
+	for _, want := range []string{"SUMMARY:Созвон по релизу", "LOCATION:Zoom", "DTSTART:20260312T160000Z", "DTEND:20260312T170000Z"} {
+		if !strings.Contains(string(tg.docs[0]), want) {
+			t.Errorf("calendar missing %q:\n%s", want, tg.docs[0])
+		}
+	}
+	if got := tg.answers[len(tg.answers)-1]; got != "" {
+		t.Fatalf("callback answer = %q, want a silent one", got)
+	}
+}
+
+func TestEventCallbackRejectsForeignOrUnknownEvent(t *testing.T) {
+	b, database, tg := newTestBot(t, &fakeSummarizer{})
+	defer func() { _ = database.Close() }()
+	ctx := context.Background()
+
+	day := time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC)
+	foreign, err := database.SaveSummary(ctx, db.StoredSummary{
+		GroupID: 77, Lang: "ru",
+		Events: []db.SummaryEvent{{Title: "Чужое", Start: day, End: day.AddDate(0, 0, 1), AllDay: true}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, data := range []string{
+		fmt.Sprintf("ics:%d:0", foreign),
+		fmt.Sprintf("ics:%d:0", foreign+100),
+	} {
+		b.handleEventCallback(ctx, expandCallback(data))
+		if got := tg.answers[len(tg.answers)-1]; got != i18n.T(i18n.Russian, i18n.EventUnavailable) {
+			t.Fatalf("answer for %s = %q", data, got)
+		}
+	}
+	if len(tg.docs) != 0 {
+		t.Fatalf("no document should be sent, got %q", tg.docNames)
+	}
+}
+
+func TestParseEventData(t *testing.T) {
+	if id, index, ok := parseEventData("ics:12:3"); !ok || id != 12 || index != 3 {
+		t.Fatalf("parseEventData = %d, %d, %t", id, index, ok)
+	}
+	for _, bad := range []string{"ics:", "ics:12", "ics:x:1", "ics:12:-1", "ics:0:1"} {
+		if _, _, ok := parseEventData(bad); ok {
+			t.Errorf("parseEventData(%q) should fail", bad)
+		}
+	}
+}
+
+func TestEventFileName(t *testing.T) {
+	for title, want := range map[string]string{
+		"Созвон по релизу":           "Созвон_по_релизу.ics",
+		"  Demo: v2.0 / final!  ":    "Demo_v2_0_final.ics",
+		"🎉🎉":                         "event.ics",
+		strings.Repeat("я", 60):      strings.Repeat("я", eventFileNameRunes) + ".ics",
+		"a/../../etc/passwd":         "a_etc_passwd.ics",
+		"Встреча, 12.03 в 19:00 МСК": "Встреча_12_03_в_19_00_МСК.ics",
+	} {
+		if got := eventFileName(title); got != want {
+			t.Errorf("eventFileName(%q) = %q, want %q", title, got, want)
+		}
+	}
+}
+
+func timezoneUpdate(text string) telego.Update {
+	return telego.Update{
+		Message: &telego.Message{
+			Text: text,
+			Chat: telego.Chat{ID: 42, Type: "group"},
+			From: &telego.User{ID: 7, Username: "alice"},
+		},
+	}
+}
+
+func TestHandleTimezone(t *testing.T) {
+	b, database, tg := newTestBot(t, &fakeSummarizer{})
+	defer func() { _ = database.Close() }()
+	ctx := context.Background()
+
+	b.handleCommand(ctx, timezoneUpdate("@testbot timezone"), "timezone")
+	if len(tg.sentTexts) != 1 || tg.sentTexts[0] != i18n.T(i18n.Russian, i18n.TimezoneCurrent, "UTC") {
+		t.Fatalf("unexpected current-timezone reply: %q", tg.sentTexts)
+	}
+
+	for _, bad := range []string{"Mars/Olympus", "Local"} {
+		b.handleCommand(ctx, timezoneUpdate("@testbot timezone "+bad), "timezone "+bad)
+		if got := tg.sentTexts[len(tg.sentTexts)-1]; got != i18n.T(i18n.Russian, i18n.TimezoneBadValue) {
+			t.Fatalf("reply to %q = %q", bad, got)
+		}
+	}
+
+	b.handleCommand(ctx, timezoneUpdate("@testbot timezone Europe/Moscow"), "timezone Europe/Moscow")
+	if got := tg.sentTexts[len(tg.sentTexts)-1]; got != i18n.T(i18n.Russian, i18n.TimezoneSet, "Europe/Moscow") {
+		t.Fatalf("set reply = %q", got)
+	}
+	if loc := b.groupTimezone(ctx, 42); loc.String() != "Europe/Moscow" {
+		t.Fatalf("group timezone = %v", loc)
+	}
+	if loc := b.groupTimezone(ctx, 43); loc != time.UTC {
+		t.Fatalf("other group's timezone = %v, want the UTC default", loc)
+	}
+}
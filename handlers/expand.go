@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/handlers/admin"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/logger"
+	"telegram_summarize_bot/summarizer"
+	"telegram_summarize_bot/tgutil"
+
+	"github.com/mymmrac/telego"
+)
+
+// expandButtonsPerRow and eventButtonsPerRow keep the keyboard narrow enough
+// for phones.
+const (
+	expandButtonsPerRow = 5
+	eventButtonsPerRow  = 3
+)
+
+// saveSummary persists a posted summary with its topic clusters, so its
+// expand buttons can find them later, and the model and instructions version
+// that produced it, for the quality report. Returns 0 when there is no summary
+// or it couldn't be stored.
+func (b *Bot) saveSummary(ctx context.Context, groupID int64, summary *summarizer.StructuredSummary) int64 {
+	if summary == nil {
+		return 0
+	}
+	version, err := b.db.LatestGroupSummaryInstructionsVersion(ctx, groupID)
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to get summary instructions version")
+	}
+	stored := db.StoredSummary{
+		GroupID:             groupID,
+		Lang:                string(summary.Lang),
+		Model:               b.cfg.Model,
+		InstructionsVersion: version,
+		Topics:              make([]db.SummaryTopic, len(summary.Topics)),
+	}
+	for i, t := range summary.Topics {
+		stored.Topics[i] = db.SummaryTopic{Title: strings.TrimSpace(t.Title), MessageIDs: t.MessageIDs}
+	}
+	if a := summary.Actions; a != nil {
+		for _, list := range []struct {
+			kind  string
+			items []summarizer.SummaryItem
+		}{
+			{db.ItemDecision, a.Decisions},
+			{db.ItemAction, a.ActionItems},
+			{db.ItemQuestion, a.OpenQuestions},
+		} {
+			for _, it := range list.items {
+				stored.Items = append(stored.Items, db.SummaryItem{
+					Kind: list.kind, Text: it.Text, Owner: it.Owner, Due: it.Due, TgMessageID: it.TgMessageID,
+				})
+			}
+		}
+	}
+	for _, ev := range summary.Events {
+		stored.Events = append(stored.Events, db.SummaryEvent{
+			Title: ev.Title, Place: ev.Place, Start: ev.Start, End: ev.End, AllDay: ev.AllDay, TgMessageID: ev.TgMessageID,
+		})
+	}
+	id, err := b.db.SaveSummary(ctx, stored)
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to save summary")
+		return 0
+	}
+	return id
+}
+
+// summaryKeyboard builds the "More: 1 2 3…" buttons for a stored summary's
+// topics, a "📅 Add" button per event and the 👍/👎 feedback row under them, or
+// nil when the summary wasn't stored.
+func summaryKeyboard(lang i18n.Lang, summaryID int64, topics, events int) *telego.InlineKeyboardMarkup {
+	if summaryID == 0 {
+		return nil
+	}
+	var rows [][]telego.InlineKeyboardButton
+	for i := range topics {
+		if i%expandButtonsPerRow == 0 {
+			rows = append(rows, nil)
+		}
+		label := strconv.Itoa(i + 1)
+		if i == 0 {
+			label = i18n.T(lang, i18n.ExpandButton, 1)
+		}
+		rows[len(rows)-1] = append(rows[len(rows)-1], telego.InlineKeyboardButton{
+			Text:         label,
+			CallbackData: fmt.Sprintf("%s%d:%d", admin.ExpandCallbackPrefix, summaryID, i),
+		})
+	}
+	rows = append(rows, eventRows(lang, summaryID, events)...)
+	rows = append(rows, feedbackRow(summaryID))
+	return &telego.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// storedSummaryKeyboard is summaryKeyboard for a summary saved earlier, e.g. a
+// shared daily digest.
+func (b *Bot) storedSummaryKeyboard(ctx context.Context, lang i18n.Lang, summaryID int64) *telego.InlineKeyboardMarkup {
+	if summaryID == 0 {
+		return nil
+	}
+	stored, err := b.db.GetSummary(ctx, summaryID)
+	if err != nil || stored == nil {
+		if err != nil {
+			logger.Error().Err(err).Int64("summary_id", summaryID).Msg("failed to get stored summary")
+		}
+		return nil
+	}
+	return summaryKeyboard(lang, summaryID, len(stored.Topics), len(stored.Events))
+}
+
+// handleExpandCallback answers an expand button press with a detailed summary
+// of that topic's cluster, posted as a reply to the digest. Presses are
+// rate-limited per user.
+func (b *Bot) handleExpandCallback(ctx context.Context, cq *telego.CallbackQuery) {
+	answer := func(text string) {
+		_ = b.telegram.AnswerCallbackQuery(ctx, &telego.AnswerCallbackQueryParams{CallbackQueryID: cq.ID, Text: text})
+	}
+	if cq.Message == nil {
+		answer("")
+		return
+	}
+	chatID := cq.Message.GetChat().ID
+	lang := b.groupLanguage(ctx, chatID)
+
+	summaryID, index, ok := parseExpandData(cq.Data)
+	if !ok {
+		answer("")
+		return
+	}
+	stored, err := b.db.GetSummary(ctx, summaryID)
+	if err != nil {
+		logger.Error().Err(err).Int64("summary_id", summaryID).Msg("failed to get stored summary")
+		answer(i18n.T(lang, i18n.ExpandFailed))
+		return
+	}
+	if stored == nil || stored.GroupID != chatID || index >= len(stored.Topics) {
+		answer(i18n.T(lang, i18n.ExpandUnavailable))
+		return
+	}
+	if l, ok := i18n.Parse(stored.Lang); ok {
+		lang = l
+	}
+	topic := stored.Topics[index]
+
+	messages, err := b.db.GetMessagesByIDs(ctx, chatID, topic.MessageIDs)
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", chatID).Msg("failed to get topic messages")
+		answer(i18n.T(lang, i18n.ExpandFailed))
+		return
+	}
+	if len(messages) == 0 {
+		answer(i18n.T(lang, i18n.ExpandUnavailable))
+		return
+	}
+
+	// Expansions are limited per user, like catch-ups; one costs what a reply
+	// summary does.
+	ticket, remaining := b.rateLimiter.TakeUser(chatID, b.userHash(ctx, cq.From.ID, chatID), KindReply)
+	if ticket == nil {
+		b.metrics.RateLimit.Record(0)
+		answer(i18n.T(lang, i18n.RateLimitWaitDM, tgutil.FormatDuration(lang, remaining)))
+		return
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			ticket.Release()
+		}
+	}()
+	answer("")
+
+	statusMsgID := b.sendMessageReply(ctx, chatID, int64(cq.Message.GetMessageID()), i18n.T(lang, i18n.ExpandWorking, topic.Title))
+
+	settings := b.groupSettings(ctx, chatID)
+	instructions := b.loadGroupSummaryInstructions(ctx, chatID)
+	expandCtx := summarizer.WithGroupSettings(ctx, settings, b.groupTimezone(ctx, chatID))
+	text, err := b.summarizer.ExpandTopic(expandCtx, messages, topic.Title, instructions, lang)
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", chatID).Msg("failed to expand topic")
+		if statusMsgID != 0 {
+			b.editWithRetry(ctx, chatID, statusMsgID, i18n.T(lang, i18n.ExpandFailed))
+		}
+		return
+	}
+
+	chunks := renderMarkdown(i18n.T(lang, i18n.ExpandHeader, topic.Title) + "\n\n" + text)
+	if err := b.deliverChunks(ctx, chatID, statusMsgID, chunks, nil); err != nil {
+		logger.Error().Err(err).Int64("group_id", chatID).Msg("failed to send expanded topic")
+		return
+	}
+	committed = true
+}
+
+// parseExpandData parses "exp:<summary_id>:<topic_index>".
+func parseExpandData(data string) (summaryID int64, index int, ok bool) {
+	idRaw, indexRaw, found := strings.Cut(strings.TrimPrefix(data, admin.ExpandCallbackPrefix), ":")
+	if !found {
+		return 0, 0, false
+	}
+	summaryID, err1 := strconv.ParseInt(idRaw, 10, 64)
+	index, err2 := strconv.Atoi(indexRaw)
+	if err1 != nil || err2 != nil || summaryID <= 0 || index < 0 {
+		return 0, 0, false
+	}
+	return summaryID, index, true
+}
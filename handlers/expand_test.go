@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/summarizer"
+
+	"github.com/mymmrac/telego"
+)
+
+func expandCallback(data string) *telego.CallbackQuery {
+	return &telego.CallbackQuery{
+		ID:      "cq1",
+		From:    telego.User{ID: 9},
+		Data:    data,
+		Message: &telego.Message{MessageID: 1, Chat: telego.Chat{ID: 42, Type: "supergroup"}},
+	}
+}
+
+func TestExpandTopicFromSummaryButtons(t *testing.T) {
+	b, database, tg := newTestBot(t, nil)
+	defer func() { _ = database.Close() }()
+	ctx := context.Background()
+
+	var ids []int64
+	for i, text := range []string{"катим релиз", "что на обед", "давайте вечером"} {
+		id, err := database.AddMessageReturningID(ctx, &db.Message{
+			GroupID: 42, UserHash: "a3f2b1c4", Text: text,
+			Timestamp: time.Now().Add(time.Duration(i-3) * time.Minute),
+		})
+		if err != nil {
+			t.Fatalf("AddMessageReturningID error: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	sum := &fakeSummarizer{
+		summary: &summarizer.StructuredSummary{
+			TLDR: "Итог.",
+			Topics: []summarizer.TopicSummary{
+				{Title: "Релиз", Summary: "Катим вечером.", MessageIDs: []int64{ids[0], ids[2]}},
+				{Title: "Обед", Summary: "Пицца.", MessageIDs: []int64{ids[1]}},
+			},
+			Lang: i18n.Russian,
+		},
+		expandText: "Подробный разбор релиза.",
+	}
+	b.summarizer = sum
+
+	b.handleSummarize(ctx, summarizeUpdate(), nil)
+	waitJobs(t, b)
+	markup := tg.editMarkups[len(tg.editMarkups)-1]
+	if markup == nil || len(markup.InlineKeyboard) != 2 || len(markup.InlineKeyboard[0]) != 2 {
+		t.Fatalf("expected a row with two expand buttons and the feedback row, got %+v", markup)
+	}
+	first := markup.InlineKeyboard[0][0]
+	if first.Text != "Подробнее: 1" || markup.InlineKeyboard[0][1].Text != "2" {
+		t.Fatalf("unexpected button labels: %+v", markup.InlineKeyboard[0])
+	}
+
+	// Routed through the shared callback entry point, open to non-admins.
+	b.admin.HandleCallbackQuery(ctx, expandCallback(first.CallbackData))
+	if sum.expandCalls != 1 || sum.expandTitle != "Релиз" {
+		t.Fatalf("ExpandTopic calls = %d, title = %q", sum.expandCalls, sum.expandTitle)
+	}
+	if len(sum.expandMessages) != 2 || sum.expandMessages[0].Text != "катим релиз" || sum.expandMessages[1].Text != "давайте вечером" {
+		t.Fatalf("expanded messages = %+v", sum.expandMessages)
+	}
+	if last := tg.editTexts[len(tg.editTexts)-1]; !strings.Contains(last, "Подробный разбор релиза") {
+		t.Fatalf("expanded topic not delivered: %q", last)
+	}
+}
+
+func TestExpandCallbackRejectsForeignOrUnknownSummary(t *testing.T) {
+	sum := &fakeSummarizer{}
+	b, database, tg := newTestBot(t, sum)
+	defer func() { _ = database.Close() }()
+	ctx := context.Background()
+
+	summaryID, err := database.SaveSummary(ctx, db.StoredSummary{
+		GroupID: -500, Lang: "ru",
+		Topics: []db.SummaryTopic{{Title: "Чужая тема", MessageIDs: []int64{1}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, data := range []string{"exp:999:0", "exp:bad", fmt.Sprintf("exp:%d:0", summaryID)} {
+		b.admin.HandleCallbackQuery(ctx, expandCallback(data))
+	}
+	if sum.expandCalls != 0 {
+		t.Fatalf("ExpandTopic should not run, got %d calls", sum.expandCalls)
+	}
+	if len(tg.answers) != 3 || tg.answers[0] != i18n.T(i18n.Russian, i18n.ExpandUnavailable) {
+		t.Fatalf("callback answers = %q", tg.answers)
+	}
+	if len(tg.sentTexts) != 0 {
+		t.Fatalf("nothing should be posted, got %q", tg.sentTexts)
+	}
+}
+
+func TestParseExpandData(t *testing.T) {
+	if id, idx, ok := parseExpandData("exp:12:3"); !ok || id != 12 || idx != 3 {
+		t.Fatalf("parseExpandData = %d, %d, %v", id, idx, ok)
+	}
+	for _, bad := range []string{"exp:", "exp:12", "exp:x:1", "exp:0:1", "exp:12:-1"} {
+		if _, _, ok := parseExpandData(bad); ok {
+			t.Errorf("parseExpandData(%q) should fail", bad)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/handlers/admin"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/logger"
+
+	"github.com/mymmrac/telego"
+)
+
+// feedbackRow is the 👍/👎 row under a stored summary.
+func feedbackRow(summaryID int64) []telego.InlineKeyboardButton {
+	return []telego.InlineKeyboardButton{
+		{Text: "👍", CallbackData: fmt.Sprintf("%s%d:up", admin.FeedbackCallbackPrefix, summaryID)},
+		{Text: "👎", CallbackData: fmt.Sprintf("%s%d:down", admin.FeedbackCallbackPrefix, summaryID)},
+	}
+}
+
+// handleFeedbackCallback records a member's 👍/👎 on a summary, one vote per
+// user (by group-scoped hash), and thanks them with a toast.
+func (b *Bot) handleFeedbackCallback(ctx context.Context, cq *telego.CallbackQuery) {
+	answer := func(text string) {
+		_ = b.telegram.AnswerCallbackQuery(ctx, &telego.AnswerCallbackQueryParams{CallbackQueryID: cq.ID, Text: text})
+	}
+	if cq.Message == nil {
+		answer("")
+		return
+	}
+	chatID := cq.Message.GetChat().ID
+	lang := b.groupLanguage(ctx, chatID)
+
+	summaryID, vote, ok := parseFeedbackData(cq.Data)
+	if !ok {
+		answer("")
+		return
+	}
+	stored, err := b.db.GetSummary(ctx, summaryID)
+	if err != nil {
+		logger.Error().Err(err).Int64("summary_id", summaryID).Msg("failed to get stored summary")
+		answer(i18n.T(lang, i18n.FeedbackFailed))
+		return
+	}
+	if stored == nil || stored.GroupID != chatID {
+		answer(i18n.T(lang, i18n.FeedbackUnavailable))
+		return
+	}
+
+	userHash := b.userHash(ctx, cq.From.ID, chatID)
+	if err := b.db.SetSummaryFeedback(ctx, summaryID, userHash, vote); err != nil {
+		logger.Error().Err(err).Int64("group_id", chatID).Msg("failed to save summary feedback")
+		answer(i18n.T(lang, i18n.FeedbackFailed))
+		return
+	}
+	answer(i18n.T(lang, i18n.FeedbackThanks))
+}
+
+// parseFeedbackData parses "fb:<summary_id>:up|down".
+func parseFeedbackData(data string) (summaryID int64, vote int, ok bool) {
+	idRaw, voteRaw, found := strings.Cut(strings.TrimPrefix(data, admin.FeedbackCallbackPrefix), ":")
+	if !found {
+		return 0, 0, false
+	}
+	summaryID, err := strconv.ParseInt(idRaw, 10, 64)
+	if err != nil || summaryID <= 0 {
+		return 0, 0, false
+	}
+	switch voteRaw {
+	case "up":
+		return summaryID, db.VoteUp, true
+	case "down":
+		return summaryID, db.VoteDown, true
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/summarizer"
+
+	"github.com/mymmrac/telego"
+)
+
+func TestSummaryFeedbackButtons(t *testing.T) {
+	sum := &fakeSummarizer{
+		summary: &summarizer.StructuredSummary{
+			TLDR:   "Итог.",
+			Topics: []summarizer.TopicSummary{{Title: "Релиз", Summary: "Катим вечером."}},
+			Lang:   i18n.Russian,
+		},
+	}
+	b, database, tg := newTestBot(t, sum)
+	defer func() { _ = database.Close() }()
+	b.cfg.Model = "gpt-test"
+	ctx := context.Background()
+
+	if err := database.SetGroupSummaryInstructions(ctx, 42, 1, "Пиши коротко"); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.AddMessage(ctx, &db.Message{GroupID: 42, UserHash: "a3f2b1c4", Text: "катим релиз", Timestamp: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	b.handleSummarize(ctx, summarizeUpdate(), nil)
+	waitJobs(t, b)
+	markup := tg.editMarkups[len(tg.editMarkups)-1]
+	if markup == nil || len(markup.InlineKeyboard) != 2 {
+		t.Fatalf("expected expand and feedback rows, got %+v", markup)
+	}
+	row := markup.InlineKeyboard[1]
+	if len(row) != 2 || row[0].Text != "👍" || row[1].Text != "👎" {
+		t.Fatalf("unexpected feedback row: %+v", row)
+	}
+
+	press := func(userID int64, data string) {
+		b.admin.HandleCallbackQuery(ctx, &telego.CallbackQuery{
+			ID:      "cq",
+			From:    telego.User{ID: userID},
+			Data:    data,
+			Message: &telego.Message{MessageID: 1, Chat: telego.Chat{ID: 42, Type: "supergroup"}},
+		})
+	}
+	press(9, row[1].CallbackData)
+	press(9, row[0].CallbackData) // changes the vote
+	press(10, row[0].CallbackData)
+	if want := i18n.T(i18n.Russian, i18n.FeedbackThanks); len(tg.answers) != 3 || tg.answers[2] != want {
+		t.Fatalf("callback answers = %q", tg.answers)
+	}
+
+	ratings, err := database.GetSummaryRatings(ctx, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ratings) != 1 {
+		t.Fatalf("ratings = %+v", ratings)
+	}
+	if r := ratings[0]; r.Up != 2 || r.Down != 0 || r.Model != "gpt-test" || r.InstructionsVersion != 1 {
+		t.Fatalf("rating = %+v; want 2 up from model gpt-test, instructions v1", r)
+	}
+}
+
+func TestFeedbackCallbackRejectsForeignSummary(t *testing.T) {
+	b, database, tg := newTestBot(t, nil)
+	defer func() { _ = database.Close() }()
+	ctx := context.Background()
+
+	summaryID, err := database.SaveSummary(ctx, db.StoredSummary{GroupID: -500, Lang: "ru"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.admin.HandleCallbackQuery(ctx, &telego.CallbackQuery{
+		ID:      "cq",
+		From:    telego.User{ID: 9},
+		Data:    fmt.Sprintf("fb:%d:up", summaryID),
+		Message: &telego.Message{MessageID: 1, Chat: telego.Chat{ID: 42, Type: "supergroup"}},
+	})
+	if len(tg.answers) != 1 || tg.answers[0] != i18n.T(i18n.Russian, i18n.FeedbackUnavailable) {
+		t.Fatalf("callback answers = %q", tg.answers)
+	}
+	if ratings, err := database.GetSummaryRatings(ctx, time.Now().Add(-time.Hour)); err != nil || len(ratings) != 0 {
+		t.Fatalf("foreign vote recorded: %+v, %v", ratings, err)
+	}
+}
+
+func TestParseFeedbackData(t *testing.T) {
+	if id, vote, ok := parseFeedbackData("fb:12:down"); !ok || id != 12 || vote != db.VoteDown {
+		t.Fatalf("parseFeedbackData = %d, %d, %v", id, vote, ok)
+	}
+	for _, bad := range []string{"fb:", "fb:12", "fb:x:up", "fb:0:up", "fb:12:meh"} {
+		if _, _, ok := parseFeedbackData(bad); ok {
+			t.Errorf("parseFeedbackData(%q) should fail", bad)
+		}
+	}
+}
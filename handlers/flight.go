@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+
+	"telegram_summarize_bot/config"
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/logger"
+	"telegram_summarize_bot/summarizer"
+)
+
+// summaryFlight is a summary in progress. Identical requests that arrive
+// meanwhile (a second "@bot summarize", a scheduled digest of the same
+// messages) wait for it instead of paying for a second LLM run.
+type summaryFlight struct {
+	done   chan struct{}
+	result flightResult // set before done is closed
+}
+
+// flightResult is what a flight's leader produced. The zero value means it
+// produced nothing (it failed, was refused or gave up), and followers run the
+// request themselves.
+type flightResult struct {
+	summary   *summarizer.StructuredSummary // group summaries only
+	summaryID int64
+	msgID     int64 // the chat message holding the result, 0 if none
+}
+
+func (r flightResult) ok() bool {
+	return r.summary != nil || r.msgID != 0
+}
+
+// summaryFlightKey identifies a group summary by everything that shapes it:
+// the exact messages, the topic cap, reply threading, PII redaction, the
+// extraction stages, language and instructions. A "summarize since" run
+// (anchor set) is posted and stored differently from a plain summary or
+// digest of the same messages, so its key carries its kind and anchor.
+func summaryFlightKey(groupID, anchor int64, messages []db.Message, settings config.GroupSettings, lang i18n.Lang, instructions string) string {
+	kind := "summary"
+	if anchor != 0 {
+		kind = "since"
+	}
+	first, last := messages[0].ID, messages[len(messages)-1].ID
+	return fmt.Sprintf("%s|%d|%d|%d-%d/%d|%d|%t|%t|%t|%t|%s|%x",
+		kind, groupID, anchor, first, last, len(messages), settings.TopicMax, settings.ReplyThreads, settings.PIIRedaction, settings.ActionItems, settings.CalendarEvents,
+		lang, sha256.Sum256([]byte(instructions)))
+}
+
+// replyFlightKey identifies a reply summary of one message under the given
+// instructions (group instructions plus steering).
+func replyFlightKey(groupID, targetMsgID int64, instructions string) string {
+	return fmt.Sprintf("reply|%d|%d|%x", groupID, targetMsgID, sha256.Sum256([]byte(instructions)))
+}
+
+// joinFlight returns key's flight in progress, or registers a new one with
+// the caller as its leader (leader is true). A leader must call landFlight.
+func (b *Bot) joinFlight(key string) (f *summaryFlight, leader bool) {
+	b.flightsMu.Lock()
+	defer b.flightsMu.Unlock()
+	if f, ok := b.flights[key]; ok {
+		return f, false
+	}
+	if b.flights == nil {
+		b.flights = make(map[string]*summaryFlight)
+	}
+	f = &summaryFlight{done: make(chan struct{})}
+	b.flights[key] = f
+	return f, true
+}
+
+// landFlight publishes the leader's result to the flight's followers. A nil
+// flight is ignored.
+func (b *Bot) landFlight(key string, f *summaryFlight, result flightResult) {
+	if f == nil {
+		return
+	}
+	b.flightsMu.Lock()
+	delete(b.flights, key)
+	b.flightsMu.Unlock()
+	f.result = result
+	close(f.done)
+}
+
+// awaitFlight waits for f to land. ok is false when ctx ends first.
+func awaitFlight(ctx context.Context, f *summaryFlight) (flightResult, bool) {
+	select {
+	case <-ctx.Done():
+		return flightResult{}, false
+	case <-f.done:
+		return f.result, true
+	}
+}
+
+// leadFlight makes the caller key's leader. While an identical request is in
+// flight it waits for it instead; when that one produces a result, the
+// caller is answered from it (see followFlight) and ok is false, as it is
+// when ctx ends.
+func (b *Bot) leadFlight(ctx context.Context, chatID int64, key string, lang i18n.Lang, statusMsgID int64) (f *summaryFlight, ok bool) {
+	for {
+		f, leader := b.joinFlight(key)
+		if leader {
+			return f, true
+		}
+		if b.followFlight(ctx, chatID, f, lang, statusMsgID) || ctx.Err() != nil {
+			return nil, false
+		}
+	}
+}
+
+// followFlight waits for f and answers from its result: a reply pointing at
+// the leader's message, or, when the leader posted none, the summary itself.
+// statusMsgID, if set, is a status message of the follower's to reuse. It
+// reports whether the request was answered.
+func (b *Bot) followFlight(ctx context.Context, chatID int64, f *summaryFlight, lang i18n.Lang, statusMsgID int64) bool {
+	result, ok := awaitFlight(ctx, f)
+	if !ok || !result.ok() {
+		return false
+	}
+	logger.Info().Int64("chat_id", chatID).Int64("leader_msg_id", result.msgID).Msg("answered from a summary in flight")
+	switch {
+	case result.msgID != 0 && statusMsgID != 0:
+		b.editWithRetry(ctx, chatID, statusMsgID, i18n.T(lang, i18n.SummaryShared))
+	case result.msgID != 0:
+		b.sendMessageReply(ctx, chatID, result.msgID, i18n.T(lang, i18n.SummaryShared))
+	default:
+		b.deliverSummary(ctx, chatID, statusMsgID, "", result.summary, result.summaryID)
+	}
+	return true
+}
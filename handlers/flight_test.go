@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"telegram_summarize_bot/config"
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/summarizer"
+
+	"github.com/mymmrac/telego"
+)
+
+// blockingSummarizer holds SummarizeByTopics until release is closed, so a
+// second request can arrive while the first is in flight.
+type blockingSummarizer struct {
+	*fakeSummarizer
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingSummarizer) SummarizeByTopics(ctx context.Context, messages []db.Message, topicMax int, instructions string, lang i18n.Lang) (*summarizer.StructuredSummary, error) {
+	close(s.started)
+	<-s.release
+	return s.fakeSummarizer.SummarizeByTopics(ctx, messages, topicMax, instructions, lang)
+}
+
+// startBlockedSummary runs a group summary in the background until it is
+// blocked in the summarizer. The returned channel closes when it finishes.
+func startBlockedSummary(t *testing.T, b *Bot, database *db.DB, sum *blockingSummarizer) <-chan struct{} {
+	t.Helper()
+	ctx := context.Background()
+	if err := database.AddMessage(ctx, &db.Message{GroupID: 42, UserHash: "abc123", Text: "привет", Timestamp: time.Now().Add(-time.Hour)}); err != nil {
+		t.Fatalf("AddMessage error: %v", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.handleSummarize(ctx, summarizeUpdate(), nil)
+	}()
+	<-sum.started
+	return done
+}
+
+func newBlockingSummarizer() *blockingSummarizer {
+	return &blockingSummarizer{
+		fakeSummarizer: &fakeSummarizer{summary: &summarizer.StructuredSummary{TLDR: "итог", Topics: []summarizer.TopicSummary{{Title: "T", Summary: "S"}}}},
+		started:        make(chan struct{}),
+		release:        make(chan struct{}),
+	}
+}
+
+func TestHandleSummarizeFollowsSummaryInFlight(t *testing.T) {
+	sum := newBlockingSummarizer()
+	b, database, tg := newTestBot(t, sum)
+	defer func() { _ = database.Close() }()
+	b.rateLimiter = NewRateLimiter(0)
+
+	leader := startBlockedSummary(t, b, database, sum)
+	time.AfterFunc(50*time.Millisecond, func() { close(sum.release) })
+	b.handleSummarize(context.Background(), summarizeUpdate(), nil)
+	waitJobs(t, b)
+	<-leader
+
+	if sum.calls != 1 {
+		t.Fatalf("summarizer calls = %d, want the follower to reuse the leader's run", sum.calls)
+	}
+	if got := tg.sentTexts[len(tg.sentTexts)-1]; got != i18n.T(i18n.Russian, i18n.SummaryShared) {
+		t.Fatalf("follower sent %q, want a pointer to the leader's summary", got)
+	}
+}
+
+func TestDailyDigestReusesSummaryInFlight(t *testing.T) {
+	sum := newBlockingSummarizer()
+	b, database, _ := newTestBot(t, sum)
+	defer func() { _ = database.Close() }()
+
+	leader := startBlockedSummary(t, b, database, sum)
+	time.AfterFunc(50*time.Millisecond, func() { close(sum.release) })
+	digest, err := b.dailyDigest(context.Background(), 42, time.Now(), false, nil)
+	<-leader
+
+	if err != nil || digest == nil {
+		t.Fatalf("dailyDigest = %v, %v; want a digest", digest, err)
+	}
+	if sum.calls != 1 {
+		t.Fatalf("summarizer calls = %d, want the digest to reuse the manual summary", sum.calls)
+	}
+	if digest.SummaryID == 0 || !strings.Contains(digest.Summary, "итог") {
+		t.Fatalf("digest = %+v, want the manual summary and its ID", digest)
+	}
+}
+
+func TestSummarizeReplyFollowsFlight(t *testing.T) {
+	sum := &fakeSummarizer{textSummary: "выжимка"}
+	b, database, tg := newTestBot(t, sum)
+	defer func() { _ = database.Close() }()
+	b.cfg.ReplyMinChars = 10
+	reply := &telego.Message{MessageID: 100, Text: strings.Repeat("я", 50)}
+	key := replyFlightKey(42, 100, "")
+
+	flight, _ := b.joinFlight(key)
+	time.AfterFunc(20*time.Millisecond, func() { b.landFlight(key, flight, flightResult{msgID: 55}) })
+	b.handleSummarizeReply(context.Background(), replyUpdate(reply), "")
+	if sum.textCalls != 0 {
+		t.Fatalf("SummarizeText calls = %d, want the follower to reuse the leader's answer", sum.textCalls)
+	}
+	if len(tg.sentTexts) != 1 || tg.sentTexts[0] != i18n.T(i18n.Russian, i18n.SummaryShared) {
+		t.Fatalf("sent = %q, want a pointer to the leader's answer", tg.sentTexts)
+	}
+
+	// A leader that produced nothing leaves the follower to run itself.
+	flight, _ = b.joinFlight(key)
+	time.AfterFunc(20*time.Millisecond, func() { b.landFlight(key, flight, flightResult{}) })
+	b.handleSummarizeReply(context.Background(), replyUpdate(reply), "")
+	if sum.textCalls != 1 {
+		t.Fatalf("SummarizeText calls = %d, want the follower to run after a failed leader", sum.textCalls)
+	}
+}
+
+func TestSummaryFlightKeySeparatesSinceRuns(t *testing.T) {
+	messages := []db.Message{{ID: 1}, {ID: 2}}
+	settings := config.GroupSettings{TopicMax: 5}
+	plain := summaryFlightKey(42, 0, messages, settings, i18n.Russian, "")
+	since := summaryFlightKey(42, 100, messages, settings, i18n.Russian, "")
+	other := summaryFlightKey(42, 101, messages, settings, i18n.Russian, "")
+	if plain == since || since == other {
+		t.Fatalf("keys should differ by kind and anchor: %q, %q, %q", plain, since, other)
+	}
+}
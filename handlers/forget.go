@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/logger"
+
+	"github.com/mymmrac/telego"
+)
+
+// handleForget handles "forget me": it deletes everything stored about the
+// invoking user in the group and stops storing their messages. The
+// confirmation goes by DM with the user's anonymous ID, which an admin's
+// /forget takes; the group only hears about it when the DM can't be sent.
+func (b *Bot) handleForget(ctx context.Context, update telego.Update, args []string) {
+	msg := update.Message
+	groupID := msg.Chat.ID
+	lang := b.groupLanguage(ctx, groupID)
+	if len(args) != 1 || !strings.EqualFold(args[0], "me") {
+		b.sendMessageReply(ctx, groupID, int64(msg.MessageID), i18n.T(lang, i18n.ForgetUsage))
+		return
+	}
+
+	userHash := b.userHash(ctx, msg.From.ID, groupID)
+	deleted, err := b.forgetUser(ctx, groupID, userHash, b.retiredUserHashes(msg.From.ID, groupID)...)
+	if err != nil {
+		b.sendMessageReply(ctx, groupID, int64(msg.MessageID), i18n.T(lang, i18n.ForgetFailed))
+		return
+	}
+	if _, err := b.sendPrivate(ctx, msg.From.ID, i18n.T(lang, i18n.ForgetDone, msg.Chat.Title, deleted, userHash)); err != nil {
+		b.sendMessageReply(ctx, groupID, int64(msg.MessageID), i18n.T(lang, i18n.ForgetDoneGroup))
+	}
+}
+
+// handleRemember handles "remember me", undoing the opt-out of "forget me".
+func (b *Bot) handleRemember(ctx context.Context, update telego.Update, args []string) {
+	msg := update.Message
+	groupID := msg.Chat.ID
+	lang := b.groupLanguage(ctx, groupID)
+	if len(args) != 1 || !strings.EqualFold(args[0], "me") {
+		b.sendMessageReply(ctx, groupID, int64(msg.MessageID), i18n.T(lang, i18n.ForgetUsage))
+		return
+	}
+
+	userHash := b.userHash(ctx, msg.From.ID, groupID)
+	if err := b.db.SetUserOptOut(ctx, groupID, userHash, false); err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to clear opt-out")
+		b.sendMessageReply(ctx, groupID, int64(msg.MessageID), i18n.T(lang, i18n.RememberFailed))
+		return
+	}
+	b.sendMessageReply(ctx, groupID, int64(msg.MessageID), i18n.T(lang, i18n.RememberDone))
+}
+
+// forgetUser opts the user out first, so a message arriving mid-purge isn't
+// stored after it, then deletes what is already stored, including votes left
+// under retiredHashes.
+func (b *Bot) forgetUser(ctx context.Context, groupID int64, userHash string, retiredHashes ...string) (int64, error) {
+	if err := b.db.SetUserOptOut(ctx, groupID, userHash, true); err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to record opt-out")
+		return 0, err
+	}
+	deleted, err := b.db.ForgetUser(ctx, groupID, userHash, retiredHashes...)
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to forget user")
+		return 0, err
+	}
+	logger.Info().Int64("group_id", groupID).Int64("deleted", deleted).Msg("forgot user on request")
+	return deleted, nil
+}
+
+// optedOut reports whether the user asked not to have their messages stored
+// in the group. When that can't be checked it errs on the side of not
+// storing.
+func (b *Bot) optedOut(ctx context.Context, groupID int64, userHash string) bool {
+	out, err := b.db.IsUserOptedOut(ctx, groupID, userHash)
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to check opt-out")
+		return true
+	}
+	return out
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
+
+	"github.com/mymmrac/telego"
+	"github.com/mymmrac/telego/telegoapi"
+)
+
+func TestForgetMe(t *testing.T) {
+	b, database, tg := newTestBot(t, &fakeSummarizer{})
+	defer func() { _ = database.Close() }()
+	ctx := context.Background()
+	const groupID int64 = 42
+	if err := database.AddAllowedGroup(ctx, groupID, 0); err != nil {
+		t.Fatalf("AddAllowedGroup: %v", err)
+	}
+	hash := db.UserHash(7, groupID, b.currentSalt())
+
+	post := func(userID int64, msgID int, text string) {
+		b.handleUpdate(ctx, telego.Update{Message: &telego.Message{
+			MessageID: msgID,
+			Text:      text,
+			Chat:      telego.Chat{ID: groupID, Type: "group", Title: "Dev chat"},
+			From:      &telego.User{ID: userID},
+		}})
+	}
+	stored := func() []db.Message {
+		t.Helper()
+		msgs, err := database.GetMessages(ctx, groupID, time.Now().Add(-time.Hour), 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return msgs
+	}
+
+	post(7, 1, "мой телефон где-то тут")
+	post(8, 2, "а это не моё")
+	post(7, 3, "@testbot forget")
+	if got := tg.sentTexts[len(tg.sentTexts)-1]; got != i18n.T(i18n.Russian, i18n.ForgetUsage) {
+		t.Fatalf("forget without \"me\" should show usage, got %q", got)
+	}
+
+	post(7, 4, "@testbot forget me")
+	if msgs := stored(); len(msgs) != 1 || msgs[0].UserHash == hash {
+		t.Fatalf("after forget me: %+v", msgs)
+	}
+	if last := len(tg.sentChats) - 1; tg.sentChats[last] != 7 || tg.sentTexts[last] != i18n.T(i18n.Russian, i18n.ForgetDone, "Dev chat", int64(1), hash) {
+		t.Fatalf("expected a private confirmation, got %v %q", tg.sentChats, tg.sentTexts)
+	}
+
+	// Opted out: new messages aren't stored, but commands still work.
+	post(7, 5, "это не должно сохраниться")
+	if msgs := stored(); len(msgs) != 1 {
+		t.Fatalf("opted-out message stored: %+v", msgs)
+	}
+
+	post(7, 6, "@testbot remember me")
+	if got := tg.sentTexts[len(tg.sentTexts)-1]; got != i18n.T(i18n.Russian, i18n.RememberDone) {
+		t.Fatalf("remember me reply = %q", got)
+	}
+	post(7, 7, "я снова здесь")
+	if msgs := stored(); len(msgs) != 2 {
+		t.Fatalf("after remember me: %+v", msgs)
+	}
+}
+
+func TestForgetMeConfirmsInGroupWhenDMFails(t *testing.T) {
+	b, database, tg := newTestBot(t, &fakeSummarizer{})
+	defer func() { _ = database.Close() }()
+	tg.sendErrs = map[int64]error{7: &telegoapi.Error{ErrorCode: 403, Description: "Forbidden: bot was blocked by the user"}}
+	ctx := context.Background()
+
+	b.handleCommand(ctx, subscribeUpdate(7, "@testbot forget me"), "forget me")
+	if len(tg.sentTexts) != 1 || tg.sentChats[0] != 42 || tg.sentTexts[0] != i18n.T(i18n.Russian, i18n.ForgetDoneGroup) {
+		t.Fatalf("expected a group confirmation, got %v %q", tg.sentChats, tg.sentTexts)
+	}
+	if out, _ := database.IsUserOptedOut(ctx, 42, db.UserHash(7, 42, b.currentSalt())); !out {
+		t.Fatal("forget me should opt the user out even without a DM")
+	}
+}
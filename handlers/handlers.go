@@ -12,6 +12,7 @@ import (
 	"telegram_summarize_bot/fetcher"
 	"telegram_summarize_bot/handlers/admin"
 	"telegram_summarize_bot/httputil"
+	"telegram_summarize_bot/i18n"
 	"telegram_summarize_bot/logger"
 	"telegram_summarize_bot/metrics"
 	"telegram_summarize_bot/provider"
@@ -37,6 +38,7 @@ type telegramClient interface {
 	GetMe(ctx context.Context) (*telego.User, error)
 	UpdatesViaLongPolling(ctx context.Context, params *telego.GetUpdatesParams, options ...telego.LongPollingOption) (<-chan telego.Update, error)
 	SendMessage(ctx context.Context, params *telego.SendMessageParams) (*telego.Message, error)
+	SendDocument(ctx context.Context, params *telego.SendDocumentParams) (*telego.Message, error)
 	EditMessageText(ctx context.Context, params *telego.EditMessageTextParams) (*telego.Message, error)
 	GetChatMember(ctx context.Context, params *telego.GetChatMemberParams) (telego.ChatMember, error)
 	GetChat(ctx context.Context, params *telego.GetChatParams) (*telego.ChatFullInfo, error)
@@ -46,26 +48,46 @@ type telegramClient interface {
 }
 
 type summaryService interface {
-	SummarizeByTopics(ctx context.Context, messages []db.Message, topicMax int, additionalInstructions string) (*summarizer.StructuredSummary, error)
-	SummarizeURL(ctx context.Context, pageURL string, content string, instructions string) (string, error)
-	SummarizeText(ctx context.Context, content string, instructions string) (string, error)
-	DescribeImage(ctx context.Context, photo db.PhotoRecord, steering string) (string, error)
+	SummarizeByTopics(ctx context.Context, messages []db.Message, topicMax int, additionalInstructions string, lang i18n.Lang) (*summarizer.StructuredSummary, error)
+	SummarizeURL(ctx context.Context, pageURL string, content string, instructions string, lang i18n.Lang) (string, error)
+	SummarizeText(ctx context.Context, content string, instructions string, lang i18n.Lang) (string, error)
+	DescribeImage(ctx context.Context, photo db.PhotoRecord, steering string, lang i18n.Lang) (string, error)
+	ExpandTopic(ctx context.Context, messages []db.Message, title, instructions string, lang i18n.Lang) (string, error)
 }
 
 type Bot struct {
-	telegram     telegramClient
-	db           *db.DB
-	summarizer   summaryService
-	rateLimiter  *RateLimiter
-	cfg          *config.Config
-	username     string
-	metrics      *metrics.Metrics
-	userHashSalt []byte
-	admin        *admin.Admin
+	telegram    telegramClient
+	db          *db.DB
+	summarizer  summaryService
+	rateLimiter *RateLimiter
+	cfg         *config.Config
+	username    string
+	metrics     *metrics.Metrics
+	salts       userSalts
+	admin       *admin.Admin
 	// fetchURL fetches and extracts readable text from a URL. Defaults to
 	// fetcher.Fetch; overridable in tests to avoid real network access.
 	fetchURL func(ctx context.Context, rawURL string, maxChars int) (string, error)
 
+	// digestLocks serializes daily digest generation per group so concurrent
+	// deliveries share one summary; guarded by digestMu.
+	digestMu    sync.Mutex
+	digestLocks map[int64]*sync.Mutex
+
+	// flights holds summaries in progress by request key, so identical
+	// requests share one run (see joinFlight); guarded by flightsMu.
+	flightsMu sync.Mutex
+	flights   map[string]*summaryFlight
+
+	// jobWake nudges idle job workers; it stays nil until startJobs, and jobs
+	// submitted before then wait in the queue. jobHooks holds what
+	// submitters wait on, by job ID. Both are guarded by jobsMu; jobWorkers tracks the
+	// workers so shutdown can drain them.
+	jobsMu     sync.Mutex
+	jobWake    chan struct{}
+	jobHooks   map[int64]func(flightResult)
+	jobWorkers sync.WaitGroup
+
 	// inflight tracks running update handlers so shutdown can drain them; sem
 	// bounds their concurrency (backpressure).
 	inflight sync.WaitGroup
@@ -87,17 +109,21 @@ func NewBot(ctx context.Context, cfg *config.Config, database *db.DB, sum *summa
 	}
 
 	b := &Bot{
-		telegram:    bot,
-		db:          database,
-		summarizer:  sum,
-		rateLimiter: NewRateLimiter(cfg.RateLimitSec),
-		cfg:         cfg,
-		username:    strings.ToLower(me.Username),
-		metrics:     m,
-		fetchURL:    fetcher.Fetch,
-		sem:         make(chan struct{}, maxConcurrentUpdates),
+		telegram:   bot,
+		db:         database,
+		summarizer: sum,
+		cfg:        cfg,
+		username:   strings.ToLower(me.Username),
+		metrics:    m,
+		fetchURL:   fetcher.Fetch,
+		sem:        make(chan struct{}, maxConcurrentUpdates),
 	}
 
+	b.rateLimiter = NewRateLimiter(cfg.RateLimitSec).
+		WithLimitFunc(b.groupRateLimit).
+		WithBurst(cfg.RateLimitBurst).
+		WithUserLimit(cfg.RateLimitUserSec, cfg.RateLimitUserBurst).
+		WithCosts(cfg.RateLimitCostSummary, cfg.RateLimitCostReply, cfg.RateLimitCostURL)
 	b.admin = admin.New(b, database, m, cfg, sum, b.rateLimiter, bot, llm)
 
 	return b, nil
@@ -106,20 +132,24 @@ func NewBot(ctx context.Context, cfg *config.Config, database *db.DB, sum *summa
 func (b *Bot) Start(ctx context.Context) error {
 	logger.Info().Msg("Starting Telegram bot with polling...")
 
-	salt, err := b.db.GetUserHashSalt(ctx)
-	if err != nil {
+	if err := b.loadSalts(ctx); err != nil {
 		return fmt.Errorf("failed to load user hash salt: %w", err)
 	}
-	b.userHashSalt = salt
 
 	if err := b.telegram.SetMyCommands(ctx, &telego.SetMyCommandsParams{
 		Commands: []telego.BotCommand{
-			{Command: "status", Description: "Статус бота и метрики"},
-			{Command: "reset", Description: "Сбросить все метрики"},
-			{Command: "groups", Description: "Управление группами"},
-			{Command: "instructions", Description: "Инструкции суммаризации"},
-			{Command: "usage", Description: "Использование токенов и квоты"},
-			{Command: "help", Description: "Справка"},
+			{Command: "status", Description: i18n.T(b.cfg.Language, i18n.CommandStatus)},
+			{Command: "reset", Description: i18n.T(b.cfg.Language, i18n.CommandReset)},
+			{Command: "groups", Description: i18n.T(b.cfg.Language, i18n.CommandGroups)},
+			{Command: "instructions", Description: i18n.T(b.cfg.Language, i18n.CommandInstructions)},
+			{Command: "preview", Description: i18n.T(b.cfg.Language, i18n.CommandPreview)},
+			{Command: "language", Description: i18n.T(b.cfg.Language, i18n.CommandLanguage)},
+			{Command: "settings", Description: i18n.T(b.cfg.Language, i18n.CommandSettings)},
+			{Command: "usage", Description: i18n.T(b.cfg.Language, i18n.CommandUsage)},
+			{Command: "quality", Description: i18n.T(b.cfg.Language, i18n.CommandQuality)},
+			{Command: "stats", Description: i18n.T(b.cfg.Language, i18n.CommandStats)},
+			{Command: "forget", Description: i18n.T(b.cfg.Language, i18n.CommandForget)},
+			{Command: "help", Description: i18n.T(b.cfg.Language, i18n.CommandHelp)},
 		},
 		Scope: tu.ScopeAllPrivateChats(),
 	}); err != nil {
@@ -152,6 +182,7 @@ func (b *Bot) Start(ctx context.Context) error {
 	go b.cleanupLoop(ctx)
 	go b.rateLimitCleanupLoop(ctx)
 	go b.schedulerLoop(ctx)
+	b.startJobs(ctx)
 
 	logger.Info().Msg("Bot started successfully, listening for updates...")
 
@@ -186,20 +217,27 @@ func (b *Bot) Start(ctx context.Context) error {
 	}
 }
 
-// drainHandlers waits up to timeout for in-flight update handlers to finish, so
-// they don't write to the DB after the caller closes it. In-flight LLM/network
-// calls use the (now-cancelled) parent context and abort quickly; this mainly
-// lets handlers unwind cleanly.
+// drainHandlers waits up to timeout for in-flight update handlers and job
+// workers to finish, so they don't write to the DB after the caller closes it.
+// In-flight LLM/network calls use the (now-cancelled) parent context and abort
+// quickly; workers checkpoint their jobs as they stop. Jobs still running when
+// the timeout hits are checkpointed here, so the next start resumes them.
 func (b *Bot) drainHandlers(timeout time.Duration) {
 	done := make(chan struct{})
 	go func() {
 		b.inflight.Wait()
+		b.jobWorkers.Wait()
 		close(done)
 	}()
 	select {
 	case <-done:
 	case <-time.After(timeout):
 		logger.Warn().Dur("timeout", timeout).Msg("shutdown drain timed out; some handlers still running")
+		if n, err := b.db.CheckpointRunningJobs(context.Background()); err != nil {
+			logger.Error().Err(err).Msg("failed to checkpoint running jobs")
+		} else if n > 0 {
+			logger.Info().Int64("count", n).Msg("checkpointed running jobs for resume")
+		}
 	}
 }
 
@@ -228,6 +266,20 @@ func (b *Bot) EditFormattedWithRetry(ctx context.Context, chatID, msgID int64, t
 	b.editFormattedWithRetry(ctx, chatID, msgID, text)
 }
 
+// HandleSummaryCallback handles a posted summary's "expand", feedback or
+// calendar button press.
+func (b *Bot) HandleSummaryCallback(ctx context.Context, cq *telego.CallbackQuery) {
+	if strings.HasPrefix(cq.Data, admin.FeedbackCallbackPrefix) {
+		b.handleFeedbackCallback(ctx, cq)
+		return
+	}
+	if strings.HasPrefix(cq.Data, admin.EventCallbackPrefix) {
+		b.handleEventCallback(ctx, cq)
+		return
+	}
+	b.handleExpandCallback(ctx, cq)
+}
+
 // TelegramClient returns the underlying Telegram client for direct API calls.
 func (b *Bot) TelegramClient() telegramClient {
 	return b.telegram
@@ -3,21 +3,43 @@ package handlers
 import (
 	"context"
 	"errors"
+	"io"
 	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/mymmrac/telego"
 	"telegram_summarize_bot/config"
 	"telegram_summarize_bot/db"
 	"telegram_summarize_bot/handlers/admin"
+	"telegram_summarize_bot/i18n"
 	"telegram_summarize_bot/metrics"
 	"telegram_summarize_bot/summarizer"
 )
 
 type fakeTelegram struct {
+	// mu guards the edits, which progress editors make from their own
+	// goroutine; see editCount.
+	mu        sync.Mutex
 	sentTexts []string
-	editTexts []string
-	nextID    int
+	sentChats []int64
+	// sentReplyTo holds the message each send replied to (0 when none).
+	sentReplyTo []int64
+	editTexts   []string
+	editChats   []int64
+	// editMarkups holds each edit's inline keyboard (nil when none).
+	editMarkups []*telego.InlineKeyboardMarkup
+	answers     []string
+	nextID      int
+	// sendErrs fails sends to the given chats, e.g. to simulate a user who
+	// hasn't started the bot.
+	sendErrs map[int64]error
+	// docNames and docs hold each uploaded document's file name and content;
+	// docReplyTo the message it replied to.
+	docNames   []string
+	docs       [][]byte
+	docReplyTo []int64
 }
 
 func (f *fakeTelegram) GetMe(_ context.Context) (*telego.User, error) {
@@ -29,16 +51,54 @@ func (f *fakeTelegram) UpdatesViaLongPolling(_ context.Context, _ *telego.GetUpd
 }
 
 func (f *fakeTelegram) SendMessage(_ context.Context, params *telego.SendMessageParams) (*telego.Message, error) {
+	if err := f.sendErrs[params.ChatID.ID]; err != nil {
+		return nil, err
+	}
+	f.sentChats = append(f.sentChats, params.ChatID.ID)
 	f.sentTexts = append(f.sentTexts, params.Text)
+	var replyTo int64
+	if params.ReplyParameters != nil {
+		replyTo = int64(params.ReplyParameters.MessageID)
+	}
+	f.sentReplyTo = append(f.sentReplyTo, replyTo)
+	f.nextID++
+	return &telego.Message{MessageID: f.nextID}, nil
+}
+
+func (f *fakeTelegram) SendDocument(_ context.Context, params *telego.SendDocumentParams) (*telego.Message, error) {
+	if err := f.sendErrs[params.ChatID.ID]; err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(params.Document.File)
+	if err != nil {
+		return nil, err
+	}
+	f.docNames = append(f.docNames, params.Document.File.Name())
+	f.docs = append(f.docs, data)
+	var replyTo int64
+	if params.ReplyParameters != nil {
+		replyTo = int64(params.ReplyParameters.MessageID)
+	}
+	f.docReplyTo = append(f.docReplyTo, replyTo)
 	f.nextID++
 	return &telego.Message{MessageID: f.nextID}, nil
 }
 
 func (f *fakeTelegram) EditMessageText(_ context.Context, params *telego.EditMessageTextParams) (*telego.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
 	f.editTexts = append(f.editTexts, params.Text)
+	f.editChats = append(f.editChats, params.ChatID.ID)
+	f.editMarkups = append(f.editMarkups, params.ReplyMarkup)
 	return &telego.Message{MessageID: params.MessageID}, nil
 }
 
+func (f *fakeTelegram) editCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.editTexts)
+}
+
 func (f *fakeTelegram) GetChatMember(_ context.Context, params *telego.GetChatMemberParams) (telego.ChatMember, error) {
 	return &telego.ChatMemberAdministrator{Status: "administrator"}, nil
 }
@@ -51,7 +111,8 @@ func (f *fakeTelegram) SetMyCommands(_ context.Context, _ *telego.SetMyCommandsP
 	return nil
 }
 
-func (f *fakeTelegram) AnswerCallbackQuery(_ context.Context, _ *telego.AnswerCallbackQueryParams) error {
+func (f *fakeTelegram) AnswerCallbackQuery(_ context.Context, params *telego.AnswerCallbackQueryParams) error {
+	f.answers = append(f.answers, params.Text)
 	return nil
 }
 
@@ -78,10 +139,19 @@ type fakeSummarizer struct {
 	imageErr               error
 	imageCalls             int
 	imageSteering          string
+	lang                   i18n.Lang
+	expandText             string
+	expandErr              error
+	expandCalls            int
+	expandTitle            string
+	expandMessages         []db.Message
+	topicMessages          []db.Message
 }
 
-func (f *fakeSummarizer) SummarizeByTopics(_ context.Context, _ []db.Message, topicMax int, additionalInstructions string) (*summarizer.StructuredSummary, error) {
+func (f *fakeSummarizer) SummarizeByTopics(_ context.Context, messages []db.Message, topicMax int, additionalInstructions string, lang i18n.Lang) (*summarizer.StructuredSummary, error) {
 	f.calls++
+	f.topicMessages = messages
+	f.lang = lang
 	f.topicMax = topicMax
 	f.additionalInstructions = additionalInstructions
 	if f.err != nil {
@@ -90,7 +160,8 @@ func (f *fakeSummarizer) SummarizeByTopics(_ context.Context, _ []db.Message, to
 	return f.summary, nil
 }
 
-func (f *fakeSummarizer) SummarizeURL(_ context.Context, _, _, instructions string) (string, error) {
+func (f *fakeSummarizer) SummarizeURL(_ context.Context, _, _, instructions string, lang i18n.Lang) (string, error) {
+	f.lang = lang
 	f.urlCalls++
 	f.urlInstr = instructions
 	if f.urlErr != nil {
@@ -99,7 +170,8 @@ func (f *fakeSummarizer) SummarizeURL(_ context.Context, _, _, instructions stri
 	return f.urlSummary, nil
 }
 
-func (f *fakeSummarizer) SummarizeText(_ context.Context, content, instructions string) (string, error) {
+func (f *fakeSummarizer) SummarizeText(_ context.Context, content, instructions string, lang i18n.Lang) (string, error) {
+	f.lang = lang
 	f.textCalls++
 	f.textInput = content
 	f.textInstr = instructions
@@ -109,7 +181,8 @@ func (f *fakeSummarizer) SummarizeText(_ context.Context, content, instructions
 	return f.textSummary, nil
 }
 
-func (f *fakeSummarizer) DescribeImage(_ context.Context, _ db.PhotoRecord, steering string) (string, error) {
+func (f *fakeSummarizer) DescribeImage(_ context.Context, _ db.PhotoRecord, steering string, lang i18n.Lang) (string, error) {
+	f.lang = lang
 	f.imageCalls++
 	f.imageSteering = steering
 	if f.imageErr != nil {
@@ -118,8 +191,69 @@ func (f *fakeSummarizer) DescribeImage(_ context.Context, _ db.PhotoRecord, stee
 	return f.imageDesc, nil
 }
 
+func (f *fakeSummarizer) ExpandTopic(_ context.Context, messages []db.Message, title, _ string, lang i18n.Lang) (string, error) {
+	f.lang = lang
+	f.expandCalls++
+	f.expandTitle = title
+	f.expandMessages = messages
+	if f.expandErr != nil {
+		return "", f.expandErr
+	}
+	return f.expandText, nil
+}
+
+// newTestBot is newIdleTestBot with a job worker running until the test
+// ends; tests wait for the jobs they submit with waitJobs.
 func newTestBot(t *testing.T, sum summaryService) (*Bot, *db.DB, *fakeTelegram) {
 	t.Helper()
+	b, database, tg := newIdleTestBot(t, sum)
+	b.cfg.JobWorkers = 1
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(func() { cancel(); b.jobWorkers.Wait() })
+	b.startJobs(ctx)
+	return b, database, tg
+}
+
+// waitJobs waits until every job submitted so far has run and its submitter
+// has been settled. Jobs paused for a retry count as settled.
+func waitJobs(t *testing.T, b *Bot) {
+	t.Helper()
+	ctx := context.Background()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if jobsSettled(ctx, b) {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("jobs never settled")
+}
+
+func jobsSettled(ctx context.Context, b *Bot) bool {
+	b.jobsMu.Lock()
+	hooks := len(b.jobHooks)
+	b.jobsMu.Unlock()
+	if hooks > 0 {
+		return false
+	}
+	for id := int64(1); ; id++ {
+		job, err := b.db.GetJob(ctx, id)
+		if err != nil {
+			return false
+		}
+		if job == nil {
+			return true
+		}
+		if job.State == db.JobRunning || job.State == db.JobQueued && !job.NextRunAt.After(time.Now()) {
+			return false
+		}
+	}
+}
+
+// newIdleTestBot builds a Bot over a fresh database and a fake Telegram,
+// without job workers: submitted jobs stay queued.
+func newIdleTestBot(t *testing.T, sum summaryService) (*Bot, *db.DB, *fakeTelegram) {
+	t.Helper()
 
 	database, err := db.New(filepath.Join(t.TempDir(), "bot.db"), metrics.New())
 	if err != nil {
@@ -135,14 +269,14 @@ func newTestBot(t *testing.T, sum summaryService) (*Bot, *db.DB, *fakeTelegram)
 	}
 	m := metrics.New()
 	b := &Bot{
-		telegram:     tg,
-		db:           database,
-		summarizer:   sum,
-		rateLimiter:  NewRateLimiter(60),
-		cfg:          cfg,
-		username:     "testbot",
-		metrics:      m,
-		userHashSalt: []byte("testsalt"),
+		telegram:    tg,
+		db:          database,
+		summarizer:  sum,
+		rateLimiter: NewRateLimiter(60),
+		cfg:         cfg,
+		username:    "testbot",
+		metrics:     m,
+		salts:       userSalts{current: db.HashSalt{Salt: []byte("testsalt")}},
 		// Default to a stub so tests never hit the network; link tests override.
 		fetchURL: func(_ context.Context, _ string, _ int) (string, error) {
 			return "", errors.New("fetchURL not stubbed in this test")
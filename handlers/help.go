@@ -3,6 +3,8 @@ package handlers
 import (
 	"context"
 
+	"telegram_summarize_bot/i18n"
+
 	"github.com/mymmrac/telego"
 )
 
@@ -12,20 +14,10 @@ func (b *Bot) handleHelp(ctx context.Context, update telego.Update) {
 		return
 	}
 
-	helpText := "📖 *Доступные команды:*\n\n" +
-		"• `summarize [часы]` \\(или `s`, `sub`\\) — суммировать сообщения за последние N часов \\(по умолчанию 24\\)\n" +
-		"• *Ответ* на сообщение с упоминанием бота — разобрать именно его \\(ссылку, изображение или текст\\); слово `summarize` необязательно\\. Если это ветка ответов — разберёт всю цепочку\\. Можно добавить запрос, например `@bot опиши мем` или `@bot как это можно использовать`\n" +
-		"• `schedule` — показать расписание ежедневной сводки\n" +
-		"• `help` — показать это сообщение\n\n" +
-		"_Примеры: @bot summarize, @bot summarize 12, ответом — @bot опиши мем_"
-
+	lang := b.groupLanguage(ctx, msg.Chat.ID)
+	helpText := i18n.T(lang, i18n.HelpGroup)
 	if b.isGroupAdmin(ctx, msg.Chat.ID, msg.From.ID) {
-		helpText += "\n\n*Команды администратора:*\n" +
-			"• `schedule on` — включить ежедневную сводку\n" +
-			"• `schedule off` — выключить ежедневную сводку\n" +
-			"• `schedule ЧЧ:ММ` — установить время ежедневной сводки в UTC\n" +
-			"• `schedule now` — запустить внеплановую сводку прямо сейчас\n\n" +
-			"_Пример: @bot schedule 08:00_"
+		helpText += i18n.T(lang, i18n.HelpAdmin)
 	}
 
 	b.sendFormatted(ctx, msg.Chat.ID, helpText)
@@ -37,8 +29,5 @@ func (b *Bot) handlePrivateChatInfo(ctx context.Context, update telego.Update) {
 		return
 	}
 
-	privateInfoText := "Я работаю только в группах и полезен для суммаризации групповых обсуждений\\.\n\n" +
-		"Добавьте меня в группу и используйте `@" + b.username + " summarize`\\."
-
-	b.sendFormatted(ctx, msg.Chat.ID, privateInfoText)
+	b.sendFormatted(ctx, msg.Chat.ID, i18n.T(b.cfg.Language, i18n.HelpPrivate, b.username))
 }
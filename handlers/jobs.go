@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/logger"
+	"telegram_summarize_bot/summarizer"
+	"telegram_summarize_bot/tgutil"
+)
+
+// Job kinds.
+const (
+	jobSummary = "summary" // a group's "@bot summarize"
+	jobDigest  = "digest"  // a group's daily digest post and subscriber DMs
+)
+
+const (
+	// jobPollInterval is how often idle workers look for jobs whose retry
+	// pause is over; new jobs wake them right away.
+	jobPollInterval = 5 * time.Second
+	// jobRetryBase is the pause after a job's first failed run; it doubles
+	// with every further one.
+	jobRetryBase = 30 * time.Second
+)
+
+// summaryJob is a jobSummary's payload: the window "@bot summarize" saw.
+type summaryJob struct {
+	MessageIDs []int64   `json:"message_ids"`
+	Upper      time.Time `json:"upper"`
+	// Anchor is the Telegram message_id a "summarize since" reply started
+	// from; such a summary links back to it and leaves the group's last
+	// summary time alone.
+	Anchor int64 `json:"anchor,omitempty"`
+}
+
+// digestJob is a jobDigest's payload.
+type digestJob struct {
+	Now     time.Time `json:"now"`               // the scheduler tick the digest is for
+	Post    bool      `json:"post,omitempty"`    // post the digest in the group
+	Refresh bool      `json:"refresh,omitempty"` // regenerate it even if today's exists
+	Subs    bool      `json:"subs,omitempty"`    // DM the subscribers due at Now
+}
+
+// submitJob stores job and hands it to the job workers. done, if set, gets
+// the job's result once its first run is over: done, failed (with an empty
+// result, whether or not a retry follows), or checkpointed by a shutdown
+// (then empty too). It reports whether the job was accepted: false on a store
+// error or when a job with the same key is already queued or running.
+func (b *Bot) submitJob(ctx context.Context, job db.Job, done func(flightResult)) bool {
+	b.jobsMu.Lock()
+	id, err := b.db.EnqueueJob(ctx, job)
+	if err == nil && id != 0 && done != nil {
+		if b.jobHooks == nil {
+			b.jobHooks = make(map[int64]func(flightResult))
+		}
+		b.jobHooks[id] = done
+	}
+	wake := b.jobWake
+	b.jobsMu.Unlock()
+
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", job.GroupID).Str("kind", job.Kind).Msg("failed to queue job")
+		return false
+	}
+	if id == 0 {
+		logger.Info().Int64("group_id", job.GroupID).Str("key", job.Key).Msg("job already queued, skipping")
+		return false
+	}
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// startJobs checkpoints jobs a crash left running, so they resume, and starts
+// the workers. Until ctx ends they run queued jobs, at most JOB_WORKERS at
+// once.
+func (b *Bot) startJobs(ctx context.Context) {
+	if n, err := b.db.CheckpointRunningJobs(ctx); err != nil {
+		logger.Error().Err(err).Msg("failed to checkpoint interrupted jobs")
+	} else if n > 0 {
+		logger.Info().Int64("count", n).Msg("resuming jobs interrupted by the last shutdown")
+	}
+
+	b.jobsMu.Lock()
+	b.jobWake = make(chan struct{}, 1)
+	b.jobsMu.Unlock()
+
+	for i := 0; i < max(b.cfg.JobWorkers, 1); i++ {
+		b.jobWorkers.Add(1)
+		go b.jobWorker(ctx)
+	}
+}
+
+func (b *Bot) jobWorker(ctx context.Context) {
+	defer b.jobWorkers.Done()
+	for {
+		job, err := b.db.ClaimJob(ctx, time.Now())
+		if err != nil && ctx.Err() == nil {
+			logger.Error().Err(err).Msg("failed to claim job")
+		}
+		if job != nil {
+			b.runJob(ctx, job)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-b.jobWake:
+		case <-time.After(jobPollInterval):
+		}
+	}
+}
+
+// runJob runs a claimed job and records how it went: done; queued again after
+// a pause while it has retries left; failed, with the status message saying
+// so; or, when ctx ended mid-run, checkpointed for the next start to resume.
+// A failed run settles its submitter right away rather than holding its
+// flight and rate-limit ticket through the pause; a summary's retry pays
+// for a fresh ticket instead, and waits for one if the group has none left.
+func (b *Bot) runJob(ctx context.Context, job *db.Job) {
+	// The DB outlives ctx until shutdown has drained the workers.
+	store := context.WithoutCancel(ctx)
+
+	var ticket *Ticket
+	if job.Kind == jobSummary && job.Attempts > 0 {
+		var wait time.Duration
+		if ticket, wait = b.rateLimiter.Take(job.GroupID, "", KindSummary); ticket == nil {
+			if err := b.db.PostponeJob(store, job.ID, time.Now().Add(wait)); err != nil {
+				logger.Error().Err(err).Int64("job_id", job.ID).Msg("failed to postpone job")
+			}
+			if job.StatusMsgID != 0 {
+				lang := b.jobLang(ctx, job)
+				b.editWithRetry(ctx, job.GroupID, job.StatusMsgID, i18n.T(lang, i18n.RateLimitQueued, tgutil.FormatDuration(lang, wait)))
+			}
+			return
+		}
+	}
+
+	if job.Resumed && job.StatusMsgID != 0 {
+		b.editWithRetry(ctx, job.GroupID, job.StatusMsgID, i18n.T(b.jobLang(ctx, job), i18n.JobResumed))
+	}
+
+	result, err := b.execJob(ctx, job)
+	switch {
+	case ctx.Err() != nil:
+		if err := b.db.CheckpointJob(store, job.ID); err != nil {
+			logger.Error().Err(err).Int64("job_id", job.ID).Msg("failed to checkpoint job")
+		} else {
+			logger.Info().Int64("job_id", job.ID).Str("kind", job.Kind).Msg("job checkpointed for resume")
+		}
+		result = flightResult{}
+	case err == nil:
+		if err := b.db.CompleteJob(store, job.ID); err != nil {
+			logger.Error().Err(err).Int64("job_id", job.ID).Msg("failed to complete job")
+		}
+	case job.Attempts+1 < b.cfg.JobMaxAttempts:
+		delay := jobRetryBase << job.Attempts
+		logger.Warn().Err(err).Int64("job_id", job.ID).Str("kind", job.Kind).Dur("retry_in", delay).Msg("job failed, retrying")
+		if err := b.db.RetryJob(store, job.ID, time.Now().Add(delay), err.Error()); err != nil {
+			logger.Error().Err(err).Int64("job_id", job.ID).Msg("failed to requeue job")
+		}
+		if job.StatusMsgID != 0 {
+			lang := b.jobLang(ctx, job)
+			b.editWithRetry(ctx, job.GroupID, job.StatusMsgID, i18n.T(lang, i18n.JobRetrying, tgutil.FormatDuration(lang, delay)))
+		}
+		result = flightResult{}
+	default:
+		logger.Error().Err(err).Int64("job_id", job.ID).Str("kind", job.Kind).Msg("job failed, giving up")
+		if err := b.db.FailJob(store, job.ID, err.Error()); err != nil {
+			logger.Error().Err(err).Int64("job_id", job.ID).Msg("failed to mark job failed")
+		}
+		if job.StatusMsgID != 0 {
+			b.editWithRetry(ctx, job.GroupID, job.StatusMsgID, i18n.T(b.jobLang(ctx, job), i18n.SummarizeFailed))
+		}
+		result = flightResult{}
+	}
+
+	if !result.ok() {
+		ticket.Release()
+	}
+
+	b.jobsMu.Lock()
+	done := b.jobHooks[job.ID]
+	b.jobsMu.Unlock()
+	if done != nil {
+		done(result)
+		b.jobsMu.Lock()
+		delete(b.jobHooks, job.ID)
+		b.jobsMu.Unlock()
+	}
+}
+
+func (b *Bot) execJob(ctx context.Context, job *db.Job) (flightResult, error) {
+	switch job.Kind {
+	case jobSummary:
+		return b.runSummaryJob(ctx, job)
+	case jobDigest:
+		return flightResult{}, b.runDigestJob(ctx, job)
+	default:
+		return flightResult{}, fmt.Errorf("unknown job kind %q", job.Kind)
+	}
+}
+
+// jobLang is the language of job's status message.
+func (b *Bot) jobLang(ctx context.Context, job *db.Job) i18n.Lang {
+	if lang, ok := i18n.Parse(job.Lang); ok && lang != i18n.Auto {
+		return lang
+	}
+	return b.groupLanguage(ctx, job.GroupID).Resolve()
+}
+
+// runSummaryJob summarizes the window a group's "@bot summarize" saw into the
+// job's status message. Summarizer errors are returned for a retry; anything
+// else is settled in the chat.
+func (b *Bot) runSummaryJob(ctx context.Context, job *db.Job) (flightResult, error) {
+	var p summaryJob
+	if err := json.Unmarshal([]byte(job.Payload), &p); err != nil {
+		return flightResult{}, fmt.Errorf("bad summary job payload: %w", err)
+	}
+	groupID := job.GroupID
+	lang := b.jobLang(ctx, job)
+	settings := b.groupSettings(ctx, groupID)
+
+	messages, err := b.db.GetMessagesByIDs(ctx, groupID, p.MessageIDs)
+	if err != nil {
+		return flightResult{}, err
+	}
+	if len(messages) == 0 {
+		// Retention cleaned the window up while the job waited.
+		b.editWithRetry(ctx, groupID, job.StatusMsgID, i18n.T(lang, i18n.SummarizeNoNew))
+		return flightResult{}, nil
+	}
+
+	logger.Info().Int("count", len(messages)).Msg("Summarizing messages")
+
+	instructions := b.loadGroupSummaryInstructions(ctx, groupID)
+	summary, err := b.summarizeByTopics(ctx, groupID, job.StatusMsgID, messages, settings, instructions, lang)
+	if err != nil {
+		return flightResult{}, fmt.Errorf("summarize: %w", err)
+	}
+
+	var intro string
+	if link := summarizer.MessageLink(groupID, p.Anchor); link != "" {
+		intro = i18n.T(lang, i18n.SinceFrom, link)
+	}
+	summaryID := b.saveSummary(ctx, groupID, summary)
+	if !b.deliverSummary(ctx, groupID, job.StatusMsgID, intro, summary, summaryID) {
+		return flightResult{}, nil
+	}
+	if p.Anchor == 0 {
+		if err := b.db.SetLastSummarizeTime(ctx, groupID, p.Upper); err != nil {
+			logger.Error().Err(err).Msg("failed to set last summarize time")
+		}
+	}
+	return flightResult{summary: summary, summaryID: summaryID, msgID: job.StatusMsgID}, nil
+}
+
+// submitDigestJob queues the group's digest work for p.Now. Jobs are keyed by
+// the scheduler minute (or by "now" for an unscheduled refresh), so a tick is
+// only ever queued once.
+func (b *Bot) submitDigestJob(ctx context.Context, groupID int64, p digestJob) bool {
+	payload, err := json.Marshal(p)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to encode digest job")
+		return false
+	}
+	key := fmt.Sprintf("digest:%d:%s", groupID, p.Now.UTC().Format("2006-01-02T15:04"))
+	if p.Refresh {
+		key = fmt.Sprintf("digest:%d:now", groupID)
+	}
+	return b.submitJob(ctx, db.Job{Kind: jobDigest, GroupID: groupID, Key: key, Payload: string(payload)}, nil)
+}
+
+// runDigestJob posts the group digest first, so subscribers due at the same
+// minute reuse it. Only the post is retried: subscriber DMs are best-effort.
+func (b *Bot) runDigestJob(ctx context.Context, job *db.Job) error {
+	var p digestJob
+	if err := json.Unmarshal([]byte(job.Payload), &p); err != nil {
+		return fmt.Errorf("bad digest job payload: %w", err)
+	}
+	if p.Post {
+		if err := b.runScheduledSummary(ctx, job, p.Now, p.Refresh); err != nil {
+			return err
+		}
+	}
+	if p.Subs {
+		if subs := b.dueSubscriptions(ctx, job.GroupID, p.Now); len(subs) > 0 {
+			b.sendSubscriberDigests(ctx, job.GroupID, subs, p.Now)
+		}
+	}
+	return nil
+}
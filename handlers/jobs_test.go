@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/summarizer"
+)
+
+// waitJobState polls until job id reaches state.
+func waitJobState(t *testing.T, database *db.DB, id int64, state string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if job, err := database.GetJob(context.Background(), id); err == nil && job != nil && job.State == state {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %d never reached state %q", id, state)
+}
+
+// queueSummaryJob stores a summary job over one fresh message, as
+// handleSummarize would, and returns its ID.
+func queueSummaryJob(t *testing.T, database *db.DB, state string, statusMsgID int64) int64 {
+	t.Helper()
+	ctx := context.Background()
+	msg := &db.Message{GroupID: 42, UserHash: "abc123", Text: "решили катить", Timestamp: time.Now().Add(-time.Hour)}
+	if err := database.AddMessage(ctx, msg); err != nil {
+		t.Fatalf("AddMessage error: %v", err)
+	}
+	messages, _ := database.GetMessages(ctx, 42, time.Now().Add(-2*time.Hour), 10)
+	payload, _ := json.Marshal(summaryJob{MessageIDs: []int64{messages[0].ID}, Upper: time.Now()})
+	id, err := database.EnqueueJob(ctx, db.Job{Kind: jobSummary, GroupID: 42, Payload: string(payload), StatusMsgID: statusMsgID, Lang: "ru", State: state})
+	if err != nil {
+		t.Fatalf("EnqueueJob error: %v", err)
+	}
+	return id
+}
+
+func TestHandleSummarizeRunsAsJob(t *testing.T) {
+	sum := &fakeSummarizer{summary: &summarizer.StructuredSummary{TLDR: "итог", Topics: []summarizer.TopicSummary{{Title: "T", Summary: "S"}}}}
+	b, database, tg := newTestBot(t, sum)
+	defer func() { _ = database.Close() }()
+	ctx := context.Background()
+
+	if err := database.AddMessage(ctx, &db.Message{GroupID: 42, UserHash: "abc123", Text: "привет", Timestamp: time.Now().Add(-time.Hour)}); err != nil {
+		t.Fatalf("AddMessage error: %v", err)
+	}
+	b.handleSummarize(ctx, summarizeUpdate(), nil)
+	waitJobState(t, database, 1, db.JobDone)
+
+	if sum.calls != 1 {
+		t.Fatalf("summarizer calls = %d, want the worker to run the summary", sum.calls)
+	}
+	if len(tg.editTexts) == 0 || !strings.Contains(tg.editTexts[len(tg.editTexts)-1], "итог") {
+		t.Fatalf("edits = %q, want the summary in the status message", tg.editTexts)
+	}
+	if last, _ := database.GetLastSummarizeTime(ctx, 42); last == nil {
+		t.Fatal("the job should advance the group's last summarize time")
+	}
+}
+
+func TestStartJobsResumesInterruptedJob(t *testing.T) {
+	sum := &fakeSummarizer{summary: &summarizer.StructuredSummary{TLDR: "итог", Topics: []summarizer.TopicSummary{{Title: "T", Summary: "S"}}}}
+	b, database, tg := newIdleTestBot(t, sum)
+	defer func() { _ = database.Close() }()
+	// The previous process died mid-summary.
+	id := queueSummaryJob(t, database, db.JobRunning, 77)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() { cancel(); b.jobWorkers.Wait() }()
+	b.startJobs(ctx)
+	waitJobState(t, database, id, db.JobDone)
+
+	if len(tg.sentTexts) != 0 {
+		t.Fatalf("sent = %q, want the resumed job to reuse its status message", tg.sentTexts)
+	}
+	if len(tg.editTexts) < 2 || tg.editTexts[0] != i18n.T(i18n.Russian, i18n.JobResumed) || !strings.Contains(tg.editTexts[len(tg.editTexts)-1], "итог") {
+		t.Fatalf("edits = %q, want the resume notice and then the summary", tg.editTexts)
+	}
+}
+
+func TestRunJobRetriesThenFails(t *testing.T) {
+	sum := &fakeSummarizer{err: errors.New("llm down")}
+	b, database, tg := newIdleTestBot(t, sum)
+	defer func() { _ = database.Close() }()
+	b.cfg.JobMaxAttempts = 2
+	ctx := context.Background()
+	id := queueSummaryJob(t, database, db.JobQueued, 77)
+	settled := false
+	b.jobHooks = map[int64]func(flightResult){id: func(r flightResult) { settled = !r.ok() }}
+
+	job, _ := database.ClaimJob(ctx, time.Now())
+	b.runJob(ctx, job)
+	job, _ = database.GetJob(ctx, id)
+	if job.State != db.JobQueued || job.Attempts != 1 || !job.NextRunAt.After(time.Now()) {
+		t.Fatalf("job after a failed run = %+v, want queued for a later retry", job)
+	}
+	if !settled || len(b.jobHooks) != 0 {
+		t.Fatal("the submitter should be settled before the retry pause")
+	}
+	if !strings.HasPrefix(tg.editTexts[len(tg.editTexts)-1], "⚠️") {
+		t.Fatalf("edits = %q, want the retry notice", tg.editTexts)
+	}
+
+	job, _ = database.ClaimJob(ctx, time.Now().Add(time.Hour))
+	b.runJob(ctx, job)
+	job, _ = database.GetJob(ctx, id)
+	if job.State != db.JobFailed || job.LastError == "" {
+		t.Fatalf("job after its last run = %+v, want failed", job)
+	}
+	if got := tg.editTexts[len(tg.editTexts)-1]; got != i18n.T(i18n.Russian, i18n.SummarizeFailed) {
+		t.Fatalf("last edit = %q, want the failure notice", got)
+	}
+}
+
+func TestRunJobRetryTakesFreshTicket(t *testing.T) {
+	sum := &fakeSummarizer{err: errors.New("llm down")}
+	b, database, _ := newIdleTestBot(t, sum)
+	defer func() { _ = database.Close() }()
+	b.cfg.JobMaxAttempts = 3
+	ctx := context.Background()
+	id := queueSummaryJob(t, database, db.JobQueued, 77)
+
+	job, _ := database.ClaimJob(ctx, time.Now())
+	b.runJob(ctx, job)
+	if sum.calls != 1 {
+		t.Fatalf("summarizer calls = %d, want 1", sum.calls)
+	}
+
+	// Someone else spent the group's token during the pause.
+	other, _ := b.rateLimiter.Take(42, "", KindSummary)
+	if other == nil {
+		t.Fatal("the failed run should have given its ticket back")
+	}
+	job, _ = database.ClaimJob(ctx, time.Now().Add(time.Hour))
+	b.runJob(ctx, job)
+	job, _ = database.GetJob(ctx, id)
+	if sum.calls != 1 || job.State != db.JobQueued || job.Attempts != 1 || !job.NextRunAt.After(time.Now()) {
+		t.Fatalf("calls = %d, job = %+v; want the retry postponed until a ticket frees up", sum.calls, job)
+	}
+
+	other.Release()
+	job, _ = database.ClaimJob(ctx, time.Now().Add(2*time.Hour))
+	b.runJob(ctx, job)
+	if job, _ = database.GetJob(ctx, id); sum.calls != 2 || job.Attempts != 2 {
+		t.Fatalf("calls = %d, job = %+v; want the retry to run", sum.calls, job)
+	}
+	// The retry failed too, so its ticket went back as well.
+	if ticket, _ := b.rateLimiter.Take(42, "", KindSummary); ticket == nil {
+		t.Fatal("a failed retry should give its ticket back")
+	}
+}
+
+func TestRunJobCheckpointsOnShutdown(t *testing.T) {
+	b, database, tg := newIdleTestBot(t, &fakeSummarizer{err: context.Canceled})
+	defer func() { _ = database.Close() }()
+	id := queueSummaryJob(t, database, db.JobQueued, 77)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job, _ := database.ClaimJob(ctx, time.Now())
+	cancel()
+	landed := false
+	b.jobHooks = map[int64]func(flightResult){id: func(flightResult) { landed = true }}
+	b.runJob(ctx, job)
+
+	job, _ = database.GetJob(context.Background(), id)
+	if job.State != db.JobQueued || !job.Resumed || job.Attempts != 0 {
+		t.Fatalf("job after shutdown = %+v, want checkpointed for resume", job)
+	}
+	if len(tg.editTexts) != 0 {
+		t.Fatalf("edits = %q, want the status left for the resumed job", tg.editTexts)
+	}
+	if !landed {
+		t.Fatal("the submitter should be released when the job is checkpointed")
+	}
+}
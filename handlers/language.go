@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"context"
+
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/logger"
+
+	"github.com/mymmrac/telego"
+)
+
+// groupLanguage returns the group's summary language, falling back to the
+// configured default when the group has none (or on a lookup error). The
+// result may be i18n.Auto; callers resolve it against the material at hand.
+func (b *Bot) groupLanguage(ctx context.Context, groupID int64) i18n.Lang {
+	raw, err := b.db.GetGroupLanguage(ctx, groupID)
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to get group language")
+	}
+	if lang, ok := i18n.Parse(raw); ok {
+		return lang
+	}
+	return b.cfg.Language.OrDefault()
+}
+
+// handleLanguage shows the group's summary language, or sets it when a group
+// admin passes ru, en or auto.
+func (b *Bot) handleLanguage(ctx context.Context, update telego.Update, args []string) {
+	msg := update.Message
+	groupID := msg.Chat.ID
+	lang := b.groupLanguage(ctx, groupID)
+
+	if len(args) == 0 {
+		b.sendMessage(ctx, groupID, i18n.T(lang, i18n.LanguageCurrent, lang))
+		return
+	}
+
+	if !b.isGroupAdmin(ctx, groupID, msg.From.ID) {
+		b.sendMessage(ctx, groupID, i18n.T(lang, i18n.LanguageAdminsOnly))
+		return
+	}
+
+	newLang, ok := i18n.Parse(args[0])
+	if !ok {
+		b.sendMessage(ctx, groupID, i18n.T(lang, i18n.LanguageBadValue))
+		return
+	}
+	if err := b.db.SetGroupLanguage(ctx, groupID, msg.From.ID, string(newLang)); err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to set group language")
+		b.sendMessage(ctx, groupID, i18n.T(lang, i18n.LanguageSaveError))
+		return
+	}
+	b.sendMessage(ctx, groupID, i18n.T(newLang, i18n.LanguageSet, newLang))
+}
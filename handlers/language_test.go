@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/summarizer"
+
+	"github.com/mymmrac/telego"
+)
+
+func languageUpdate(text string) telego.Update {
+	return telego.Update{
+		Message: &telego.Message{
+			Text: text,
+			Chat: telego.Chat{ID: 42, Type: "group"},
+			From: &telego.User{ID: 7, Username: "alice"},
+		},
+	}
+}
+
+func TestHandleLanguage(t *testing.T) {
+	sum := &fakeSummarizer{
+		summary: &summarizer.StructuredSummary{
+			TLDR:   "Agreed to ship.",
+			Topics: []summarizer.TopicSummary{{Title: "Release", Summary: "Ship tonight."}},
+			Lang:   i18n.English,
+		},
+	}
+	b, database, tg := newTestBot(t, sum)
+	defer func() { _ = database.Close() }()
+	ctx := context.Background()
+
+	b.handleCommand(ctx, languageUpdate("@testbot language"), "language")
+	if len(tg.sentTexts) != 1 || !strings.Contains(tg.sentTexts[0], "Язык сводок: ru") {
+		t.Fatalf("unexpected current-language reply: %q", tg.sentTexts)
+	}
+
+	b.handleCommand(ctx, languageUpdate("@testbot language de"), "language de")
+	if got := tg.sentTexts[len(tg.sentTexts)-1]; got != i18n.T(i18n.Russian, i18n.LanguageBadValue) {
+		t.Fatalf("bad value reply = %q", got)
+	}
+
+	b.handleCommand(ctx, languageUpdate("@testbot language EN"), "language EN")
+	if got := tg.sentTexts[len(tg.sentTexts)-1]; got != i18n.T(i18n.English, i18n.LanguageSet, i18n.English) {
+		t.Fatalf("set reply = %q, want English confirmation", got)
+	}
+	stored, err := database.GetGroupLanguage(ctx, 42)
+	if err != nil || stored != "en" {
+		t.Fatalf("stored language = %q, %v; want en", stored, err)
+	}
+
+	if err := database.AddMessage(ctx, &db.Message{
+		GroupID:   42,
+		UserHash:  "a3f2b1c4",
+		Text:      "Надо катить сегодня",
+		Timestamp: time.Now().Add(-time.Hour),
+	}); err != nil {
+		t.Fatalf("AddMessage error: %v", err)
+	}
+	b.handleSummarize(ctx, summarizeUpdate(), nil)
+	waitJobs(t, b)
+	if sum.lang != i18n.English {
+		t.Fatalf("summary language = %q, want en", sum.lang)
+	}
+	if last := tg.editTexts[len(tg.editTexts)-1]; !strings.Contains(last, "Summary") {
+		t.Fatalf("summary should use English labels, got %q", last)
+	}
+}
+
+func TestGroupLanguageFallsBackToConfig(t *testing.T) {
+	b, database, _ := newTestBot(t, &fakeSummarizer{})
+	defer func() { _ = database.Close() }()
+
+	if got := b.groupLanguage(context.Background(), 42); got != i18n.Russian {
+		t.Fatalf("unset language with empty config = %q, want ru", got)
+	}
+	b.cfg.Language = i18n.Auto
+	if got := b.groupLanguage(context.Background(), 42); got != i18n.Auto {
+		t.Fatalf("unset language = %q, want config default auto", got)
+	}
+}
@@ -17,6 +17,17 @@ const metricsRetention = 30 * 24 * time.Hour
 // window so trends remain visible without unbounded growth.
 const tokenUsageRetention = 90 * 24 * time.Hour
 
+// dailyDigestRetention keeps shared daily digests only as long as a job due
+// at their minute could still resume and deliver them.
+const dailyDigestRetention = 48 * time.Hour
+
+// summaryRetention keeps posted summaries and their votes long enough for the
+// quality report's trends; their topic clusters go with the messages.
+const summaryRetention = 90 * 24 * time.Hour
+
+// jobRetention keeps finished jobs around for debugging a failed delivery.
+const jobRetention = 7 * 24 * time.Hour
+
 func (b *Bot) statsCacheLoop(ctx context.Context) {
 	ticker := time.NewTicker(time.Minute)
 	defer ticker.Stop()
@@ -58,6 +69,7 @@ func (b *Bot) refreshStatsCache(ctx context.Context) {
 	summarizeOK, _ := b.db.CountBotEvents(ctx, "llm_summarize", since)
 	summarizeFail, _ := b.db.CountErrors(ctx, since, "llm_cluster", "llm_summarize")
 	rateLimitHits, _ := b.db.CountBotEvents(ctx, "rate_limit", since)
+	parseRetries, _ := b.db.CountBotEvents(ctx, "llm_parse_retry", since)
 	errorCounts, _ := b.db.QueryErrorCounts(ctx, since)
 	if errorCounts == nil {
 		errorCounts = make(map[string]int64)
@@ -68,6 +80,7 @@ func (b *Bot) refreshStatsCache(ctx context.Context) {
 		SummarizeOK:    summarizeOK,
 		SummarizeFail:  summarizeFail,
 		RateLimitHits:  rateLimitHits,
+		ParseRetries:   parseRetries,
 		ErrorCounts:    errorCounts,
 	})
 }
@@ -103,11 +116,47 @@ func (b *Bot) cleanupLoop(ctx context.Context) {
 			} else if purged > 0 {
 				logger.Info().Int64("purged", purged).Msg("purged old image descriptions")
 			}
+			if purged, err := b.db.CleanupOldCatchups(ctx, b.cfg.RetentionDuration()); err != nil {
+				logger.Error().Err(err).Msg("failed to purge old catchups")
+			} else if purged > 0 {
+				logger.Info().Int64("purged", purged).Msg("purged old catchups")
+			}
+			if purged, err := b.db.CleanupOldSummaryTopics(ctx, b.cfg.RetentionDuration()); err != nil {
+				logger.Error().Err(err).Msg("failed to purge old summary topics")
+			} else if purged > 0 {
+				logger.Info().Int64("purged", purged).Msg("purged old summary topics")
+			}
+			if purged, err := b.db.CleanupOldSummaryItems(ctx, b.cfg.RetentionDuration()); err != nil {
+				logger.Error().Err(err).Msg("failed to purge old summary items")
+			} else if purged > 0 {
+				logger.Info().Int64("purged", purged).Msg("purged old summary items")
+			}
+			if purged, err := b.db.CleanupOldSummaryEvents(ctx, b.cfg.RetentionDuration()); err != nil {
+				logger.Error().Err(err).Msg("failed to purge old summary events")
+			} else if purged > 0 {
+				logger.Info().Int64("purged", purged).Msg("purged old summary events")
+			}
+			if purged, err := b.db.CleanupOldSummaries(ctx, summaryRetention); err != nil {
+				logger.Error().Err(err).Msg("failed to purge old summaries")
+			} else if purged > 0 {
+				logger.Info().Int64("purged", purged).Msg("purged old summaries")
+			}
+			if purged, err := b.db.CleanupOldDailyDigests(ctx, dailyDigestRetention); err != nil {
+				logger.Error().Err(err).Msg("failed to purge old daily digests")
+			} else if purged > 0 {
+				logger.Info().Int64("purged", purged).Msg("purged old daily digests")
+			}
+			if purged, err := b.db.CleanupOldJobs(ctx, jobRetention); err != nil {
+				logger.Error().Err(err).Msg("failed to purge old jobs")
+			} else if purged > 0 {
+				logger.Info().Int64("purged", purged).Msg("purged old jobs")
+			}
 			if purged, err := b.db.PurgeOldTokenUsage(ctx, time.Now().Add(-tokenUsageRetention)); err != nil {
 				logger.Error().Err(err).Msg("failed to purge old token usage")
 			} else if purged > 0 {
 				logger.Info().Int64("purged", purged).Msg("purged old token usage")
 			}
+			b.maintainSalts(ctx)
 		}
 	}
 }
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"telegram_summarize_bot/config"
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/summarizer"
+)
+
+// progressEditInterval is the least time between two progress edits of a
+// status message. Telegram throttles bots that edit a chat's messages more
+// than about once a second, and a throttled edit would delay the summary.
+const progressEditInterval = 3 * time.Second
+
+// progressEditor shows a running summary's progress in its status message.
+// Reports only store the latest text; a single goroutine edits it in, at most
+// once per progressEditInterval, so slow or throttled edits never hold up the
+// summary and reports in between are coalesced.
+type progressEditor struct {
+	b             *Bot
+	chatID, msgID int64
+	lang          i18n.Lang
+	tldr          bool
+
+	mu   sync.Mutex
+	text string
+
+	wake   chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (b *Bot) startProgress(ctx context.Context, chatID, msgID int64, lang i18n.Lang) *progressEditor {
+	ctx, cancel := context.WithCancel(ctx)
+	p := &progressEditor{
+		b:      b,
+		chatID: chatID,
+		msgID:  msgID,
+		lang:   lang,
+		tldr:   b.cfg.StreamTLDR,
+		wake:   make(chan struct{}, 1),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go p.run(ctx)
+	return p
+}
+
+// report queues sp's text for the status message. It never blocks.
+func (p *progressEditor) report(sp summarizer.Progress) {
+	text := progressText(p.lang, sp, p.tldr)
+	p.mu.Lock()
+	p.text = text
+	p.mu.Unlock()
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// stop ends the progress edits, waiting out one in flight, so the caller's
+// final edit of the status message can't be overwritten by a late one.
+func (p *progressEditor) stop() {
+	p.cancel()
+	<-p.done
+}
+
+func (p *progressEditor) run(ctx context.Context) {
+	defer close(p.done)
+	var shown string
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.wake:
+		}
+		p.mu.Lock()
+		text := p.text
+		p.mu.Unlock()
+		if text == shown {
+			continue
+		}
+		p.b.editWithRetry(ctx, p.chatID, p.msgID, text)
+		shown = text
+		if !sleepCtx(ctx, progressEditInterval) {
+			return
+		}
+	}
+}
+
+// progressText is the status message for sp, with the TL;DR written so far
+// when tldr is set.
+func progressText(lang i18n.Lang, sp summarizer.Progress, tldr bool) string {
+	switch sp.Stage {
+	case summarizer.StageImages:
+		return i18n.T(lang, i18n.ProgressImages, sp.Done, sp.Total)
+	case summarizer.StageClustering:
+		return i18n.T(lang, i18n.ProgressClustering)
+	case summarizer.StageExtracting:
+		return i18n.T(lang, i18n.ProgressExtracting)
+	case summarizer.StageEvents:
+		return i18n.T(lang, i18n.ProgressEvents)
+	}
+	// Done counts finished topics; the one being written is the next.
+	text := i18n.T(lang, i18n.ProgressSummarizing, min(sp.Done+1, sp.Total), sp.Total)
+	if tldr && sp.TLDR != "" {
+		text += "\n\n" + sp.TLDR
+	}
+	return text
+}
+
+// summarizeByTopics summarizes a group's messages with its settings, showing
+// progress in the status message msgID of chatID (none when msgID is 0).
+func (b *Bot) summarizeByTopics(ctx context.Context, chatID, msgID int64, messages []db.Message, settings config.GroupSettings, instructions string, lang i18n.Lang) (*summarizer.StructuredSummary, error) {
+	var loc *time.Location
+	if len(messages) > 0 {
+		loc = b.groupTimezone(ctx, messages[0].GroupID)
+	}
+	ctx = summarizer.WithGroupSettings(ctx, settings, loc)
+	if msgID != 0 {
+		progress := b.startProgress(ctx, chatID, msgID, lang)
+		defer progress.stop()
+		ctx = summarizer.WithProgress(ctx, progress.report)
+	}
+	return b.summarizer.SummarizeByTopics(ctx, messages, settings.TopicMax, instructions, lang)
+}
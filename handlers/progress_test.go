@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/summarizer"
+)
+
+func TestProgressEditorThrottlesAndStops(t *testing.T) {
+	b, database, tg := newTestBot(t, &fakeSummarizer{})
+	defer func() { _ = database.Close() }()
+
+	p := b.startProgress(context.Background(), 42, 7, i18n.English)
+	p.report(summarizer.Progress{Stage: summarizer.StageImages, Total: 2})
+	deadline := time.Now().Add(5 * time.Second)
+	for tg.editCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	// Within progressEditInterval of the first edit: held back, then dropped
+	// by stop so they can't land on top of the final text.
+	p.report(summarizer.Progress{Stage: summarizer.StageImages, Done: 2, Total: 2})
+	p.report(summarizer.Progress{Stage: summarizer.StageClustering})
+	p.stop()
+
+	want := i18n.T(i18n.English, i18n.ProgressImages, 0, 2)
+	if len(tg.editTexts) != 1 || tg.editTexts[0] != want {
+		t.Fatalf("edits = %q, want just %q", tg.editTexts, want)
+	}
+}
+
+func TestProgressText(t *testing.T) {
+	sp := summarizer.Progress{Stage: summarizer.StageSummarizing, Done: 1, Total: 3, TLDR: "Shipped"}
+	if got, want := progressText(i18n.English, sp, false), "✍️ Writing the summary: topic 2 of 3..."; got != want {
+		t.Fatalf("progressText = %q, want %q", got, want)
+	}
+	if got, want := progressText(i18n.English, sp, true), "✍️ Writing the summary: topic 2 of 3...\n\nShipped"; got != want {
+		t.Fatalf("progressText with TL;DR = %q, want %q", got, want)
+	}
+	sp.Done = 3
+	if got, want := progressText(i18n.English, sp, false), "✍️ Writing the summary: topic 3 of 3..."; got != want {
+		t.Fatalf("progressText when done = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/tgutil"
+
+	"github.com/mymmrac/telego"
+)
+
+// requesterHash is the per-group hash of the user who sent msg, or "" when
+// it has no user sender (e.g. an anonymous admin).
+func (b *Bot) requesterHash(groupID int64, msg *telego.Message) string {
+	if msg == nil || msg.From == nil {
+		return ""
+	}
+	return db.UserHash(msg.From.ID, groupID, b.currentSalt())
+}
+
+// admit charges a request to its group's and user's buckets. When they can't
+// pay, the request is refused with the wait, posted through send — or, in
+// queue mode, waits its turn behind a "queued" status posted the same way.
+// It returns nil when the request was refused or cancelled, and the queued
+// status message (0 if none) for the caller to reuse.
+func (b *Bot) admit(ctx context.Context, groupID int64, userHash string, kind RequestKind, lang i18n.Lang, send func(text string) int64) (*Ticket, int64) {
+	ticket, wait := b.rateLimiter.Take(groupID, userHash, kind)
+	if ticket != nil {
+		return ticket, 0
+	}
+	b.metrics.RateLimit.Record(0)
+
+	maxWait := time.Duration(b.cfg.RateLimitQueueMaxSec) * time.Second
+	if !b.cfg.RateLimitQueue || wait > maxWait {
+		send(i18n.T(lang, i18n.RateLimitWait, tgutil.FormatDuration(lang, wait)))
+		return nil, 0
+	}
+
+	statusMsgID := send(i18n.T(lang, i18n.RateLimitQueued, tgutil.FormatDuration(lang, wait)))
+	ticket, wait, err := b.rateLimiter.Wait(ctx, groupID, userHash, kind, maxWait)
+	if ticket == nil {
+		// Other requests kept taking the tokens until the queue wait ran out.
+		if err == nil && statusMsgID != 0 {
+			b.editWithRetry(ctx, groupID, statusMsgID, i18n.T(lang, i18n.RateLimitWait, tgutil.FormatDuration(lang, wait)))
+		}
+		return nil, 0
+	}
+	return ticket, statusMsgID
+}
+
+// showStatus puts text in the queued status message when there is one, and
+// otherwise posts it through send. Returns the status message ID.
+func (b *Bot) showStatus(ctx context.Context, chatID, queuedMsgID int64, text string, send func(text string) int64) int64 {
+	if queuedMsgID == 0 {
+		return send(text)
+	}
+	b.editWithRetry(ctx, chatID, queuedMsgID, text)
+	return queuedMsgID
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/summarizer"
+)
+
+func TestHandleSummarizeQueuesInsteadOfRefusing(t *testing.T) {
+	sum := &fakeSummarizer{summary: &summarizer.StructuredSummary{TLDR: "итог", Topics: []summarizer.TopicSummary{{Title: "T", Summary: "S"}}}}
+	b, database, tg := newTestBot(t, sum)
+	defer func() { _ = database.Close() }()
+	b.cfg.RateLimitQueue = true
+	b.cfg.RateLimitQueueMaxSec = 5
+	b.rateLimiter = NewRateLimiter(60).WithLimitFunc(func(int64) time.Duration { return 30 * time.Millisecond }).WithUserLimit(0, 1)
+	ctx := context.Background()
+
+	add := func(text string) {
+		if err := database.AddMessage(ctx, &db.Message{GroupID: 42, UserHash: "abc123", Text: text, Timestamp: time.Now()}); err != nil {
+			t.Fatalf("AddMessage error: %v", err)
+		}
+	}
+	add("первое")
+	b.handleSummarize(ctx, summarizeUpdate(), nil)
+	waitJobs(t, b)
+	add("второе")
+
+	tg.sentTexts = nil
+	b.handleSummarize(ctx, summarizeUpdate(), nil)
+	waitJobs(t, b)
+	if sum.calls != 2 {
+		t.Fatalf("summarizer calls = %d, want the queued request to run", sum.calls)
+	}
+	if len(tg.sentTexts) != 1 || !strings.HasPrefix(tg.sentTexts[0], "⏳") {
+		t.Fatalf("sent = %q, want only the queued status", tg.sentTexts)
+	}
+	if got := tg.editTexts[len(tg.editTexts)-2]; got != i18n.T(i18n.Russian, i18n.SummarizeCollecting, 24) {
+		t.Fatalf("queued status should become the progress message, got %q", got)
+	}
+}
+
+func TestHandleSummarizeQueueLimit(t *testing.T) {
+	sum := &fakeSummarizer{summary: &summarizer.StructuredSummary{TLDR: "итог"}}
+	b, database, tg := newTestBot(t, sum)
+	defer func() { _ = database.Close() }()
+	b.cfg.RateLimitQueue = true
+	b.cfg.RateLimitQueueMaxSec = 10 // shorter than the 60s wait
+	ctx := context.Background()
+
+	if err := database.AddMessage(ctx, &db.Message{GroupID: 42, UserHash: "abc123", Text: "привет", Timestamp: time.Now().Add(-time.Hour)}); err != nil {
+		t.Fatalf("AddMessage error: %v", err)
+	}
+	b.rateLimiter.Take(42, "", KindSummary)
+
+	b.handleSummarize(ctx, summarizeUpdate(), nil)
+	waitJobs(t, b)
+	if sum.calls != 0 {
+		t.Fatal("a wait beyond RATE_LIMIT_QUEUE_MAX_SEC should be refused, not queued")
+	}
+	if len(tg.sentTexts) != 1 || !strings.Contains(tg.sentTexts[0], "Подождите") {
+		t.Fatalf("sent = %q, want the wait message", tg.sentTexts)
+	}
+}
@@ -1,81 +1,271 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
+	"math"
 	"sync"
 	"time"
 
 	"telegram_summarize_bot/logger"
 )
 
+// RequestKind selects what a rate-limited request costs.
+type RequestKind int
+
+const (
+	KindSummary RequestKind = iota // full group summary or catch-up
+	KindReply                      // reply summary of text, images or a thread
+	KindURL                        // reply summary that fetches links
+)
+
+// RateLimiter is a token-bucket limiter with a bucket per group and one per
+// user hash (user hashes are per group, so a member's bucket is too). A bucket
+// holds up to its burst of tokens and refills one token per interval; a
+// request takes its kind's cost from every bucket it is charged to, or from
+// none.
+//
+// The group interval is the global limit unless a limit func (see
+// WithLimitFunc) resolves a per-group one; a group with a zero interval is not
+// limited at all, per-user buckets included. Costs above a bucket's burst are
+// capped at it, so every request can eventually run.
 type RateLimiter struct {
-	entries map[string]time.Time
-	mu      sync.RWMutex
-	limit   time.Duration
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	limit     time.Duration
+	limitFn   func(groupID int64) time.Duration
+	burst     int
+	userLimit time.Duration
+	userBurst int
+	costs     map[RequestKind]int
+	now       func() time.Time
+}
+
+type tokenBucket struct {
+	tokens   float64
+	updated  time.Time
+	interval time.Duration
+	burst    int
+}
+
+// refill brings b up to now under the given parameters.
+func (b *tokenBucket) refill(now time.Time, interval time.Duration, burst int) {
+	b.interval, b.burst = interval, burst
+	if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens = math.Min(float64(burst), b.tokens+float64(elapsed)/float64(interval))
+	}
+	b.updated = now
+}
+
+// wait is how long until b holds cost tokens.
+func (b *tokenBucket) wait(cost int) time.Duration {
+	missing := float64(cost) - b.tokens
+	if missing <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(missing * float64(b.interval)))
 }
 
+// NewRateLimiter returns a limiter allowing one request per limitSeconds,
+// per group and per user, with every request costing one token.
 func NewRateLimiter(limitSeconds int) *RateLimiter {
+	limit := time.Duration(limitSeconds) * time.Second
 	return &RateLimiter{
-		entries: make(map[string]time.Time),
-		limit:   time.Duration(limitSeconds) * time.Second,
+		buckets:   make(map[string]*tokenBucket),
+		limit:     limit,
+		burst:     1,
+		userLimit: limit,
+		userBurst: 1,
+		costs:     map[RequestKind]int{KindSummary: 1, KindReply: 1, KindURL: 1},
+		now:       time.Now,
 	}
 }
 
-func (r *RateLimiter) Allow(groupID int64) bool {
-	key := r.key(groupID)
+// WithLimitFunc sets a resolver for per-group intervals, used instead of the
+// global limit. It is called outside the limiter's lock. Returns r for
+// chaining.
+func (r *RateLimiter) WithLimitFunc(fn func(groupID int64) time.Duration) *RateLimiter {
+	r.limitFn = fn
+	return r
+}
+
+// WithBurst sets how many tokens a group bucket holds. Returns r for chaining.
+func (r *RateLimiter) WithBurst(burst int) *RateLimiter {
+	r.burst = max(burst, 1)
+	return r
+}
+
+// WithUserLimit sets the per-user refill interval and burst; a zero interval
+// disables per-user buckets. Returns r for chaining.
+func (r *RateLimiter) WithUserLimit(limitSeconds, burst int) *RateLimiter {
+	r.userLimit = time.Duration(limitSeconds) * time.Second
+	r.userBurst = max(burst, 1)
+	return r
+}
+
+// WithCosts sets the tokens each kind of request takes. Returns r for
+// chaining.
+func (r *RateLimiter) WithCosts(summary, reply, url int) *RateLimiter {
+	r.costs = map[RequestKind]int{KindSummary: max(summary, 1), KindReply: max(reply, 1), KindURL: max(url, 1)}
+	return r
+}
+
+func (r *RateLimiter) limitFor(groupID int64) time.Duration {
+	if r.limitFn != nil {
+		return r.limitFn(groupID)
+	}
+	return r.limit
+}
+
+// Ticket is an admitted request's charge. Release refunds it, for requests
+// that fail before producing anything.
+type Ticket struct {
+	r       *RateLimiter
+	charges map[string]int // bucket key => tokens taken
+	once    sync.Once
+}
+
+// Release returns the ticket's tokens to their buckets. It is safe to call on
+// a nil ticket and more than once.
+func (t *Ticket) Release() {
+	if t == nil {
+		return
+	}
+	t.once.Do(func() {
+		t.r.mu.Lock()
+		defer t.r.mu.Unlock()
+		for key, cost := range t.charges {
+			if b, ok := t.r.buckets[key]; ok {
+				b.tokens = math.Min(float64(b.burst), b.tokens+float64(cost))
+			}
+		}
+	})
+}
+
+// bucketCharge is one bucket a request is charged to.
+type bucketCharge struct {
+	key      string
+	interval time.Duration
+	burst    int
+}
+
+// Take charges a kind request by userHash (empty for none) to the group's
+// and the user's buckets. When either can't pay it returns a nil ticket and
+// how long until both can.
+func (r *RateLimiter) Take(groupID int64, userHash string, kind RequestKind) (*Ticket, time.Duration) {
+	return r.take(groupID, userHash, r.costs[kind], true)
+}
+
+// TakeUser is Take without the group's bucket, for requests that serve only
+// the user (catch-ups, topic expansions) and shouldn't hold up the group.
+func (r *RateLimiter) TakeUser(groupID int64, userHash string, kind RequestKind) (*Ticket, time.Duration) {
+	return r.take(groupID, userHash, r.costs[kind], false)
+}
+
+func (r *RateLimiter) take(groupID int64, userHash string, cost int, chargeGroup bool) (*Ticket, time.Duration) {
+	groupLimit := r.limitFor(groupID)
+	ticket := &Ticket{r: r, charges: make(map[string]int)}
+	if groupLimit <= 0 {
+		return ticket, 0
+	}
+	var charges []bucketCharge
+	if chargeGroup {
+		charges = append(charges, bucketCharge{key: groupKey(groupID), interval: groupLimit, burst: r.burst})
+	}
+	if userHash != "" && r.userLimit > 0 {
+		charges = append(charges, bucketCharge{key: userKey(groupID, userHash), interval: r.userLimit, burst: r.userBurst})
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	now := time.Now()
-	if lastTime, exists := r.entries[key]; exists {
-		if now.Sub(lastTime) < r.limit {
-			remaining := r.limit - now.Sub(lastTime)
-			logger.Info().
-				Int64("group_id", groupID).
-				Dur("remaining", remaining).
-				Msg("rate limited")
-			return false
+	now := r.now()
+	var wait time.Duration
+	for _, c := range charges {
+		b := r.bucket(c, now)
+		wait = max(wait, b.wait(min(cost, c.burst)))
+	}
+	if wait > 0 {
+		logger.Info().
+			Int64("group_id", groupID).
+			Dur("remaining", wait).
+			Msg("rate limited")
+		return nil, wait
+	}
+	for _, c := range charges {
+		r.buckets[c.key].tokens -= float64(min(cost, c.burst))
+		ticket.charges[c.key] = min(cost, c.burst)
+	}
+	return ticket, 0
+}
+
+// bucket returns c's bucket refilled to now, creating a full one if needed.
+// Called with r.mu held.
+func (r *RateLimiter) bucket(c bucketCharge, now time.Time) *tokenBucket {
+	b, ok := r.buckets[c.key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(c.burst), updated: now}
+		r.buckets[c.key] = b
+	}
+	b.refill(now, c.interval, c.burst)
+	return b
+}
+
+// Wait is Take that waits its turn for up to maxWait instead of failing. It
+// gives up early, returning the last wait, when the buckets can't pay within
+// what is left of maxWait.
+func (r *RateLimiter) Wait(ctx context.Context, groupID int64, userHash string, kind RequestKind, maxWait time.Duration) (*Ticket, time.Duration, error) {
+	deadline := r.now().Add(maxWait)
+	for {
+		ticket, wait := r.Take(groupID, userHash, kind)
+		if ticket != nil {
+			return ticket, 0, nil
+		}
+		if r.now().Add(wait).After(deadline) {
+			return nil, wait, nil
+		}
+		if !sleepCtx(ctx, wait) {
+			return nil, wait, ctx.Err()
 		}
 	}
+}
 
-	r.entries[key] = now
-	return true
+// Allow charges one token to id's group bucket. It serves the admin DM
+// commands, which are limited per admin chat.
+func (r *RateLimiter) Allow(id int64) bool {
+	ticket, _ := r.take(id, "", 1, true)
+	return ticket != nil
 }
 
-func (r *RateLimiter) Release(groupID int64) {
-	key := r.key(groupID)
+// RemainingTime is how long until id's group bucket holds a token.
+func (r *RateLimiter) RemainingTime(id int64) time.Duration {
+	limit := r.limitFor(id)
+	if limit <= 0 {
+		return 0
+	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	delete(r.entries, key)
+	return r.bucket(bucketCharge{key: groupKey(id), interval: limit, burst: r.burst}, r.now()).wait(1)
 }
 
+// ClearOldEntries drops buckets that have refilled to their burst; a new full
+// bucket replaces them on demand.
 func (r *RateLimiter) ClearOldEntries() {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	cutoff := time.Now().Add(-r.limit * 2)
-	for key, t := range r.entries {
-		if t.Before(cutoff) {
-			delete(r.entries, key)
+	now := r.now()
+	for key, b := range r.buckets {
+		if b.interval <= 0 || b.tokens+float64(now.Sub(b.updated))/float64(b.interval) >= float64(b.burst) {
+			delete(r.buckets, key)
 		}
 	}
 }
 
-func (r *RateLimiter) RemainingTime(groupID int64) time.Duration {
-	key := r.key(groupID)
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	now := time.Now()
-	if lastTime, exists := r.entries[key]; exists {
-		remaining := r.limit - now.Sub(lastTime)
-		if remaining > 0 {
-			return remaining
-		}
-	}
-	return 0
+func groupKey(groupID int64) string {
+	return fmt.Sprintf("g:%d", groupID)
 }
 
-func (r *RateLimiter) key(groupID int64) string {
-	return fmt.Sprintf("%d", groupID)
+func userKey(groupID int64, userHash string) string {
+	return fmt.Sprintf("u:%d:%s", groupID, userHash)
 }
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// clockedLimiter returns a limiter on a fake clock advanced by the returned
+// func.
+func clockedLimiter(limitSeconds int) (*RateLimiter, func(time.Duration)) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	rl := NewRateLimiter(limitSeconds)
+	rl.now = func() time.Time { return now }
+	return rl, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestRateLimiterBurstAndRefill(t *testing.T) {
+	rl, advance := clockedLimiter(60)
+	rl.WithBurst(2).WithUserLimit(0, 1)
+
+	for i := 0; i < 2; i++ {
+		if ticket, _ := rl.Take(42, "", KindSummary); ticket == nil {
+			t.Fatalf("request %d should fit the burst", i+1)
+		}
+	}
+	ticket, wait := rl.Take(42, "", KindSummary)
+	if ticket != nil || wait != time.Minute {
+		t.Fatalf("third request: ticket %v, wait %v; want refused for a minute", ticket, wait)
+	}
+
+	advance(30 * time.Second)
+	if _, wait := rl.Take(42, "", KindSummary); wait != 30*time.Second {
+		t.Fatalf("wait after half an interval = %v, want 30s", wait)
+	}
+	advance(30 * time.Second)
+	if ticket, _ := rl.Take(42, "", KindSummary); ticket == nil {
+		t.Fatal("a token should have refilled after the interval")
+	}
+}
+
+func TestRateLimiterPerUserBuckets(t *testing.T) {
+	rl, _ := clockedLimiter(60)
+	rl.WithBurst(3).WithUserLimit(600, 1)
+
+	if ticket, _ := rl.Take(42, "alice", KindSummary); ticket == nil {
+		t.Fatal("alice's first request should pass")
+	}
+	ticket, wait := rl.Take(42, "alice", KindSummary)
+	if ticket != nil || wait != 10*time.Minute {
+		t.Fatalf("alice's second request: ticket %v, wait %v; want her own bucket to refuse it", ticket, wait)
+	}
+	if ticket, _ := rl.Take(42, "bob", KindSummary); ticket == nil {
+		t.Fatal("bob should not be locked out by alice")
+	}
+	if ticket, _ := rl.Take(43, "alice", KindSummary); ticket == nil {
+		t.Fatal("user buckets are per group")
+	}
+}
+
+func TestRateLimiterCostsAndRelease(t *testing.T) {
+	rl, _ := clockedLimiter(60)
+	rl.WithBurst(3).WithUserLimit(0, 1).WithCosts(3, 1, 2)
+
+	url, _ := rl.Take(42, "", KindURL)
+	if url == nil {
+		t.Fatal("a URL summary should fit the burst")
+	}
+	if ticket, _ := rl.Take(42, "", KindSummary); ticket != nil {
+		t.Fatal("a full summary should not fit the one token left")
+	}
+	if ticket, _ := rl.Take(42, "", KindReply); ticket == nil {
+		t.Fatal("a reply summary should take the last token")
+	}
+
+	url.Release()
+	url.Release()
+	if ticket, _ := rl.Take(42, "", KindURL); ticket == nil {
+		t.Fatal("a released ticket should refund its tokens")
+	}
+	if ticket, _ := rl.Take(42, "", KindReply); ticket != nil {
+		t.Fatal("releasing twice should refund only once")
+	}
+}
+
+func TestRateLimiterCostCappedAtBurst(t *testing.T) {
+	rl, advance := clockedLimiter(60)
+	rl.WithCosts(5, 1, 1)
+
+	if ticket, _ := rl.Take(42, "alice", KindSummary); ticket == nil {
+		t.Fatal("a cost above the burst should take the whole bucket, not block forever")
+	}
+	advance(time.Minute)
+	if ticket, _ := rl.Take(42, "alice", KindSummary); ticket == nil {
+		t.Fatal("a full bucket should admit the capped cost again")
+	}
+}
+
+func TestRateLimiterZeroGroupLimit(t *testing.T) {
+	rl := NewRateLimiter(60).WithLimitFunc(func(int64) time.Duration { return 0 })
+	for i := 0; i < 3; i++ {
+		if ticket, _ := rl.Take(42, "alice", KindSummary); ticket == nil {
+			t.Fatal("a group with a zero limit should not be limited, users included")
+		}
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	rl := NewRateLimiter(60).WithLimitFunc(func(int64) time.Duration { return 20 * time.Millisecond }).WithUserLimit(0, 1)
+	ctx := context.Background()
+
+	if ticket, _ := rl.Take(42, "", KindSummary); ticket == nil {
+		t.Fatal("first request should pass")
+	}
+	start := time.Now()
+	ticket, _, err := rl.Wait(ctx, 42, "", KindSummary, time.Second)
+	if err != nil || ticket == nil {
+		t.Fatalf("Wait = %v, %v; want a ticket once the bucket refills", ticket, err)
+	}
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Fatalf("Wait returned after %v; should have waited for the refill", elapsed)
+	}
+
+	if ticket, wait, err := rl.Wait(ctx, 42, "", KindSummary, time.Millisecond); ticket != nil || err != nil || wait <= 0 {
+		t.Fatalf("Wait past maxWait = %v, %v, %v; want refused with the wait", ticket, wait, err)
+	}
+}
+
+func TestRateLimiterClearOldEntries(t *testing.T) {
+	rl, advance := clockedLimiter(60)
+	rl.Take(42, "alice", KindSummary)
+	rl.ClearOldEntries()
+	if len(rl.buckets) != 2 {
+		t.Fatalf("buckets = %d, want the 2 still refilling", len(rl.buckets))
+	}
+	advance(time.Minute)
+	rl.ClearOldEntries()
+	if len(rl.buckets) != 0 {
+		t.Fatalf("buckets = %d, want refilled ones dropped", len(rl.buckets))
+	}
+}
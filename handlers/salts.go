@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/logger"
+)
+
+// userSalts holds the salts members are hashed with: the current one, and
+// the retired ones still kept so a member's older rows can be relinked to
+// their current hash.
+type userSalts struct {
+	mu      sync.Mutex
+	current db.HashSalt
+	retired []db.HashSalt
+	// relinked marks "<group>:<hash>" pairs already relinked, so a member
+	// costs the relink queries once per salt change rather than per message.
+	relinked map[string]bool
+}
+
+// loadSalts (re)reads the salts from the DB.
+func (b *Bot) loadSalts(ctx context.Context) error {
+	current, retired, err := b.db.GetUserHashSalts(ctx)
+	if err != nil {
+		return err
+	}
+	b.salts.mu.Lock()
+	defer b.salts.mu.Unlock()
+	if current.Epoch != b.salts.current.Epoch || len(retired) != len(b.salts.retired) {
+		b.salts.relinked = nil
+	}
+	b.salts.current, b.salts.retired = current, retired
+	return nil
+}
+
+// currentSalt returns the salt new hashes are made with.
+func (b *Bot) currentSalt() []byte {
+	b.salts.mu.Lock()
+	defer b.salts.mu.Unlock()
+	return b.salts.current.Salt
+}
+
+// userHash returns the member's hash in the group under the current salt.
+// After a rotation, the first call for a member also relinks what is stored
+// under their hashes from retired salts, so their messages keep one alias
+// across a summary window that spans the rotation.
+func (b *Bot) userHash(ctx context.Context, userID, groupID int64) string {
+	b.salts.mu.Lock()
+	current, retired := b.salts.current, b.salts.retired
+	hash := db.UserHash(userID, groupID, current.Salt)
+	key := strconv.FormatInt(groupID, 10) + ":" + hash
+	if len(retired) == 0 || b.salts.relinked[key] {
+		b.salts.mu.Unlock()
+		return hash
+	}
+	b.salts.mu.Unlock()
+
+	old := retiredHashes(userID, groupID, retired)
+	if err := b.db.RelinkUserHash(ctx, groupID, hash, current.Epoch, old); err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to relink user hash")
+		return hash
+	}
+
+	b.salts.mu.Lock()
+	if b.salts.current.Epoch == current.Epoch {
+		if b.salts.relinked == nil {
+			b.salts.relinked = make(map[string]bool)
+		}
+		b.salts.relinked[key] = true
+	}
+	b.salts.mu.Unlock()
+	return hash
+}
+
+// retiredUserHashes returns the member's hashes in the group under the
+// retired salts still kept.
+func (b *Bot) retiredUserHashes(userID, groupID int64) []string {
+	b.salts.mu.Lock()
+	retired := b.salts.retired
+	b.salts.mu.Unlock()
+	return retiredHashes(userID, groupID, retired)
+}
+
+func retiredHashes(userID, groupID int64, retired []db.HashSalt) []string {
+	old := make([]string, len(retired))
+	for i, s := range retired {
+		old[i] = db.UserHash(userID, groupID, s.Salt)
+	}
+	return old
+}
+
+// maintainSalts rotates the salt when USER_HASH_ROTATE_DAYS is due, drops
+// retired salts past the retention period (by then the messages hashed with
+// them are gone) and picks up a rotation made with "bot db rotate-salt".
+func (b *Bot) maintainSalts(ctx context.Context) {
+	b.salts.mu.Lock()
+	current := b.salts.current
+	b.salts.mu.Unlock()
+
+	if every := b.cfg.UserHashRotateInterval(); every > 0 && time.Since(current.CreatedAt) >= every {
+		next, err := b.db.RotateUserHashSalt(ctx)
+		if err != nil {
+			logger.Error().Err(err).Msg("failed to rotate user hash salt")
+		} else {
+			logger.Info().Int64("epoch", next.Epoch).Msg("rotated user hash salt")
+		}
+	}
+	if purged, err := b.db.PurgeRetiredSalts(ctx, b.cfg.RetentionDuration()); err != nil {
+		logger.Error().Err(err).Msg("failed to purge retired user hash salts")
+	} else if purged > 0 {
+		logger.Info().Int64("purged", purged).Msg("purged retired user hash salts")
+	}
+	if err := b.loadSalts(ctx); err != nil {
+		logger.Error().Err(err).Msg("failed to reload user hash salts")
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"telegram_summarize_bot/summarizer"
+
+	"github.com/mymmrac/telego"
+)
+
+func TestSaltRotationKeepsAliasesAndOptOuts(t *testing.T) {
+	b, database, _ := newTestBot(t, &fakeSummarizer{})
+	defer func() { _ = database.Close() }()
+	ctx := context.Background()
+	const groupID int64 = 42
+	if err := database.AddAllowedGroup(ctx, groupID, 0); err != nil {
+		t.Fatalf("AddAllowedGroup: %v", err)
+	}
+	if err := b.loadSalts(ctx); err != nil {
+		t.Fatalf("loadSalts: %v", err)
+	}
+
+	post := func(userID int64, msgID int, text string) {
+		b.handleUpdate(ctx, telego.Update{Message: &telego.Message{
+			MessageID: msgID,
+			Text:      text,
+			Chat:      telego.Chat{ID: groupID, Type: "group", Title: "Dev chat"},
+			From:      &telego.User{ID: userID},
+		}})
+	}
+	rotate := func() {
+		t.Helper()
+		if _, err := database.RotateUserHashSalt(ctx); err != nil {
+			t.Fatalf("RotateUserHashSalt: %v", err)
+		}
+		if err := b.loadSalts(ctx); err != nil {
+			t.Fatalf("loadSalts: %v", err)
+		}
+	}
+
+	post(7, 1, "до ротации")
+	post(8, 2, "@testbot forget me")
+	before := b.userHash(ctx, 7, groupID)
+	rotate()
+	if after := b.userHash(ctx, 7, groupID); after == before {
+		t.Fatal("hash unchanged by rotation")
+	}
+	post(7, 3, "после ротации")
+	post(8, 4, "молчун не должен сохраниться")
+
+	msgs, err := database.GetMessages(ctx, groupID, time.Now().Add(-time.Hour), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 2 {
+		t.Fatalf("stored %d messages, want member 7's two (member 8 opted out): %+v", len(msgs), msgs)
+	}
+	if aliases := summarizer.BuildUserAliasMap(msgs); len(aliases) != 1 {
+		t.Errorf("aliases = %v, want one member across the rotation", aliases)
+	}
+}
@@ -8,8 +8,8 @@ import (
 	"time"
 
 	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
 	"telegram_summarize_bot/logger"
-	"telegram_summarize_bot/summarizer"
 
 	"github.com/mymmrac/telego"
 )
@@ -17,26 +17,27 @@ import (
 func (b *Bot) handleSchedule(ctx context.Context, update telego.Update, args []string) {
 	msg := update.Message
 	groupID := msg.Chat.ID
+	lang := b.groupLanguage(ctx, groupID)
 
 	if len(args) == 0 {
 		// Show current schedule to anyone.
 		s, err := b.db.GetGroupSchedule(ctx, groupID)
 		if err != nil {
 			logger.Error().Err(err).Msg("failed to get group schedule")
-			b.sendMessage(ctx, groupID, "Ошибка получения расписания.")
+			b.sendMessage(ctx, groupID, i18n.T(lang, i18n.ScheduleError))
 			return
 		}
 		if s == nil || !s.Enabled {
-			b.sendFormatted(ctx, groupID, "⏰ Ежедневная сводка *отключена*\\.")
+			b.sendFormatted(ctx, groupID, i18n.T(lang, i18n.ScheduleDisabled))
 		} else {
-			b.sendFormatted(ctx, groupID, fmt.Sprintf("⏰ Ежедневная сводка *включена*, время: *%02d:%02d UTC*\\.", s.Hour, s.Minute))
+			b.sendFormatted(ctx, groupID, i18n.T(lang, i18n.ScheduleEnabled, s.Hour, s.Minute))
 		}
 		return
 	}
 
 	// Mutating operations require admin privileges.
 	if !b.isGroupAdmin(ctx, groupID, msg.From.ID) {
-		b.sendMessage(ctx, groupID, "Только администраторы группы могут изменять расписание.")
+		b.sendMessage(ctx, groupID, i18n.T(lang, i18n.ScheduleAdminsOnly))
 		return
 	}
 
@@ -44,8 +45,8 @@ func (b *Bot) handleSchedule(ctx context.Context, update telego.Update, args []s
 
 	// "now" triggers an immediate unscheduled summary.
 	if arg == "now" {
-		b.sendFormatted(ctx, groupID, "🔄 Запускаю внеплановую сводку\\.\\.\\.")
-		b.runScheduledSummary(ctx, groupID, time.Now())
+		b.sendFormatted(ctx, groupID, i18n.T(lang, i18n.ScheduleRunningNow))
+		b.submitDigestJob(ctx, groupID, digestJob{Now: time.Now().UTC(), Post: true, Refresh: true})
 		return
 	}
 
@@ -55,13 +56,13 @@ func (b *Bot) handleSchedule(ctx context.Context, update telego.Update, args []s
 	if arg != "on" && arg != "off" {
 		parts := strings.SplitN(arg, ":", 2)
 		if len(parts) != 2 {
-			b.sendFormatted(ctx, groupID, "Неверный формат\\. Используйте: `schedule on`, `schedule off`, `schedule now` или `schedule ЧЧ:ММ`\\.")
+			b.sendFormatted(ctx, groupID, i18n.T(lang, i18n.ScheduleBadFormat))
 			return
 		}
 		h, err1 := strconv.Atoi(parts[0])
 		m, err2 := strconv.Atoi(parts[1])
 		if err1 != nil || err2 != nil || h < 0 || h > 23 || m < 0 || m > 59 {
-			b.sendFormatted(ctx, groupID, "Неверное время\\. Используйте формат ЧЧ:ММ, например `07:00`\\.")
+			b.sendFormatted(ctx, groupID, i18n.T(lang, i18n.ScheduleBadTime))
 			return
 		}
 		parsedHour, parsedMinute, isTime = h, m, true
@@ -71,7 +72,7 @@ func (b *Bot) handleSchedule(ctx context.Context, update telego.Update, args []s
 	s, err := b.db.GetGroupSchedule(ctx, groupID)
 	if err != nil {
 		logger.Error().Err(err).Msg("failed to get group schedule")
-		b.sendMessage(ctx, groupID, "Ошибка получения расписания.")
+		b.sendMessage(ctx, groupID, i18n.T(lang, i18n.ScheduleError))
 		return
 	}
 	if s == nil {
@@ -92,17 +93,24 @@ func (b *Bot) handleSchedule(ctx context.Context, update telego.Update, args []s
 
 	if err := b.db.SetGroupSchedule(ctx, s); err != nil {
 		logger.Error().Err(err).Msg("failed to set group schedule")
-		b.sendMessage(ctx, groupID, "Ошибка сохранения расписания.")
+		b.sendMessage(ctx, groupID, i18n.T(lang, i18n.ScheduleSaveError))
 		return
 	}
 
 	if s.Enabled {
-		b.sendFormatted(ctx, groupID, fmt.Sprintf("⏰ Ежедневная сводка *включена*, время: *%02d:%02d UTC*\\.", s.Hour, s.Minute))
+		b.sendFormatted(ctx, groupID, i18n.T(lang, i18n.ScheduleEnabled, s.Hour, s.Minute))
 	} else {
-		b.sendFormatted(ctx, groupID, "⏰ Ежедневная сводка *отключена*\\.")
+		b.sendFormatted(ctx, groupID, i18n.T(lang, i18n.ScheduleDisabled))
 	}
 }
 
+// dueDigests is what one group owes at a scheduler tick: the group post
+// and/or subscriber DMs.
+type dueDigests struct {
+	post bool
+	subs []db.DigestSubscription
+}
+
 func (b *Bot) schedulerLoop(ctx context.Context) {
 	ticker := time.NewTicker(1 * time.Minute)
 	defer ticker.Stop()
@@ -113,65 +121,109 @@ func (b *Bot) schedulerLoop(ctx context.Context) {
 			return
 		case now := <-ticker.C:
 			now = now.UTC()
-			schedules, err := b.db.GetEnabledSchedules(ctx)
-			if err != nil {
-				logger.Error().Err(err).Msg("failed to get enabled schedules")
-				continue
-			}
-			for _, s := range schedules {
-				if s.Hour != now.Hour() || s.Minute != now.Minute() {
-					continue
-				}
-				today := now.Truncate(24 * time.Hour)
-				if s.LastDailySummary != nil && !s.LastDailySummary.UTC().Truncate(24*time.Hour).Before(today) {
-					continue
-				}
-				groupID := s.GroupID
-				go b.runScheduledSummary(ctx, groupID, now)
+			for groupID, due := range b.dueDigests(ctx, now) {
+				b.submitDigestJob(ctx, groupID, digestJob{Now: now, Post: due.post, Subs: len(due.subs) > 0})
 			}
 		}
 	}
 }
 
-func (b *Bot) runScheduledSummary(ctx context.Context, groupID int64, now time.Time) {
-	since := now.UTC().Add(-24 * time.Hour)
-	messages, err := b.db.GetMessages(ctx, groupID, since, b.cfg.MaxMessages)
+// sentOn reports whether t falls on now's UTC day or later.
+func sentOn(t *time.Time, now time.Time) bool {
+	return t != nil && !t.UTC().Truncate(24*time.Hour).Before(now.UTC().Truncate(24*time.Hour))
+}
+
+// dueDigests collects the group posts and subscriber DMs scheduled for now's
+// minute that haven't been delivered today.
+func (b *Bot) dueDigests(ctx context.Context, now time.Time) map[int64]*dueDigests {
+	due := make(map[int64]*dueDigests)
+	dueFor := func(groupID int64) *dueDigests {
+		if due[groupID] == nil {
+			due[groupID] = &dueDigests{}
+		}
+		return due[groupID]
+	}
+
+	schedules, err := b.db.GetEnabledSchedules(ctx)
 	if err != nil {
-		logger.Error().Err(err).Int64("group_id", groupID).Msg("scheduled summary: failed to get messages")
-		return
+		logger.Error().Err(err).Msg("failed to get enabled schedules")
 	}
-	if len(messages) == 0 {
-		logger.Info().Int64("group_id", groupID).Msg("scheduled summary: no messages, skipping")
-		return
+	for _, s := range schedules {
+		if s.Hour != now.Hour() || s.Minute != now.Minute() || sentOn(s.LastDailySummary, now) {
+			continue
+		}
+		dueFor(s.GroupID).post = true
+	}
+
+	subs, err := b.db.GetDigestSubscriptionsAt(ctx, now.Hour(), now.Minute())
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to get digest subscriptions")
 	}
+	for _, sub := range subs {
+		if sentOn(sub.LastSent, now) {
+			continue
+		}
+		d := dueFor(sub.GroupID)
+		d.subs = append(d.subs, sub)
+	}
+	return due
+}
 
-	logger.Info().Int64("group_id", groupID).Int("count", len(messages)).Msg("running scheduled summary")
+// dueSubscriptions is the group's share of dueDigests(now).subs, read again
+// when the job runs so a resumed job skips subscribers already served.
+func (b *Bot) dueSubscriptions(ctx context.Context, groupID int64, now time.Time) []db.DigestSubscription {
+	if due := b.dueDigests(ctx, now)[groupID]; due != nil {
+		return due.subs
+	}
+	return nil
+}
 
-	statusMsgID := b.sendMessage(ctx, groupID, "Готовлю утреннюю сводку...")
+// runScheduledSummary posts the group's daily digest into job's status
+// message, posting one first if the job has none yet. With refresh (an
+// unscheduled "schedule now") the digest is regenerated instead of reused;
+// without, a digest already posted today isn't posted again. Summarizer errors
+// are returned for a retry.
+func (b *Bot) runScheduledSummary(ctx context.Context, job *db.Job, now time.Time, refresh bool) error {
+	groupID := job.GroupID
+	if !refresh {
+		if s, err := b.db.GetGroupSchedule(ctx, groupID); err == nil && s != nil && sentOn(s.LastDailySummary, now) {
+			logger.Info().Int64("group_id", groupID).Msg("scheduled summary: already posted today")
+			return nil
+		}
+	}
 
-	instructions := b.loadGroupSummaryInstructions(ctx, groupID)
-	summary, err := b.summarizer.SummarizeByTopics(ctx, messages, b.cfg.TopicMax, instructions)
+	lang := b.jobLang(ctx, job)
+	digest, err := b.dailyDigest(ctx, groupID, now, refresh, func(l i18n.Lang) int64 {
+		lang = l
+		if job.StatusMsgID == 0 {
+			job.StatusMsgID = b.sendMessage(ctx, groupID, i18n.T(lang, i18n.SchedulePreparing))
+		}
+		job.Lang = string(lang)
+		if err := b.db.SetJobStatus(ctx, job.ID, job.StatusMsgID, job.Lang); err != nil {
+			logger.Error().Err(err).Int64("job_id", job.ID).Msg("failed to record job status message")
+		}
+		return job.StatusMsgID
+	})
 	if err != nil {
-		logger.Error().Err(err).Int64("group_id", groupID).Msg("scheduled summary: failed to summarize")
-		b.editWithRetry(ctx, groupID, statusMsgID, "Ошибка суммаризации. Попробуйте позже.")
-		return
+		return fmt.Errorf("scheduled summary: %w", err)
+	}
+	if digest == nil {
+		logger.Info().Int64("group_id", groupID).Msg("scheduled summary: no messages, skipping")
+		return nil
 	}
+	lang, _ = i18n.Parse(digest.Lang)
 
-	preamble := "🌅 **Утренняя #сводка за последние 24 часа:**"
-	raw := preamble + "\n\n" + summarizer.FormatTelegramSummary(summary, groupID)
-	chunks := renderMarkdown(raw)
+	chunks := renderMarkdown(i18n.T(lang, i18n.ScheduleHeader) + "\n\n" + digest.Summary)
 	if len(chunks) == 0 {
-		return
+		return nil
 	}
-	if err := b.editFormattedFinal(ctx, groupID, statusMsgID, chunks[0]); err != nil {
+	if err := b.deliverChunks(ctx, groupID, job.StatusMsgID, chunks, b.storedSummaryKeyboard(ctx, lang, digest.SummaryID)); err != nil {
 		logger.Error().Err(err).Int64("group_id", groupID).Msg("scheduled summary: failed to send to Telegram")
-		return
-	}
-	for _, chunk := range chunks[1:] {
-		b.sendFormatted(ctx, groupID, chunk)
+		return nil
 	}
 
 	if err := b.db.UpdateLastDailySummary(ctx, groupID, now); err != nil {
 		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to update last daily summary")
 	}
+	return nil
 }
@@ -111,7 +111,8 @@ func TestRunScheduledSummaryPassesGroupSummaryInstructions(t *testing.T) {
 		t.Fatalf("AddMessage error: %v", err)
 	}
 
-	b.runScheduledSummary(ctx, 42, now)
+	b.submitDigestJob(ctx, 42, digestJob{Now: now, Post: true})
+	waitJobs(t, b)
 
 	if sum.additionalInstructions != "фокусируйся на решениях" {
 		t.Fatalf("additionalInstructions = %q, want %q", sum.additionalInstructions, "фокусируйся на решениях")
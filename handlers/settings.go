@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"telegram_summarize_bot/config"
+	"telegram_summarize_bot/logger"
+)
+
+// groupSettings returns the group's effective summary settings: the global
+// values with the group's overrides applied (globals alone on a lookup error).
+func (b *Bot) groupSettings(ctx context.Context, groupID int64) config.GroupSettings {
+	s, err := b.db.GroupSettings(ctx, groupID, b.cfg.GroupDefaults())
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to get group settings")
+	}
+	return s
+}
+
+// groupRateLimit resolves the rate limiter's per-group cooldown.
+func (b *Bot) groupRateLimit(groupID int64) time.Duration {
+	return b.groupSettings(context.Background(), groupID).RateLimit()
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/summarizer"
+)
+
+func TestHandleSummarizeUsesGroupOverrides(t *testing.T) {
+	sum := &fakeSummarizer{
+		summary: &summarizer.StructuredSummary{
+			TLDR:   "Обсудили релиз.",
+			Topics: []summarizer.TopicSummary{{Title: "Релиз", Summary: "Катим вечером."}},
+		},
+	}
+	b, database, tg := newTestBot(t, sum)
+	defer func() { _ = database.Close() }()
+	ctx := context.Background()
+
+	topics, hours := 9, 2
+	if err := database.SetGroupSettingsOverrides(ctx, 42, 7, db.GroupSettingsOverrides{TopicMax: &topics, SummaryHours: &hours}); err != nil {
+		t.Fatalf("SetGroupSettingsOverrides error: %v", err)
+	}
+	for _, age := range []time.Duration{3 * time.Hour, time.Hour} {
+		if err := database.AddMessage(ctx, &db.Message{GroupID: 42, UserHash: "a3f2b1c4", Text: "сообщение", Timestamp: time.Now().Add(-age)}); err != nil {
+			t.Fatalf("AddMessage error: %v", err)
+		}
+	}
+
+	b.handleSummarize(ctx, summarizeUpdate(), []string{"5"})
+	waitJobs(t, b)
+	if sum.calls != 0 || len(tg.sentTexts) != 1 || tg.sentTexts[0] != "Максимальный период суммаризации — 2 часов." {
+		t.Fatalf("hours above the group's window should be rejected, got calls=%d sent=%q", sum.calls, tg.sentTexts)
+	}
+
+	b.handleSummarize(ctx, summarizeUpdate(), nil)
+	waitJobs(t, b)
+	if sum.calls != 1 {
+		t.Fatalf("summarizer calls = %d, want 1", sum.calls)
+	}
+	if sum.topicMax != 9 {
+		t.Fatalf("topicMax = %d, want group override 9", sum.topicMax)
+	}
+}
+
+func TestRateLimiterUsesGroupOverride(t *testing.T) {
+	b, database, _ := newTestBot(t, &fakeSummarizer{})
+	defer func() { _ = database.Close() }()
+	b.cfg.RateLimitSec = 60
+	rl := NewRateLimiter(60).WithLimitFunc(b.groupRateLimit)
+
+	zero := 0
+	if err := database.SetGroupSettingsOverrides(context.Background(), 42, 7, db.GroupSettingsOverrides{RateLimitSec: &zero}); err != nil {
+		t.Fatalf("SetGroupSettingsOverrides error: %v", err)
+	}
+
+	if !rl.Allow(42) || !rl.Allow(42) {
+		t.Fatal("group with a zero cooldown override should never be limited")
+	}
+	if !rl.Allow(43) || rl.Allow(43) {
+		t.Fatal("group without an override should use the global cooldown")
+	}
+	if rem := rl.RemainingTime(43); rem <= 0 || rem > time.Minute {
+		t.Fatalf("remaining time = %v, want within the global minute", rem)
+	}
+}
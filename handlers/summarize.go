@@ -2,13 +2,14 @@ package handlers
 
 import (
 	"context"
-	"fmt"
+	"encoding/json"
 	"strconv"
 	"time"
 
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
 	"telegram_summarize_bot/logger"
 	"telegram_summarize_bot/summarizer"
-	"telegram_summarize_bot/tgutil"
 
 	"github.com/mymmrac/telego"
 )
@@ -16,83 +17,179 @@ import (
 func (b *Bot) handleSummarize(ctx context.Context, update telego.Update, args []string) {
 	msg := update.Message
 	groupID := msg.Chat.ID
+	lang := b.groupLanguage(ctx, groupID)
+	settings := b.groupSettings(ctx, groupID)
 
-	hours := b.cfg.SummaryHours
+	hours := settings.SummaryHours
 	if len(args) > 0 {
 		parsed, err := strconv.Atoi(args[0])
 		if err != nil || parsed <= 0 {
-			b.sendMessage(ctx, groupID, "Неверный формат. Используйте: @bot summarize [часы]\nПример: @bot summarize 12")
+			b.sendMessage(ctx, groupID, i18n.T(lang, i18n.SummarizeUsage))
 			return
 		}
-		if parsed > b.cfg.SummaryHours {
-			b.sendMessage(ctx, groupID, fmt.Sprintf("Максимальный период суммаризации — %d часов.", b.cfg.SummaryHours))
+		if parsed > settings.SummaryHours {
+			b.sendMessage(ctx, groupID, i18n.T(lang, i18n.SummarizeMaxHours, settings.SummaryHours))
 			return
 		}
 		hours = parsed
 	}
 
-	lastSummarize, err := b.db.GetLastSummarizeTime(ctx, groupID)
+	w, err := b.summaryWindow(ctx, groupID, hours, settings.MaxMessages)
 	if err != nil {
-		logger.Error().Err(err).Msg("failed to get last summarize time")
+		logger.Error().Err(err).Msg("failed to get messages")
+		b.sendMessage(ctx, groupID, i18n.T(lang, i18n.MessagesError))
+		return
+	}
+	if len(w.messages) == 0 {
+		b.sendMessage(ctx, groupID, i18n.T(lang, w.emptyKey()))
+		return
 	}
+	// From here on replies follow the window's language in auto mode.
+	lang = lang.Resolve(summarizer.MessageTexts(w.messages)...)
 
-	since := time.Now().Add(-time.Duration(hours) * time.Hour)
-	if lastSummarize != nil && since.Before(*lastSummarize) {
-		since = *lastSummarize
+	instructions := b.loadGroupSummaryInstructions(ctx, groupID)
+	b.submitSummary(ctx, summaryRequest{
+		groupID:   groupID,
+		lang:      lang,
+		requester: b.requesterHash(groupID, msg),
+		send:      func(text string) int64 { return b.sendMessage(ctx, groupID, text) },
+		key:       summaryFlightKey(groupID, 0, w.messages, settings, lang, instructions),
+		job:       newSummaryJob(w.messages, w.upper, 0),
+		status:    i18n.T(lang, i18n.SummarizeCollecting, hours),
+		requeued: func(queuedMsgID int64) (summaryJob, string, bool) {
+			// Summaries that ran while this one queued may have moved the window.
+			w, err := b.summaryWindow(ctx, groupID, hours, settings.MaxMessages)
+			if err != nil || len(w.messages) == 0 {
+				text := i18n.T(lang, i18n.MessagesError)
+				if err == nil {
+					text = i18n.T(lang, w.emptyKey())
+				}
+				b.editWithRetry(ctx, groupID, queuedMsgID, text)
+				return summaryJob{}, "", false
+			}
+			return newSummaryJob(w.messages, w.upper, 0), summaryFlightKey(groupID, 0, w.messages, settings, lang, instructions), true
+		},
+	})
+}
+
+// summaryRequest is a topic summary on its way to the job queue.
+type summaryRequest struct {
+	groupID   int64
+	lang      i18n.Lang
+	requester string // requesterHash of who asked
+	send      func(text string) int64
+	key       string     // flight key of the messages as first read
+	job       summaryJob // the job's payload
+	status    string     // status text while the summary is collected
+	// requeued, if set, reads the messages again after the request waited
+	// in the queue, returning the new payload and flight key. It returns
+	// false after editing queuedMsgID with why nothing is left to summarize.
+	requeued func(queuedMsgID int64) (summaryJob, string, bool)
+}
+
+// submitSummary leads r's flight, so an identical summary already in
+// progress answers r too, takes a rate-limit ticket and hands the summary to
+// the job runner, which settles the flight and the ticket. The summary runs
+// as a job so that a restart resumes it in its status message.
+func (b *Bot) submitSummary(ctx context.Context, r summaryRequest) {
+	key := r.key
+	flight, ok := b.leadFlight(ctx, r.groupID, key, r.lang, 0)
+	if !ok {
+		return
 	}
-	upperBound := time.Now()
+	var ticket *Ticket
+	handedOff := false
+	defer func() {
+		if !handedOff {
+			b.landFlight(key, flight, flightResult{})
+			ticket.Release()
+		}
+	}()
 
-	messages, err := b.db.GetMessages(ctx, groupID, since, b.cfg.MaxMessages)
-	if err != nil {
-		logger.Error().Err(err).Msg("failed to get messages")
-		b.sendMessage(ctx, groupID, "Ошибка получения сообщений.")
+	ticket, queuedMsgID := b.admit(ctx, r.groupID, r.requester, KindSummary, r.lang, r.send)
+	if ticket == nil {
 		return
 	}
 
-	if len(messages) == 0 {
-		if lastSummarize != nil {
-			b.sendMessage(ctx, groupID, "Нет новых сообщений с последней суммаризации.")
-		} else {
-			b.sendMessage(ctx, groupID, "Нет сообщений за последние 24 часа.")
+	p := r.job
+	if queuedMsgID != 0 && r.requeued != nil {
+		var moved string
+		if p, moved, ok = r.requeued(queuedMsgID); !ok {
+			return
+		}
+		if moved != key {
+			b.landFlight(key, flight, flightResult{})
+			key = moved
+			if flight, ok = b.leadFlight(ctx, r.groupID, key, r.lang, queuedMsgID); !ok {
+				return
+			}
 		}
-		return
 	}
 
-	if !b.rateLimiter.Allow(groupID) {
-		b.metrics.RateLimit.Record(0)
-		remaining := b.rateLimiter.RemainingTime(groupID)
-		b.sendMessage(ctx, groupID, "Подождите "+tgutil.FormatDuration(remaining)+" перед следующим запросом суммаризации.")
+	statusMsgID := b.showStatus(ctx, r.groupID, queuedMsgID, r.status, r.send)
+
+	payload, err := json.Marshal(p)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to encode summary job")
+		b.editWithRetry(ctx, r.groupID, statusMsgID, i18n.T(r.lang, i18n.SummarizeFailed))
 		return
 	}
-
-	committed := false
-	defer func() {
-		if !committed {
-			b.rateLimiter.Release(groupID)
+	handedOff = b.submitJob(ctx, db.Job{
+		Kind:        jobSummary,
+		GroupID:     r.groupID,
+		Payload:     string(payload),
+		StatusMsgID: statusMsgID,
+		Lang:        string(r.lang),
+	}, func(result flightResult) {
+		b.landFlight(key, flight, result)
+		if !result.ok() {
+			ticket.Release()
 		}
-	}()
+	})
+	if !handedOff {
+		b.editWithRetry(ctx, r.groupID, statusMsgID, i18n.T(r.lang, i18n.SummarizeFailed))
+	}
+}
 
-	logger.Info().Int("count", len(messages)).Msg("Summarizing messages")
+// newSummaryJob is the payload summarizing messages.
+func newSummaryJob(messages []db.Message, upper time.Time, anchor int64) summaryJob {
+	p := summaryJob{Upper: upper, Anchor: anchor}
+	for _, m := range messages {
+		p.MessageIDs = append(p.MessageIDs, m.ID)
+	}
+	return p
+}
 
-	statusMsgID := b.sendMessage(ctx, groupID, fmt.Sprintf("Собираю сообщения за последние %d часов...", hours))
+// summaryWindow is the span a group summary covers: the last hours, or since
+// the previous summary when that is more recent.
+type summaryWindow struct {
+	upper        time.Time
+	messages     []db.Message
+	firstSummary bool // the group has no previous summary
+}
 
-	instructions := b.loadGroupSummaryInstructions(ctx, groupID)
-	summary, err := b.summarizer.SummarizeByTopics(ctx, messages, b.cfg.TopicMax, instructions)
+func (b *Bot) summaryWindow(ctx context.Context, groupID int64, hours, maxMessages int) (summaryWindow, error) {
+	lastSummarize, err := b.db.GetLastSummarizeTime(ctx, groupID)
 	if err != nil {
-		logger.Error().Err(err).Msg("failed to summarize")
-		b.editWithRetry(ctx, groupID, statusMsgID, "Ошибка суммаризации. Попробуйте позже.")
-		return
+		logger.Error().Err(err).Msg("failed to get last summarize time")
 	}
 
-	if !b.sendSummary(ctx, groupID, statusMsgID, summary) {
-		return
+	w := summaryWindow{upper: time.Now(), firstSummary: lastSummarize == nil}
+	since := w.upper.Add(-time.Duration(hours) * time.Hour)
+	if lastSummarize != nil && since.Before(*lastSummarize) {
+		since = *lastSummarize
 	}
 
-	committed = true
+	w.messages, err = b.db.GetMessages(ctx, groupID, since, maxMessages)
+	return w, err
+}
 
-	if err := b.db.SetLastSummarizeTime(ctx, groupID, upperBound); err != nil {
-		logger.Error().Err(err).Msg("failed to set last summarize time")
+// emptyKey is the reply for a window without messages.
+func (w summaryWindow) emptyKey() i18n.Key {
+	if w.firstSummary {
+		return i18n.SummarizeNoMessages
 	}
+	return i18n.SummarizeNoNew
 }
 
 func (b *Bot) loadGroupSummaryInstructions(ctx context.Context, groupID int64) string {
@@ -107,18 +204,26 @@ func (b *Bot) loadGroupSummaryInstructions(ctx context.Context, groupID int64) s
 	return item.Instructions
 }
 
-func (b *Bot) sendSummary(ctx context.Context, chatID, statusMsgID int64, summary *summarizer.StructuredSummary) bool {
-	chunks := renderMarkdown(summarizer.FormatTelegramSummary(summary, chatID))
+// deliverSummary delivers the summary saved as summaryID into statusMsgID,
+// with per-topic expand, calendar and feedback buttons under it. intro, if
+// set, is a Markdown line put above the summary.
+func (b *Bot) deliverSummary(ctx context.Context, chatID, statusMsgID int64, intro string, summary *summarizer.StructuredSummary, summaryID int64) bool {
+	text := summarizer.FormatTelegramSummary(summary, chatID)
+	if intro != "" {
+		text = intro + "\n\n" + text
+	}
+	chunks := renderMarkdown(text)
 	if len(chunks) == 0 {
-		chunks = renderMarkdown("📝 **Суммаризация:**\n\nНет данных для суммаризации.")
+		chunks = renderMarkdown(summarizer.FormatTelegramSummary(nil, chatID))
 	}
 
-	if err := b.editFormattedFinal(ctx, chatID, statusMsgID, chunks[0]); err != nil {
+	var markup *telego.InlineKeyboardMarkup
+	if summary != nil {
+		markup = summaryKeyboard(summary.Lang, summaryID, len(summary.Topics), len(summary.Events))
+	}
+	if err := b.deliverChunks(ctx, chatID, statusMsgID, chunks, markup); err != nil {
 		logger.Error().Err(err).Int64("chat_id", chatID).Msg("failed to send summary to Telegram")
 		return false
 	}
-	for _, chunk := range chunks[1:] {
-		b.sendFormatted(ctx, chatID, chunk)
-	}
 	return true
 }
@@ -4,12 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 	"unicode/utf16"
 	"unicode/utf8"
 
 	"telegram_summarize_bot/db"
 	"telegram_summarize_bot/fetcher"
+	"telegram_summarize_bot/i18n"
 	"telegram_summarize_bot/logger"
 	"telegram_summarize_bot/summarizer"
 	"telegram_summarize_bot/tgutil"
@@ -29,25 +32,42 @@ type replyPart struct {
 }
 
 // handleSummarizeReply handles "@bot summarize" sent as a reply. When reply
-// threading is on and the replied-to message belongs to a stored reply chain, it
-// summarizes the whole branch; otherwise it acts on the single replied-to
-// message (link(s), image(s), and/or text).
+// threading is on and the replied-to message has stored replies, it summarizes
+// the whole reply tree under it, branch by branch, with the chain leading to
+// it as context; failing that, when it belongs to a stored reply chain, it
+// summarizes the branch leading to it; otherwise it acts on the single
+// replied-to message (link(s), image(s), and/or text).
 func (b *Bot) handleSummarizeReply(ctx context.Context, update telego.Update, steering string) {
 	groupID := update.Message.Chat.ID
 	reply := update.Message.ReplyToMessage
-
-	if chain := b.replyChain(ctx, groupID, reply); len(chain) >= 2 {
-		b.summarizeReplyThread(ctx, groupID, reply, chain, steering)
+	userHash := b.requesterHash(groupID, update.Message)
+	ctx = summarizer.WithRedaction(ctx, b.groupSettings(ctx, groupID).PIIRedaction)
+
+	chain := b.replyChain(ctx, groupID, reply)
+	if tree := b.replyTree(ctx, groupID, reply); len(tree) >= 2 {
+		thread := replyThread{nodes: tree, tree: true}
+		if len(chain) >= 2 {
+			thread.ancestors = chain[:len(chain)-1]
+		}
+		b.summarizeReplyThread(ctx, groupID, userHash, reply, thread, steering)
 		return
 	}
-	b.summarizeSingleReply(ctx, groupID, reply, steering)
+	if len(chain) >= 2 {
+		nodes := make([]summarizer.ThreadNode, len(chain))
+		for i, m := range chain {
+			nodes[i] = summarizer.ThreadNode{Message: m}
+		}
+		b.summarizeReplyThread(ctx, groupID, userHash, reply, replyThread{nodes: nodes, target: len(nodes) - 1}, steering)
+		return
+	}
+	b.summarizeSingleReply(ctx, groupID, userHash, reply, steering)
 }
 
 // replyChain reconstructs the reply branch ending at the replied-to message,
 // ordered root→target, from the DB. Returns nil (→ single-message handling) when
 // threading is off, the target isn't stored, or it has no resolvable ancestors.
 func (b *Bot) replyChain(ctx context.Context, groupID int64, reply *telego.Message) []db.Message {
-	if !b.cfg.ReplyThreads {
+	if !b.groupSettings(ctx, groupID).ReplyThreads {
 		return nil
 	}
 	target, err := b.db.GetMessageByTgID(ctx, groupID, int64(reply.MessageID))
@@ -64,10 +84,49 @@ func (b *Bot) replyChain(ctx context.Context, groupID int64, reply *telego.Messa
 	return chain
 }
 
+// replyTree reconstructs the stored replies under the replied-to message, in
+// transcript order with the target first. Returns nil when threading is off or
+// nothing stored replies to it. The target itself may have aged out of storage
+// while its replies haven't; the live message stands in for it then.
+func (b *Bot) replyTree(ctx context.Context, groupID int64, reply *telego.Message) []summarizer.ThreadNode {
+	if !b.groupSettings(ctx, groupID).ReplyThreads {
+		return nil
+	}
+	target, err := b.db.GetMessageByTgID(ctx, groupID, int64(reply.MessageID))
+	if err != nil {
+		return nil
+	}
+	if target == nil {
+		text, _ := replyTextAndEntities(reply)
+		target = &db.Message{
+			GroupID:     groupID,
+			Text:        text,
+			Timestamp:   time.Unix(reply.Date, 0),
+			TgMessageID: int64(reply.MessageID),
+		}
+		if reply.From != nil {
+			target.UserHash = db.UserHash(reply.From.ID, groupID, b.currentSalt())
+		}
+	}
+	lookup := func(parents []int64) ([]db.Message, error) {
+		return b.db.GetRepliesTo(ctx, groupID, parents)
+	}
+	tree, err := summarizer.WalkDescendants(*target, lookup, b.cfg.ReplyTreeMaxDepth, b.cfg.ReplyTreeMaxMessages)
+	if err != nil {
+		logger.Warn().Err(err).Int64("group_id", groupID).Msg("reply-summarize: failed to walk reply tree")
+		return nil
+	}
+	if len(tree) < 2 {
+		return nil
+	}
+	return tree
+}
+
 // summarizeSingleReply acts on the single replied-to message (no resolvable
 // ancestors): summarize its link(s), describe its image(s), and/or summarize its
 // text, blended into one unified summary; a lone link or image short-circuits.
-func (b *Bot) summarizeSingleReply(ctx context.Context, groupID int64, reply *telego.Message, steering string) {
+// It is charged to the requester (userHash) and the group.
+func (b *Bot) summarizeSingleReply(ctx context.Context, groupID int64, userHash string, reply *telego.Message, steering string) {
 	text, entities := replyTextAndEntities(reply)
 	links := tgutil.ExtractURLs(text, entities, replyMaxLinks)
 	photos := extractPhotoRecords(reply)
@@ -82,34 +141,51 @@ func (b *Bot) summarizeSingleReply(ctx context.Context, groupID int64, reply *te
 	// prompt — the prose is folded in regardless of length.
 	includeText := prose != "" && (steering != "" || utf8.RuneCountInString(prose) >= b.cfg.ReplyMinChars || hasOther)
 
+	// The UI follows the group setting; auto is resolved from the message
+	// itself (and the steering prompt) since that is what gets summarized.
+	lang := b.groupLanguage(ctx, groupID).Resolve(text, steering)
+
 	if len(links) == 0 && len(photos) == 0 && !includeText {
 		switch {
 		case hasUnsupportedMedia(reply):
-			b.sendMessageReply(ctx, groupID, int64(reply.MessageID), "Этот тип сообщения пока не поддерживается для суммаризации.")
+			b.sendMessageReply(ctx, groupID, int64(reply.MessageID), i18n.T(lang, i18n.ReplyUnsupported))
 		case prose != "":
-			b.sendMessageReply(ctx, groupID, int64(reply.MessageID), "Сообщение слишком короткое для суммаризации.")
+			b.sendMessageReply(ctx, groupID, int64(reply.MessageID), i18n.T(lang, i18n.ReplyTooShort))
 		default:
-			b.sendMessageReply(ctx, groupID, int64(reply.MessageID), "Нечего суммаризировать в этом сообщении.")
+			b.sendMessageReply(ctx, groupID, int64(reply.MessageID), i18n.T(lang, i18n.ReplyNothing))
 		}
 		return
 	}
 
-	if !b.rateLimiter.Allow(groupID) {
-		b.metrics.RateLimit.Record(0)
-		remaining := b.rateLimiter.RemainingTime(groupID)
-		b.sendMessageReply(ctx, groupID, int64(reply.MessageID), "Подождите "+tgutil.FormatDuration(remaining)+" перед следующим запросом суммаризации.")
+	instructions := combineInstructions(b.loadGroupSummaryInstructions(ctx, groupID), steering)
+
+	// Someone else already asked for this message under the same
+	// instructions: point at their answer rather than summarizing it twice.
+	key := replyFlightKey(groupID, int64(reply.MessageID), instructions)
+	flight, ok := b.leadFlight(ctx, groupID, key, lang, 0)
+	if !ok {
+		return
+	}
+	var landed flightResult
+	defer func() { b.landFlight(key, flight, landed) }()
+
+	kind := KindReply
+	if len(links) > 0 {
+		kind = KindURL
+	}
+	send := func(text string) int64 { return b.sendMessageReply(ctx, groupID, int64(reply.MessageID), text) }
+	ticket, queuedMsgID := b.admit(ctx, groupID, userHash, kind, lang, send)
+	if ticket == nil {
 		return
 	}
 	committed := false
 	defer func() {
 		if !committed {
-			b.rateLimiter.Release(groupID)
+			ticket.Release()
 		}
 	}()
 
-	statusMsgID := b.sendMessageReply(ctx, groupID, int64(reply.MessageID), "Обрабатываю сообщение...")
-
-	instructions := combineInstructions(b.loadGroupSummaryInstructions(ctx, groupID), steering)
+	statusMsgID := b.showStatus(ctx, groupID, queuedMsgID, i18n.T(lang, i18n.ReplyProcessing), send)
 
 	// Condense each link and image into compact text. The message text stays
 	// raw and is added at blend time.
@@ -122,7 +198,7 @@ func (b *Bot) summarizeSingleReply(ctx context.Context, groupID int64, reply *te
 			logger.Warn().Err(ferr).Str("url", link).Msg("reply-summarize: failed to fetch URL")
 			continue
 		}
-		summary, serr := b.summarizer.SummarizeURL(ctx, link, content, instructions)
+		summary, serr := b.summarizer.SummarizeURL(ctx, link, content, instructions, lang)
 		if serr != nil {
 			lastLinkErr = serr
 			logger.Warn().Err(serr).Str("url", link).Msg("reply-summarize: failed to summarize URL")
@@ -135,7 +211,7 @@ func (b *Bot) summarizeSingleReply(ctx context.Context, groupID int64, reply *te
 
 	visionDisabled := false
 	for _, photo := range photos {
-		desc, derr := b.summarizer.DescribeImage(ctx, photo, steering)
+		desc, derr := b.summarizer.DescribeImage(ctx, photo, steering, lang)
 		if errors.Is(derr, summarizer.ErrVisionDisabled) {
 			visionDisabled = true
 			continue
@@ -156,14 +232,14 @@ func (b *Bot) summarizeSingleReply(ctx context.Context, groupID int64, reply *te
 		switch {
 		case len(links) > 0:
 			if errors.Is(lastLinkErr, fetcher.ErrNoReadableContent) {
-				b.editWithRetry(ctx, groupID, statusMsgID, "Не удалось прочитать страницу — возможно, она требует входа или контент подгружается через JavaScript.")
+				b.editWithRetry(ctx, groupID, statusMsgID, i18n.T(lang, i18n.PageUnreadable))
 			} else {
-				b.editWithRetry(ctx, groupID, statusMsgID, "Не удалось загрузить ссылку.")
+				b.editWithRetry(ctx, groupID, statusMsgID, i18n.T(lang, i18n.ReplyLinkFailed))
 			}
 		case visionDisabled:
-			b.editWithRetry(ctx, groupID, statusMsgID, "Распознавание изображений отключено.")
+			b.editWithRetry(ctx, groupID, statusMsgID, i18n.T(lang, i18n.ReplyVisionDisabled))
 		default:
-			b.editWithRetry(ctx, groupID, statusMsgID, "Не удалось обработать сообщение. Попробуйте позже.")
+			b.editWithRetry(ctx, groupID, statusMsgID, i18n.T(lang, i18n.ReplyFailed))
 		}
 		return
 	case len(parts) == 1 && !includeText:
@@ -171,26 +247,26 @@ func (b *Bot) summarizeSingleReply(ctx context.Context, groupID int64, reply *te
 		result = parts[0].body
 	default:
 		// Text-only, or multiple parts → blend into one unified summary.
-		summary, serr := b.summarizer.SummarizeText(ctx, buildReplyMaterial(parts, prose, includeText), instructions)
+		summary, serr := b.summarizer.SummarizeText(ctx, buildReplyMaterial(parts, prose, includeText), instructions, lang)
 		if serr != nil {
 			logger.Error().Err(serr).Msg("reply-summarize: failed to summarize")
-			b.editWithRetry(ctx, groupID, statusMsgID, "Ошибка суммаризации. Попробуйте позже.")
+			b.editWithRetry(ctx, groupID, statusMsgID, i18n.T(lang, i18n.SummarizeFailed))
 			return
 		}
 		result = strings.TrimSpace(summary)
 	}
 
 	if result == "" {
-		b.editWithRetry(ctx, groupID, statusMsgID, "Нет данных для суммаризации.")
+		b.editWithRetry(ctx, groupID, statusMsgID, i18n.T(lang, i18n.SummaryEmpty))
 		return
 	}
 
 	// The LLM result is Markdown; convert it (plus our header) to Telegram
 	// MarkdownV2 so **bold**, lists, links etc. render instead of leaking as
 	// literal markers.
-	chunks := renderMarkdown("📝 **Суммаризация:**\n\n" + result)
+	chunks := renderMarkdown(i18n.T(lang, i18n.SummaryHeader) + "\n\n" + result)
 	if len(chunks) == 0 {
-		b.editWithRetry(ctx, groupID, statusMsgID, "Нет данных для суммаризации.")
+		b.editWithRetry(ctx, groupID, statusMsgID, i18n.T(lang, i18n.SummaryEmpty))
 		return
 	}
 	if err := b.editFormattedFinal(ctx, groupID, statusMsgID, chunks[0]); err != nil {
@@ -200,6 +276,7 @@ func (b *Bot) summarizeSingleReply(ctx context.Context, groupID int64, reply *te
 	for _, chunk := range chunks[1:] {
 		b.sendFormatted(ctx, groupID, chunk)
 	}
+	landed = flightResult{msgID: statusMsgID}
 	committed = true
 }
 
@@ -219,38 +296,113 @@ func combineInstructions(group, steering string) string {
 	}
 }
 
-// summarizeReplyThread summarizes a full reply branch (chain ordered root→target)
-// as one conversation: every message gets full treatment (its text, followed
-// links, described images) within chain-wide budgets, then the transcript is
-// summarized — honoring any steering prompt over the whole thread.
-func (b *Bot) summarizeReplyThread(ctx context.Context, groupID int64, reply *telego.Message, chain []db.Message, steering string) {
-	if !b.rateLimiter.Allow(groupID) {
-		b.metrics.RateLimit.Record(0)
-		remaining := b.rateLimiter.RemainingTime(groupID)
-		b.sendMessageReply(ctx, groupID, int64(reply.MessageID), "Подождите "+tgutil.FormatDuration(remaining)+" перед следующим запросом суммаризации.")
+// replyThread is a set of related messages summarized as one conversation:
+// either the reply chain leading to the replied-to message (all at depth 0,
+// root→target) or the reply tree under it (target first, replies indented by
+// depth and numbered by branch).
+type replyThread struct {
+	nodes  []summarizer.ThreadNode
+	target int // index of the replied-to message in nodes
+	tree   bool
+	// ancestors is, for a tree, the chain the target answers, root first:
+	// context for reading the tree, not summarized itself.
+	ancestors []db.Message
+}
+
+// enrichOrder returns node indexes in budget-priority order: the target, then
+// the messages closest to it.
+func (t replyThread) enrichOrder() []int {
+	order := make([]int, len(t.nodes))
+	for i := range order {
+		order[i] = i
+	}
+	if t.tree {
+		slices.SortStableFunc(order, func(x, y int) int { return t.nodes[x].Depth - t.nodes[y].Depth })
+	} else {
+		slices.Reverse(order)
+	}
+	return order
+}
+
+// material lays out the enriched blocks (indexed like nodes), after the
+// ancestors' lines, as the transcript fed to SummarizeText.
+func (t replyThread) material(ancestors, blocks []string) string {
+	var sb strings.Builder
+	if !t.tree {
+		sb.WriteString("Ниже — ветка переписки Telegram, от начала к последнему сообщению, на которое ответили:\n\n")
+		for _, blk := range blocks {
+			sb.WriteString(blk)
+			sb.WriteString("\n\n")
+		}
+		return strings.TrimSpace(sb.String())
+	}
+
+	if len(ancestors) > 0 {
+		sb.WriteString("Контекст — ветка переписки, на конец которой отвечает первое сообщение обсуждения. " +
+			"Она нужна, чтобы понять, о чём речь; отдельно её не пересказывай:\n\n")
+		for _, line := range ancestors {
+			sb.WriteString(line)
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("Ниже — обсуждение в Telegram: первое сообщение и все ответы на него. " +
+		"Ответ стоит с отступом под сообщением, на которое отвечает; каждый прямой ответ на первое сообщение начинает свою ветку. " +
+		"Перескажи обсуждение по веткам: какую позицию заняли участники каждой ветки и к чему она пришла.\n\n")
+	for i, n := range t.nodes {
+		indent := strings.Repeat("  ", n.Depth)
+		if n.Depth == 1 {
+			fmt.Fprintf(&sb, "%sВетка %d:\n", indent, n.Branch)
+		}
+		sb.WriteString(indent)
+		sb.WriteString(strings.ReplaceAll(blocks[i], "\n", "\n"+indent))
+		sb.WriteString("\n")
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// summarizeReplyThread summarizes a reply branch or tree as one conversation:
+// every message gets full treatment (its text, followed links, described
+// images) within thread-wide budgets, then the transcript is summarized —
+// honoring any steering prompt over the whole thread.
+func (b *Bot) summarizeReplyThread(ctx context.Context, groupID int64, userHash string, reply *telego.Message, thread replyThread, steering string) {
+	msgs := make([]db.Message, len(thread.nodes))
+	for i, n := range thread.nodes {
+		msgs[i] = n.Message
+	}
+	all := append(slices.Clone(thread.ancestors), msgs...)
+	lang := b.groupLanguage(ctx, groupID).Resolve(append(summarizer.MessageTexts(all), steering)...)
+	working, header := i18n.ReplyThreadWorking, i18n.ReplyThreadHeader
+	if thread.tree {
+		working, header = i18n.ReplyTreeWorking, i18n.ReplyTreeHeader
+	}
+
+	send := func(text string) int64 { return b.sendMessageReply(ctx, groupID, int64(reply.MessageID), text) }
+	ticket, queuedMsgID := b.admit(ctx, groupID, userHash, KindReply, lang, send)
+	if ticket == nil {
 		return
 	}
 	committed := false
 	defer func() {
 		if !committed {
-			b.rateLimiter.Release(groupID)
+			ticket.Release()
 		}
 	}()
 
-	statusMsgID := b.sendMessageReply(ctx, groupID, int64(reply.MessageID), "Собираю ветку обсуждения...")
+	statusMsgID := b.showStatus(ctx, groupID, queuedMsgID, i18n.T(lang, working), send)
 	instructions := combineInstructions(b.loadGroupSummaryInstructions(ctx, groupID), steering)
 
-	aliases := summarizer.BuildUserAliasMap(chain)
+	aliases := summarizer.BuildUserAliasMap(all)
 	linkBudget := b.cfg.ReplyChainMaxLinks
 	imageBudget := b.cfg.ReplyChainMaxImages
-	seenImg := map[string]string{} // file_unique_id → description, deduped chain-wide
+	seenImg := map[string]string{} // file_unique_id → description, deduped thread-wide
 
-	// Enrich target-first so the most relevant message gets budget priority; keep
-	// the transcript in root→target order.
-	blocks := make([]string, len(chain))
-	for i := len(chain) - 1; i >= 0; i-- {
-		m := chain[i]
-		isTarget := i == len(chain)-1
+	// Enrich target-first so the most relevant messages get budget priority;
+	// the transcript keeps the thread's own order.
+	blocks := make([]string, len(msgs))
+	for _, i := range thread.enrichOrder() {
+		m := msgs[i]
+		isTarget := i == thread.target
 
 		var links []string
 		var photos []db.PhotoRecord
@@ -264,7 +416,7 @@ func (b *Bot) summarizeReplyThread(ctx context.Context, groupID int64, reply *te
 				body = p
 			}
 		} else {
-			// Ancestor (stored, no entities): scan plain text + stored photos.
+			// Stored message (no entities): scan plain text + stored photos.
 			links = tgutil.ExtractURLsFromText(m.Text, replyMaxLinks)
 			if recs, perr := b.db.GetPhotosForMessages(ctx, []int64{m.ID}); perr == nil {
 				photos = recs[m.ID]
@@ -272,10 +424,7 @@ func (b *Bot) summarizeReplyThread(ctx context.Context, groupID int64, reply *te
 		}
 
 		var sb strings.Builder
-		fmt.Fprintf(&sb, "%s [%s]: %s", aliasOrAnon(aliases, m.UserHash), m.Timestamp.Format("15:04"), body)
-		if m.ForwardedFrom != "" {
-			fmt.Fprintf(&sb, " (переслано от %s)", m.ForwardedFrom)
-		}
+		sb.WriteString(threadLine(aliases, m, body))
 
 		for _, p := range photos {
 			if p.FileUniqueID == "" {
@@ -286,7 +435,7 @@ func (b *Bot) summarizeReplyThread(ctx context.Context, groupID int64, reply *te
 				if imageBudget <= 0 {
 					continue
 				}
-				d, derr := b.summarizer.DescribeImage(ctx, p, steering)
+				d, derr := b.summarizer.DescribeImage(ctx, p, steering, lang)
 				if errors.Is(derr, summarizer.ErrVisionDisabled) {
 					break
 				}
@@ -312,7 +461,7 @@ func (b *Bot) summarizeReplyThread(ctx context.Context, groupID int64, reply *te
 			if ferr != nil {
 				continue
 			}
-			summary, serr := b.summarizer.SummarizeURL(ctx, link, content, instructions)
+			summary, serr := b.summarizer.SummarizeURL(ctx, link, content, instructions, lang)
 			if serr != nil || strings.TrimSpace(summary) == "" {
 				continue
 			}
@@ -323,28 +472,27 @@ func (b *Bot) summarizeReplyThread(ctx context.Context, groupID int64, reply *te
 		blocks[i] = sb.String()
 	}
 
-	var material strings.Builder
-	material.WriteString("Ниже — ветка переписки Telegram, от начала к последнему сообщению, на которое ответили:\n\n")
-	for _, blk := range blocks {
-		material.WriteString(blk)
-		material.WriteString("\n\n")
+	// Ancestors are only context: their text, without links or images.
+	ancestorLines := make([]string, len(thread.ancestors))
+	for i, m := range thread.ancestors {
+		ancestorLines[i] = threadLine(aliases, m, strings.TrimSpace(m.Text))
 	}
 
-	summary, err := b.summarizer.SummarizeText(ctx, strings.TrimSpace(material.String()), instructions)
+	summary, err := b.summarizer.SummarizeText(ctx, thread.material(ancestorLines, blocks), instructions, lang)
 	if err != nil {
 		logger.Error().Err(err).Msg("reply-summarize: failed to summarize thread")
-		b.editWithRetry(ctx, groupID, statusMsgID, "Ошибка суммаризации. Попробуйте позже.")
+		b.editWithRetry(ctx, groupID, statusMsgID, i18n.T(lang, i18n.SummarizeFailed))
 		return
 	}
 	result := strings.TrimSpace(summary)
 	if result == "" {
-		b.editWithRetry(ctx, groupID, statusMsgID, "Нет данных для суммаризации.")
+		b.editWithRetry(ctx, groupID, statusMsgID, i18n.T(lang, i18n.SummaryEmpty))
 		return
 	}
 
-	chunks := renderMarkdown("📝 **Суммаризация ветки:**\n\n" + result)
+	chunks := renderMarkdown(i18n.T(lang, header) + "\n\n" + result)
 	if len(chunks) == 0 {
-		b.editWithRetry(ctx, groupID, statusMsgID, "Нет данных для суммаризации.")
+		b.editWithRetry(ctx, groupID, statusMsgID, i18n.T(lang, i18n.SummaryEmpty))
 		return
 	}
 	if err := b.editFormattedFinal(ctx, groupID, statusMsgID, chunks[0]); err != nil {
@@ -357,6 +505,16 @@ func (b *Bot) summarizeReplyThread(ctx context.Context, groupID int64, reply *te
 	committed = true
 }
 
+// threadLine renders a thread message as "alias [15:04]: body", noting where
+// a forward came from.
+func threadLine(aliases map[string]string, m db.Message, body string) string {
+	line := fmt.Sprintf("%s [%s]: %s", aliasOrAnon(aliases, m.UserHash), m.Timestamp.Format("15:04"), body)
+	if m.ForwardedFrom != "" {
+		line += fmt.Sprintf(" (переслано от %s)", m.ForwardedFrom)
+	}
+	return line
+}
+
 // aliasOrAnon returns the alias for a user hash, or "anon" when unknown/empty.
 func aliasOrAnon(aliases map[string]string, hash string) string {
 	if a := aliases[hash]; a != "" {
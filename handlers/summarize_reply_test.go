@@ -515,3 +515,96 @@ func TestHandleSummarizeReplyThreadFallbackWhenTargetNotStored(t *testing.T) {
 		t.Fatalf("unexpected result: %#v", tg.editTexts)
 	}
 }
+
+func TestHandleSummarizeReplyWalksReplyTree(t *testing.T) {
+	sum := &fakeSummarizer{textSummary: "позиции веток"}
+	b, database, tg := newTestBot(t, sum)
+	defer func() { _ = database.Close() }()
+	b.cfg.ReplyThreads = true
+	ctx := context.Background()
+
+	// 20 is the argument's opening post, which has itself aged out; two
+	// branches answer it, one with a nested reply.
+	seed := []struct {
+		tg, replyTo int64
+		text        string
+	}{
+		{21, 20, "я за монолит"},
+		{22, 20, "а я за микросервисы"},
+		{23, 21, "монолит проще деплоить"},
+		{30, 0, "не относится к спору"},
+	}
+	for i, s := range seed {
+		if _, err := database.AddMessageReturningID(ctx, &db.Message{
+			GroupID: 42, UserHash: "h" + string(rune('1'+i)), Text: s.text,
+			Timestamp:   time.Now().Add(time.Duration(i-len(seed)) * time.Minute),
+			TgMessageID: s.tg, ReplyToTgID: s.replyTo,
+		}); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+
+	b.handleSummarizeReply(ctx, replyUpdate(&telego.Message{MessageID: 20, Text: "монолит или микросервисы?"}), "")
+
+	if sum.textCalls != 1 {
+		t.Fatalf("expected one SummarizeText call, got %d", sum.textCalls)
+	}
+	mat := sum.textInput
+	for _, want := range []string{"монолит или микросервисы?", "Ветка 1:", "\n  У1 [", "\n    У2 [", "монолит проще деплоить", "Ветка 2:", "а я за микросервисы"} {
+		if !strings.Contains(mat, want) {
+			t.Fatalf("tree material missing %q:\n%s", want, mat)
+		}
+	}
+	if strings.Contains(mat, "не относится к спору") {
+		t.Fatalf("tree material includes an unrelated message:\n%s", mat)
+	}
+	if strings.Index(mat, "монолит проще деплоить") > strings.Index(mat, "Ветка 2:") {
+		t.Fatalf("nested reply not kept under its branch:\n%s", mat)
+	}
+	if len(tg.editTexts) != 1 || !strings.Contains(tg.editTexts[0], "позиции веток") {
+		t.Fatalf("unexpected result: %#v", tg.editTexts)
+	}
+}
+
+func TestHandleSummarizeReplyTreeKeepsAncestorContext(t *testing.T) {
+	sum := &fakeSummarizer{textSummary: "позиции веток"}
+	b, database, _ := newTestBot(t, sum)
+	defer func() { _ = database.Close() }()
+	b.cfg.ReplyThreads = true
+	ctx := context.Background()
+
+	// 20 asks about 10 and is itself answered twice.
+	seed := []struct {
+		tg, replyTo int64
+		text        string
+	}{
+		{10, 0, "переносим биллинг на новый кластер"},
+		{20, 10, "монолит или микросервисы?"},
+		{21, 20, "я за монолит"},
+		{22, 20, "а я за микросервисы"},
+	}
+	for i, s := range seed {
+		if _, err := database.AddMessageReturningID(ctx, &db.Message{
+			GroupID: 42, UserHash: "h" + string(rune('1'+i)), Text: s.text,
+			Timestamp:   time.Now().Add(time.Duration(i-len(seed)) * time.Minute),
+			TgMessageID: s.tg, ReplyToTgID: s.replyTo,
+		}); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+
+	b.handleSummarizeReply(ctx, replyUpdate(&telego.Message{MessageID: 20, Text: "монолит или микросервисы?"}), "")
+
+	if sum.textCalls != 1 {
+		t.Fatalf("expected one SummarizeText call, got %d", sum.textCalls)
+	}
+	mat := sum.textInput
+	for _, want := range []string{"Контекст", "У1 [", "переносим биллинг на новый кластер", "Ветка 1:", "я за монолит", "Ветка 2:", "а я за микросервисы"} {
+		if !strings.Contains(mat, want) {
+			t.Fatalf("tree material missing %q:\n%s", want, mat)
+		}
+	}
+	if strings.Index(mat, "переносим биллинг") > strings.Index(mat, "монолит или микросервисы?") {
+		t.Fatalf("the ancestor should come before the tree:\n%s", mat)
+	}
+}
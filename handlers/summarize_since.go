@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/logger"
+	"telegram_summarize_bot/summarizer"
+
+	"github.com/mymmrac/telego"
+)
+
+// handleSummarizeSince handles "@bot summarize since" sent as a reply: a topic
+// summary of everything stored from the replied-to message onwards, up to the
+// group's message cap. The summary is posted as a reply to that message and
+// links back to it; unlike "@bot summarize" it doesn't move the group's last
+// summary time, as it looks back rather than catching the chat up.
+func (b *Bot) handleSummarizeSince(ctx context.Context, update telego.Update) {
+	msg := update.Message
+	groupID := msg.Chat.ID
+	anchorID := int64(msg.ReplyToMessage.MessageID)
+	lang := b.groupLanguage(ctx, groupID)
+	settings := b.groupSettings(ctx, groupID)
+	send := func(text string) int64 { return b.sendMessageReply(ctx, groupID, anchorID, text) }
+
+	anchor, err := b.db.GetMessageByTgID(ctx, groupID, anchorID)
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to get since anchor")
+		send(i18n.T(lang, i18n.MessagesError))
+		return
+	}
+	if anchor == nil {
+		send(i18n.T(lang, i18n.SinceNotStored))
+		return
+	}
+	messages, err := b.db.GetMessagesFrom(ctx, groupID, anchor.Timestamp, settings.MaxMessages)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to get messages")
+		send(i18n.T(lang, i18n.MessagesError))
+		return
+	}
+	if len(messages) == 0 {
+		send(i18n.T(lang, i18n.SinceNotStored))
+		return
+	}
+	lang = lang.Resolve(summarizer.MessageTexts(messages)...)
+
+	instructions := b.loadGroupSummaryInstructions(ctx, groupID)
+	b.submitSummary(ctx, summaryRequest{
+		groupID:   groupID,
+		lang:      lang,
+		requester: b.requesterHash(groupID, msg),
+		send:      send,
+		key:       summaryFlightKey(groupID, anchorID, messages, settings, lang, instructions),
+		job:       newSummaryJob(messages, time.Time{}, anchorID),
+		status:    i18n.T(lang, i18n.SinceCollecting, len(messages)),
+	})
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/summarizer"
+
+	"github.com/mymmrac/telego"
+)
+
+// sinceUpdate builds "@bot summarize since" as a reply to anchorID in a
+// supergroup, so message links resolve.
+func sinceUpdate(groupID int64, anchorID int, text string) telego.Update {
+	return telego.Update{Message: &telego.Message{
+		MessageID:      900,
+		Text:           text,
+		Chat:           telego.Chat{ID: groupID, Type: "supergroup"},
+		From:           &telego.User{ID: 7},
+		ReplyToMessage: &telego.Message{MessageID: anchorID, Text: "с этого"},
+	}}
+}
+
+func TestSummarizeSince(t *testing.T) {
+	sum := &fakeSummarizer{summary: &summarizer.StructuredSummary{
+		Lang:   i18n.Russian,
+		Topics: []summarizer.TopicSummary{{Title: "Релиз", Summary: "Решили катить вечером."}},
+	}}
+	b, database, tg := newTestBot(t, sum)
+	defer func() { _ = database.Close() }()
+	ctx := context.Background()
+	const groupID int64 = -1001234567
+	start := time.Now().Add(-30 * time.Hour) // older than the default window
+
+	for i, text := range []string{"до якоря", "якорь", "после якоря", "ещё после"} {
+		if err := database.AddMessage(ctx, &db.Message{
+			GroupID: groupID, UserHash: "a3f2b1c4", Text: text,
+			Timestamp: start.Add(time.Duration(i) * time.Minute), TgMessageID: int64(10 + i),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b.handleCommand(ctx, sinceUpdate(groupID, 11, "@testbot summarize since"), "summarize since")
+	waitJobs(t, b)
+
+	if sum.calls != 1 {
+		t.Fatalf("topic summaries = %d, want 1", sum.calls)
+	}
+	var got []string
+	for _, m := range sum.topicMessages {
+		got = append(got, m.Text)
+	}
+	if strings.Join(got, "|") != "якорь|после якоря|ещё после" {
+		t.Fatalf("summarized %v, want the anchor and everything after", got)
+	}
+	if len(tg.sentReplyTo) != 1 || tg.sentReplyTo[0] != 11 {
+		t.Fatalf("status not posted as a reply to the anchor: %v", tg.sentReplyTo)
+	}
+	if len(tg.editTexts) != 1 || !strings.Contains(tg.editTexts[0], "https://t.me/c/1234567/11") || !strings.Contains(tg.editTexts[0], "Релиз") {
+		t.Fatalf("summary doesn't link back to the anchor: %q", tg.editTexts)
+	}
+	if last, _ := database.GetLastSummarizeTime(ctx, groupID); last != nil {
+		t.Errorf("summarize since moved the last summary time to %v", last)
+	}
+}
+
+func TestSummarizeSinceAnchorNotStored(t *testing.T) {
+	sum := &fakeSummarizer{}
+	b, database, tg := newTestBot(t, sum)
+	defer func() { _ = database.Close() }()
+
+	b.handleCommand(context.Background(), sinceUpdate(-1001234567, 11, "@testbot since"), "since")
+
+	if sum.calls != 0 {
+		t.Fatalf("summarized without an anchor: %d calls", sum.calls)
+	}
+	if len(tg.sentTexts) != 1 || tg.sentTexts[0] != i18n.T(i18n.Russian, i18n.SinceNotStored) {
+		t.Fatalf("unexpected reply: %q", tg.sentTexts)
+	}
+}
@@ -16,6 +16,7 @@ func TestHandleSummarizeNoMessages(t *testing.T) {
 	defer func() { _ = database.Close() }()
 
 	b.handleSummarize(context.Background(), summarizeUpdate(), nil)
+	waitJobs(t, b)
 
 	if len(tg.sentTexts) != 1 {
 		t.Fatalf("sent message count = %d, want 1", len(tg.sentTexts))
@@ -48,6 +49,7 @@ func TestHandleSummarizeUpdatesLastSummarizeOnSuccess(t *testing.T) {
 	}
 
 	b.handleSummarize(context.Background(), summarizeUpdate(), nil)
+	waitJobs(t, b)
 
 	if sum.calls != 1 {
 		t.Fatalf("summarizer calls = %d, want 1", sum.calls)
@@ -94,6 +96,7 @@ func TestHandleSummarizePassesGroupSummaryInstructions(t *testing.T) {
 	}
 
 	b.handleSummarize(ctx, summarizeUpdate(), nil)
+	waitJobs(t, b)
 
 	if sum.additionalInstructions != "выделяй риски" {
 		t.Fatalf("additionalInstructions = %q, want %q", sum.additionalInstructions, "выделяй риски")
@@ -116,6 +119,7 @@ func TestHandleSummarizeDoesNotUpdateLastSummarizeOnFailure(t *testing.T) {
 	}
 
 	b.handleSummarize(context.Background(), summarizeUpdate(), nil)
+	waitJobs(t, b)
 
 	last, err := database.GetLastSummarizeTime(context.Background(), 42)
 	if err != nil {
@@ -153,6 +157,7 @@ func TestHandleSummarizeRateLimited(t *testing.T) {
 
 	// First call should succeed.
 	b.handleSummarize(context.Background(), summarizeUpdate(), nil)
+	waitJobs(t, b)
 	if sum.calls != 1 {
 		t.Fatalf("expected 1 summarizer call, got %d", sum.calls)
 	}
@@ -172,6 +177,7 @@ func TestHandleSummarizeRateLimited(t *testing.T) {
 	// Second call should be rate limited.
 	tg.sentTexts = nil
 	b.handleSummarize(context.Background(), summarizeUpdate(), nil)
+	waitJobs(t, b)
 	if sum.calls != 1 {
 		t.Fatalf("expected summarizer not called again, got %d calls", sum.calls)
 	}
@@ -186,6 +192,7 @@ func TestHandleSummarizeCustomHours(t *testing.T) {
 
 	// Try invalid hours.
 	b.handleSummarize(context.Background(), summarizeUpdate(), []string{"-5"})
+	waitJobs(t, b)
 	if len(tg.sentTexts) != 1 || !strings.Contains(tg.sentTexts[0], "Неверный формат") {
 		t.Fatalf("expected format error, got: %v", tg.sentTexts)
 	}
@@ -193,6 +200,7 @@ func TestHandleSummarizeCustomHours(t *testing.T) {
 	// Try hours exceeding max.
 	tg.sentTexts = nil
 	b.handleSummarize(context.Background(), summarizeUpdate(), []string{"48"})
+	waitJobs(t, b)
 	if len(tg.sentTexts) != 1 || !strings.Contains(tg.sentTexts[0], fmt.Sprintf("Максимальный период суммаризации — %d", b.cfg.SummaryHours)) {
 		t.Fatalf("expected max hours error, got: %v", tg.sentTexts)
 	}
@@ -2,12 +2,17 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"strings"
 	"time"
 
 	"telegram_summarize_bot/logger"
+	"telegram_summarize_bot/summarizer"
 
 	telegramify "github.com/barbashov/telegramify-markdown-go"
 	"github.com/mymmrac/telego"
+	"github.com/mymmrac/telego/telegoapi"
 	tu "github.com/mymmrac/telego/telegoutil"
 )
 
@@ -19,6 +24,22 @@ func renderMarkdown(md string) []string {
 	return telegramify.Split(telegramify.Markdownify(md), telegramMessageLimit)
 }
 
+// chatTitlePlaceholder stands in for a chat title in Markdown passed to
+// renderMarkdownWithTitle. A private-use rune passes through telegramify
+// untouched and never appears in chat titles or summaries in practice.
+const chatTitlePlaceholder = "\uE000"
+
+// renderMarkdownWithTitle is renderMarkdown for Markdown that names a chat
+// by title at chatTitlePlaceholder. Titles are set by group members, and
+// telegramify keeps Markdown backslash escapes as literal backslashes, so the
+// title is put in after conversion, escaped for MarkdownV2, where no
+// formatting character in it can break the message.
+func renderMarkdownWithTitle(md, title string) []string {
+	v2 := telegramify.Markdownify(md)
+	v2 = strings.Replace(v2, chatTitlePlaceholder, summarizer.EscapeMarkdown(title), 1)
+	return telegramify.Split(v2, telegramMessageLimit)
+}
+
 const (
 	editRetries    = 3
 	editRetryDelay = 2 * time.Second
@@ -56,6 +77,57 @@ func (b *Bot) sendMessageReply(ctx context.Context, chatID, replyToMsgID int64,
 	return int64(msg.MessageID)
 }
 
+// sendDocumentReply uploads data as a file named name, replying to
+// replyToMsgID (none when 0), with a plain-text caption.
+func (b *Bot) sendDocumentReply(ctx context.Context, chatID, replyToMsgID int64, name string, data []byte, caption string) error {
+	defer b.metrics.TelegramSend.Start()()
+	params := tu.Document(tu.ID(chatID), tu.FileFromBytes(data, name)).WithCaption(caption)
+	if replyToMsgID != 0 {
+		params = params.WithReplyParameters(&telego.ReplyParameters{MessageID: int(replyToMsgID)})
+	}
+	if _, err := b.telegram.SendDocument(ctx, params); err != nil {
+		logger.Error().Err(err).Int64("chat_id", chatID).Msg("failed to send document")
+		b.metrics.RecordError("telegram_send", err.Error())
+		return err
+	}
+	return nil
+}
+
+// sendPrivate sends a plain-text message to a user's private chat and returns
+// the new message's ID. Unlike sendMessage it returns the error, so callers
+// can tell a user who hasn't started the bot (see isBotBlocked) apart from
+// other failures.
+func (b *Bot) sendPrivate(ctx context.Context, userID int64, text string) (int64, error) {
+	defer b.metrics.TelegramSend.Start()()
+	msg, err := b.telegram.SendMessage(ctx, tu.Message(tu.ID(userID), text))
+	if err != nil {
+		if !isBotBlocked(err) {
+			logger.Error().Err(err).Int64("chat_id", userID).Msg("failed to send private message")
+			b.metrics.RecordError("telegram_send", err.Error())
+		}
+		return 0, err
+	}
+	return int64(msg.MessageID), nil
+}
+
+// sendPrivateFormatted is sendPrivate for a MarkdownV2 message.
+func (b *Bot) sendPrivateFormatted(ctx context.Context, userID int64, text string) error {
+	defer b.metrics.TelegramSend.Start()()
+	_, err := b.telegram.SendMessage(ctx, tu.Message(tu.ID(userID), text).WithParseMode("MarkdownV2"))
+	if err != nil && !isBotBlocked(err) {
+		logger.Error().Err(err).Int64("chat_id", userID).Msg("failed to send formatted private message")
+		b.metrics.RecordError("telegram_send", err.Error())
+	}
+	return err
+}
+
+// isBotBlocked reports whether err is Telegram refusing a private message
+// because the user never started the bot or has blocked it.
+func isBotBlocked(err error) bool {
+	var apiErr *telegoapi.Error
+	return errors.As(err, &apiErr) && apiErr.ErrorCode == http.StatusForbidden
+}
+
 func (b *Bot) editWithRetry(ctx context.Context, chatID, msgID int64, text string) {
 	for range editRetries {
 		if err := b.editMessage(ctx, chatID, msgID, text); err == nil {
@@ -82,24 +154,37 @@ func (b *Bot) editMessage(ctx context.Context, chatID, messageID int64, text str
 }
 
 func (b *Bot) sendFormatted(ctx context.Context, chatID int64, text string) {
+	_ = b.sendFormattedMarkup(ctx, chatID, text, nil)
+}
+
+// sendFormattedMarkup sends a MarkdownV2 message with an optional inline
+// keyboard and returns the error (already logged).
+func (b *Bot) sendFormattedMarkup(ctx context.Context, chatID int64, text string, markup *telego.InlineKeyboardMarkup) error {
 	defer b.metrics.TelegramSend.Start()()
-	_, err := b.telegram.SendMessage(ctx, tu.Message(
-		tu.ID(chatID),
-		text,
-	).WithParseMode("MarkdownV2"))
+	params := tu.Message(tu.ID(chatID), text).WithParseMode("MarkdownV2")
+	if markup != nil {
+		params = params.WithReplyMarkup(markup)
+	}
+	_, err := b.telegram.SendMessage(ctx, params)
 	if err != nil {
 		logger.Error().Err(err).Int64("chat_id", chatID).Msg("failed to send formatted message")
 		b.metrics.RecordError("telegram_send", err.Error())
 	}
+	return err
 }
 
 func (b *Bot) editFormatted(ctx context.Context, chatID, messageID int64, text string) error {
+	return b.editFormattedMarkup(ctx, chatID, messageID, text, nil)
+}
+
+func (b *Bot) editFormattedMarkup(ctx context.Context, chatID, messageID int64, text string, markup *telego.InlineKeyboardMarkup) error {
 	defer b.metrics.TelegramEdit.Start()()
 	_, err := b.telegram.EditMessageText(ctx, &telego.EditMessageTextParams{
-		ChatID:    tu.ID(chatID),
-		MessageID: int(messageID),
-		Text:      text,
-		ParseMode: "MarkdownV2",
+		ChatID:      tu.ID(chatID),
+		MessageID:   int(messageID),
+		Text:        text,
+		ParseMode:   "MarkdownV2",
+		ReplyMarkup: markup,
 	})
 	if err != nil {
 		logger.Error().Err(err).Int64("chat_id", chatID).Int64("message_id", messageID).Msg("failed to edit formatted message")
@@ -122,9 +207,13 @@ func (b *Bot) editFormattedWithRetry(ctx context.Context, chatID, msgID int64, t
 // editFormattedFinal is like editFormattedWithRetry but returns the last error
 // so the caller can decide whether the delivery succeeded.
 func (b *Bot) editFormattedFinal(ctx context.Context, chatID, msgID int64, text string) error {
+	return b.editFormattedFinalMarkup(ctx, chatID, msgID, text, nil)
+}
+
+func (b *Bot) editFormattedFinalMarkup(ctx context.Context, chatID, msgID int64, text string, markup *telego.InlineKeyboardMarkup) error {
 	var lastErr error
 	for range editRetries {
-		if lastErr = b.editFormatted(ctx, chatID, msgID, text); lastErr == nil {
+		if lastErr = b.editFormattedMarkup(ctx, chatID, msgID, text, markup); lastErr == nil {
 			return nil
 		}
 		if !sleepCtx(ctx, editRetryDelay) {
@@ -135,6 +224,32 @@ func (b *Bot) editFormattedFinal(ctx context.Context, chatID, msgID int64, text
 	return lastErr
 }
 
+// deliverChunks puts rendered MarkdownV2 chunks into the chat: the first one
+// replaces the status message (or is sent anew when statusMsgID is 0) and the
+// rest follow. markup, if any, goes under the last chunk. Only a failure to
+// deliver the first chunk is returned.
+func (b *Bot) deliverChunks(ctx context.Context, chatID, statusMsgID int64, chunks []string, markup *telego.InlineKeyboardMarkup) error {
+	markupFor := func(i int) *telego.InlineKeyboardMarkup {
+		if i == len(chunks)-1 {
+			return markup
+		}
+		return nil
+	}
+	var err error
+	if statusMsgID != 0 {
+		err = b.editFormattedFinalMarkup(ctx, chatID, statusMsgID, chunks[0], markupFor(0))
+	} else {
+		err = b.sendFormattedMarkup(ctx, chatID, chunks[0], markupFor(0))
+	}
+	if err != nil {
+		return err
+	}
+	for i, chunk := range chunks[1:] {
+		_ = b.sendFormattedMarkup(ctx, chatID, chunk, markupFor(i+1))
+	}
+	return nil
+}
+
 // sleepCtx waits for d to elapse or ctx to be cancelled. Returns true on
 // timer completion, false on cancellation.
 func sleepCtx(ctx context.Context, d time.Duration) bool {
@@ -143,3 +143,13 @@ func TestSleepCtx(t *testing.T) {
 		}
 	})
 }
+
+func TestRenderMarkdownWithTitle(t *testing.T) {
+	// Formatting characters in the title are shown as typed and don't
+	// unbalance the surrounding bold.
+	chunks := renderMarkdownWithTitle("📬 **Catchup: "+chatTitlePlaceholder+"** (since 09:00 UTC)\n\n**1. Релиз**", "Dev_chat *[beta]*")
+	want := "📬 *Catchup: Dev\\_chat \\*\\[beta\\]\\** \\(since 09:00 UTC\\)\n\n*1\\. Релиз*"
+	if len(chunks) != 1 || chunks[0] != want {
+		t.Fatalf("renderMarkdownWithTitle = %q, want %q", chunks, want)
+	}
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/logger"
+
+	"github.com/mymmrac/telego"
+)
+
+// groupTimezone returns the group's timezone, falling back to the configured
+// default when the group has none, on a lookup error or when the stored name
+// no longer loads.
+func (b *Bot) groupTimezone(ctx context.Context, groupID int64) *time.Location {
+	raw, err := b.db.GetGroupTimezone(ctx, groupID)
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to get group timezone")
+	}
+	if raw != "" {
+		if loc, err := time.LoadLocation(raw); err == nil {
+			return loc
+		}
+	}
+	if b.cfg.Timezone != nil {
+		return b.cfg.Timezone
+	}
+	return time.UTC
+}
+
+// handleTimezone shows the group's timezone, or sets it when a group admin
+// passes an IANA name such as Europe/Moscow.
+func (b *Bot) handleTimezone(ctx context.Context, update telego.Update, args []string) {
+	msg := update.Message
+	groupID := msg.Chat.ID
+	lang := b.groupLanguage(ctx, groupID)
+
+	if len(args) == 0 {
+		b.sendMessage(ctx, groupID, i18n.T(lang, i18n.TimezoneCurrent, b.groupTimezone(ctx, groupID)))
+		return
+	}
+
+	if !b.isGroupAdmin(ctx, groupID, msg.From.ID) {
+		b.sendMessage(ctx, groupID, i18n.T(lang, i18n.TimezoneAdminsOnly))
+		return
+	}
+
+	// "Local" would mean the server's zone, which the group can't see.
+	loc, err := time.LoadLocation(args[0])
+	if err != nil || args[0] == "Local" {
+		b.sendMessage(ctx, groupID, i18n.T(lang, i18n.TimezoneBadValue))
+		return
+	}
+	if err := b.db.SetGroupTimezone(ctx, groupID, msg.From.ID, loc.String()); err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to set group timezone")
+		b.sendMessage(ctx, groupID, i18n.T(lang, i18n.TimezoneSaveError))
+		return
+	}
+	b.sendMessage(ctx, groupID, i18n.T(lang, i18n.TimezoneSet, loc))
+}
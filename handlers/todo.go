@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/logger"
+	"telegram_summarize_bot/summarizer"
+
+	"github.com/mymmrac/telego"
+)
+
+const (
+	// todoMaxItems caps the "@bot todo" list.
+	todoMaxItems = 30
+	// todoDateLayout formats the day headings of the list.
+	todoDateLayout = "02.01"
+)
+
+// handleTodo handles "todo": the action items extracted with the group's
+// summaries over the retention period, newest first, under the days of the
+// group's timezone. An item restated by a later summary of the same message
+// is listed once.
+func (b *Bot) handleTodo(ctx context.Context, update telego.Update) {
+	msg := update.Message
+	groupID := msg.Chat.ID
+	lang := b.groupLanguage(ctx, groupID)
+	reply := func(text string) { b.sendMessageReply(ctx, groupID, int64(msg.MessageID), text) }
+
+	days := b.cfg.RetentionDays
+	items, err := b.db.GetSummaryItems(ctx, groupID, db.ItemAction, time.Now().Add(-b.cfg.RetentionDuration()))
+	if err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to get action items")
+		reply(i18n.T(lang, i18n.TodoLoadError))
+		return
+	}
+	items = dedupeTodo(items)
+	if len(items) == 0 {
+		if !b.groupSettings(ctx, groupID).ActionItems {
+			reply(i18n.T(lang, i18n.TodoDisabled))
+			return
+		}
+		reply(i18n.T(lang, i18n.TodoEmpty, days))
+		return
+	}
+
+	chunks := renderMarkdown(formatTodo(lang, groupID, days, b.groupTimezone(ctx, groupID), items))
+	if err := b.deliverChunks(ctx, groupID, 0, chunks, nil); err != nil {
+		logger.Error().Err(err).Int64("group_id", groupID).Msg("failed to send action items")
+	}
+}
+
+// todoKey identifies an item across summaries: its source message and its
+// text, ignoring case, spacing and closing punctuation. Aliases differ between
+// summaries, so the owner is left out; one message can hold several tasks,
+// so the message alone isn't enough.
+type todoKey struct {
+	tgMessageID int64
+	text        string
+}
+
+// dedupeTodo keeps the first (newest) of the items with the same todoKey, up
+// to todoMaxItems.
+func dedupeTodo(items []db.SummaryItem) []db.SummaryItem {
+	seen := make(map[todoKey]bool)
+	var out []db.SummaryItem
+	for _, it := range items {
+		key := todoKey{it.TgMessageID, normalizeTodoText(it.Text)}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, it)
+		if len(out) == todoMaxItems {
+			break
+		}
+	}
+	return out
+}
+
+func normalizeTodoText(s string) string {
+	return strings.TrimRight(strings.Join(strings.Fields(strings.ToLower(s)), " "), ".!;…")
+}
+
+// formatTodo renders the list as Markdown, under a heading per summary day
+// in loc.
+func formatTodo(lang i18n.Lang, groupID int64, days int, loc *time.Location, items []db.SummaryItem) string {
+	var sb strings.Builder
+	sb.WriteString(i18n.T(lang, i18n.TodoHeader, days))
+	day := ""
+	for _, it := range items {
+		if d := it.CreatedAt.In(loc).Format(todoDateLayout); d != day {
+			day = d
+			sb.WriteString("\n\n" + i18n.T(lang, i18n.TodoDay, day))
+		}
+		sb.WriteString("\n• " + summarizer.FormatSummaryItem(summarizer.SummaryItem{
+			Text: it.Text, Owner: it.Owner, Due: it.Due, TgMessageID: it.TgMessageID,
+		}, groupID, lang))
+	}
+	sb.WriteString("\n\n" + i18n.T(lang, i18n.TodoAliasNote))
+	return sb.String()
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/summarizer"
+
+	"github.com/mymmrac/telego"
+)
+
+func todoUpdate() telego.Update {
+	return telego.Update{
+		Message: &telego.Message{
+			MessageID: 100,
+			Text:      "@testbot todo",
+			Chat:      telego.Chat{ID: -1001234567890, Type: "supergroup", Title: "Dev chat"},
+			From:      &telego.User{ID: 7},
+		},
+	}
+}
+
+func TestHandleTodo(t *testing.T) {
+	b, database, tg := newTestBot(t, &fakeSummarizer{})
+	defer func() { _ = database.Close() }()
+	b.cfg.RetentionDays = 7
+	ctx := context.Background()
+	const groupID = -1001234567890
+
+	b.handleCommand(ctx, todoUpdate(), "todo")
+	if len(tg.sentTexts) != 1 || tg.sentTexts[0] != i18n.T(i18n.Russian, i18n.TodoDisabled) {
+		t.Fatalf("without extraction = %q, want the disabled hint", tg.sentTexts)
+	}
+
+	// Two summaries restate the same task; the newer one wins.
+	for _, owner := range []string{"У3", "У2"} {
+		b.saveSummary(ctx, groupID, &summarizer.StructuredSummary{
+			Lang: i18n.Russian,
+			Actions: &summarizer.SummaryActions{
+				Decisions: []summarizer.SummaryItem{{Text: "Релиз в пятницу", TgMessageID: 11}},
+				ActionItems: []summarizer.SummaryItem{
+					{Text: "Собрать сборку", Owner: owner, Due: "четверг", TgMessageID: 12},
+				},
+			},
+		})
+	}
+	b.saveSummary(ctx, groupID, &summarizer.StructuredSummary{
+		Lang:    i18n.Russian,
+		Actions: &summarizer.SummaryActions{ActionItems: []summarizer.SummaryItem{{Text: "Написать анонс"}}},
+	})
+
+	tg.sentTexts = nil
+	b.handleCommand(ctx, todoUpdate(), "todo")
+	if len(tg.sentTexts) != 1 {
+		t.Fatalf("todo replies = %q", tg.sentTexts)
+	}
+	got := tg.sentTexts[0]
+	for _, want := range []string{"Написать анонс", "У2", "Собрать сборку", "https://t.me/c/1234567890/12", "четверг"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("todo missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "У3") || strings.Count(got, "Собрать сборку") != 1 {
+		t.Errorf("a restated task should be listed once, from the newest summary:\n%s", got)
+	}
+	if strings.Contains(got, "Релиз в пятницу") {
+		t.Errorf("decisions don't belong in the todo list:\n%s", got)
+	}
+	if strings.Index(got, "Написать анонс") > strings.Index(got, "Собрать сборку") {
+		t.Errorf("newest items should come first:\n%s", got)
+	}
+}
+
+func TestDedupeTodo(t *testing.T) {
+	items := []db.SummaryItem{
+		{Text: "Собрать сборку", Owner: "У2", TgMessageID: 12},
+		{Text: "Написать анонс", TgMessageID: 12},
+		{Text: "собрать  сборку.", Owner: "У3", TgMessageID: 12},
+		{Text: "Собрать сборку", TgMessageID: 13},
+		{Text: "Обновить доку"},
+		{Text: "обновить доку"},
+	}
+	want := []db.SummaryItem{items[0], items[1], items[3], items[4]}
+	if got := dedupeTodo(items); !reflect.DeepEqual(got, want) {
+		t.Fatalf("dedupeTodo = %+v\nwant %+v", got, want)
+	}
+
+	many := make([]db.SummaryItem, todoMaxItems+5)
+	for i := range many {
+		many[i] = db.SummaryItem{Text: "задача", TgMessageID: int64(i + 1)}
+	}
+	if got := dedupeTodo(many); len(got) != todoMaxItems {
+		t.Fatalf("dedupeTodo kept %d items, want the cap of %d", len(got), todoMaxItems)
+	}
+}
+
+func TestFormatTodoDaysInGroupTimezone(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 22:30 UTC on March 9 is already March 10 in Moscow.
+	items := []db.SummaryItem{{Text: "Собрать сборку", CreatedAt: time.Date(2026, 3, 9, 22, 30, 0, 0, time.UTC)}}
+	got := formatTodo(i18n.Russian, -100, 7, moscow, items)
+	if !strings.Contains(got, i18n.T(i18n.Russian, i18n.TodoDay, "10.03")) {
+		t.Fatalf("todo should head the item with the group's day:\n%s", got)
+	}
+}
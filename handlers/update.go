@@ -2,10 +2,10 @@ package handlers
 
 import (
 	"context"
-	"fmt"
 	"time"
 
 	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
 	"telegram_summarize_bot/logger"
 
 	"github.com/mymmrac/telego"
@@ -106,10 +106,14 @@ func (b *Bot) handleUpdate(ctx context.Context, update telego.Update) {
 	// Forwarded messages are stored with original author attribution but never
 	// treated as commands — the forwarder didn't intend to issue one.
 	if msg.ForwardOrigin != nil {
-		forwardedFrom := forwardOriginHandle(msg.ForwardOrigin, groupID, b.userHashSalt)
+		userHash := b.userHash(ctx, msg.From.ID, groupID)
+		if b.optedOut(ctx, groupID, userHash) {
+			return
+		}
+		forwardedFrom := forwardOriginHandle(msg.ForwardOrigin, groupID, b.currentSalt())
 		msgID, err := b.db.AddMessageReturningID(ctx, &db.Message{
 			GroupID:       groupID,
-			UserHash:      db.UserHash(msg.From.ID, groupID, b.userHashSalt),
+			UserHash:      userHash,
 			Text:          text,
 			Timestamp:     time.Now(),
 			ForwardedFrom: forwardedFrom,
@@ -139,9 +143,15 @@ func (b *Bot) handleUpdate(ctx context.Context, update telego.Update) {
 		return
 	}
 
+	// Members who asked to be forgotten can still use commands, but what
+	// they write isn't kept.
+	userHash := b.userHash(ctx, msg.From.ID, groupID)
+	if b.optedOut(ctx, groupID, userHash) {
+		return
+	}
 	msgID, err := b.db.AddMessageReturningID(ctx, &db.Message{
 		GroupID:     groupID,
-		UserHash:    db.UserHash(msg.From.ID, groupID, b.userHashSalt),
+		UserHash:    userHash,
 		Text:        text,
 		Timestamp:   time.Now(),
 		TgMessageID: tgMessageID,
@@ -173,7 +183,7 @@ func (b *Bot) handleMyChatMember(ctx context.Context, cmu *telego.ChatMemberUpda
 		logger.Info().Int64("group_id", groupID).Str("title", title).Str("username", cmu.Chat.Username).Msg("upserted known group on bot join")
 	}
 
-	msg := fmt.Sprintf("Бот добавлен в группу «%s» (%d).\nДля разрешения: /groups add %d", title, groupID, groupID)
+	msg := i18n.T(b.cfg.Language, i18n.AdminBotAdded, title, groupID, groupID)
 	b.NotifyUsers(ctx, msg)
 }
 
@@ -0,0 +1,74 @@
+package i18n
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+var verbRe = regexp.MustCompile(`%[0-9]*[a-zA-Z]`)
+
+func TestCatalogueComplete(t *testing.T) {
+	for key, entry := range catalogue {
+		ru, ok := entry[Russian]
+		if !ok {
+			t.Errorf("%s: missing Russian text", key)
+			continue
+		}
+		en, ok := entry[English]
+		if !ok {
+			t.Errorf("%s: missing English text", key)
+			continue
+		}
+		if !slices.Equal(verbRe.FindAllString(ru, -1), verbRe.FindAllString(en, -1)) {
+			t.Errorf("%s: format verbs differ between ru %q and en %q", key, ru, en)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	if got := T(English, InstSaved, int64(-100)); got != "Instructions for group -100 saved." {
+		t.Fatalf("T(en) = %q", got)
+	}
+	if got := T("", SummaryEmpty); got != "Нет данных для суммаризации." {
+		t.Fatalf("T(empty lang) = %q, want Russian default", got)
+	}
+	if got := T(Auto, SummaryEmpty); got != "Нет данных для суммаризации." {
+		t.Fatalf("T(auto) = %q, want Russian default", got)
+	}
+	if got := T(English, Key("no.such.key")); got != "no.such.key" {
+		t.Fatalf("T(unknown key) = %q", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	for in, want := range map[string]Lang{"ru": Russian, " EN ": English, "Auto": Auto} {
+		if got, ok := Parse(in); !ok || got != want {
+			t.Errorf("Parse(%q) = %q, %v", in, got, ok)
+		}
+	}
+	if _, ok := Parse("de"); ok {
+		t.Error("Parse(de) should fail")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		lang  Lang
+		texts []string
+		want  Lang
+	}{
+		{Russian, []string{"hello world"}, Russian},
+		{English, []string{"привет"}, English},
+		{"", nil, Russian},
+		{Auto, []string{"let's ship the release on Friday", "ок"}, English},
+		{Auto, []string{"релиз в пятницу", "ok"}, Russian},
+		{Auto, []string{"12:00 👍"}, Russian},
+		{Auto, nil, Russian},
+	}
+	for _, tt := range tests {
+		if got := tt.lang.Resolve(tt.texts...); got != tt.want {
+			t.Errorf("%q.Resolve(%q) = %q, want %q", tt.lang, tt.texts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Package i18n holds the bot's UI message catalogue and the output-language
+// setting shared by the handlers and the summarizer prompts.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Lang is an output/UI language code.
+type Lang string
+
+const (
+	Russian Lang = "ru"
+	English Lang = "en"
+	// Auto picks the dominant language of the material being summarized; see
+	// Resolve.
+	Auto Lang = "auto"
+
+	// Default is used when nothing is configured, so existing groups keep
+	// Russian output.
+	Default = Russian
+)
+
+// Supported lists the settable values in display order.
+var Supported = []Lang{Russian, English, Auto}
+
+// Parse validates a user- or env-supplied language code (case-insensitive).
+func Parse(s string) (Lang, bool) {
+	l := Lang(strings.ToLower(strings.TrimSpace(s)))
+	for _, v := range Supported {
+		if l == v {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// OrDefault returns l, or Default when l is empty or unknown.
+func (l Lang) OrDefault() Lang {
+	if parsed, ok := Parse(string(l)); ok {
+		return parsed
+	}
+	return Default
+}
+
+// Resolve turns l into a concrete language. Auto detects the dominant
+// language of texts, falling back to Default when texts carry no letters;
+// any other value is returned as-is (empty or unknown => Default).
+func (l Lang) Resolve(texts ...string) Lang {
+	l = l.OrDefault()
+	if l != Auto {
+		return l
+	}
+	return Detect(texts...)
+}
+
+// Detect picks the dominant supported language of texts by script: Cyrillic
+// letters count towards Russian, Latin ones towards English. Ties and
+// letter-less input resolve to Default.
+func Detect(texts ...string) Lang {
+	var cyrillic, latin int
+	for _, t := range texts {
+		for _, r := range t {
+			switch {
+			case unicode.Is(unicode.Cyrillic, r):
+				cyrillic++
+			case unicode.Is(unicode.Latin, r):
+				latin++
+			}
+		}
+	}
+	switch {
+	case latin > cyrillic:
+		return English
+	case cyrillic > latin:
+		return Russian
+	default:
+		return Default
+	}
+}
+
+// PromptName is the language name as it appears in the (Russian) LLM prompts,
+// e.g. "только на русском языке".
+func (l Lang) PromptName() string {
+	if l.OrDefault() == English {
+		return "английском языке"
+	}
+	return "русском языке"
+}
+
+// T returns the catalogue text for key in l, formatted with args when given.
+// Missing translations fall back to Russian, then to the key itself.
+func T(l Lang, key Key, args ...any) string {
+	entry := catalogue[key]
+	text, ok := entry[l.Resolve()]
+	if !ok {
+		text, ok = entry[Russian]
+	}
+	if !ok {
+		text = string(key)
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
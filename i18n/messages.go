@@ -0,0 +1,888 @@
+package i18n
+
+// Key identifies a UI message in the catalogue.
+type Key string
+
+// Texts marked "MarkdownV2" are sent with that parse mode and are stored
+// pre-escaped; "Markdown" ones are plain Markdown later run through
+// telegramify. Everything else is plain text.
+const (
+	// Shared.
+	DurationSeconds Key = "duration.seconds"
+	DurationMinutes Key = "duration.minutes"
+	DurationHours   Key = "duration.hours"
+	RateLimitWait   Key = "ratelimit.wait"
+	RateLimitWaitDM Key = "ratelimit.wait_dm"
+	// RateLimitQueued is the status of a request waiting in queue mode
+	// (RATE_LIMIT_QUEUE).
+	RateLimitQueued Key = "ratelimit.queued"
+	// SummaryShared answers a request that an identical one in progress
+	// already covered; it is sent as a reply to that one's result.
+	SummaryShared Key = "summary.shared"
+	// JobRetrying and JobResumed are the status of a summary job after a
+	// failed run and after a restart interrupted it.
+	JobRetrying Key = "job.retrying"
+	JobResumed  Key = "job.resumed"
+	// ProgressImages, ProgressClustering, ProgressSummarizing,
+	// ProgressExtracting and ProgressEvents are the status of a running
+	// summary, per stage.
+	ProgressImages      Key = "progress.images"
+	ProgressClustering  Key = "progress.clustering"
+	ProgressSummarizing Key = "progress.summarizing"
+	ProgressExtracting  Key = "progress.extracting"
+	ProgressEvents      Key = "progress.events"
+	MessagesError       Key = "messages.error"
+	SummarizeFailed     Key = "summarize.failed"
+	SummaryHeader       Key = "summary.header" // Markdown
+	SummaryTLDR         Key = "summary.tldr"   // Markdown
+	SummaryEmpty        Key = "summary.empty"
+	TopicFallback       Key = "summary.topic_fallback"
+	// Decisions, action items and open questions under a summary, and the
+	// "@bot todo" list of stored action items.
+	SummaryDecisions     Key = "summary.decisions"      // Markdown
+	SummaryActionItems   Key = "summary.action_items"   // Markdown
+	SummaryOpenQuestions Key = "summary.open_questions" // Markdown
+	SummaryDue           Key = "summary.due"
+	TodoHeader           Key = "todo.header" // Markdown
+	TodoDay              Key = "todo.day"    // Markdown
+	TodoAliasNote        Key = "todo.alias_note"
+	TodoEmpty            Key = "todo.empty"
+	TodoDisabled         Key = "todo.disabled"
+	TodoLoadError        Key = "todo.load_error"
+	// Calendar events under a summary and their "add to calendar" buttons.
+	SummaryEvents    Key = "summary.events" // Markdown
+	EventButton      Key = "event.button"
+	EventButtonN     Key = "event.button_n"
+	EventUnavailable Key = "event.unavailable"
+	EventFailed      Key = "event.failed"
+	PageUnreadable   Key = "fetch.unreadable"
+	// StartPrivateChat asks a group member to open a private chat with the bot
+	// before it can DM them.
+	StartPrivateChat Key = "private.start"
+
+	// Group summarize.
+	SummarizeUsage      Key = "summarize.usage"
+	SummarizeMaxHours   Key = "summarize.max_hours"
+	SummarizeNoNew      Key = "summarize.no_new"
+	SummarizeNoMessages Key = "summarize.no_messages"
+	SummarizeCollecting Key = "summarize.collecting"
+	SinceNotStored      Key = "since.not_stored"
+	SinceCollecting     Key = "since.collecting"
+	SinceFrom           Key = "since.from" // Markdown
+
+	// Conversation statistics.
+	StatsHeader       Key = "stats.header"
+	StatsEmpty        Key = "stats.empty"
+	StatsTotals       Key = "stats.totals"
+	StatsMembers      Key = "stats.members"
+	StatsMemberLine   Key = "stats.member_line"
+	StatsPairs        Key = "stats.pairs"
+	StatsThreads      Key = "stats.threads"
+	StatsThreadLine   Key = "stats.thread_line"
+	StatsLatency      Key = "stats.latency"
+	StatsUnanswered   Key = "stats.unanswered"
+	StatsQuestionLine Key = "stats.question_line"
+	StatsClusters     Key = "stats.clusters"
+	StatsClusterLine  Key = "stats.cluster_line"
+
+	// Reply summarize.
+	ReplyUnsupported    Key = "reply.unsupported"
+	ReplyTooShort       Key = "reply.too_short"
+	ReplyNothing        Key = "reply.nothing"
+	ReplyProcessing     Key = "reply.processing"
+	ReplyLinkFailed     Key = "reply.link_failed"
+	ReplyVisionDisabled Key = "reply.vision_disabled"
+	ReplyFailed         Key = "reply.failed"
+	ReplyThreadWorking  Key = "reply.thread_working"
+	ReplyThreadHeader   Key = "reply.thread_header" // Markdown
+	ReplyTreeWorking    Key = "reply.tree_working"
+	ReplyTreeHeader     Key = "reply.tree_header" // Markdown
+
+	// Schedule.
+	ScheduleError      Key = "schedule.error"
+	ScheduleSaveError  Key = "schedule.save_error"
+	ScheduleDisabled   Key = "schedule.disabled" // MarkdownV2
+	ScheduleEnabled    Key = "schedule.enabled"  // MarkdownV2
+	ScheduleAdminsOnly Key = "schedule.admins_only"
+	ScheduleRunningNow Key = "schedule.running_now" // MarkdownV2
+	ScheduleBadFormat  Key = "schedule.bad_format"  // MarkdownV2
+	ScheduleBadTime    Key = "schedule.bad_time"    // MarkdownV2
+	SchedulePreparing  Key = "schedule.preparing"
+	ScheduleHeader     Key = "schedule.header" // Markdown
+
+	// Language.
+	LanguageCurrent    Key = "language.current"
+	LanguageSet        Key = "language.set"
+	LanguageBadValue   Key = "language.bad_value"
+	LanguageAdminsOnly Key = "language.admins_only"
+	LanguageSaveError  Key = "language.save_error"
+
+	// Timezone.
+	TimezoneCurrent    Key = "timezone.current"
+	TimezoneSet        Key = "timezone.set"
+	TimezoneBadValue   Key = "timezone.bad_value"
+	TimezoneAdminsOnly Key = "timezone.admins_only"
+	TimezoneSaveError  Key = "timezone.save_error"
+
+	// Catch-up.
+	CatchupUsage      Key = "catchup.usage"
+	CatchupCollecting Key = "catchup.collecting"
+	CatchupNothing    Key = "catchup.nothing"
+	CatchupHeader     Key = "catchup.header" // Markdown
+
+	// Digest subscriptions.
+	SubscribeUsage     Key = "subscribe.usage"
+	SubscribeDone      Key = "subscribe.done"
+	SubscribeSaveError Key = "subscribe.save_error"
+	UnsubscribeDone    Key = "unsubscribe.done"
+	UnsubscribeNone    Key = "unsubscribe.none"
+	DigestDMHeader     Key = "digest.dm_header" // Markdown
+
+	// Forget me.
+	ForgetUsage     Key = "forget.usage"
+	ForgetDone      Key = "forget.done"
+	ForgetDoneGroup Key = "forget.done_group"
+	ForgetFailed    Key = "forget.failed"
+	RememberDone    Key = "remember.done"
+	RememberFailed  Key = "remember.failed"
+
+	// Topic expansion.
+	ExpandButton      Key = "expand.button"
+	ExpandWorking     Key = "expand.working"
+	ExpandUnavailable Key = "expand.unavailable"
+	ExpandFailed      Key = "expand.failed"
+	ExpandHeader      Key = "expand.header" // Markdown
+
+	FeedbackThanks      Key = "feedback.thanks"
+	FeedbackUnavailable Key = "feedback.unavailable"
+	FeedbackFailed      Key = "feedback.failed"
+
+	// Group help.
+	HelpGroup   Key = "help.group"   // MarkdownV2
+	HelpAdmin   Key = "help.admin"   // MarkdownV2
+	HelpPrivate Key = "help.private" // MarkdownV2
+
+	// Bot-admin DMs.
+	AdminBotAdded            Key = "admin.bot_added"
+	AdminMetricsReset        Key = "admin.metrics_reset"
+	AdminHelp                Key = "admin.help" // MarkdownV2
+	AdminBadGroupID          Key = "admin.bad_group_id"
+	AdminGroupsError         Key = "admin.groups_error"
+	AdminGroupsAddUsage      Key = "admin.groups_add_usage"    // MarkdownV2
+	AdminGroupsRemoveUsage   Key = "admin.groups_remove_usage" // MarkdownV2
+	AdminGroupsAddError      Key = "admin.groups_add_error"
+	AdminGroupsAdded         Key = "admin.groups_added"
+	AdminGroupsUnknown       Key = "admin.groups_unknown"
+	AdminGroupsRemoveError   Key = "admin.groups_remove_error"
+	AdminGroupsRemoved       Key = "admin.groups_removed"
+	AdminGroupsBadSubcommand Key = "admin.groups_bad_subcommand" // MarkdownV2
+	AdminGroupsNone          Key = "admin.groups_none"
+	AdminGroupsListHeader    Key = "admin.groups_list_header" // MarkdownV2
+	AdminGroupsListFooter    Key = "admin.groups_list_footer" // MarkdownV2
+	AdminGroupCheckError     Key = "admin.group_check_error"
+	AdminGroupNotAllowed     Key = "admin.group_not_allowed"
+	AdminLanguageUsage       Key = "admin.language_usage" // MarkdownV2
+	AdminLanguageCurrent     Key = "admin.language_current"
+	AdminLanguageSet         Key = "admin.language_set"
+	AdminUsageCollecting     Key = "admin.usage_collecting"
+	AdminForgetUsage         Key = "admin.forget_usage" // MarkdownV2
+	AdminForgetBadHash       Key = "admin.forget_bad_hash"
+	AdminForgetDone          Key = "admin.forget_done"
+
+	QualityUsage          Key = "quality.usage" // MarkdownV2
+	QualityLoadError      Key = "quality.load_error"
+	QualityEmpty          Key = "quality.empty"
+	QualityHeader         Key = "quality.header"
+	QualityLine           Key = "quality.line"
+	QualityTotal          Key = "quality.total"
+	QualityByGroup        Key = "quality.by_group"
+	QualityByModel        Key = "quality.by_model"
+	QualityByVersion      Key = "quality.by_version"
+	QualityByWeek         Key = "quality.by_week"
+	QualityWeek           Key = "quality.week"
+	QualityNoInstructions Key = "quality.no_instructions"
+
+	InstNoGroups       Key = "inst.no_groups"
+	InstPickGroup      Key = "inst.pick_group"
+	InstCancelled      Key = "inst.cancelled"
+	InstEditPrompt     Key = "inst.edit_prompt"
+	InstClearError     Key = "inst.clear_error"
+	InstCleared        Key = "inst.cleared"
+	InstVersionMissing Key = "inst.version_missing"
+	InstRestoreError   Key = "inst.restore_error"
+	InstRestored       Key = "inst.restored"
+	InstLoadError      Key = "inst.load_error"
+	InstViewEmpty      Key = "inst.view_empty"  // MarkdownV2
+	InstViewHeader     Key = "inst.view_header" // MarkdownV2
+	InstHistoryError   Key = "inst.history_error"
+	InstHistoryEmpty   Key = "inst.history_empty"
+	InstHistoryHeader  Key = "inst.history_header"  // MarkdownV2
+	InstHistoryCleared Key = "inst.history_cleared" // MarkdownV2
+	InstHistoryCurrent Key = "inst.history_current" // MarkdownV2
+	InstVersionError   Key = "inst.version_error"
+	InstDiffNoChanges  Key = "inst.diff_no_changes"
+	InstDiffHeader     Key = "inst.diff_header"  // MarkdownV2
+	InstDiffBase       Key = "inst.diff_base"    // MarkdownV2
+	InstDiffNoBase     Key = "inst.diff_no_base" // MarkdownV2
+	InstSaveError      Key = "inst.save_error"
+	InstSaved          Key = "inst.saved"
+
+	PreviewUsage        Key = "preview.usage" // MarkdownV2
+	PreviewDraftTooLong Key = "preview.draft_too_long"
+	PreviewLabelDraft   Key = "preview.label_draft"
+	PreviewLabelNone    Key = "preview.label_none"
+	PreviewLabelCurrent Key = "preview.label_current"
+	PreviewNoMessages   Key = "preview.no_messages"
+	PreviewPreparing    Key = "preview.preparing"
+	PreviewHeader       Key = "preview.header" // Markdown
+	URLLoading          Key = "url.loading"
+	URLFetchFailed      Key = "url.fetch_failed"
+	URLSummarizing      Key = "url.summarizing"
+	URLHeader           Key = "url.header"  // Markdown
+	StatsUsage          Key = "stats.usage" // MarkdownV2
+	StatsWindow         Key = "stats.window"
+
+	SettingsPickGroup   Key = "settings.pick_group"
+	SettingsLoadError   Key = "settings.load_error"
+	SettingsHeader      Key = "settings.header" // MarkdownV2
+	SettingsLine        Key = "settings.line"   // MarkdownV2
+	SettingsFooter      Key = "settings.footer" // MarkdownV2
+	SettingsGlobal      Key = "settings.global"
+	SettingsOverride    Key = "settings.override"
+	SettingsOn          Key = "settings.on"
+	SettingsOff         Key = "settings.off"
+	SettingsEditPrompt  Key = "settings.edit_prompt"
+	SettingsBadValue    Key = "settings.bad_value"
+	SettingsSaveError   Key = "settings.save_error"
+	SettingsSaved       Key = "settings.saved"
+	SettingsCancelled   Key = "settings.cancelled"
+	SettingTopicMax     Key = "setting.topic_max"
+	SettingSummaryHours Key = "setting.summary_hours"
+	SettingMaxMessages  Key = "setting.max_messages"
+	SettingRateLimitSec Key = "setting.rate_limit_sec"
+	SettingReplyThreads Key = "setting.reply_threads"
+	SettingPIIRedaction Key = "setting.pii_redaction"
+	SettingDigestStats  Key = "setting.digest_stats"
+	SettingActionItems  Key = "setting.action_items"
+	SettingEvents       Key = "setting.calendar_events"
+
+	CommandStatus       Key = "command.status"
+	CommandReset        Key = "command.reset"
+	CommandGroups       Key = "command.groups"
+	CommandInstructions Key = "command.instructions"
+	CommandPreview      Key = "command.preview"
+	CommandLanguage     Key = "command.language"
+	CommandSettings     Key = "command.settings"
+	CommandUsage        Key = "command.usage"
+	CommandQuality      Key = "command.quality"
+	CommandStats        Key = "command.stats"
+	CommandForget       Key = "command.forget"
+	CommandHelp         Key = "command.help"
+)
+
+var catalogue = map[Key]map[Lang]string{
+	DurationSeconds: {Russian: "%d секунд", English: "%d seconds"},
+	DurationMinutes: {Russian: "%d минут", English: "%d minutes"},
+	DurationHours:   {Russian: "%d ч %d мин", English: "%d h %d min"},
+	RateLimitWait: {
+		Russian: "Подождите %s перед следующим запросом суммаризации.",
+		English: "Please wait %s before the next summary request.",
+	},
+	RateLimitWaitDM: {
+		Russian: "Подождите %s перед следующим запросом.",
+		English: "Please wait %s before the next request.",
+	},
+	RateLimitQueued: {
+		Russian: "⏳ Запрос в очереди, начну примерно через %s.",
+		English: "⏳ Request queued, starting in about %s.",
+	},
+	SummaryShared: {
+		Russian: "☝️ Это уже суммировано выше.",
+		English: "☝️ Already summarized above.",
+	},
+	JobRetrying: {
+		Russian: "⚠️ Не получилось, попробую ещё раз через %s.",
+		English: "⚠️ That didn't work, trying again in %s.",
+	},
+	JobResumed: {
+		Russian: "🔄 Бот перезапустился, продолжаю...",
+		English: "🔄 The bot restarted, picking up where it left off...",
+	},
+	ProgressImages: {
+		Russian: "🖼 Описываю изображения: %d из %d...",
+		English: "🖼 Describing images: %d of %d...",
+	},
+	ProgressClustering: {
+		Russian: "🗂 Разбираю сообщения по темам...",
+		English: "🗂 Sorting messages into topics...",
+	},
+	ProgressSummarizing: {
+		Russian: "✍️ Пишу сводку: тема %d из %d...",
+		English: "✍️ Writing the summary: topic %d of %d...",
+	},
+	ProgressExtracting: {
+		Russian: "📌 Выписываю решения, задачи и открытые вопросы...",
+		English: "📌 Listing decisions, action items and open questions...",
+	},
+	ProgressEvents: {
+		Russian: "📅 Ищу встречи и дедлайны...",
+		English: "📅 Looking for meetups and deadlines...",
+	},
+	MessagesError: {Russian: "Ошибка получения сообщений.", English: "Failed to load messages."},
+	SummarizeFailed: {
+		Russian: "Ошибка суммаризации. Попробуйте позже.",
+		English: "Summarization failed. Please try again later.",
+	},
+	SummaryHeader:        {Russian: "📝 **Суммаризация:**", English: "📝 **Summary:**"},
+	SummaryTLDR:          {Russian: "**TL;DR:** ", English: "**TL;DR:** "},
+	SummaryEmpty:         {Russian: "Нет данных для суммаризации.", English: "Nothing to summarize."},
+	TopicFallback:        {Russian: "Тема %d", English: "Topic %d"},
+	SummaryDecisions:     {Russian: "✅ **Решения**", English: "✅ **Decisions**"},
+	SummaryActionItems:   {Russian: "📌 **Задачи**", English: "📌 **Action items**"},
+	SummaryOpenQuestions: {Russian: "❓ **Открытые вопросы**", English: "❓ **Open questions**"},
+	SummaryDue:           {Russian: "срок: %s", English: "due: %s"},
+	TodoHeader: {
+		Russian: "📌 **Задачи из сводок за %d дн.**",
+		English: "📌 **Action items from the last %d days' summaries**",
+	},
+	TodoDay: {Russian: "**%s**", English: "**%s**"},
+	TodoAliasNote: {
+		Russian: "У1, У2… — метки участников в сводке, где появилась задача; в разных сводках они разные.",
+		English: "У1, У2… are member labels of the summary the item came from; they differ between summaries.",
+	},
+	TodoEmpty: {
+		Russian: "В сводках за %d дн. задач не нашлось.",
+		English: "No action items in the last %d days' summaries.",
+	},
+	TodoDisabled: {
+		Russian: "Задачи из сводок не выписываются в этой группе. Администратор бота может включить это в /settings.",
+		English: "Action items aren't extracted from summaries in this group. A bot admin can turn this on in /settings.",
+	},
+	TodoLoadError: {Russian: "Ошибка получения задач.", English: "Failed to load action items."},
+	SummaryEvents: {Russian: "📅 **События**", English: "📅 **Events**"},
+	EventButton:   {Russian: "📅 Добавить", English: "📅 Add"},
+	EventButtonN:  {Russian: "📅 Добавить %d", English: "📅 Add %d"},
+	EventUnavailable: {
+		Russian: "Это событие уже недоступно.",
+		English: "This event is no longer available.",
+	},
+	EventFailed: {
+		Russian: "Не удалось отправить файл события. Попробуйте позже.",
+		English: "Couldn't send the event file. Please try again later.",
+	},
+	PageUnreadable: {
+		Russian: "Не удалось прочитать страницу — возможно, она требует входа или контент подгружается через JavaScript.",
+		English: "Couldn't read the page — it may require a login or load its content with JavaScript.",
+	},
+
+	StartPrivateChat: {
+		Russian: "Я отвечаю на это в личные сообщения, но не могу вам написать. Откройте чат с @%s, нажмите «Start» и повторите команду.",
+		English: "I answer this by private message but can't write to you. Open a chat with @%s, press Start and run the command again.",
+	},
+
+	SummarizeUsage: {
+		Russian: "Неверный формат. Используйте: @bot summarize [часы]\nПример: @bot summarize 12",
+		English: "Invalid format. Use: @bot summarize [hours]\nExample: @bot summarize 12",
+	},
+	SummarizeMaxHours: {
+		Russian: "Максимальный период суммаризации — %d часов.",
+		English: "The maximum summary period is %d hours.",
+	},
+	SummarizeNoNew: {
+		Russian: "Нет новых сообщений с последней суммаризации.",
+		English: "No new messages since the last summary.",
+	},
+	SummarizeNoMessages: {
+		Russian: "Нет сообщений за последние 24 часа.",
+		English: "No messages in the last 24 hours.",
+	},
+	SummarizeCollecting: {
+		Russian: "Собираю сообщения за последние %d часов...",
+		English: "Collecting messages from the last %d hours...",
+	},
+	SinceNotStored: {
+		Russian: "Этого сообщения у меня нет — оно старше срока хранения или пришло до того, как меня добавили. Ответьте на более позднее сообщение.",
+		English: "I don't have that message: it's older than the retention period or from before I joined. Reply to a later message.",
+	},
+	SinceCollecting: {
+		Russian: "Собираю сообщения начиная с этого (%d)...",
+		English: "Collecting messages from this one on (%d)...",
+	},
+	SinceFrom: {Russian: "↩️ С [этого сообщения](%s)", English: "↩️ Since [this message](%s)"},
+
+	StatsHeader:     {Russian: "📊 Статистика", English: "📊 Statistics"},
+	StatsEmpty:      {Russian: "Нет сообщений за этот период.", English: "No messages in this period."},
+	StatsTotals:     {Russian: "Сообщений: %d · участников: %d", English: "Messages: %d · participants: %d"},
+	StatsMembers:    {Russian: "Самые активные:", English: "Most active:"},
+	StatsMemberLine: {Russian: "%s — %d сообщ., ответил %d, получил ответов %d", English: "%s — %d messages, %d replies sent, %d received"},
+	StatsPairs:      {Russian: "Кто кому отвечает:", English: "Who replies to whom:"},
+	StatsThreads:    {Russian: "Самые длинные ветки:", English: "Longest threads:"},
+	StatsThreadLine: {Russian: "%s — %d сообщ., %d участн., %s", English: "%s — %d messages, %d participants, %s"},
+	StatsLatency: {
+		Russian: "Первый ответ: медиана %s, 90%% — в пределах %s (ответили на %d сообщ.)",
+		English: "First reply: median %s, 90%% within %s (%d messages answered)",
+	},
+	StatsUnanswered:   {Russian: "Вопросы без ответа (%d):", English: "Unanswered questions (%d):"},
+	StatsQuestionLine: {Russian: "%s: %s (%s назад)", English: "%s: %s (%s ago)"},
+	StatsClusters:     {Russian: "Кружки общения:", English: "Reply clusters:"},
+	StatsClusterLine:  {Russian: "%s — %d ответов друг другу", English: "%s — %d replies among them"},
+
+	ReplyUnsupported: {
+		Russian: "Этот тип сообщения пока не поддерживается для суммаризации.",
+		English: "This message type can't be summarized yet.",
+	},
+	ReplyTooShort: {
+		Russian: "Сообщение слишком короткое для суммаризации.",
+		English: "The message is too short to summarize.",
+	},
+	ReplyNothing: {
+		Russian: "Нечего суммаризировать в этом сообщении.",
+		English: "There's nothing to summarize in this message.",
+	},
+	ReplyProcessing:     {Russian: "Обрабатываю сообщение...", English: "Processing the message..."},
+	ReplyLinkFailed:     {Russian: "Не удалось загрузить ссылку.", English: "Couldn't load the link."},
+	ReplyVisionDisabled: {Russian: "Распознавание изображений отключено.", English: "Image recognition is disabled."},
+	ReplyFailed: {
+		Russian: "Не удалось обработать сообщение. Попробуйте позже.",
+		English: "Couldn't process the message. Please try again later.",
+	},
+	ReplyThreadWorking: {Russian: "Собираю ветку обсуждения...", English: "Collecting the reply thread..."},
+	ReplyThreadHeader:  {Russian: "📝 **Суммаризация ветки:**", English: "📝 **Thread summary:**"},
+	ReplyTreeWorking:   {Russian: "Собираю ответы на сообщение...", English: "Collecting the replies..."},
+	ReplyTreeHeader:    {Russian: "📝 **Обсуждение по веткам:**", English: "📝 **Discussion by branch:**"},
+
+	ScheduleError:     {Russian: "Ошибка получения расписания.", English: "Failed to load the schedule."},
+	ScheduleSaveError: {Russian: "Ошибка сохранения расписания.", English: "Failed to save the schedule."},
+	ScheduleDisabled: {
+		Russian: "⏰ Ежедневная сводка *отключена*\\.",
+		English: "⏰ The daily digest is *off*\\.",
+	},
+	ScheduleEnabled: {
+		Russian: "⏰ Ежедневная сводка *включена*, время: *%02d:%02d UTC*\\.",
+		English: "⏰ The daily digest is *on*, time: *%02d:%02d UTC*\\.",
+	},
+	ScheduleAdminsOnly: {
+		Russian: "Только администраторы группы могут изменять расписание.",
+		English: "Only group admins can change the schedule.",
+	},
+	ScheduleRunningNow: {
+		Russian: "🔄 Запускаю внеплановую сводку\\.\\.\\.",
+		English: "🔄 Running an unscheduled digest\\.\\.\\.",
+	},
+	ScheduleBadFormat: {
+		Russian: "Неверный формат\\. Используйте: `schedule on`, `schedule off`, `schedule now` или `schedule ЧЧ:ММ`\\.",
+		English: "Invalid format\\. Use: `schedule on`, `schedule off`, `schedule now` or `schedule HH:MM`\\.",
+	},
+	ScheduleBadTime: {
+		Russian: "Неверное время\\. Используйте формат ЧЧ:ММ, например `07:00`\\.",
+		English: "Invalid time\\. Use the HH:MM format, e\\.g\\. `07:00`\\.",
+	},
+	SchedulePreparing: {Russian: "Готовлю утреннюю сводку...", English: "Preparing the morning digest..."},
+	ScheduleHeader: {
+		Russian: "🌅 **Утренняя #сводка за последние 24 часа:**",
+		English: "🌅 **Morning #digest for the last 24 hours:**",
+	},
+
+	LanguageCurrent: {
+		Russian: "Язык сводок: %s. Изменить (администраторы группы): @bot language ru|en|auto",
+		English: "Summary language: %s. To change it (group admins): @bot language ru|en|auto",
+	},
+	LanguageSet: {Russian: "Язык сводок: %s.", English: "Summary language: %s."},
+	LanguageBadValue: {
+		Russian: "Неизвестный язык. Доступно: ru, en, auto.",
+		English: "Unknown language. Available: ru, en, auto.",
+	},
+	LanguageAdminsOnly: {
+		Russian: "Только администраторы группы могут изменять язык сводок.",
+		English: "Only group admins can change the summary language.",
+	},
+	LanguageSaveError: {Russian: "Ошибка сохранения языка.", English: "Failed to save the language."},
+
+	TimezoneCurrent: {
+		Russian: "Часовой пояс группы: %s. Изменить (администраторы группы): @bot timezone Europe/Moscow",
+		English: "Group timezone: %s. To change it (group admins): @bot timezone Europe/London",
+	},
+	TimezoneSet: {Russian: "Часовой пояс группы: %s.", English: "Group timezone: %s."},
+	TimezoneBadValue: {
+		Russian: "Неизвестный часовой пояс. Укажите его в формате Регион/Город, например Europe/Moscow.",
+		English: "Unknown timezone. Use the Area/City form, e.g. Europe/London.",
+	},
+	TimezoneAdminsOnly: {
+		Russian: "Только администраторы группы могут изменять часовой пояс.",
+		English: "Only group admins can change the timezone.",
+	},
+	TimezoneSaveError: {Russian: "Ошибка сохранения часового пояса.", English: "Failed to save the timezone."},
+
+	CatchupUsage: {
+		Russian: "Неверный формат. Используйте: @bot catchup [часы|ЧЧ:ММ]\nБез аргумента — всё с вашего прошлого catchup; ЧЧ:ММ — время в UTC.",
+		English: "Invalid format. Use: @bot catchup [hours|HH:MM]\nWithout an argument — everything since your last catchup; HH:MM is a UTC time.",
+	},
+	CatchupCollecting: {
+		Russian: "Собираю сообщения из «%s» с %s UTC...",
+		English: "Collecting messages from “%s” since %s UTC...",
+	},
+	CatchupNothing: {
+		Russian: "В «%s» нет новых сообщений с %s UTC.",
+		English: "No new messages in “%s” since %s UTC.",
+	},
+	CatchupHeader: {Russian: "📬 **Catchup: %s** (с %s UTC)", English: "📬 **Catchup: %s** (since %s UTC)"},
+
+	SubscribeUsage: {
+		Russian: "Неверный формат. Используйте: @bot subscribe [ЧЧ:ММ] (время в UTC)\nПример: @bot subscribe 08:00",
+		English: "Invalid format. Use: @bot subscribe [HH:MM] (UTC time)\nExample: @bot subscribe 08:00",
+	},
+	SubscribeDone: {
+		Russian: "✅ Ежедневный дайджест «%s» будет приходить сюда в %02d:%02d UTC. Отписаться: @bot unsubscribe в группе.",
+		English: "✅ The daily digest of “%s” will arrive here at %02d:%02d UTC. To stop: @bot unsubscribe in the group.",
+	},
+	SubscribeSaveError: {Russian: "Ошибка сохранения подписки.", English: "Failed to save the subscription."},
+	UnsubscribeDone: {
+		Russian: "Подписка на ежедневный дайджест отменена.",
+		English: "You're unsubscribed from the daily digest.",
+	},
+	UnsubscribeNone: {
+		Russian: "Вы не подписаны на дайджест этой группы.",
+		English: "You aren't subscribed to this group's digest.",
+	},
+	DigestDMHeader: {Russian: "📅 **Ежедневный дайджест: %s**", English: "📅 **Daily digest: %s**"},
+
+	ForgetUsage: {
+		Russian: "Используйте: @bot forget me — удалить все ваши сохранённые сообщения в этой группе и больше не сохранять новые; @bot remember me — снова сохранять.",
+		English: "Use: @bot forget me — delete all your stored messages in this group and stop storing new ones; @bot remember me — store them again.",
+	},
+	ForgetDone: {
+		Russian: "🗑 Удалено ваших сообщений в «%s»: %d. Новые сообщения сохраняться и попадать в сводки не будут; чтобы вернуть, напишите в группе @bot remember me.\nВаш анонимный ID в этой группе: %s.",
+		English: "🗑 Your messages deleted in “%s”: %d. New ones won't be stored or summarized; to undo, send @bot remember me in the group.\nYour anonymous ID in this group: %s.",
+	},
+	ForgetDoneGroup: {
+		Russian: "🗑 Ваши сообщения удалены, новые сохраняться не будут.",
+		English: "🗑 Your messages are deleted; new ones won't be stored.",
+	},
+	ForgetFailed: {
+		Russian: "Не удалось удалить сообщения. Попробуйте позже.",
+		English: "Couldn't delete the messages. Please try again later.",
+	},
+	RememberFailed: {
+		Russian: "Не удалось сохранить настройку. Попробуйте позже.",
+		English: "Couldn't save the setting. Please try again later.",
+	},
+	RememberDone: {
+		Russian: "Ваши новые сообщения снова будут сохраняться и попадать в сводки.",
+		English: "Your new messages will be stored and summarized again.",
+	},
+
+	ExpandButton:  {Russian: "Подробнее: %d", English: "More: %d"},
+	ExpandWorking: {Russian: "Разбираю тему «%s» подробнее...", English: "Looking into “%s” in detail..."},
+	ExpandUnavailable: {
+		Russian: "Эта сводка устарела — сообщений темы уже нет.",
+		English: "This summary is too old — the topic's messages are gone.",
+	},
+	ExpandFailed: {
+		Russian: "Не удалось подробно разобрать тему. Попробуйте позже.",
+		English: "Couldn't expand the topic. Please try again later.",
+	},
+	ExpandHeader: {Russian: "🔍 **Подробнее: %s**", English: "🔍 **In detail: %s**"},
+
+	FeedbackThanks: {Russian: "Спасибо за оценку!", English: "Thanks for the feedback!"},
+	FeedbackUnavailable: {
+		Russian: "Эту сводку уже нельзя оценить.",
+		English: "This summary can no longer be rated.",
+	},
+	FeedbackFailed: {
+		Russian: "Не удалось сохранить оценку. Попробуйте позже.",
+		English: "Couldn't save the rating. Please try again later.",
+	},
+
+	HelpGroup: {
+		Russian: "📖 *Доступные команды:*\n\n" +
+			"• `summarize [часы]` \\(или `s`, `sub`\\) — суммировать сообщения за последние N часов \\(по умолчанию 24\\)\n" +
+			"• *Ответ* на сообщение с упоминанием бота — разобрать именно его \\(ссылку, изображение или текст\\); слово `summarize` необязательно\\. Если это ветка ответов — разберёт всю цепочку\\. `@bot summarize since` ответом — сводка всего, что написано начиная с этого сообщения\\. Можно добавить запрос, например `@bot опиши мем` или `@bot как это можно использовать`\n" +
+			"• `schedule` — показать расписание ежедневной сводки\n" +
+			"• `language` — показать язык сводок\n" +
+			"• `timezone` — показать часовой пояс группы, по которому бот понимает «завтра в 7»\n" +
+			"• `catchup [часы|ЧЧ:ММ]` — прислать в личные сообщения сводку всего, что вы пропустили с прошлого раза \\(или с указанного времени UTC\\)\n" +
+			"• `subscribe [ЧЧ:ММ]` — получать ежедневный дайджест группы в личные сообщения в указанное время UTC; `unsubscribe` — отписаться\\. Пока вы подписаны, бот хранит ваш Telegram ID, чтобы писать вам; если шифрование базы не настроено, ID хранится в открытом виде\n" +
+			"• `todo` — задачи из недавних сводок: кто что взялся сделать, со ссылками на сообщения \\(если в группе включены решения и задачи\\)\n" +
+			"• `forget me` — удалить все ваши сохранённые сообщения в группе и больше не сохранять новые; `remember me` — снова сохранять\n" +
+			"• `help` — показать это сообщение\n\n" +
+			"_Примеры: @bot summarize, @bot summarize 12, ответом — @bot опиши мем_",
+		English: "📖 *Available commands:*\n\n" +
+			"• `summarize [hours]` \\(or `s`, `sub`\\) — summarize messages from the last N hours \\(24 by default\\)\n" +
+			"• *Reply* to a message and mention the bot — act on that message \\(link, image or text\\); the word `summarize` is optional\\. If it's part of a reply thread, the whole branch is summarized\\. `@bot summarize since` as a reply — summary of everything from that message on\\. You can add a request, e\\.g\\. `@bot describe the meme` or `@bot how could we use this`\n" +
+			"• `schedule` — show the daily digest schedule\n" +
+			"• `language` — show the summary language\n" +
+			"• `timezone` — show the group's timezone, used to make sense of “tomorrow at 7”\n" +
+			"• `catchup [hours|HH:MM]` — DM you a summary of everything you missed since last time \\(or since the given UTC time\\)\n" +
+			"• `subscribe [HH:MM]` — get the group's daily digest by private message at the given UTC time; `unsubscribe` — stop\\. While you're subscribed the bot stores your Telegram user ID to message you, in plaintext unless database encryption is set up\n" +
+			"• `todo` — action items from recent summaries: who took on what, with links to the messages \\(when decisions and action items are on for the group\\)\n" +
+			"• `forget me` — delete all your stored messages in the group and stop storing new ones; `remember me` — store them again\n" +
+			"• `help` — show this message\n\n" +
+			"_Examples: @bot summarize, @bot summarize 12, as a reply — @bot describe the meme_",
+	},
+	HelpAdmin: {
+		Russian: "\n\n*Команды администратора:*\n" +
+			"• `schedule on` — включить ежедневную сводку\n" +
+			"• `schedule off` — выключить ежедневную сводку\n" +
+			"• `schedule ЧЧ:ММ` — установить время ежедневной сводки в UTC\n" +
+			"• `schedule now` — запустить внеплановую сводку прямо сейчас\n" +
+			"• `language ru|en|auto` — язык сводок \\(`auto` — по преобладающему языку переписки\\)\n" +
+			"• `timezone Регион/Город` — часовой пояс группы для событий из сводок, например `Europe/Moscow`\n\n" +
+			"_Пример: @bot schedule 08:00_",
+		English: "\n\n*Admin commands:*\n" +
+			"• `schedule on` — turn the daily digest on\n" +
+			"• `schedule off` — turn the daily digest off\n" +
+			"• `schedule HH:MM` — set the daily digest time in UTC\n" +
+			"• `schedule now` — run an unscheduled digest right now\n" +
+			"• `language ru|en|auto` — summary language \\(`auto` follows the chat's dominant language\\)\n" +
+			"• `timezone Area/City` — the group's timezone for events in summaries, e\\.g\\. `Europe/London`\n\n" +
+			"_Example: @bot schedule 08:00_",
+	},
+	HelpPrivate: {
+		Russian: "Я работаю только в группах и полезен для суммаризации групповых обсуждений\\.\n\n" +
+			"Добавьте меня в группу и используйте `@%s summarize`\\.",
+		English: "I only work in groups, where I summarize group discussions\\.\n\n" +
+			"Add me to a group and use `@%s summarize`\\.",
+	},
+
+	AdminBotAdded: {
+		Russian: "Бот добавлен в группу «%s» (%d).\nДля разрешения: /groups add %d",
+		English: "The bot was added to the group “%s” (%d).\nTo allow it: /groups add %d",
+	},
+	AdminMetricsReset: {Russian: "Метрики сброшены.", English: "Metrics reset."},
+	AdminHelp: {
+		Russian: "*Команды администратора*\n\n" +
+			"`/help` — показать это сообщение\n" +
+			"`/status` — статус бота и метрики\n" +
+			"`/reset` — сбросить все метрики\n" +
+			"`/groups` — список разрешённых групп\n" +
+			"`/groups add <group_id>` — добавить группу\n" +
+			"`/groups remove <group_id>` — удалить группу\n" +
+			"`/instructions` — настроить дополнительные инструкции суммаризации для группы\n" +
+			"`/preview <group_id> [черновик]` — предпросмотр сводки группы в личке \\(с текущими инструкциями или черновиком\\)\n" +
+			"`/language <group_id> [ru|en|auto]` — язык сводок группы\n" +
+			"`/settings [group_id]` — параметры сводки группы \\(число тем, окно, лимиты\\) поверх глобальных\n" +
+			"`/usage` — использование токенов и квоты Codex\n" +
+			"`/quality [дней]` — оценки 👍/👎 сводок по группам, моделям и версиям инструкций\n" +
+			"`/stats <group_id> [часов]` — статистика переписки: кто кому отвечает, треды, скорость ответов\n" +
+			"`/forget <group_id> <hash>` — удалить сообщения участника по анонимному ID и больше их не сохранять\n\n" +
+			"*Суммаризация URL:*\nОтправьте ссылку — бот загрузит страницу и вернёт краткое содержание\\.",
+		English: "*Admin commands*\n\n" +
+			"`/help` — show this message\n" +
+			"`/status` — bot status and metrics\n" +
+			"`/reset` — reset all metrics\n" +
+			"`/groups` — list allowed groups\n" +
+			"`/groups add <group_id>` — add a group\n" +
+			"`/groups remove <group_id>` — remove a group\n" +
+			"`/instructions` — configure additional summary instructions for a group\n" +
+			"`/preview <group_id> [draft]` — preview a group digest in this chat \\(with the current instructions or a draft\\)\n" +
+			"`/language <group_id> [ru|en|auto]` — a group's summary language\n" +
+			"`/settings [group_id]` — per-group overrides of the summary settings \\(topics, window, limits\\)\n" +
+			"`/usage` — token usage and Codex quotas\n" +
+			"`/quality [days]` — 👍/👎 ratings of summaries by group, model and instructions version\n" +
+			"`/stats <group_id> [hours]` — conversation statistics: who replies to whom, threads, response times\n" +
+			"`/forget <group_id> <hash>` — delete a member's messages by anonymous ID and stop storing new ones\n\n" +
+			"*URL summaries:*\nSend a link and the bot will fetch the page and reply with a short summary\\.",
+	},
+	AdminBadGroupID:  {Russian: "Неверный ID группы.", English: "Invalid group ID."},
+	AdminGroupsError: {Russian: "Ошибка получения списка групп.", English: "Failed to load the group list."},
+	AdminGroupsAddUsage: {
+		Russian: "Использование: `/groups add <group_id>`",
+		English: "Usage: `/groups add <group_id>`",
+	},
+	AdminGroupsRemoveUsage: {
+		Russian: "Использование: `/groups remove <group_id>`",
+		English: "Usage: `/groups remove <group_id>`",
+	},
+	AdminGroupsAddError: {Russian: "Ошибка добавления группы.", English: "Failed to add the group."},
+	AdminGroupsAdded:    {Russian: "✅ %s добавлена.", English: "✅ %s added."},
+	AdminGroupsUnknown: {
+		Russian: "Группа %d не найдена в списке известных групп.",
+		English: "Group %d is not among the known groups.",
+	},
+	AdminGroupsRemoveError: {Russian: "Ошибка удаления группы.", English: "Failed to remove the group."},
+	AdminGroupsRemoved:     {Russian: "❌ %s удалена.", English: "❌ %s removed."},
+	AdminGroupsBadSubcommand: {
+		Russian: "Неизвестная подкоманда\\. Используйте: `/groups`, `/groups add <id>`, `/groups remove <id>`",
+		English: "Unknown subcommand\\. Use: `/groups`, `/groups add <id>`, `/groups remove <id>`",
+	},
+	AdminGroupsNone:       {Russian: "Нет известных групп.", English: "No known groups."},
+	AdminGroupsListHeader: {Russian: "📋 *Известные группы:*\n\n", English: "📋 *Known groups:*\n\n"},
+	AdminGroupsListFooter: {
+		Russian: "\nДля управления:\n• `/groups add <group_id>`\n• `/groups remove <group_id>`",
+		English: "\nTo manage:\n• `/groups add <group_id>`\n• `/groups remove <group_id>`",
+	},
+	AdminGroupCheckError: {Russian: "Ошибка проверки группы.", English: "Failed to check the group."},
+	AdminGroupNotAllowed: {Russian: "Группа %d не разрешена для бота.", English: "Group %d is not allowed for the bot."},
+	AdminLanguageUsage: {
+		Russian: "Использование: `/language <group_id> [ru|en|auto]`",
+		English: "Usage: `/language <group_id> [ru|en|auto]`",
+	},
+	AdminLanguageCurrent: {Russian: "Язык сводок группы %d: %s.", English: "Summary language of group %d: %s."},
+	AdminLanguageSet: {
+		Russian: "Язык сводок группы %d изменён на %s.",
+		English: "Summary language of group %d set to %s.",
+	},
+	AdminUsageCollecting: {Russian: "⏳ Собираю данные об использовании…", English: "⏳ Collecting usage data…"},
+	AdminForgetUsage: {
+		Russian: "Использование: `/forget <group_id> <hash>` — удалить сообщения участника по его анонимному ID и больше их не сохранять",
+		English: "Usage: `/forget <group_id> <hash>` — delete a member's messages by their anonymous ID and stop storing new ones",
+	},
+	AdminForgetBadHash: {
+		Russian: "Неверный ID участника: нужно 8 шестнадцатеричных символов.",
+		English: "Invalid member ID: expected 8 hex characters.",
+	},
+	AdminForgetDone: {
+		Russian: "🗑 Удалено сообщений участника %s в группе %d: %d. Новые сохраняться не будут.",
+		English: "🗑 Messages of member %s deleted in group %d: %d. New ones won't be stored.",
+	},
+
+	QualityUsage: {
+		Russian: "Использование: `/quality [дней]` — от 1 до %d, по умолчанию 30",
+		English: "Usage: `/quality [days]` — 1 to %d, 30 by default",
+	},
+	QualityLoadError: {Russian: "Ошибка получения оценок сводок.", English: "Failed to load summary ratings."},
+	QualityEmpty: {
+		Russian: "⭐ Качество сводок\n\nНет оценок за %d дн.",
+		English: "⭐ Summary quality\n\nNo ratings in the last %d days.",
+	},
+	QualityHeader:         {Russian: "⭐ Качество сводок за %d дн.\n", English: "⭐ Summary quality, last %d days\n"},
+	QualityLine:           {Russian: "%s: %d%% 👍 (👍 %d · 👎 %d · сводок: %d)", English: "%s: %d%% 👍 (👍 %d · 👎 %d · summaries: %d)"},
+	QualityTotal:          {Russian: "Всего", English: "Total"},
+	QualityByGroup:        {Russian: "По группе", English: "By group"},
+	QualityByModel:        {Russian: "По модели", English: "By model"},
+	QualityByVersion:      {Russian: "По версии инструкций", English: "By instructions version"},
+	QualityByWeek:         {Russian: "По неделям", English: "By week"},
+	QualityWeek:           {Russian: "неделя с %s", English: "week of %s"},
+	QualityNoInstructions: {Russian: "без инструкций", English: "no instructions"},
+
+	InstNoGroups: {Russian: "Нет разрешённых групп.", English: "No allowed groups."},
+	InstPickGroup: {
+		Russian: "Выберите группу для настройки инструкций суммаризации:",
+		English: "Pick a group to configure its summary instructions:",
+	},
+	InstCancelled: {Russian: "Настройка инструкций отменена.", English: "Instructions setup cancelled."},
+	InstEditPrompt: {
+		Russian: "Отправьте новые дополнительные инструкции для группы %d одним сообщением. Максимум %d символов. Для отмены: /cancel",
+		English: "Send the new additional instructions for group %d as one message. At most %d characters. To cancel: /cancel",
+	},
+	InstClearError: {Russian: "Ошибка удаления инструкций.", English: "Failed to clear the instructions."},
+	InstCleared: {
+		Russian: "Инструкции для группы %d очищены. Прежнюю версию можно вернуть через History.",
+		English: "Instructions for group %d cleared. The previous version can be restored from History.",
+	},
+	InstVersionMissing: {Russian: "Версия %d не найдена.", English: "Version %d not found."},
+	InstRestoreError:   {Russian: "Ошибка восстановления инструкций.", English: "Failed to restore the instructions."},
+	InstRestored: {
+		Russian: "Инструкции для группы %d восстановлены из версии %d.",
+		English: "Instructions for group %d restored from version %d.",
+	},
+	InstLoadError: {Russian: "Ошибка получения инструкций.", English: "Failed to load the instructions."},
+	InstViewEmpty: {
+		Russian: "*Инструкции для группы %s:*\n\n_Не заданы\\._",
+		English: "*Instructions for group %s:*\n\n_Not set\\._",
+	},
+	InstViewHeader: {
+		Russian: "*Инструкции для группы %s \\(v%d\\):*\n\n",
+		English: "*Instructions for group %s \\(v%d\\):*\n\n",
+	},
+	InstHistoryError: {Russian: "Ошибка получения истории инструкций.", English: "Failed to load the instructions history."},
+	InstHistoryEmpty: {
+		Russian: "История инструкций для группы %d пуста.",
+		English: "The instructions history for group %d is empty.",
+	},
+	InstHistoryHeader:  {Russian: "*История инструкций для группы %s:*\n", English: "*Instructions history for group %s:*\n"},
+	InstHistoryCleared: {Russian: "_очищены_", English: "_cleared_"},
+	InstHistoryCurrent: {Russian: " \\(текущая\\)", English: " \\(current\\)"},
+	InstVersionError:   {Russian: "Ошибка получения версии инструкций.", English: "Failed to load the instructions version."},
+	InstDiffNoChanges:  {Russian: "(нет изменений)", English: "(no changes)"},
+	InstDiffHeader: {
+		Russian: "*Изменения в v%d* \\(группа %s\\):\n",
+		English: "*Changes in v%d* \\(group %s\\):\n",
+	},
+	InstDiffBase: {
+		Russian: "_Сравнение с v%d: промежуточные версии удалены\\._\n",
+		English: "_Compared with v%d: the versions in between were pruned\\._\n",
+	},
+	InstDiffNoBase: {
+		Russian: "_Более ранние версии удалены, показан весь текст\\._\n",
+		English: "_Earlier versions were pruned; showing the whole text\\._\n",
+	},
+	InstSaveError: {Russian: "Ошибка сохранения инструкций: %s", English: "Failed to save the instructions: %s"},
+	InstSaved:     {Russian: "Инструкции для группы %d сохранены.", English: "Instructions for group %d saved."},
+
+	PreviewUsage: {
+		Russian: "Использование: `/preview <group_id> [черновик инструкций]`",
+		English: "Usage: `/preview <group_id> [draft instructions]`",
+	},
+	PreviewDraftTooLong: {Russian: "Черновик длиннее %d символов.", English: "The draft is longer than %d characters."},
+	PreviewLabelDraft:   {Russian: "черновик", English: "draft"},
+	PreviewLabelNone:    {Russian: "не заданы", English: "not set"},
+	PreviewLabelCurrent: {Russian: "текущие, v%d", English: "current, v%d"},
+	PreviewNoMessages: {
+		Russian: "Нет сообщений группы %d за последние %d часов.",
+		English: "No messages in group %d in the last %d hours.",
+	},
+	PreviewPreparing: {
+		Russian: "Готовлю предпросмотр сводки (%d сообщений)...",
+		English: "Preparing a digest preview (%d messages)...",
+	},
+	PreviewHeader: {
+		Russian: "👁 **Предпросмотр для группы %d** (инструкции: %s)",
+		English: "👁 **Preview for group %d** (instructions: %s)",
+	},
+	URLLoading:     {Russian: "Загружаю страницу...", English: "Loading the page..."},
+	URLFetchFailed: {Russian: "Не удалось загрузить страницу: %s", English: "Couldn't load the page: %s"},
+	URLSummarizing: {Russian: "Суммаризую содержимое...", English: "Summarizing the content..."},
+	URLHeader:      {Russian: "🔗 **Суммаризация URL:**", English: "🔗 **URL summary:**"},
+	StatsUsage: {
+		Russian: "Использование: `/stats <group_id> [часов]`, не больше %d часов",
+		English: "Usage: `/stats <group_id> [hours]`, at most %d hours",
+	},
+	StatsWindow: {Russian: "Группа «%s», последние %d ч", English: "Group “%s”, last %d h"},
+
+	SettingsPickGroup: {
+		Russian: "Выберите группу для настройки параметров сводки:",
+		English: "Pick a group to configure its summary settings:",
+	},
+	SettingsLoadError: {Russian: "Ошибка получения настроек.", English: "Failed to load the settings."},
+	SettingsHeader:    {Russian: "*Настройки сводки для группы %s:*\n\n", English: "*Summary settings for group %s:*\n\n"},
+	SettingsLine:      {Russian: "%s: *%s* \\(%s\\)\n", English: "%s: *%s* \\(%s\\)\n"},
+	SettingsFooter: {
+		Russian: "\nНажмите на параметр, чтобы изменить его; Reset возвращает глобальное значение\\.",
+		English: "\nTap a setting to change it; Reset returns it to the global value\\.",
+	},
+	SettingsGlobal:   {Russian: "глобально", English: "global"},
+	SettingsOverride: {Russian: "для группы", English: "override"},
+	SettingsOn:       {Russian: "вкл", English: "on"},
+	SettingsOff:      {Russian: "выкл", English: "off"},
+	SettingsEditPrompt: {
+		Russian: "Отправьте новое значение «%s» для группы %d — целое число от %d до %d (глобально: %d). Для отмены: /cancel",
+		English: "Send a new value of \"%s\" for group %d — a whole number from %d to %d (global: %d). To cancel: /cancel",
+	},
+	SettingsBadValue:    {Russian: "Нужно целое число от %d до %d.", English: "Expected a whole number from %d to %d."},
+	SettingsSaveError:   {Russian: "Ошибка сохранения настроек.", English: "Failed to save the settings."},
+	SettingsSaved:       {Russian: "Настройки группы %d сохранены.", English: "Settings of group %d saved."},
+	SettingsCancelled:   {Russian: "Изменение настроек отменено.", English: "Settings change cancelled."},
+	SettingTopicMax:     {Russian: "Тем в сводке", English: "Topics per summary"},
+	SettingSummaryHours: {Russian: "Окно сводки, ч", English: "Summary window, h"},
+	SettingMaxMessages:  {Russian: "Максимум сообщений", English: "Max messages"},
+	SettingRateLimitSec: {Russian: "Пауза между сводками, с", English: "Cooldown between summaries, s"},
+	SettingReplyThreads: {Russian: "Ветки ответов", English: "Reply threads"},
+	SettingPIIRedaction: {Russian: "Скрывать личные данные", English: "Redact personal data"},
+	SettingDigestStats:  {Russian: "Статистика в дайджесте", English: "Statistics in the digest"},
+	SettingActionItems:  {Russian: "Решения и задачи", English: "Decisions and action items"},
+	SettingEvents:       {Russian: "События в календарь", English: "Calendar events"},
+
+	CommandStatus:       {Russian: "Статус бота и метрики", English: "Bot status and metrics"},
+	CommandReset:        {Russian: "Сбросить все метрики", English: "Reset all metrics"},
+	CommandGroups:       {Russian: "Управление группами", English: "Manage groups"},
+	CommandInstructions: {Russian: "Инструкции суммаризации", English: "Summary instructions"},
+	CommandPreview:      {Russian: "Предпросмотр сводки группы", English: "Preview a group digest"},
+	CommandLanguage:     {Russian: "Язык сводок группы", English: "Group summary language"},
+	CommandSettings:     {Russian: "Настройки сводки группы", English: "Group summary settings"},
+	CommandUsage:        {Russian: "Использование токенов и квоты", English: "Token usage and quotas"},
+	CommandQuality:      {Russian: "Оценки сводок", English: "Summary ratings"},
+	CommandStats:        {Russian: "Статистика переписки группы", English: "Group conversation statistics"},
+	CommandForget:       {Russian: "Удалить данные участника", English: "Delete a member's data"},
+	CommandHelp:         {Russian: "Справка", English: "Help"},
+}
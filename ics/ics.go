@@ -0,0 +1,105 @@
+// Package ics renders a single event as an iCalendar (RFC 5545) file that
+// calendar apps can import. Timed events are written in UTC, so the file
+// needs no VTIMEZONE block; all-day events keep their calendar dates.
+package ics
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// foldOctets is the longest content line RFC 5545 allows, CRLF excluded.
+	foldOctets = 75
+	prodID     = "-//telegram_summarize_bot//events//RU"
+)
+
+// Event is what goes into the calendar file.
+type Event struct {
+	// UID identifies the event across imports, so a second import updates
+	// the first rather than duplicating it.
+	UID   string
+	Title string
+	Place string
+	// URL links back to where the event was agreed on; may be empty.
+	URL   string
+	Start time.Time
+	// End is exclusive: for an all-day event, midnight after its last day.
+	End    time.Time
+	AllDay bool
+}
+
+// Encode returns ev as a VCALENDAR with one VEVENT, stamped at stamp.
+func Encode(ev Event, stamp time.Time) []byte {
+	var sb strings.Builder
+	line := func(s string) {
+		sb.WriteString(fold(s))
+		sb.WriteString("\r\n")
+	}
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:" + prodID)
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("BEGIN:VEVENT")
+	line("UID:" + escape(ev.UID))
+	line("DTSTAMP:" + utcStamp(stamp))
+	if ev.AllDay {
+		line("DTSTART;VALUE=DATE:" + ev.Start.Format("20060102"))
+		line("DTEND;VALUE=DATE:" + ev.End.Format("20060102"))
+	} else {
+		line("DTSTART:" + utcStamp(ev.Start))
+		line("DTEND:" + utcStamp(ev.End))
+	}
+	line("SUMMARY:" + escape(ev.Title))
+	if ev.Place != "" {
+		line("LOCATION:" + escape(ev.Place))
+	}
+	if ev.URL != "" {
+		line("URL:" + ev.URL)
+		line("DESCRIPTION:" + escape(ev.URL))
+	}
+	line("END:VEVENT")
+	line("END:VCALENDAR")
+	return []byte(sb.String())
+}
+
+func utcStamp(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// escape escapes a TEXT value: backslashes, separators and line breaks.
+func escape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(s)
+}
+
+// fold splits a content line into chunks of at most foldOctets octets,
+// never inside a UTF-8 sequence; continuation lines start with a space.
+func fold(s string) string {
+	if len(s) <= foldOctets {
+		return s
+	}
+	var sb strings.Builder
+	limit := foldOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		sb.WriteString(s[:cut])
+		sb.WriteString("\r\n ")
+		s = s[cut:]
+		// The leading space counts towards the continuation line's length.
+		limit = foldOctets - 1
+	}
+	sb.WriteString(s)
+	return sb.String()
+}
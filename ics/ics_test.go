@@ -0,0 +1,75 @@
+package ics
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncodeTimedEvent(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 3, 12, 19, 0, 0, 0, moscow)
+	got := string(Encode(Event{
+		UID:   "42-0@example",
+		Title: "Встреча; обсудить релиз, план",
+		Place: "Кофейня\nна углу",
+		URL:   "https://t.me/c/1234567890/11",
+		Start: start,
+		End:   start.Add(90 * time.Minute),
+	}, time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC)))
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\n",
+		"BEGIN:VEVENT\r\nUID:42-0@example\r\nDTSTAMP:20260310T080000Z\r\n",
+		"DTSTART:20260312T160000Z\r\nDTEND:20260312T173000Z\r\n",
+		`SUMMARY:Встреча\; обсудить релиз\, план` + "\r\n",
+		`LOCATION:Кофейня\nна углу` + "\r\n",
+		"URL:https://t.me/c/1234567890/11\r\n",
+		"END:VEVENT\r\nEND:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("calendar missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestEncodeAllDayEvent(t *testing.T) {
+	day := time.Date(2026, 3, 20, 0, 0, 0, 0, time.FixedZone("UTC+3", 3*3600))
+	got := string(Encode(Event{UID: "1", Title: "Дедлайн", Start: day, End: day.AddDate(0, 0, 1), AllDay: true}, day))
+
+	if !strings.Contains(got, "DTSTART;VALUE=DATE:20260320\r\nDTEND;VALUE=DATE:20260321\r\n") {
+		t.Fatalf("all-day dates missing:\n%s", got)
+	}
+	if strings.Contains(got, "LOCATION") || strings.Contains(got, "URL") {
+		t.Fatalf("empty fields should be left out:\n%s", got)
+	}
+}
+
+func TestFoldKeepsRunesWhole(t *testing.T) {
+	line := "SUMMARY:" + strings.Repeat("я", 100)
+	folded := fold(line)
+
+	parts := strings.Split(folded, "\r\n")
+	if len(parts) < 3 {
+		t.Fatalf("expected the line to be folded, got %q", folded)
+	}
+	var joined strings.Builder
+	for i, p := range parts {
+		if len(p) > foldOctets {
+			t.Errorf("line %d is %d octets", i, len(p))
+		}
+		if i > 0 {
+			if !strings.HasPrefix(p, " ") {
+				t.Errorf("continuation line %d doesn't start with a space: %q", i, p)
+			}
+			p = p[1:]
+		}
+		joined.WriteString(p)
+	}
+	if joined.String() != line {
+		t.Fatalf("unfolded line differs:\n%q\n%q", joined.String(), line)
+	}
+}
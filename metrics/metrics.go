@@ -97,6 +97,7 @@ type CachedCounters struct {
 	SummarizeOK    int64
 	SummarizeFail  int64
 	RateLimitHits  int64
+	ParseRetries   int64
 	ErrorCounts    map[string]int64
 }
 
@@ -113,6 +114,7 @@ type MetricsSnapshot struct {
 	SummarizeOK    int64
 	SummarizeFail  int64
 	RateLimitHits  int64
+	ParseRetries   int64
 	ErrorCounts    map[string]int64
 	RecentErrors   []ErrorEntry
 }
@@ -129,6 +131,9 @@ type Metrics struct {
 	DBAdd        LatencyStat
 	DBGet        LatencyStat
 	RateLimit    LatencyStat
+	// ParseRetry counts clustering and summary answers that weren't valid
+	// JSON and cost a retry.
+	ParseRetry LatencyStat
 
 	db EventWriter // set by InitLatencyStats
 
@@ -162,6 +167,7 @@ func (m *Metrics) InitLatencyStats(db EventWriter) {
 	m.DBAdd = NewLatencyStat("db_add", db)
 	m.DBGet = NewLatencyStat("db_get", db)
 	m.RateLimit = NewLatencyStat("rate_limit", db)
+	m.ParseRetry = NewLatencyStat("llm_parse_retry", db)
 }
 
 // UpdateCache replaces the latency cache and counter values.
@@ -250,6 +256,7 @@ func (m *Metrics) Snapshot() MetricsSnapshot {
 		SummarizeOK:    c.SummarizeOK,
 		SummarizeFail:  c.SummarizeFail,
 		RateLimitHits:  c.RateLimitHits,
+		ParseRetries:   c.ParseRetries,
 		ErrorCounts:    c.ErrorCounts,
 		RecentErrors:   m.recentErrors(),
 	}
@@ -409,6 +416,7 @@ func formatSnapshot(snap MetricsSnapshot, model string) string {
 		fmt.Fprintf(&sb, "Суммаризаций ошибок:      %d\n", snap.SummarizeFail)
 	}
 	fmt.Fprintf(&sb, "Срабатываний рейт-лимита: %d\n", snap.RateLimitHits)
+	fmt.Fprintf(&sb, "Повторов разбора JSON:    %d\n", snap.ParseRetries)
 
 	if len(snap.ErrorCounts) > 0 {
 		sb.WriteString("\nОшибки по типу:\n")
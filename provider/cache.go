@@ -0,0 +1,116 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// cacheBreakpoints returns the indexes of req's messages marked
+// CacheBreakpoint.
+func cacheBreakpoints(req CompletionRequest) []int {
+	var idx []int
+	for i, m := range req.Messages {
+		if m.CacheBreakpoint {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// promptCacheKey names the prompt prefix up to req's last cache breakpoint,
+// so calls sharing it are routed to the same cache; "" without breakpoints.
+func promptCacheKey(req CompletionRequest) string {
+	idx := cacheBreakpoints(req)
+	if len(idx) == 0 {
+		return ""
+	}
+	h := sha256.New()
+	h.Write([]byte(req.Model))
+	for _, m := range req.Messages[:idx[len(idx)-1]+1] {
+		h.Write([]byte{0})
+		h.Write([]byte(m.Role))
+		h.Write([]byte{0})
+		h.Write([]byte(m.Content))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+type cacheControlKey struct{}
+
+// withCacheControl has the request made under ctx mark the messages at idx
+// for caching (see cacheControlTransport).
+func withCacheControl(ctx context.Context, idx []int) context.Context {
+	return context.WithValue(ctx, cacheControlKey{}, idx)
+}
+
+// cacheControlTransport adds "cache_control" markers to the chat messages its
+// request's context lists (see withCacheControl). OpenRouter passes them on to
+// the providers with explicit prompt caching, such as Anthropic and Gemini.
+// go-openai has no field for them, hence the body rewrite.
+type cacheControlTransport struct{ inner http.RoundTripper }
+
+func (t *cacheControlTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	idx, _ := req.Context().Value(cacheControlKey{}).([]int)
+	if len(idx) == 0 || req.Body == nil {
+		return t.inner.RoundTrip(req)
+	}
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	body = markCacheControl(body, idx)
+
+	marked := req.Clone(req.Context())
+	marked.Body = io.NopCloser(bytes.NewReader(body))
+	marked.ContentLength = int64(len(body))
+	marked.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	return t.inner.RoundTrip(marked)
+}
+
+// markCacheControl puts an ephemeral cache_control on the last content part
+// of each message at idx in a chat completion request body, turning plain
+// string content into a single text part first. A body it can't read is
+// returned as is: the hint is an optimization, not worth failing the call.
+func markCacheControl(body []byte, idx []int) []byte {
+	var req map[string]json.RawMessage
+	if err := json.Unmarshal(body, &req); err != nil {
+		return body
+	}
+	var messages []map[string]any
+	if err := json.Unmarshal(req["messages"], &messages); err != nil {
+		return body
+	}
+	marker := map[string]any{"type": "ephemeral"}
+	for _, i := range idx {
+		if i < 0 || i >= len(messages) {
+			continue
+		}
+		switch content := messages[i]["content"].(type) {
+		case string:
+			messages[i]["content"] = []any{map[string]any{"type": "text", "text": content, "cache_control": marker}}
+		case []any:
+			if len(content) == 0 {
+				continue
+			}
+			if part, ok := content[len(content)-1].(map[string]any); ok {
+				part["cache_control"] = marker
+			}
+		}
+	}
+	raw, err := json.Marshal(messages)
+	if err != nil {
+		return body
+	}
+	req["messages"] = raw
+	out, err := json.Marshal(req)
+	if err != nil {
+		return body
+	}
+	return out
+}
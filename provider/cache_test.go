@@ -0,0 +1,158 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPromptCacheKey(t *testing.T) {
+	prefix := []Message{
+		{Role: "system", Content: "sys"},
+		{Role: "user", Content: "transcript", CacheBreakpoint: true},
+	}
+	req := func(model, transcript, task string) CompletionRequest {
+		msgs := append([]Message(nil), prefix...)
+		msgs[1].Content = transcript
+		return CompletionRequest{Model: model, Messages: append(msgs, Message{Role: "user", Content: task})}
+	}
+
+	cluster := promptCacheKey(req("m", "transcript", "cluster"))
+	if cluster == "" {
+		t.Fatal("expected a key for a request with a breakpoint")
+	}
+	if got := promptCacheKey(req("m", "transcript", "summarize")); got != cluster {
+		t.Errorf("key changed with the task after the breakpoint: %q vs %q", got, cluster)
+	}
+	if got := promptCacheKey(req("m", "other", "cluster")); got == cluster {
+		t.Error("key should change with the prefix")
+	}
+	if got := promptCacheKey(req("n", "transcript", "cluster")); got == cluster {
+		t.Error("key should change with the model")
+	}
+	if got := promptCacheKey(CompletionRequest{Model: "m", Messages: []Message{{Role: "user", Content: "hi"}}}); got != "" {
+		t.Errorf("key without breakpoints = %q, want empty", got)
+	}
+}
+
+func TestMarkCacheControl(t *testing.T) {
+	body := []byte(`{"model":"m","messages":[` +
+		`{"role":"system","content":"sys"},` +
+		`{"role":"user","content":[{"type":"text","text":"a"},{"type":"text","text":"b"}]},` +
+		`{"role":"user","content":"task"}]}`)
+
+	var got struct {
+		Model    string           `json:"model"`
+		Messages []map[string]any `json:"messages"`
+	}
+	if err := json.Unmarshal(markCacheControl(body, []int{0, 1, 7}), &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got.Model != "m" {
+		t.Errorf("model = %q, want m", got.Model)
+	}
+
+	sys, _ := got.Messages[0]["content"].([]any)
+	if len(sys) != 1 {
+		t.Fatalf("system content = %v, want one text part", got.Messages[0]["content"])
+	}
+	if part := sys[0].(map[string]any); part["text"] != "sys" || part["cache_control"] == nil {
+		t.Errorf("system part = %v, want marked text part", part)
+	}
+	parts := got.Messages[1]["content"].([]any)
+	if parts[0].(map[string]any)["cache_control"] != nil || parts[1].(map[string]any)["cache_control"] == nil {
+		t.Errorf("parts = %v, want only the last one marked", parts)
+	}
+	if got.Messages[2]["content"] != "task" {
+		t.Errorf("unmarked message changed: %v", got.Messages[2]["content"])
+	}
+
+	if out := markCacheControl([]byte("not json"), []int{0}); string(out) != "not json" {
+		t.Errorf("unreadable body changed: %q", out)
+	}
+}
+
+func TestCompletionsClientCacheControl(t *testing.T) {
+	for _, on := range []bool{true, false} {
+		var captured map[string]any
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := json.NewDecoder(r.Body).Decode(&captured); err != nil {
+				t.Fatalf("decode request: %v", err)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"ok"},"finish_reason":"stop"}]}`))
+		}))
+
+		client, err := NewCompletionsClient("k", server.URL, 0, WithCacheControl(on))
+		if err != nil {
+			t.Fatalf("NewCompletionsClient: %v", err)
+		}
+		_, err = client.Complete(context.Background(), CompletionRequest{
+			Model: "m",
+			Messages: []Message{
+				{Role: "system", Content: "sys"},
+				{Role: "user", Content: "transcript", CacheBreakpoint: true},
+				{Role: "user", Content: "task"},
+			},
+		})
+		server.Close()
+		if err != nil {
+			t.Fatalf("Complete: %v", err)
+		}
+
+		messages := captured["messages"].([]any)
+		transcript := messages[1].(map[string]any)["content"]
+		parts, marked := transcript.([]any)
+		if marked != on {
+			t.Fatalf("cache control %v: transcript content = %v", on, transcript)
+		}
+		if on && parts[0].(map[string]any)["cache_control"] == nil {
+			t.Errorf("transcript part = %v, want cache_control", parts[0])
+		}
+		if _, ok := messages[2].(map[string]any)["content"].(string); !ok {
+			t.Errorf("task after the breakpoint should stay plain: %v", messages[2])
+		}
+	}
+}
+
+func TestResponsesClientPromptCacheKey(t *testing.T) {
+	var captured map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&captured); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"r","status":"completed","output":[{"type":"message","content":[{"type":"output_text","text":"ok"}]}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewResponsesClient("k", server.URL, 0)
+	if err != nil {
+		t.Fatalf("NewResponsesClient: %v", err)
+	}
+	req := CompletionRequest{
+		Model: "gpt-4o",
+		Messages: []Message{
+			{Role: "system", Content: "sys"},
+			{Role: "user", Content: "transcript", CacheBreakpoint: true},
+			{Role: "user", Content: "task"},
+		},
+	}
+	if _, err := client.Complete(context.Background(), req); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if captured["prompt_cache_key"] != promptCacheKey(req) {
+		t.Errorf("prompt_cache_key = %v, want %q", captured["prompt_cache_key"], promptCacheKey(req))
+	}
+
+	captured = nil
+	req.Messages = req.Messages[2:]
+	if _, err := client.Complete(context.Background(), req); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if _, ok := captured["prompt_cache_key"]; ok {
+		t.Errorf("prompt_cache_key sent without a breakpoint: %v", captured["prompt_cache_key"])
+	}
+}
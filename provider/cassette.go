@@ -0,0 +1,294 @@
+package provider
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"telegram_summarize_bot/logger"
+)
+
+// A cassette is a JSONL file of recorded LLM calls, one cassetteEntry per
+// line. The cassette recorder appends to it; the replay client serves the
+// recorded responses back to identical requests, so a real day can be
+// reproduced locally with no network (LLM_MODE=replay).
+
+// cassetteEntry is one recorded call.
+type cassetteEntry struct {
+	Key        string           `json:"key"`
+	RecordedAt time.Time        `json:"recorded_at"`
+	Operation  string           `json:"operation,omitempty"`
+	Request    cassetteRequest  `json:"request"`
+	Response   *cassetteReply   `json:"response,omitempty"`
+	Error      *cassetteFailure `json:"error,omitempty"`
+}
+
+type cassetteRequest struct {
+	Model       string            `json:"model"`
+	Messages    []cassetteMessage `json:"messages"`
+	MaxTokens   int               `json:"max_tokens,omitempty"`
+	Temperature float32           `json:"temperature"`
+}
+
+type cassetteMessage struct {
+	Role    string          `json:"role"`
+	Content string          `json:"content"`
+	Images  []cassetteImage `json:"images,omitempty"`
+}
+
+// cassetteImage stands in for an image: its digest keys the request, the
+// bytes themselves are never written.
+type cassetteImage struct {
+	MIMEType string `json:"mime_type"`
+	SHA256   string `json:"sha256"`
+	Size     int    `json:"size"`
+}
+
+type cassetteReply struct {
+	Content        string     `json:"content"`
+	FinishReason   string     `json:"finish_reason,omitempty"`
+	HTTPStatusCode int        `json:"http_status,omitempty"`
+	Usage          TokenUsage `json:"usage"`
+}
+
+// cassetteFailure is a recorded error; Status is 0 for non-HTTP errors.
+type cassetteFailure struct {
+	Status  int    `json:"status,omitempty"`
+	Message string `json:"message"`
+}
+
+// cassetteRequestOf converts req to its recorded form (unredacted).
+func cassetteRequestOf(req CompletionRequest) cassetteRequest {
+	out := cassetteRequest{
+		Model:       req.Model,
+		Messages:    make([]cassetteMessage, 0, len(req.Messages)),
+		MaxTokens:   req.MaxTokens,
+		Temperature: req.Temperature,
+	}
+	for _, m := range req.Messages {
+		cm := cassetteMessage{Role: m.Role, Content: m.Content}
+		for _, img := range m.Images {
+			sum := sha256.Sum256(img.Bytes)
+			cm.Images = append(cm.Images, cassetteImage{
+				MIMEType: img.MIMEType,
+				SHA256:   hex.EncodeToString(sum[:]),
+				Size:     len(img.Bytes),
+			})
+		}
+		out.Messages = append(out.Messages, cm)
+	}
+	return out
+}
+
+// CassetteKey identifies a request on a cassette: a hash of the model, the
+// messages (images by digest), MaxTokens and Temperature. Operation is not
+// part of it, so a preview replays a recorded summary of the same window, nor
+// is Schema, so cassettes recorded without structured outputs still replay.
+func CassetteKey(req CompletionRequest) string {
+	raw, _ := json.Marshal(cassetteRequestOf(req))
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// CassetteOption configures a cassette recorder.
+type CassetteOption func(*cassetteRecorder)
+
+// WithCassetteRedaction keeps message text out of the cassette: each recorded
+// request message is replaced by its length. Keys are computed from the full
+// request, so a redacted cassette still replays; responses are kept as-is.
+func WithCassetteRedaction() CassetteOption {
+	return func(c *cassetteRecorder) { c.redact = true }
+}
+
+// cassetteRecorder wraps an LLMClient and appends every call to a cassette.
+type cassetteRecorder struct {
+	inner  LLMClient
+	redact bool
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewCassetteRecorder returns a client that forwards to inner and appends
+// each request with its response or error to the cassette at path, creating
+// the file if needed. Calls cancelled by the caller are not recorded.
+func NewCassetteRecorder(inner LLMClient, path string, opts ...CassetteOption) (LLMClient, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600) // #nosec G304 -- path comes from LLM_CASSETTE
+	if err != nil {
+		return nil, fmt.Errorf("open cassette: %w", err)
+	}
+	c := &cassetteRecorder{inner: inner, file: f}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(c)
+		}
+	}
+	return c, nil
+}
+
+func (c *cassetteRecorder) Complete(ctx context.Context, req CompletionRequest) (CompletionResponse, error) {
+	resp, err := c.inner.Complete(ctx, req)
+	if err != nil && ctx.Err() != nil {
+		return resp, err
+	}
+
+	entry := cassetteEntry{
+		Key:        CassetteKey(req),
+		RecordedAt: time.Now().UTC(),
+		Operation:  operationFor(ctx, req),
+		Request:    cassetteRequestOf(req),
+	}
+	if c.redact {
+		for i := range entry.Request.Messages {
+			m := &entry.Request.Messages[i]
+			m.Content = fmt.Sprintf("[redacted: %d chars]", len([]rune(m.Content)))
+		}
+	}
+	if err != nil {
+		entry.Error = &cassetteFailure{Message: err.Error()}
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			entry.Error = &cassetteFailure{Status: apiErr.HTTPStatusCode, Message: apiErr.Message}
+		}
+	} else {
+		entry.Response = &cassetteReply{
+			Content:        resp.Content,
+			FinishReason:   resp.FinishReason,
+			HTTPStatusCode: resp.HTTPStatusCode,
+			Usage:          resp.Usage,
+		}
+	}
+
+	// Recording is a debugging aid; never fail the call because of it.
+	line, wErr := json.Marshal(entry)
+	if wErr == nil {
+		c.mu.Lock()
+		_, wErr = c.file.Write(append(line, '\n'))
+		c.mu.Unlock()
+	}
+	if wErr != nil {
+		logger.Warn().Err(wErr).Str("operation", entry.Operation).Msg("failed to record LLM call to cassette")
+	}
+	return resp, err
+}
+
+// SupportsVision forwards the capability check so vision gating keeps working
+// through the cassette recorder.
+func (c *cassetteRecorder) SupportsVision(model string) bool {
+	if vc, ok := c.inner.(VisionCapable); ok {
+		return vc.SupportsVision(model)
+	}
+	return false
+}
+
+// CodexTokenStore forwards the Codex credentials accessor through the wrapper.
+func (c *cassetteRecorder) CodexTokenStore() *TokenStore {
+	if h, ok := c.inner.(CodexTokenStorer); ok {
+		return h.CodexTokenStore()
+	}
+	return nil
+}
+
+// replayClient serves recorded calls from a cassette.
+type replayClient struct {
+	mu      sync.Mutex
+	entries map[string][]cassetteEntry
+	served  map[string]int
+	vision  map[string]bool // models recorded with image inputs
+}
+
+// NewReplayClient loads the cassette at path and returns a client that
+// answers each request with the response recorded for its CassetteKey.
+// Repeated requests get the recorded calls in order, the last one repeating
+// once they run out. A request that was never recorded fails with a
+// non-retryable APIError.
+func NewReplayClient(path string) (LLMClient, error) {
+	f, err := os.Open(path) // #nosec G304 -- path comes from LLM_CASSETTE
+	if err != nil {
+		return nil, fmt.Errorf("open cassette: %w", err)
+	}
+	defer f.Close()
+
+	c := &replayClient{
+		entries: make(map[string][]cassetteEntry),
+		served:  make(map[string]int),
+		vision:  make(map[string]bool),
+	}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for n := 1; sc.Scan(); n++ {
+		line := sc.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var e cassetteEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		if e.Key == "" || (e.Response == nil && e.Error == nil) {
+			return nil, fmt.Errorf("%s:%d: entry has no key or outcome", path, n)
+		}
+		c.entries[e.Key] = append(c.entries[e.Key], e)
+		for _, m := range e.Request.Messages {
+			if len(m.Images) > 0 {
+				c.vision[e.Request.Model] = true
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read cassette: %w", err)
+	}
+	return c, nil
+}
+
+func (c *replayClient) Complete(ctx context.Context, req CompletionRequest) (CompletionResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return CompletionResponse{}, err
+	}
+	key := CassetteKey(req)
+
+	c.mu.Lock()
+	recorded := c.entries[key]
+	var e cassetteEntry
+	if len(recorded) > 0 {
+		i := c.served[key]
+		if i >= len(recorded) {
+			i = len(recorded) - 1
+		}
+		e = recorded[i]
+		c.served[key] = i + 1
+	}
+	c.mu.Unlock()
+
+	switch {
+	case len(recorded) == 0:
+		return CompletionResponse{}, &APIError{
+			HTTPStatusCode: http.StatusNotFound,
+			Message:        fmt.Sprintf("replay: no recorded %s call for request %s", operationFor(ctx, req), key[:12]),
+		}
+	case e.Error != nil && e.Error.Status != 0:
+		return CompletionResponse{HTTPStatusCode: e.Error.Status}, &APIError{HTTPStatusCode: e.Error.Status, Message: e.Error.Message}
+	case e.Error != nil:
+		return CompletionResponse{}, errors.New(e.Error.Message)
+	}
+	return CompletionResponse{
+		Content:        e.Response.Content,
+		FinishReason:   e.Response.FinishReason,
+		HTTPStatusCode: e.Response.HTTPStatusCode,
+		Usage:          e.Response.Usage,
+	}, nil
+}
+
+// SupportsVision reports whether the cassette holds image requests for model,
+// so replay makes the same vision calls the recorded run did.
+func (c *replayClient) SupportsVision(model string) bool {
+	return c.vision[model]
+}
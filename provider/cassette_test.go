@@ -0,0 +1,203 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"telegram_summarize_bot/config"
+)
+
+// scriptedClient answers calls in order; an entry with a non-nil err fails.
+type scriptedClient struct {
+	replies []scriptedReply
+	calls   int
+}
+
+type scriptedReply struct {
+	content string
+	err     error
+}
+
+func (s *scriptedClient) Complete(context.Context, CompletionRequest) (CompletionResponse, error) {
+	r := s.replies[s.calls]
+	s.calls++
+	if r.err != nil {
+		return CompletionResponse{}, r.err
+	}
+	return CompletionResponse{Content: r.content, FinishReason: "stop", Usage: TokenUsage{PromptTokens: 7, CompletionTokens: 3, TotalTokens: 10}}, nil
+}
+
+func (s *scriptedClient) SupportsVision(string) bool { return true }
+
+func clusterRequest(text string) CompletionRequest {
+	return CompletionRequest{
+		Model:     "test-model",
+		Messages:  []Message{{Role: "system", Content: "cluster"}, {Role: "user", Content: text}},
+		MaxTokens: 500,
+		Operation: OpCluster,
+	}
+}
+
+func TestCassetteRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "day.cassette.jsonl")
+	inner := &scriptedClient{replies: []scriptedReply{
+		{err: &APIError{HTTPStatusCode: 502, Message: "bad gateway"}},
+		{content: `{"topics":[]}`},
+		{content: "описание"},
+	}}
+	rec, err := NewCassetteRecorder(inner, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rec.(VisionCapable).SupportsVision("test-model") {
+		t.Error("recorder should forward SupportsVision")
+	}
+
+	ctx := context.Background()
+	req := clusterRequest("привет")
+	if _, err := rec.Complete(ctx, req); err == nil {
+		t.Fatal("first call should fail")
+	}
+	if _, err := rec.Complete(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	vision := CompletionRequest{Model: "vision-model", Messages: []Message{{Role: "user", Content: "опиши",
+		Images: []ImageInput{{Bytes: []byte{0xff, 0xd8}, MIMEType: "image/jpeg"}}}}, Operation: OpVision}
+	if _, err := rec.Complete(ctx, vision); err != nil {
+		t.Fatal(err)
+	}
+
+	replay, err := NewReplayClient(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The same request replays the recorded failure, then the success, which
+	// repeats once the recorded calls run out.
+	_, err = replay.Complete(ctx, req)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != 502 {
+		t.Fatalf("first replay err = %v, want recorded HTTP 502", err)
+	}
+	for i := 0; i < 2; i++ {
+		resp, err := replay.Complete(ctx, req)
+		if err != nil || resp.Content != `{"topics":[]}` || resp.Usage.TotalTokens != 10 {
+			t.Fatalf("replay %d = %+v, %v", i, resp, err)
+		}
+	}
+	if resp, err := replay.Complete(ctx, vision); err != nil || resp.Content != "описание" {
+		t.Fatalf("vision replay = %+v, %v", resp, err)
+	}
+
+	vc := replay.(VisionCapable)
+	if !vc.SupportsVision("vision-model") || vc.SupportsVision("test-model") {
+		t.Error("replay should report vision only for models recorded with images")
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "\xff\xd8") || !strings.Contains(string(raw), `"sha256"`) {
+		t.Errorf("cassette should hold image digests, not bytes:\n%s", raw)
+	}
+}
+
+func TestCassetteReplayUnknownRequest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.cassette.jsonl")
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	replay, err := NewReplayClient(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = replay.Complete(context.Background(), clusterRequest("привет"))
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != 404 {
+		t.Fatalf("err = %v, want a non-retryable APIError", err)
+	}
+}
+
+func TestCassetteRedaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "redacted.cassette.jsonl")
+	rec, err := NewCassetteRecorder(&scriptedClient{replies: []scriptedReply{{content: "итог"}}}, path, WithCassetteRedaction())
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := clusterRequest("секретный план")
+	if _, err := rec.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "секретный") || !strings.Contains(string(raw), "[redacted: 14 chars]") {
+		t.Fatalf("request text should be redacted:\n%s", raw)
+	}
+
+	// Keys come from the original request, so a redacted cassette replays.
+	replay, err := NewReplayClient(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := replay.Complete(context.Background(), req); err != nil || resp.Content != "итог" {
+		t.Fatalf("replay = %+v, %v", resp, err)
+	}
+}
+
+func TestCassetteKeyIgnoresOperation(t *testing.T) {
+	a := clusterRequest("привет")
+	b := clusterRequest("привет")
+	b.Operation = OpPreview
+	if CassetteKey(a) != CassetteKey(b) {
+		t.Error("operation should not change the key")
+	}
+	b.Temperature = 0.5
+	if CassetteKey(a) == CassetteKey(b) {
+		t.Error("temperature should change the key")
+	}
+}
+
+func TestNewReplayMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "day.cassette.jsonl")
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	client, err := New(&config.Config{LLMMode: config.LLMModeReplay, LLMCassette: path}, &fakeRecorder{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, ok := client.(*replayClient); !ok {
+		t.Errorf("expected *replayClient, got %T", client)
+	}
+
+	if _, err := New(&config.Config{LLMMode: config.LLMModeReplay, LLMCassette: filepath.Join(t.TempDir(), "missing")}, nil); err == nil {
+		t.Error("expected error for a missing cassette")
+	}
+}
+
+func TestNewRecordsCassette(t *testing.T) {
+	cfg := &config.Config{
+		LLMMode:     config.LLMModeCompletions,
+		LLMToken:    "test-key",
+		LLMEndpoint: "https://example.com/v1",
+		LLMCassette: filepath.Join(t.TempDir(), "day.cassette.jsonl"),
+	}
+	client, err := New(cfg, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	rec, ok := client.(*cassetteRecorder)
+	if !ok {
+		t.Fatalf("expected *cassetteRecorder, got %T", client)
+	}
+	if _, ok := rec.inner.(*completionsClient); !ok {
+		t.Errorf("expected the recorder to wrap *completionsClient, got %T", rec.inner)
+	}
+}
@@ -5,26 +5,40 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"io"
+	"strings"
 	"time"
 
 	"github.com/sashabaranov/go-openai"
+	"telegram_summarize_bot/config"
 )
 
 type completionsClient struct {
-	client *openai.Client
+	client       *openai.Client
+	structured   config.StructuredOutput
+	cacheControl bool
 }
 
 // NewCompletionsClient creates an LLMClient using the OpenAI Chat Completions API.
 // Works with any OpenAI-compatible endpoint (OpenRouter, LiteLLM, etc.).
 // A non-positive timeout falls back to defaultLLMHTTPTimeout.
-func NewCompletionsClient(token, endpoint string, timeout time.Duration) (LLMClient, error) {
+func NewCompletionsClient(token, endpoint string, timeout time.Duration, opts ...ClientOption) (LLMClient, error) {
 	if timeout <= 0 {
 		timeout = defaultLLMHTTPTimeout
 	}
+	o := applyClientOptions(opts)
 	cfg := openai.DefaultConfig(token)
 	cfg.BaseURL = endpoint
-	cfg.HTTPClient = HTTPClient(timeout)
-	return &completionsClient{client: openai.NewClientWithConfig(cfg)}, nil
+	httpClient := HTTPClient(timeout)
+	if o.cacheControl {
+		httpClient.Transport = &cacheControlTransport{inner: httpClient.Transport}
+	}
+	cfg.HTTPClient = httpClient
+	return &completionsClient{
+		client:       openai.NewClientWithConfig(cfg),
+		structured:   o.structured,
+		cacheControl: o.cacheControl,
+	}, nil
 }
 
 func (c *completionsClient) Complete(ctx context.Context, req CompletionRequest) (CompletionResponse, error) {
@@ -59,12 +73,47 @@ func (c *completionsClient) Complete(ctx context.Context, req CompletionRequest)
 		}
 	}
 
-	resp, err := c.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+	chatReq := openai.ChatCompletionRequest{
 		Model:       req.Model,
 		Messages:    messages,
 		MaxTokens:   req.MaxTokens,
 		Temperature: req.Temperature,
-	})
+	}
+	if req.Schema != nil {
+		switch c.structured {
+		case config.StructuredOff:
+		case config.StructuredTool:
+			chatReq.Tools = []openai.Tool{{
+				Type: openai.ToolTypeFunction,
+				Function: &openai.FunctionDefinition{
+					Name:       req.Schema.Name,
+					Strict:     true,
+					Parameters: schemaJSON(req.Schema.Schema),
+				},
+			}}
+			chatReq.ToolChoice = openai.ToolChoice{
+				Type:     openai.ToolTypeFunction,
+				Function: openai.ToolFunction{Name: req.Schema.Name},
+			}
+		default:
+			chatReq.ResponseFormat = &openai.ChatCompletionResponseFormat{
+				Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+				JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+					Name:   req.Schema.Name,
+					Schema: schemaJSON(req.Schema.Schema),
+					Strict: true,
+				},
+			}
+		}
+	}
+	if idx := cacheBreakpoints(req); c.cacheControl && len(idx) > 0 {
+		ctx = withCacheControl(ctx, idx)
+	}
+	if onDelta := deltasFrom(ctx); onDelta != nil {
+		return c.completeStreaming(ctx, chatReq, onDelta)
+	}
+
+	resp, err := c.client.CreateChatCompletion(ctx, chatReq)
 	if err != nil {
 		return CompletionResponse{}, wrapOpenAIError(err)
 	}
@@ -85,14 +134,87 @@ func (c *completionsClient) Complete(ctx context.Context, req CompletionRequest)
 		usage.CachedInputTokens = resp.Usage.PromptTokensDetails.CachedTokens
 	}
 
+	// A forced tool call (StructuredTool) answers in its arguments.
+	content := resp.Choices[0].Message.Content
+	if content == "" && len(resp.Choices[0].Message.ToolCalls) > 0 {
+		content = resp.Choices[0].Message.ToolCalls[0].Function.Arguments
+	}
+
 	return CompletionResponse{
-		Content:        resp.Choices[0].Message.Content,
+		Content:        content,
 		FinishReason:   string(resp.Choices[0].FinishReason),
 		HTTPStatusCode: 200,
 		Usage:          usage,
 	}, nil
 }
 
+// completeStreaming is Complete over a streamed completion, passing each
+// chunk of content (or of a forced tool call's arguments) to onDelta and
+// asking for usage in the final chunk.
+func (c *completionsClient) completeStreaming(ctx context.Context, req openai.ChatCompletionRequest, onDelta func(string)) (CompletionResponse, error) {
+	req.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	stream, err := c.client.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		return CompletionResponse{}, wrapOpenAIError(err)
+	}
+	defer func() { _ = stream.Close() }()
+
+	var (
+		content      strings.Builder
+		finishReason string
+		usage        TokenUsage
+		sawChoice    bool
+	)
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return CompletionResponse{}, wrapOpenAIError(err)
+		}
+		if chunk.Usage != nil {
+			usage = TokenUsage{
+				PromptTokens:     chunk.Usage.PromptTokens,
+				CompletionTokens: chunk.Usage.CompletionTokens,
+				TotalTokens:      chunk.Usage.TotalTokens,
+			}
+			if chunk.Usage.PromptTokensDetails != nil {
+				usage.CachedInputTokens = chunk.Usage.PromptTokensDetails.CachedTokens
+			}
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+		sawChoice = true
+		choice := chunk.Choices[0]
+		delta := choice.Delta.Content
+		if len(choice.Delta.ToolCalls) > 0 {
+			delta += choice.Delta.ToolCalls[0].Function.Arguments
+		}
+		if delta != "" {
+			content.WriteString(delta)
+			onDelta(delta)
+		}
+		if choice.FinishReason != "" {
+			finishReason = string(choice.FinishReason)
+		}
+	}
+	if !sawChoice {
+		return CompletionResponse{}, &APIError{
+			HTTPStatusCode: 0,
+			Message:        "no choices returned from API",
+		}
+	}
+
+	return CompletionResponse{
+		Content:        content.String(),
+		FinishReason:   finishReason,
+		HTTPStatusCode: 200,
+		Usage:          usage,
+	}, nil
+}
+
 // dataURI builds an RFC-2397 data URL for an image payload. MIME defaults to
 // image/jpeg when missing — the OpenAI/Chat Completions endpoints both accept
 // JPEG/PNG/GIF/WebP without requiring an exact match.
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/sashabaranov/go-openai"
+	"telegram_summarize_bot/config"
 )
 
 func TestCompletionsClientComplete(t *testing.T) {
@@ -259,3 +260,114 @@ func TestWrapOpenAIError(t *testing.T) {
 		}
 	})
 }
+
+func TestCompletionsClientStreamsDeltas(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		if !req.Stream || req.StreamOptions == nil || !req.StreamOptions.IncludeUsage {
+			t.Errorf("stream = %v, options = %+v; want a stream with usage", req.Stream, req.StreamOptions)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range []string{
+			`{"choices":[{"index":0,"delta":{"content":"hello "}}]}`,
+			`{"choices":[{"index":0,"delta":{"content":"world"},"finish_reason":"stop"}]}`,
+			`{"choices":[],"usage":{"prompt_tokens":10,"completion_tokens":2,"total_tokens":12}}`,
+		} {
+			_, _ = w.Write([]byte("data: " + chunk + "\n\n"))
+		}
+		_, _ = w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	client, err := NewCompletionsClient("test-token", server.URL, 0)
+	if err != nil {
+		t.Fatalf("NewCompletionsClient: %v", err)
+	}
+
+	var deltas []string
+	ctx := WithDeltas(context.Background(), func(d string) { deltas = append(deltas, d) })
+	resp, err := client.Complete(ctx, CompletionRequest{
+		Model:    "test-model",
+		Messages: []Message{{Role: "user", Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if strings.Join(deltas, "|") != "hello |world" {
+		t.Errorf("deltas = %q, want each chunk in order", deltas)
+	}
+	if resp.Content != "hello world" || resp.FinishReason != "stop" {
+		t.Errorf("response = %+v, want the joined content", resp)
+	}
+	if resp.Usage.TotalTokens != 12 {
+		t.Errorf("usage = %+v, want the final chunk's usage", resp.Usage)
+	}
+}
+
+func TestCompletionsClientSchema(t *testing.T) {
+	schema := SchemaFor("answer", struct {
+		TLDR string `json:"tldr"`
+	}{})
+	tests := []struct {
+		mode                     config.StructuredOutput
+		wantFormat, wantToolCall bool
+	}{
+		{config.StructuredJSONSchema, true, false},
+		{config.StructuredTool, false, true},
+		{config.StructuredOff, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			var captured map[string]any
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&captured); err != nil {
+					t.Fatalf("decode request: %v", err)
+				}
+				msg := openai.ChatCompletionMessage{Content: `{"tldr":"ok"}`}
+				if captured["tool_choice"] != nil {
+					msg = openai.ChatCompletionMessage{ToolCalls: []openai.ToolCall{{
+						Type:     openai.ToolTypeFunction,
+						Function: openai.FunctionCall{Name: "answer", Arguments: `{"tldr":"ok"}`},
+					}}}
+				}
+				resp := openai.ChatCompletionResponse{Choices: []openai.ChatCompletionChoice{{Message: msg, FinishReason: "stop"}}}
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(resp)
+			}))
+			defer server.Close()
+
+			client, err := NewCompletionsClient("k", server.URL, 0, WithStructuredOutput(tt.mode))
+			if err != nil {
+				t.Fatalf("NewCompletionsClient: %v", err)
+			}
+			resp, err := client.Complete(context.Background(), CompletionRequest{
+				Model:    "m",
+				Messages: []Message{{Role: "user", Content: "hi"}},
+				Schema:   schema,
+			})
+			if err != nil {
+				t.Fatalf("Complete: %v", err)
+			}
+			if resp.Content != `{"tldr":"ok"}` {
+				t.Errorf("content = %q, want the JSON answer", resp.Content)
+			}
+
+			format, _ := captured["response_format"].(map[string]any)
+			if gotFormat := format["type"] == "json_schema"; gotFormat != tt.wantFormat {
+				t.Errorf("response_format = %v, want json_schema: %v", captured["response_format"], tt.wantFormat)
+			}
+			if tt.wantFormat {
+				js, _ := format["json_schema"].(map[string]any)
+				if js["name"] != "answer" || js["strict"] != true || js["schema"] == nil {
+					t.Errorf("json_schema = %v", js)
+				}
+			}
+			if gotTool := captured["tool_choice"] != nil && captured["tools"] != nil; gotTool != tt.wantToolCall {
+				t.Errorf("tools = %v, tool_choice = %v; want forced tool: %v", captured["tools"], captured["tool_choice"], tt.wantToolCall)
+			}
+		})
+	}
+}
@@ -60,6 +60,12 @@ type Message struct {
 	// Images is the optional list of images attached to this message.
 	// Providers without multimodal support ignore this field.
 	Images []ImageInput
+	// CacheBreakpoint marks the end of a prompt prefix other calls repeat,
+	// such as the instructions and transcript shared by a summary's calls.
+	// Backends with explicit prompt caching cache up to here: Chat Completions
+	// with cache_control markers (see WithCacheControl), the Responses API
+	// with a prompt_cache_key. Others rely on automatic prefix caching.
+	CacheBreakpoint bool
 }
 
 // Operation labels identify which logical task an LLM call serves. They are
@@ -70,9 +76,37 @@ const (
 	OpText      = "text"
 	OpURL       = "url"
 	OpVision    = "vision"
-	OpProbe     = "probe" // throwaway quota probe; excluded from usage reports
+	OpExpand    = "expand"  // deeper summary of one digest topic
+	OpActions   = "actions" // decisions, action items and open questions of a summary
+	OpEvents    = "events"  // calendar events of a summary
+	OpPreview   = "preview" // admin-only digest preview; see WithOperation
+	OpProbe     = "probe"   // throwaway quota probe; excluded from usage reports
 )
 
+type operationKey struct{}
+
+// WithOperation returns a context whose LLM calls are all accounted under op,
+// overriding each request's own Operation. Used to attribute a whole pipeline
+// (clustering, summary, vision) to one task such as a preview.
+func WithOperation(ctx context.Context, op string) context.Context {
+	return context.WithValue(ctx, operationKey{}, op)
+}
+
+// OperationFromContext returns the operation set by WithOperation, if any.
+func OperationFromContext(ctx context.Context) (string, bool) {
+	op, ok := ctx.Value(operationKey{}).(string)
+	return op, ok && op != ""
+}
+
+// operationFor returns the accounting label for req: the context override set
+// by WithOperation when present, otherwise req.Operation.
+func operationFor(ctx context.Context, req CompletionRequest) string {
+	if op, ok := OperationFromContext(ctx); ok {
+		return op
+	}
+	return req.Operation
+}
+
 // CompletionRequest is an API-agnostic request to the LLM.
 type CompletionRequest struct {
 	Model       string
@@ -82,6 +116,9 @@ type CompletionRequest struct {
 	// Operation tags the call for usage accounting (see Op* constants). Empty
 	// is allowed and recorded as an unlabeled operation.
 	Operation string
+	// Schema, if set, is the JSON the answer must be; the client enforces it
+	// as its LLM_STRUCTURED_OUTPUT mode says (see WithStructuredOutput).
+	Schema *JSONSchema
 }
 
 // TokenUsage is the token accounting reported by the LLM for a single call.
@@ -151,8 +188,14 @@ const defaultLLMHTTPTimeout = 180 * time.Second
 
 // New creates the appropriate LLM client based on config. When rec is non-nil,
 // the client records per-call token usage and (in OAuth mode) Codex quota
-// snapshots through it.
+// snapshots through it. With LLM_CASSETTE set, API modes append every call to
+// the cassette and replay mode serves it back; replayed calls are not
+// recorded as usage.
 func New(cfg *config.Config, rec Recorder) (LLMClient, error) {
+	if cfg.LLMMode == config.LLMModeReplay {
+		return NewReplayClient(cfg.LLMCassette)
+	}
+
 	timeout := cfg.LLMHTTPTimeout()
 	var (
 		client LLMClient
@@ -160,17 +203,26 @@ func New(cfg *config.Config, rec Recorder) (LLMClient, error) {
 	)
 	switch cfg.LLMMode {
 	case config.LLMModeCompletions, "":
-		client, err = NewCompletionsClient(cfg.LLMToken, cfg.LLMEndpoint, timeout)
+		client, err = NewCompletionsClient(cfg.LLMToken, cfg.LLMEndpoint, timeout, WithStructuredOutput(cfg.LLMStructuredOutput), WithCacheControl(cfg.LLMCacheControl))
 	case config.LLMModeResponses:
-		client, err = NewResponsesClient(cfg.LLMToken, cfg.LLMEndpoint, timeout, WithRecorder(rec))
+		client, err = NewResponsesClient(cfg.LLMToken, cfg.LLMEndpoint, timeout, WithRecorder(rec), WithStructuredOutput(cfg.LLMStructuredOutput))
 	case config.LLMModeOAuth:
-		client, err = NewOAuthClient(cfg.OAuthTokenDir, cfg.OAuthClientID, cfg.OAuthCodexVersion, timeout, WithRecorder(rec))
+		client, err = NewOAuthClient(cfg.OAuthTokenDir, cfg.OAuthClientID, cfg.OAuthCodexVersion, timeout, WithRecorder(rec), WithStructuredOutput(cfg.LLMStructuredOutput))
 	default:
 		return nil, fmt.Errorf("unknown LLM mode: %q", cfg.LLMMode)
 	}
 	if err != nil {
 		return nil, err
 	}
+	if cfg.LLMCassette != "" {
+		var opts []CassetteOption
+		if cfg.LLMCassetteRedact {
+			opts = append(opts, WithCassetteRedaction())
+		}
+		if client, err = NewCassetteRecorder(client, cfg.LLMCassette, opts...); err != nil {
+			return nil, err
+		}
+	}
 	if rec != nil {
 		client = &recordingClient{inner: client, rec: rec}
 	}
@@ -178,7 +230,11 @@ func New(cfg *config.Config, rec Recorder) (LLMClient, error) {
 }
 
 // clientOptions collects optional behaviour shared by the client constructors.
-type clientOptions struct{ rec Recorder }
+type clientOptions struct {
+	rec          Recorder
+	structured   config.StructuredOutput
+	cacheControl bool
+}
 
 // ClientOption configures an LLM client at construction.
 type ClientOption func(*clientOptions)
@@ -188,8 +244,25 @@ func WithRecorder(rec Recorder) ClientOption {
 	return func(o *clientOptions) { o.rec = rec }
 }
 
+// WithStructuredOutput sets how requests' schemas are enforced. Without it,
+// or with an empty mode, clients use config.StructuredJSONSchema.
+func WithStructuredOutput(mode config.StructuredOutput) ClientOption {
+	return func(o *clientOptions) {
+		if mode != "" {
+			o.structured = mode
+		}
+	}
+}
+
+// WithCacheControl has a Chat Completions client send cache_control markers at
+// messages' cache breakpoints. Only some endpoints accept them (OpenRouter
+// does); the Responses API ignores the option.
+func WithCacheControl(on bool) ClientOption {
+	return func(o *clientOptions) { o.cacheControl = on }
+}
+
 func applyClientOptions(opts []ClientOption) clientOptions {
-	var o clientOptions
+	o := clientOptions{structured: config.StructuredJSONSchema}
 	for _, opt := range opts {
 		if opt != nil {
 			opt(&o)
@@ -207,7 +280,7 @@ type recordingClient struct {
 func (c *recordingClient) Complete(ctx context.Context, req CompletionRequest) (CompletionResponse, error) {
 	resp, err := c.inner.Complete(ctx, req)
 	if err == nil && c.rec != nil && resp.Usage.TotalTokens > 0 {
-		c.rec.RecordTokenUsage(ctx, req.Model, req.Operation, resp.Usage)
+		c.rec.RecordTokenUsage(ctx, req.Model, operationFor(ctx, req), resp.Usage)
 	}
 	return resp, err
 }
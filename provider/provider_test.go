@@ -1,6 +1,7 @@
 package provider
 
 import (
+	"context"
 	"testing"
 
 	"telegram_summarize_bot/config"
@@ -65,3 +66,33 @@ func TestNewInvalidMode(t *testing.T) {
 		t.Fatal("expected error for invalid mode")
 	}
 }
+
+type fakeRecorder struct{ ops []string }
+
+func (f *fakeRecorder) RecordTokenUsage(_ context.Context, _, operation string, _ TokenUsage) {
+	f.ops = append(f.ops, operation)
+}
+
+func (f *fakeRecorder) SaveCodexRateLimits(context.Context, RateLimitSnapshot) {}
+
+type fixedClient struct{}
+
+func (fixedClient) Complete(context.Context, CompletionRequest) (CompletionResponse, error) {
+	return CompletionResponse{Usage: TokenUsage{TotalTokens: 10}}, nil
+}
+
+func TestRecordingClientOperationOverride(t *testing.T) {
+	rec := &fakeRecorder{}
+	client := &recordingClient{inner: fixedClient{}, rec: rec}
+
+	ctx := context.Background()
+	if _, err := client.Complete(ctx, CompletionRequest{Operation: OpCluster}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Complete(WithOperation(ctx, OpPreview), CompletionRequest{Operation: OpCluster}); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.ops) != 2 || rec.ops[0] != OpCluster || rec.ops[1] != OpPreview {
+		t.Fatalf("recorded operations = %v, want [%s %s]", rec.ops, OpCluster, OpPreview)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/openai/openai-go/option"
 	"github.com/openai/openai-go/responses"
 	"github.com/openai/openai-go/shared"
+	"telegram_summarize_bot/config"
 	"telegram_summarize_bot/logger"
 )
 
@@ -19,6 +20,7 @@ type responsesClient struct {
 	token              string // mutable for OAuth token injection
 	accountID          string // ChatGPT-Account-ID header, set for OAuth mode
 	codexClientVersion string // optional OAuth override for the "version" header
+	structured         config.StructuredOutput
 }
 
 // NewResponsesClient creates an LLMClient using the OpenAI Responses API.
@@ -40,8 +42,9 @@ func NewResponsesClient(token, endpoint string, timeout time.Duration, opts ...C
 	)
 
 	return &responsesClient{
-		client: &client,
-		token:  token,
+		client:     &client,
+		token:      token,
+		structured: o.structured,
 	}, nil
 }
 
@@ -81,6 +84,15 @@ func (c *responsesClient) Complete(ctx context.Context, req CompletionRequest) (
 			},
 		},
 	}
+	if key := promptCacheKey(req); key != "" {
+		params.PromptCacheKey = openai.String(key)
+	}
+	// The Responses API has native schemas, so StructuredTool uses them too.
+	if req.Schema != nil && c.structured != config.StructuredOff {
+		format := responses.ResponseFormatTextConfigParamOfJSONSchema(req.Schema.Name, req.Schema.Schema)
+		format.OfJSONSchema.Strict = openai.Bool(true)
+		params.Text.Format = format
+	}
 
 	// Build per-request options: token + optional account ID header for OAuth mode.
 	reqOpts := []option.RequestOption{option.WithAPIKey(c.token)}
@@ -100,6 +112,9 @@ func (c *responsesClient) Complete(ctx context.Context, req CompletionRequest) (
 	// Standard API supports max_output_tokens and temperature.
 	params.MaxOutputTokens = openai.Int(int64(req.MaxTokens))
 	params.Temperature = openai.Float(float64(req.Temperature))
+	if deltasFrom(ctx) != nil {
+		return c.completeStreaming(ctx, params, reqOpts)
+	}
 	resp, err := c.client.Responses.New(ctx, params, reqOpts...)
 	if err != nil {
 		return CompletionResponse{}, wrapResponsesError(err)
@@ -108,11 +123,13 @@ func (c *responsesClient) Complete(ctx context.Context, req CompletionRequest) (
 	return buildResponse(resp)
 }
 
-// completeStreaming uses the streaming API (required by ChatGPT backend) and
-// collects the final response from the response.completed event.
+// completeStreaming uses the streaming API (required by ChatGPT backend, and
+// used for WithDeltas calls) and collects the final response from the
+// response.completed event.
 func (c *responsesClient) completeStreaming(ctx context.Context, params responses.ResponseNewParams, reqOpts []option.RequestOption) (CompletionResponse, error) {
 	stream := c.client.Responses.NewStreaming(ctx, params, reqOpts...)
 	defer func() { _ = stream.Close() }()
+	onDelta := deltasFrom(ctx)
 
 	var finalResp *responses.Response
 	var accumulated strings.Builder
@@ -123,6 +140,9 @@ func (c *responsesClient) completeStreaming(ctx context.Context, params response
 		case "response.output_text.delta":
 			delta := event.AsResponseOutputTextDelta()
 			accumulated.WriteString(delta.Delta)
+			if onDelta != nil {
+				onDelta(delta.Delta)
+			}
 		case "response.completed":
 			completed := event.AsResponseCompleted()
 			finalResp = &completed.Response
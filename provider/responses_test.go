@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	oaierr "github.com/openai/openai-go"
+	"telegram_summarize_bot/config"
 )
 
 func TestResponsesClientComplete(t *testing.T) {
@@ -156,6 +157,54 @@ func TestResponsesClientImageContent(t *testing.T) {
 	}
 }
 
+func TestResponsesClientSchema(t *testing.T) {
+	var captured map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&captured); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		resp := map[string]any{
+			"id":     "resp_schema",
+			"status": "completed",
+			"output": []map[string]any{
+				{
+					"type": "message",
+					"content": []map[string]any{
+						{"type": "output_text", "text": `{"tldr":"ok"}`},
+					},
+				},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	// Tool mode is a Chat Completions fallback; the Responses API keeps its
+	// native schemas.
+	schema := SchemaFor("answer", struct {
+		TLDR string `json:"tldr"`
+	}{})
+	client, err := NewResponsesClient("k", server.URL, 0, WithStructuredOutput(config.StructuredTool))
+	if err != nil {
+		t.Fatalf("NewResponsesClient: %v", err)
+	}
+	_, err = client.Complete(context.Background(), CompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []Message{{Role: "user", Content: "hi"}},
+		Schema:   schema,
+	})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	text, _ := captured["text"].(map[string]any)
+	format, _ := text["format"].(map[string]any)
+	if format["type"] != "json_schema" || format["name"] != "answer" || format["strict"] != true || format["schema"] == nil {
+		t.Fatalf("text.format = %v, want the strict answer schema", format)
+	}
+}
+
 func TestResponsesClientIncompleteStatus(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		resp := map[string]any{
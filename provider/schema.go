@@ -0,0 +1,75 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// JSONSchema is the shape a CompletionRequest's answer must have. Backends
+// enforce it strictly, so every object lists all its properties as required
+// and allows no others; SchemaFor builds such schemas from Go types.
+type JSONSchema struct {
+	Name   string // a-z, A-Z, 0-9, _ and -; at most 64 characters
+	Schema map[string]any
+}
+
+// SchemaFor returns the schema of the JSON encoding of v, a struct, under
+// name. Fields tagged "-" or omitempty are left out: they are filled in by
+// the caller, not the model, and a strict schema can't have optional fields.
+// It panics on types it can't describe, so it belongs in package-level vars.
+func SchemaFor(name string, v any) *JSONSchema {
+	return &JSONSchema{Name: name, Schema: schemaOf(reflect.TypeOf(v))}
+}
+
+func schemaOf(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaOf(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaOf(t.Elem())}
+	case reflect.Struct:
+		properties := make(map[string]any)
+		required := []string{}
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" || strings.Contains(","+opts+",", ",omitempty,") {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			properties[name] = schemaOf(f.Type)
+			required = append(required, name)
+		}
+		return map[string]any{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             required,
+			"additionalProperties": false,
+		}
+	default:
+		panic(fmt.Sprintf("provider: no JSON schema for %s", t))
+	}
+}
+
+// schemaJSON lets a schema map be used where go-openai wants a json.Marshaler.
+type schemaJSON map[string]any
+
+func (s schemaJSON) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any(s))
+}
@@ -0,0 +1,46 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSchemaFor(t *testing.T) {
+	type item struct {
+		Title string  `json:"title"`
+		IDs   []int64 `json:"ids"`
+		Extra int     `json:"extra,omitempty"`
+	}
+	type answer struct {
+		Items  []item  `json:"items"`
+		Score  float64 `json:"score"`
+		OK     bool    `json:"ok"`
+		Hidden string  `json:"-"`
+		Name   string
+	}
+
+	got := SchemaFor("answer", answer{})
+	itemSchema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"title": map[string]any{"type": "string"},
+			"ids":   map[string]any{"type": "array", "items": map[string]any{"type": "integer"}},
+		},
+		"required":             []string{"title", "ids"},
+		"additionalProperties": false,
+	}
+	want := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"items": map[string]any{"type": "array", "items": itemSchema},
+			"score": map[string]any{"type": "number"},
+			"ok":    map[string]any{"type": "boolean"},
+			"Name":  map[string]any{"type": "string"},
+		},
+		"required":             []string{"items", "score", "ok", "Name"},
+		"additionalProperties": false,
+	}
+	if got.Name != "answer" || !reflect.DeepEqual(got.Schema, want) {
+		t.Fatalf("SchemaFor = %s %#v\nwant %#v", got.Name, got.Schema, want)
+	}
+}
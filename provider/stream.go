@@ -0,0 +1,20 @@
+package provider
+
+import "context"
+
+type deltasKey struct{}
+
+// WithDeltas returns a context whose LLM calls stream their output: clients
+// that can stream call fn with each chunk of output text as it arrives,
+// before Complete returns the whole response. Clients that can't just return
+// the response. fn runs on the calling goroutine and must not block.
+func WithDeltas(ctx context.Context, fn func(delta string)) context.Context {
+	return context.WithValue(ctx, deltasKey{}, fn)
+}
+
+// deltasFrom returns the WithDeltas callback, or nil when the call shouldn't
+// stream.
+func deltasFrom(ctx context.Context) func(string) {
+	fn, _ := ctx.Value(deltasKey{}).(func(string))
+	return fn
+}
@@ -0,0 +1,189 @@
+package summarizer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/logger"
+	"telegram_summarize_bot/provider"
+)
+
+const (
+	actionsMaxTokens = 1200
+	// maxItemsPerKind caps each list of an extraction.
+	maxItemsPerKind = 10
+)
+
+// SummaryItem is a decision, action item or open question found in a chat.
+// Owner and Due are only kept for action items, and only when the chat names
+// them: Owner is the author alias (У1, У2…) of the summary's transcript.
+type SummaryItem struct {
+	Text         string `json:"text"`
+	Owner        string `json:"owner"`
+	Due          string `json:"due"`
+	MessageIndex int    `json:"message_index"`
+	// TgMessageID is the source message, resolved from MessageIndex; 0 when
+	// the model pointed nowhere or the message has no Telegram ID.
+	TgMessageID int64 `json:"-"`
+}
+
+// SummaryActions is what the extraction stage pulls out of a chat: what was
+// decided, who owes what and what is still open.
+type SummaryActions struct {
+	Decisions     []SummaryItem `json:"decisions"`
+	ActionItems   []SummaryItem `json:"action_items"`
+	OpenQuestions []SummaryItem `json:"open_questions"`
+}
+
+// IsEmpty reports whether nothing was found.
+func (a *SummaryActions) IsEmpty() bool {
+	return a == nil || len(a.Decisions)+len(a.ActionItems)+len(a.OpenQuestions) == 0
+}
+
+var actionsSchema = provider.SchemaFor("summary_actions", SummaryActions{})
+
+type actionsKey struct{}
+
+// WithActions returns a context whose topic summaries also extract decisions,
+// action items and open questions (a per-group setting). Off by default.
+func WithActions(ctx context.Context, enabled bool) context.Context {
+	return context.WithValue(ctx, actionsKey{}, enabled)
+}
+
+func actionsEnabled(ctx context.Context) bool {
+	enabled, _ := ctx.Value(actionsKey{}).(bool)
+	return enabled
+}
+
+// ExtractActions lists the decisions, action items and open questions of
+// messages in lang, each pointing at its source message. The prompt opens
+// with the same prefix as the topic summary's, so it reuses its cache.
+func (s *Summarizer) ExtractActions(ctx context.Context, messages []db.Message, additionalInstructions string, descriptions map[int64][]string, lang i18n.Lang) (*SummaryActions, error) {
+	defer s.metrics.LLMSummarize.Start()()
+	reportProgress(ctx, Progress{Stage: StageExtracting})
+	lang = lang.Resolve(MessageTexts(messages)...)
+	prompt := append(s.topicPrefix(ctx, messages, additionalInstructions, descriptions, lang),
+		provider.Message{Role: "user", Content: buildActionsTask(lang)})
+
+	var lastErr error
+	for attempt := range maxLLMRetries {
+		resp, err := s.complete(ctx, provider.OpActions, prompt, actionsMaxTokens, 0.1, actionsSchema)
+		if err != nil {
+			logger.Error().Err(err).Int("attempt", attempt+1).Msg("failed to create action extraction completion")
+			s.metrics.RecordError("llm_actions", err.Error())
+			if !isRetryableError(err) {
+				return nil, fmt.Errorf("failed to extract actions: %w", err)
+			}
+			lastErr = fmt.Errorf("failed to extract actions: %w", err)
+			if attempt < maxLLMRetries-1 {
+				if sleepErr := s.retrySleep(ctx, attempt); sleepErr != nil {
+					return nil, lastErr
+				}
+			}
+			continue
+		}
+
+		content := strings.TrimSpace(resp.Content)
+
+		var parsed SummaryActions
+		if err := unmarshalJSONObject(content, &parsed); err != nil {
+			logger.Warn().Err(err).Int("attempt", attempt+1).Str("raw_response", content).Msg("actions parse failed, retrying")
+			s.metrics.ParseRetry.Record(0)
+			lastErr = fmt.Errorf("failed to parse actions: %w", err)
+			continue
+		}
+		return normalizeActions(parsed, messages), nil
+	}
+	return nil, lastErr
+}
+
+func buildActionsTask(lang i18n.Lang) string {
+	return fmt.Sprintf(`Выпиши из сообщений выше в JSON формате:
+{"decisions":[{"text":"...", "owner":"", "due":"", "message_index":3}],
+ "action_items":[{"text":"...", "owner":"У2", "due":"до пятницы", "message_index":5}],
+ "open_questions":[{"text":"...", "owner":"", "due":"", "message_index":7}]}
+
+Требования:
+- Пиши только на %s.
+- decisions — о чём договорились или что решили; action_items — кто что взялся или должен сделать; open_questions — вопросы, которые остались без ответа или решения.
+- Каждый пункт — одно короткое предложение.
+- message_index — номер сообщения, из которого взят пункт.
+- owner — метка автора (например, У2) того, кто взял задачу, только если это прямо следует из сообщений; иначе пустая строка. Для решений и вопросов — пустая строка.
+- due — срок словами из сообщений, только если он назван; иначе пустая строка.
+- Не придумывай пунктов, которых нет в сообщениях; пустой список — нормальный ответ.
+- Не больше %d пунктов в каждом списке.`, lang.PromptName(), maxItemsPerKind)
+}
+
+// normalizeActions trims the items, drops empty ones, resolves their source
+// messages and keeps Owner only when it is an alias of the transcript.
+func normalizeActions(parsed SummaryActions, messages []db.Message) *SummaryActions {
+	aliases := make(map[string]bool)
+	for _, alias := range BuildUserAliasMap(messages) {
+		aliases[alias] = true
+	}
+	clean := func(items []SummaryItem, withOwner bool) []SummaryItem {
+		var out []SummaryItem
+		for _, it := range items {
+			it.Text = strings.TrimSpace(it.Text)
+			if it.Text == "" {
+				continue
+			}
+			it.Owner, it.Due = strings.TrimSpace(it.Owner), strings.TrimSpace(it.Due)
+			if !withOwner {
+				it.Owner, it.Due = "", ""
+			} else if !aliases[it.Owner] {
+				it.Owner = ""
+			}
+			it.TgMessageID = 0
+			if it.MessageIndex >= 0 && it.MessageIndex < len(messages) {
+				it.TgMessageID = messages[it.MessageIndex].TgMessageID
+			}
+			out = append(out, it)
+			if len(out) == maxItemsPerKind {
+				break
+			}
+		}
+		return out
+	}
+	return &SummaryActions{
+		Decisions:     clean(parsed.Decisions, false),
+		ActionItems:   clean(parsed.ActionItems, true),
+		OpenQuestions: clean(parsed.OpenQuestions, false),
+	}
+}
+
+// formatActions renders the extraction as Markdown sections for
+// FormatTelegramSummary, each item linked to its source message.
+func formatActions(sb *strings.Builder, actions *SummaryActions, groupID int64, lang i18n.Lang) {
+	section := func(header i18n.Key, items []SummaryItem) {
+		if len(items) == 0 {
+			return
+		}
+		sb.WriteString("\n\n" + i18n.T(lang, header))
+		for _, it := range items {
+			sb.WriteString("\n• " + FormatSummaryItem(it, groupID, lang))
+		}
+	}
+	section(i18n.SummaryDecisions, actions.Decisions)
+	section(i18n.SummaryActionItems, actions.ActionItems)
+	section(i18n.SummaryOpenQuestions, actions.OpenQuestions)
+}
+
+// FormatSummaryItem renders one item as a Markdown line: its owner, its text
+// linked to the source message when there is a link, and its due date.
+func FormatSummaryItem(it SummaryItem, groupID int64, lang i18n.Lang) string {
+	text := it.Text
+	if link := MessageLink(groupID, it.TgMessageID); link != "" {
+		text = fmt.Sprintf("[%s](%s)", text, link)
+	}
+	if it.Owner != "" {
+		text = "**" + it.Owner + "**: " + text
+	}
+	if it.Due != "" {
+		text += " — " + i18n.T(lang, i18n.SummaryDue, it.Due)
+	}
+	return text
+}
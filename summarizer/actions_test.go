@@ -0,0 +1,93 @@
+package summarizer
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/metrics"
+	"telegram_summarize_bot/provider"
+)
+
+func TestSummarizeByTopicsExtractsActions(t *testing.T) {
+	client := &fakeLLMClient{
+		responses: []string{
+			`{"topics":[{"title":"Релиз","message_indexes":[0,1,2],"message_count":3}]}`,
+			`{"tldr":"Договорились о релизе.","topics":[{"title":"Релиз","summary":"Катим в пятницу.","message_count":3}]}`,
+			`{"decisions":[{"text":"Релиз в пятницу","owner":"У1","due":"пятница","message_index":0},{"text":"  ","owner":"","due":"","message_index":1}],
+			  "action_items":[{"text":"Собрать сборку","owner":"У2","due":"четверг","message_index":1},{"text":"Написать анонс","owner":"Вася","due":"","message_index":9}],
+			  "open_questions":[{"text":"Кто дежурит?","owner":"","due":"","message_index":2}]}`,
+		},
+	}
+	sum := New(client, "test-model", metrics.New(), true)
+	messages := []db.Message{
+		{UserHash: "aaaa1111", Text: "катим в пятницу", TgMessageID: 11, Timestamp: time.Unix(0, 0)},
+		{UserHash: "bbbb2222", Text: "соберу сборку к четвергу", TgMessageID: 12, Timestamp: time.Unix(60, 0)},
+		{UserHash: "aaaa1111", Text: "а кто дежурит?", Timestamp: time.Unix(120, 0)},
+	}
+
+	summary, err := sum.SummarizeByTopics(WithActions(context.Background(), true), messages, 5, "", i18n.Russian)
+	if err != nil {
+		t.Fatalf("SummarizeByTopics returned error: %v", err)
+	}
+	if len(client.requests) != 3 {
+		t.Fatalf("request count = %d, want 3", len(client.requests))
+	}
+	extract := client.requests[2]
+	if extract.Operation != provider.OpActions || extract.Schema != actionsSchema {
+		t.Fatalf("extraction request = %s %+v", extract.Operation, extract.Schema)
+	}
+	// The extraction reuses the summary's cached prefix.
+	for i := range 2 {
+		if !reflect.DeepEqual(client.requests[1].Messages[i], extract.Messages[i]) {
+			t.Fatalf("message %d differs from the summary's prefix", i)
+		}
+	}
+
+	want := &SummaryActions{
+		Decisions:     []SummaryItem{{Text: "Релиз в пятницу", MessageIndex: 0, TgMessageID: 11}},
+		ActionItems:   []SummaryItem{{Text: "Собрать сборку", Owner: "У2", Due: "четверг", MessageIndex: 1, TgMessageID: 12}, {Text: "Написать анонс", MessageIndex: 9}},
+		OpenQuestions: []SummaryItem{{Text: "Кто дежурит?", MessageIndex: 2}},
+	}
+	if !reflect.DeepEqual(summary.Actions, want) {
+		t.Fatalf("actions = %+v\nwant %+v", summary.Actions, want)
+	}
+
+	text := FormatTelegramSummary(summary, -1001234567890)
+	for _, s := range []string{
+		"✅ **Решения**\n• [Релиз в пятницу](https://t.me/c/1234567890/11)",
+		"📌 **Задачи**\n• **У2**: [Собрать сборку](https://t.me/c/1234567890/12) — срок: четверг\n• Написать анонс",
+		"❓ **Открытые вопросы**\n• Кто дежурит?",
+	} {
+		if !strings.Contains(text, s) {
+			t.Errorf("summary missing %q:\n%s", s, text)
+		}
+	}
+}
+
+func TestSummarizeByTopicsSurvivesFailedExtraction(t *testing.T) {
+	responses := []string{
+		`{"topics":[{"title":"Релиз","message_indexes":[0],"message_count":1}]}`,
+		`{"tldr":"Итог.","topics":[{"title":"Релиз","summary":"Кратко.","message_count":1}]}`,
+	}
+	for range maxLLMRetries {
+		responses = append(responses, "not-json")
+	}
+	client := &fakeLLMClient{responses: responses}
+	sum := New(client, "test-model", metrics.New(), true)
+
+	summary, err := sum.SummarizeByTopics(WithActions(context.Background(), true), []db.Message{{Text: "катим релиз"}}, 5, "", i18n.Russian)
+	if err != nil {
+		t.Fatalf("SummarizeByTopics returned error: %v", err)
+	}
+	if summary.TLDR != "Итог." || summary.Actions != nil {
+		t.Fatalf("summary = %+v; want it without actions", summary)
+	}
+	if strings.Contains(FormatTelegramSummary(summary, -100), "📌") {
+		t.Fatal("a failed extraction should leave no sections")
+	}
+}
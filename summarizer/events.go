@@ -0,0 +1,261 @@
+package summarizer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/logger"
+	"telegram_summarize_bot/provider"
+)
+
+const (
+	eventsMaxTokens = 800
+	// maxEvents caps the events of one summary; each gets its own button.
+	maxEvents = 5
+	// defaultEventDuration is used when the chat names no duration.
+	defaultEventDuration = time.Hour
+	// maxEventDuration and maxEventAhead bound what passes for a real event
+	// rather than a misread date.
+	maxEventDuration = 24 * time.Hour
+	maxEventAhead    = 366 * 24 * time.Hour
+	eventDateLayout  = "02.01.2006"
+)
+
+// SummaryEvent is a concrete event agreed on in a chat: a meetup, a call, a
+// deadline. Start and End are in the group's timezone; an all-day event
+// starts at midnight and ends at the next one.
+type SummaryEvent struct {
+	Title  string
+	Place  string
+	Start  time.Time
+	End    time.Time
+	AllDay bool
+	// TgMessageID is the message the event was agreed in; 0 when unknown.
+	TgMessageID int64
+}
+
+// eventCandidate is an event as the model reports it, before its date is
+// checked and resolved.
+type eventCandidate struct {
+	Title           string `json:"title"`
+	Place           string `json:"place"`
+	Date            string `json:"date"`
+	Time            string `json:"time"`
+	DurationMinutes int    `json:"duration_minutes"`
+	MessageIndex    int    `json:"message_index"`
+}
+
+type eventsResponse struct {
+	Events []eventCandidate `json:"events"`
+}
+
+var eventsSchema = provider.SchemaFor("summary_events", eventsResponse{})
+
+type eventsKey struct{}
+
+// WithEvents returns a context whose topic summaries also look for calendar
+// events, resolving their dates in loc (the group's timezone). A nil loc
+// turns the stage off, which is the default.
+func WithEvents(ctx context.Context, loc *time.Location) context.Context {
+	return context.WithValue(ctx, eventsKey{}, loc)
+}
+
+func eventsLocation(ctx context.Context) *time.Location {
+	loc, _ := ctx.Value(eventsKey{}).(*time.Location)
+	return loc
+}
+
+// ExtractEvents lists the concrete events agreed on in messages, with dates
+// resolved in loc: the prompt carries each day's message range, so relative
+// dates ("tomorrow at 7") are read against the message that names them. The
+// prompt opens with the topic summary's prefix, so it reuses its cache. With
+// redaction on, redacted values the model copied into a title or place (an
+// address, say) are restored, so the calendar entry is usable.
+func (s *Summarizer) ExtractEvents(ctx context.Context, messages []db.Message, additionalInstructions string, descriptions map[int64][]string, lang i18n.Lang, loc *time.Location) ([]SummaryEvent, error) {
+	defer s.metrics.LLMSummarize.Start()()
+	reportProgress(ctx, Progress{Stage: StageEvents})
+	lang = lang.Resolve(MessageTexts(messages)...)
+	rd := s.redaction(ctx)
+	prompt := append(s.redactedTopicPrefix(ctx, rd, messages, additionalInstructions, descriptions, lang),
+		provider.Message{Role: "user", Content: buildEventsTask(messages, loc, lang)})
+
+	var lastErr error
+	for attempt := range maxLLMRetries {
+		resp, err := s.complete(ctx, provider.OpEvents, prompt, eventsMaxTokens, 0.1, eventsSchema)
+		if err != nil {
+			logger.Error().Err(err).Int("attempt", attempt+1).Msg("failed to create event extraction completion")
+			s.metrics.RecordError("llm_events", err.Error())
+			if !isRetryableError(err) {
+				return nil, fmt.Errorf("failed to extract events: %w", err)
+			}
+			lastErr = fmt.Errorf("failed to extract events: %w", err)
+			if attempt < maxLLMRetries-1 {
+				if sleepErr := s.retrySleep(ctx, attempt); sleepErr != nil {
+					return nil, lastErr
+				}
+			}
+			continue
+		}
+
+		content := strings.TrimSpace(resp.Content)
+
+		var parsed eventsResponse
+		if err := unmarshalJSONObject(content, &parsed); err != nil {
+			logger.Warn().Err(err).Int("attempt", attempt+1).Str("raw_response", content).Msg("events parse failed, retrying")
+			s.metrics.ParseRetry.Record(0)
+			lastErr = fmt.Errorf("failed to parse events: %w", err)
+			continue
+		}
+		events := normalizeEvents(parsed.Events, messages, loc)
+		for i := range events {
+			events[i].Title = rd.restore(events[i].Title)
+			events[i].Place = rd.restore(events[i].Place)
+		}
+		return events, nil
+	}
+	return nil, lastErr
+}
+
+// weekdayNames label the days in the events prompt. Like every prompt of the
+// bot, it is written in Russian whatever the output language: only the model
+// reads it, and the answer's language is set by the prompt's "write in"
+// requirement, so one set of prompts serves every language.
+var weekdayNames = [...]string{"воскресенье", "понедельник", "вторник", "среда", "четверг", "пятница", "суббота"}
+
+func buildEventsTask(messages []db.Message, loc *time.Location, lang i18n.Lang) string {
+	var days strings.Builder
+	for i := 0; i < len(messages); {
+		day := messages[i].Timestamp.In(loc)
+		j := i + 1
+		for j < len(messages) && sameDay(messages[j].Timestamp.In(loc), day) {
+			j++
+		}
+		fmt.Fprintf(&days, "- %s, %s: ", day.Format("2006-01-02"), weekdayNames[day.Weekday()])
+		if j-i == 1 {
+			fmt.Fprintf(&days, "сообщение %d\n", i)
+		} else {
+			fmt.Fprintf(&days, "сообщения %d–%d\n", i, j-1)
+		}
+		i = j
+	}
+	var zone string
+	if len(messages) > 0 {
+		zone = messages[len(messages)-1].Timestamp.In(loc).Format("-07:00")
+	}
+
+	return fmt.Sprintf(`Найди в сообщениях выше конкретные события, о которых договорились участники: встречи, созвоны, мероприятия, дедлайны. Ответ в JSON формате:
+{"events":[{"title":"Созвон по релизу", "place":"Zoom", "date":"2026-03-12", "time":"19:00", "duration_minutes":60, "message_index":4}]}
+
+Даты сообщений (часовой пояс группы — %s, UTC%s):
+%s
+Требования:
+- Пиши только на %s.
+- Бери только события с конкретной датой. Относительную дату («завтра», «в пятницу», «через неделю») отсчитывай от даты сообщения, в котором она названа. Прошедшие события и расплывчатые планы («как-нибудь», «на днях») не включай.
+- title — короткое название события; place — место или ссылка, если названы, иначе пустая строка.
+- date — дата в формате ГГГГ-ММ-ДД; time — время начала в формате ЧЧ:ММ по часовому поясу группы или пустая строка, если время не названо.
+- duration_minutes — длительность, если она названа; иначе 0.
+- message_index — номер сообщения, в котором событие назначено.
+- Не придумывай событий; пустой список — нормальный ответ.
+- Не больше %d событий.`, loc, zone, days.String(), lang.PromptName(), maxEvents)
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// normalizeEvents resolves the model's events in loc and drops the ones that
+// don't hold up: no title, an unparseable date or time, a date before the
+// day the event was named or too far after it, or a repeat.
+func normalizeEvents(candidates []eventCandidate, messages []db.Message, loc *time.Location) []SummaryEvent {
+	var out []SummaryEvent
+	seen := make(map[string]bool)
+	for _, c := range candidates {
+		title := strings.TrimSpace(c.Title)
+		date, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(c.Date), loc)
+		if title == "" || err != nil {
+			continue
+		}
+
+		ev := SummaryEvent{Title: title, Place: strings.TrimSpace(c.Place), Start: date}
+		if clock := strings.TrimSpace(c.Time); clock == "" {
+			ev.AllDay = true
+			ev.End = date.AddDate(0, 0, 1)
+		} else {
+			hm, err := time.Parse("15:04", clock)
+			if err != nil {
+				continue
+			}
+			ev.Start = time.Date(date.Year(), date.Month(), date.Day(), hm.Hour(), hm.Minute(), 0, 0, loc)
+			duration := time.Duration(c.DurationMinutes) * time.Minute
+			if duration <= 0 {
+				duration = defaultEventDuration
+			}
+			ev.End = ev.Start.Add(min(duration, maxEventDuration))
+		}
+
+		// Dates are checked against the message that names the event, or the
+		// window's first message when the model pointed nowhere.
+		var named time.Time
+		if c.MessageIndex >= 0 && c.MessageIndex < len(messages) {
+			named = messages[c.MessageIndex].Timestamp.In(loc)
+			ev.TgMessageID = messages[c.MessageIndex].TgMessageID
+		} else if len(messages) > 0 {
+			named = messages[0].Timestamp.In(loc)
+		}
+		namedDay := time.Date(named.Year(), named.Month(), named.Day(), 0, 0, 0, 0, loc)
+		if ev.Start.Before(namedDay) || ev.Start.After(named.Add(maxEventAhead)) {
+			continue
+		}
+
+		key := strings.ToLower(title) + "|" + ev.Start.String()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, ev)
+		if len(out) == maxEvents {
+			break
+		}
+	}
+	return out
+}
+
+// formatEvents renders the events as a Markdown section for
+// FormatTelegramSummary. Several events are numbered, matching their
+// "add to calendar" buttons.
+func formatEvents(sb *strings.Builder, events []SummaryEvent, groupID int64, lang i18n.Lang) {
+	sb.WriteString("\n\n" + i18n.T(lang, i18n.SummaryEvents))
+	for i, ev := range events {
+		sb.WriteString("\n")
+		if len(events) > 1 {
+			fmt.Fprintf(sb, "%d. ", i+1)
+		} else {
+			sb.WriteString("• ")
+		}
+		sb.WriteString(FormatSummaryEvent(ev, groupID))
+	}
+}
+
+// FormatSummaryEvent renders one event as a Markdown line: its title linked
+// to the source message when there is a link, its date (and time) and place.
+func FormatSummaryEvent(ev SummaryEvent, groupID int64) string {
+	text := ev.Title
+	if link := MessageLink(groupID, ev.TgMessageID); link != "" {
+		text = fmt.Sprintf("[%s](%s)", text, link)
+	}
+	when := ev.Start.Format(eventDateLayout)
+	if !ev.AllDay {
+		when += " " + ev.Start.Format("15:04")
+	}
+	text += " — " + when
+	if ev.Place != "" {
+		text += ", " + ev.Place
+	}
+	return text
+}
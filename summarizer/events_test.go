@@ -0,0 +1,156 @@
+package summarizer
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/metrics"
+	"telegram_summarize_bot/provider"
+)
+
+func TestSummarizeByTopicsExtractsEvents(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 22:30 UTC on Monday is already Tuesday in Moscow, so "завтра" in the
+	// last message means Wednesday there.
+	messages := []db.Message{
+		{UserHash: "aaaa1111", Text: "давайте созвонимся в четверг в 19", TgMessageID: 11, Timestamp: time.Date(2026, 3, 9, 12, 0, 0, 0, time.UTC)},
+		{UserHash: "bbbb2222", Text: "ок, в зуме", TgMessageID: 12, Timestamp: time.Date(2026, 3, 9, 12, 5, 0, 0, time.UTC)},
+		{UserHash: "aaaa1111", Text: "и завтра дедлайн по отчёту", TgMessageID: 13, Timestamp: time.Date(2026, 3, 9, 22, 30, 0, 0, time.UTC)},
+	}
+	client := &fakeLLMClient{
+		responses: []string{
+			`{"topics":[{"title":"Планы","message_indexes":[0,1,2],"message_count":3}]}`,
+			`{"tldr":"Созвон в четверг.","topics":[{"title":"Планы","summary":"Созвон и дедлайн.","message_count":3}]}`,
+			`{"events":[
+			  {"title":"Созвон","place":"Zoom","date":"2026-03-12","time":"19:00","duration_minutes":30,"message_index":0},
+			  {"title":"Дедлайн по отчёту","place":"","date":"2026-03-11","time":"","duration_minutes":0,"message_index":2},
+			  {"title":"созвон","place":"","date":"2026-03-12","time":"19:00","duration_minutes":0,"message_index":1},
+			  {"title":"Прошлая встреча","place":"","date":"2026-03-01","time":"10:00","duration_minutes":0,"message_index":0},
+			  {"title":"Обед","place":"","date":"2026-03-12","time":"в обед","duration_minutes":0,"message_index":1},
+			  {"title":"Отпуск","place":"","date":"12.03.2026","time":"","duration_minutes":0,"message_index":1},
+			  {"title":"  ","place":"","date":"2026-03-12","time":"","duration_minutes":0,"message_index":1}
+			]}`,
+		},
+	}
+	sum := New(client, "test-model", metrics.New(), true)
+
+	summary, err := sum.SummarizeByTopics(WithEvents(context.Background(), moscow), messages, 5, "", i18n.Russian)
+	if err != nil {
+		t.Fatalf("SummarizeByTopics returned error: %v", err)
+	}
+	if len(client.requests) != 3 {
+		t.Fatalf("request count = %d, want 3", len(client.requests))
+	}
+	extract := client.requests[2]
+	if extract.Operation != provider.OpEvents || extract.Schema != eventsSchema {
+		t.Fatalf("extraction request = %s %+v", extract.Operation, extract.Schema)
+	}
+	// The extraction reuses the summary's cached prefix.
+	for i := range 2 {
+		if !reflect.DeepEqual(client.requests[1].Messages[i], extract.Messages[i]) {
+			t.Fatalf("message %d differs from the summary's prefix", i)
+		}
+	}
+	task := extract.Messages[2].Content
+	for _, want := range []string{
+		"Europe/Moscow, UTC+03:00",
+		"- 2026-03-09, понедельник: сообщения 0–1",
+		"- 2026-03-10, вторник: сообщение 2",
+	} {
+		if !strings.Contains(task, want) {
+			t.Errorf("task missing %q:\n%s", want, task)
+		}
+	}
+
+	call := time.Date(2026, 3, 12, 19, 0, 0, 0, moscow)
+	deadline := time.Date(2026, 3, 11, 0, 0, 0, 0, moscow)
+	want := []SummaryEvent{
+		{Title: "Созвон", Place: "Zoom", Start: call, End: call.Add(30 * time.Minute), TgMessageID: 11},
+		{Title: "Дедлайн по отчёту", Start: deadline, End: deadline.AddDate(0, 0, 1), AllDay: true, TgMessageID: 13},
+	}
+	if !reflect.DeepEqual(summary.Events, want) {
+		t.Fatalf("events = %+v\nwant %+v", summary.Events, want)
+	}
+
+	text := FormatTelegramSummary(summary, -1001234567890)
+	for _, s := range []string{
+		"📅 **События**\n1. [Созвон](https://t.me/c/1234567890/11) — 12.03.2026 19:00, Zoom",
+		"2. [Дедлайн по отчёту](https://t.me/c/1234567890/13) — 11.03.2026",
+	} {
+		if !strings.Contains(text, s) {
+			t.Errorf("summary missing %q:\n%s", s, text)
+		}
+	}
+}
+
+func TestNormalizeEventsDefaults(t *testing.T) {
+	messages := []db.Message{{Text: "в субботу шашлыки", Timestamp: time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)}}
+	got := normalizeEvents([]eventCandidate{
+		{Title: "Шашлыки", Date: "2026-03-14", Time: "13:00", MessageIndex: 0},
+		{Title: "Марафон", Date: "2026-03-15", Time: "08:00", DurationMinutes: 3000, MessageIndex: 0},
+		{Title: "Через два года", Date: "2028-03-15", MessageIndex: 0},
+	}, messages, time.UTC)
+
+	if len(got) != 2 {
+		t.Fatalf("events = %+v; want the far-off one dropped", got)
+	}
+	if d := got[0].End.Sub(got[0].Start); d != defaultEventDuration || got[0].AllDay {
+		t.Fatalf("event without a duration lasts %v, want %v", d, defaultEventDuration)
+	}
+	if d := got[1].End.Sub(got[1].Start); d != maxEventDuration {
+		t.Fatalf("overlong event lasts %v, want it capped at %v", d, maxEventDuration)
+	}
+}
+
+func TestSummarizeByTopicsWithoutEvents(t *testing.T) {
+	client := &fakeLLMClient{
+		responses: []string{
+			`{"topics":[{"title":"Релиз","message_indexes":[0],"message_count":1}]}`,
+			`{"tldr":"Итог.","topics":[{"title":"Релиз","summary":"Кратко.","message_count":1}]}`,
+		},
+	}
+	sum := New(client, "test-model", metrics.New(), true)
+
+	summary, err := sum.SummarizeByTopics(WithEvents(context.Background(), nil), []db.Message{{Text: "катим релиз"}}, 5, "", i18n.Russian)
+	if err != nil {
+		t.Fatalf("SummarizeByTopics returned error: %v", err)
+	}
+	if len(client.requests) != 2 || summary.Events != nil {
+		t.Fatalf("requests = %d, events = %+v; want no event stage", len(client.requests), summary.Events)
+	}
+	if strings.Contains(FormatTelegramSummary(summary, -100), "📅") {
+		t.Fatal("a summary without events should have no events section")
+	}
+}
+
+func TestExtractEventsRestoresRedactedValues(t *testing.T) {
+	messages := []db.Message{
+		{UserHash: "aaaa1111", Text: "в субботу в 12 собираемся на ул. Ленина, д. 5", TgMessageID: 11, Timestamp: time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)},
+	}
+	client := &fakeLLMClient{
+		responses: []string{
+			`{"events":[{"title":"Встреча на [ADDRESS_1]","place":"[ADDRESS_1]","date":"2026-03-14","time":"12:00","duration_minutes":0,"message_index":0}]}`,
+		},
+	}
+	sum := New(client, "test-model", metrics.New(), true).WithRedactor(NewRedactor(nil), true)
+
+	events, err := sum.ExtractEvents(context.Background(), messages, "", nil, i18n.Russian, time.UTC)
+	if err != nil {
+		t.Fatalf("ExtractEvents: %v", err)
+	}
+	transcript := client.requests[0].Messages[1].Content
+	if strings.Contains(transcript, "Ленина") || !strings.Contains(transcript, "[ADDRESS_1]") {
+		t.Fatalf("the address should reach the model redacted:\n%s", transcript)
+	}
+	if len(events) != 1 || events[0].Place != "ул. Ленина, д. 5" || events[0].Title != "Встреча на ул. Ленина, д. 5" {
+		t.Fatalf("events = %+v, want the address restored", events)
+	}
+}
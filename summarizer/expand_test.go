@@ -0,0 +1,68 @@
+package summarizer
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/metrics"
+	"telegram_summarize_bot/provider"
+)
+
+func TestSummarizeByTopicsKeepsClusterMessageIDs(t *testing.T) {
+	client := &fakeLLMClient{
+		responses: []string{
+			`{"topics":[{"title":"Релиз","message_indexes":[0,2],"message_count":2},{"title":"Обед","message_indexes":[1],"message_count":1}]}`,
+			`{"tldr":"Итог.","topics":[{"title":"Релиз","summary":"Катим."},{"title":"Обед","summary":"Пицца."}]}`,
+		},
+	}
+	sum := New(client, "test-model", metrics.New(), true)
+
+	summary, err := sum.SummarizeByTopics(context.Background(), []db.Message{
+		{ID: 11, Text: "катим релиз", Timestamp: time.Unix(0, 0)},
+		{ID: 12, Text: "что на обед", Timestamp: time.Unix(60, 0)},
+		{ID: 13, Text: "вечером", Timestamp: time.Unix(120, 0)},
+	}, 5, "", i18n.Russian)
+	if err != nil {
+		t.Fatalf("SummarizeByTopics returned error: %v", err)
+	}
+	if got := summary.Topics[0].MessageIDs; len(got) != 2 || got[0] != 11 || got[1] != 13 {
+		t.Fatalf("first topic message IDs = %v, want [11 13]", got)
+	}
+	if got := summary.Topics[1].MessageIDs; len(got) != 1 || got[0] != 12 {
+		t.Fatalf("second topic message IDs = %v, want [12]", got)
+	}
+}
+
+func TestExpandTopic(t *testing.T) {
+	client := &fakeLLMClient{responses: []string{"  **Подробно:** катим вечером.  "}}
+	sum := New(client, "test-model", metrics.New(), true)
+
+	got, err := sum.ExpandTopic(context.Background(), []db.Message{
+		{UserHash: "aaaa1111", Text: "катим релиз", Timestamp: time.Unix(0, 0)},
+		{UserHash: "bbbb2222", Text: "давайте вечером", Timestamp: time.Unix(60, 0)},
+	}, "Релиз", "пиши кратко", i18n.Russian)
+	if err != nil {
+		t.Fatalf("ExpandTopic returned error: %v", err)
+	}
+	if got != "**Подробно:** катим вечером." {
+		t.Fatalf("ExpandTopic = %q", got)
+	}
+
+	req := client.requests[0]
+	if req.Operation != provider.OpExpand {
+		t.Fatalf("operation = %q, want %q", req.Operation, provider.OpExpand)
+	}
+	system, user := req.Messages[0].Content, req.Messages[1].Content
+	if !strings.Contains(system, "пиши кратко") {
+		t.Fatalf("system prompt lacks group instructions: %q", system)
+	}
+	for _, want := range []string{"Тема: Релиз", "катим релиз", "давайте вечером"} {
+		if !strings.Contains(user, want) {
+			t.Fatalf("user prompt lacks %q: %q", want, user)
+		}
+	}
+}
@@ -5,11 +5,13 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 	"unicode/utf8"
 
 	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
 	"telegram_summarize_bot/logger"
 	"telegram_summarize_bot/provider"
 )
@@ -29,18 +31,20 @@ const maxDescriptionRunes = 600
 // for screenshots heavy on transcribed text.
 const visionMaxTokens = 400
 
-const visionSystemPromptRU = `Опиши кратко (1–3 предложения) что изображено и какой текст виден.
+// visionSystemPromptTmpl is filled with the output language (i18n.Lang.PromptName).
+const visionSystemPromptTmpl = `Опиши кратко (1–3 предложения) что изображено и какой текст виден.
 Если это скриншот соцсети (Twitter/Reddit/HackerNews и т.п.) — приведи автора, тему и суть поста.
-Только факты, без интерпретаций. Только русский. Максимум 60 слов.`
+Только факты, без интерпретаций. Пиши только на %s. Максимум 60 слов.`
 
 // visionSteerMaxTokens is the budget for a user-steered vision call. Larger than
 // the default since the user may ask to transcribe or explain in more detail.
 const visionSteerMaxTokens = 600
 
-// visionSteeredSystemPromptRU is used when the user supplies a steering prompt;
-// it drops the fixed 60-word cap so the model can answer the actual request.
-const visionSteeredSystemPromptRU = `Ответь на запрос пользователя об изображении.
-Опирайся только на то, что видно на изображении. Только факты, без домыслов. Только русский язык.`
+// visionSteeredSystemPromptTmpl is used when the user supplies a steering
+// prompt; it drops the fixed 60-word cap so the model can answer the actual
+// request. Filled with the output language like visionSystemPromptTmpl.
+const visionSteeredSystemPromptTmpl = `Ответь на запрос пользователя об изображении.
+Опирайся только на то, что видно на изображении. Только факты, без домыслов. Пиши только на %s.`
 
 // ErrFileExpired mirrors handlers.ErrFileExpired so the summarizer package
 // doesn't need to import handlers. PhotoFetcher implementations must return
@@ -60,9 +64,10 @@ type PhotoFetcher interface {
 // Returning "" with nil error means "no description available, skip" —
 // callers must treat that as a non-fatal degradation.
 type ImageDescriber interface {
-	// Describe returns a description of the photo. A non-empty steering prompt
-	// asks the vision model to answer that specific request about the image.
-	Describe(ctx context.Context, photo db.PhotoRecord, steering string) (string, error)
+	// Describe returns a description of the photo in lang. A non-empty
+	// steering prompt asks the vision model to answer that specific request
+	// about the image.
+	Describe(ctx context.Context, photo db.PhotoRecord, steering string, lang i18n.Lang) (string, error)
 }
 
 // describerDB is the subset of *db.DB the cached describer needs. Defined
@@ -77,12 +82,11 @@ type describerDB interface {
 // descriptions in DB, falls back to a vision-model call on miss, and stores
 // the result (or a negative-cache entry on error).
 type CachedDescriber struct {
-	db          describerDB
-	client      provider.LLMClient
-	fetcher     PhotoFetcher
-	model       string
-	timeout     time.Duration
-	systemPromp string
+	db      describerDB
+	client  provider.LLMClient
+	fetcher PhotoFetcher
+	model   string
+	timeout time.Duration
 	// steerEnabled gates user-steered vision calls; when off, a steering prompt
 	// is ignored for the image and the standard cached description is returned.
 	steerEnabled bool
@@ -101,7 +105,6 @@ func NewCachedDescriber(database describerDB, client provider.LLMClient, fetcher
 		fetcher:      fetcher,
 		model:        model,
 		timeout:      timeout,
-		systemPromp:  visionSystemPromptRU,
 		steerEnabled: steerEnabled,
 	}
 }
@@ -115,7 +118,10 @@ func NewCachedDescriber(database describerDB, client provider.LLMClient, fetcher
 // entries (with the file-expired case skipping the negative cache so a fresh
 // re-upload can recover). The caller never receives a hard error — vision is
 // best-effort.
-func (d *CachedDescriber) Describe(ctx context.Context, photo db.PhotoRecord, steering string) (string, error) {
+//
+// Russian descriptions keep the plain cache keys they always had; other
+// languages get a "@<lang>" suffix so each language is cached separately.
+func (d *CachedDescriber) Describe(ctx context.Context, photo db.PhotoRecord, steering string, lang i18n.Lang) (string, error) {
 	if photo.FileUniqueID == "" {
 		return "", nil
 	}
@@ -130,6 +136,10 @@ func (d *CachedDescriber) Describe(ctx context.Context, photo db.PhotoRecord, st
 	if steered {
 		cacheKey = photo.FileUniqueID + "#" + steeringHash(steering)
 	}
+	lang = lang.Resolve(steering)
+	if lang != i18n.Russian {
+		cacheKey += "@" + string(lang)
+	}
 
 	cached, err := d.db.GetImageDescription(ctx, cacheKey)
 	if err != nil {
@@ -155,11 +165,11 @@ func (d *CachedDescriber) Describe(ctx context.Context, photo db.PhotoRecord, st
 		return "", nil
 	}
 
-	sysPrompt := d.systemPromp
+	sysPrompt := fmt.Sprintf(visionSystemPromptTmpl, lang.PromptName())
 	userPrompt := "Опиши это изображение по правилам системного промпта."
 	maxTokens := visionMaxTokens
 	if steered {
-		sysPrompt = visionSteeredSystemPromptRU
+		sysPrompt = fmt.Sprintf(visionSteeredSystemPromptTmpl, lang.PromptName())
 		userPrompt = "Запрос пользователя: " + steering
 		maxTokens = visionSteerMaxTokens
 	}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
 	"telegram_summarize_bot/provider"
 )
 
@@ -92,7 +93,7 @@ func TestCachedDescriber_CacheHitSkipsFetch(t *testing.T) {
 	llm := &stubLLM{}
 
 	d := NewCachedDescriber(s, llm, fetcher, "gpt-5.5", time.Second, true)
-	got, err := d.Describe(context.Background(), db.PhotoRecord{FileUniqueID: "k", FileID: "fid"}, "")
+	got, err := d.Describe(context.Background(), db.PhotoRecord{FileUniqueID: "k", FileID: "fid"}, "", i18n.Russian)
 	if err != nil {
 		t.Fatalf("Describe: %v", err)
 	}
@@ -121,7 +122,7 @@ func TestCachedDescriber_FreshNegativeCacheSkipsCall(t *testing.T) {
 	llm := &stubLLM{}
 
 	d := NewCachedDescriber(s, llm, fetcher, "gpt-5.5", time.Second, true)
-	got, err := d.Describe(context.Background(), db.PhotoRecord{FileUniqueID: "k", FileID: "fid"}, "")
+	got, err := d.Describe(context.Background(), db.PhotoRecord{FileUniqueID: "k", FileID: "fid"}, "", i18n.Russian)
 	if err != nil {
 		t.Fatalf("Describe: %v", err)
 	}
@@ -147,7 +148,7 @@ func TestCachedDescriber_StaleNegativeCacheRetries(t *testing.T) {
 	llm := &stubLLM{resp: provider.CompletionResponse{Content: "fresh desc"}}
 
 	d := NewCachedDescriber(s, llm, fetcher, "gpt-5.5", time.Second, true)
-	got, err := d.Describe(context.Background(), db.PhotoRecord{FileUniqueID: "k", FileID: "fid"}, "")
+	got, err := d.Describe(context.Background(), db.PhotoRecord{FileUniqueID: "k", FileID: "fid"}, "", i18n.Russian)
 	if err != nil {
 		t.Fatalf("Describe: %v", err)
 	}
@@ -162,7 +163,7 @@ func TestCachedDescriber_FileExpiredSkipsNegativeCache(t *testing.T) {
 	llm := &stubLLM{}
 
 	d := NewCachedDescriber(s, llm, fetcher, "gpt-5.5", time.Second, true)
-	got, err := d.Describe(context.Background(), db.PhotoRecord{FileUniqueID: "k", FileID: "fid"}, "")
+	got, err := d.Describe(context.Background(), db.PhotoRecord{FileUniqueID: "k", FileID: "fid"}, "", i18n.Russian)
 	if err != nil {
 		t.Fatalf("Describe: %v", err)
 	}
@@ -183,7 +184,7 @@ func TestCachedDescriber_VisionErrorWritesNegativeCache(t *testing.T) {
 	llm := &stubLLM{err: errors.New("boom")}
 
 	d := NewCachedDescriber(s, llm, fetcher, "gpt-5.5", time.Second, true)
-	got, err := d.Describe(context.Background(), db.PhotoRecord{FileUniqueID: "k", FileID: "fid"}, "")
+	got, err := d.Describe(context.Background(), db.PhotoRecord{FileUniqueID: "k", FileID: "fid"}, "", i18n.Russian)
 	if err != nil {
 		t.Fatalf("Describe: %v", err)
 	}
@@ -205,7 +206,7 @@ func TestCachedDescriber_HappyPathPersists(t *testing.T) {
 	llm := &stubLLM{resp: provider.CompletionResponse{Content: "  cat on a sill  "}}
 
 	d := NewCachedDescriber(s, llm, fetcher, "gpt-5.5", time.Second, true)
-	got, err := d.Describe(context.Background(), db.PhotoRecord{FileUniqueID: "k", FileID: "fid"}, "")
+	got, err := d.Describe(context.Background(), db.PhotoRecord{FileUniqueID: "k", FileID: "fid"}, "", i18n.Russian)
 	if err != nil {
 		t.Fatalf("Describe: %v", err)
 	}
@@ -245,7 +246,7 @@ func TestCachedDescriber_SteeredUsesCompositeKeyAndPrompt(t *testing.T) {
 	d := NewCachedDescriber(s, llm, fetcher, "gpt-5.5", time.Second, true)
 	photo := db.PhotoRecord{FileUniqueID: "k", FileID: "fid"}
 
-	got, err := d.Describe(context.Background(), photo, "опиши мем")
+	got, err := d.Describe(context.Background(), photo, "опиши мем", i18n.Russian)
 	if err != nil {
 		t.Fatalf("Describe: %v", err)
 	}
@@ -264,7 +265,7 @@ func TestCachedDescriber_SteeredUsesCompositeKeyAndPrompt(t *testing.T) {
 	}
 
 	// Repeat identical ask → cache hit, no second vision call.
-	if _, err := d.Describe(context.Background(), photo, "опиши мем"); err != nil {
+	if _, err := d.Describe(context.Background(), photo, "опиши мем", i18n.Russian); err != nil {
 		t.Fatalf("Describe (repeat): %v", err)
 	}
 	if atomic.LoadInt32(&llm.calls) != 1 {
@@ -278,7 +279,7 @@ func TestCachedDescriber_SteeringDisabledUsesStandardCache(t *testing.T) {
 	llm := &stubLLM{resp: provider.CompletionResponse{Content: "стандартное описание"}}
 
 	d := NewCachedDescriber(s, llm, fetcher, "gpt-5.5", time.Second, false) // steering off
-	got, err := d.Describe(context.Background(), db.PhotoRecord{FileUniqueID: "k", FileID: "fid"}, "опиши мем")
+	got, err := d.Describe(context.Background(), db.PhotoRecord{FileUniqueID: "k", FileID: "fid"}, "опиши мем", i18n.Russian)
 	if err != nil {
 		t.Fatalf("Describe: %v", err)
 	}
@@ -302,3 +303,25 @@ func TestTruncateRunes(t *testing.T) {
 		t.Errorf("got %q, want 'абв…'", got)
 	}
 }
+
+func TestCachedDescriber_NonRussianUsesLanguageKeyAndPrompt(t *testing.T) {
+	s := newStubDB()
+	s.entries["k"] = db.ImageDescription{FileUniqueID: "k", Description: "кот на подоконнике", CreatedAt: time.Now()}
+	fetcher := &stubFetcher{bytes: []byte("img"), mime: "image/jpeg"}
+	llm := &stubLLM{resp: provider.CompletionResponse{Content: "a cat on a sill"}}
+
+	d := NewCachedDescriber(s, llm, fetcher, "gpt-5.5", time.Second, true)
+	got, err := d.Describe(context.Background(), db.PhotoRecord{FileUniqueID: "k", FileID: "fid"}, "", i18n.English)
+	if err != nil {
+		t.Fatalf("Describe: %v", err)
+	}
+	if got != "a cat on a sill" {
+		t.Errorf("got %q, want the English description rather than the cached Russian one", got)
+	}
+	if _, ok := s.entries["k@en"]; !ok {
+		t.Fatal("expected English description cached under k@en")
+	}
+	if !strings.Contains(llm.req.Messages[0].Content, "английском языке") {
+		t.Errorf("system prompt missing language: %q", llm.req.Messages[0].Content)
+	}
+}
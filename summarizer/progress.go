@@ -0,0 +1,184 @@
+package summarizer
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"telegram_summarize_bot/provider"
+)
+
+// Stage is a step of SummarizeByTopics reported through WithProgress.
+type Stage int
+
+const (
+	StageImages      Stage = iota + 1 // describing images: Done of Total
+	StageClustering                   // grouping messages into topics
+	StageSummarizing                  // writing the summary: Done of Total topics
+	StageExtracting                   // listing decisions, action items and open questions
+	StageEvents                       // finding calendar events
+)
+
+// Progress is a SummarizeByTopics progress report.
+type Progress struct {
+	Stage       Stage
+	Done, Total int
+	// TLDR is the summary's TL;DR as streamed so far (StageSummarizing).
+	TLDR string
+}
+
+type progressKey struct{}
+
+// WithProgress returns a context whose summaries report their progress to fn.
+// Reports come from the summarizing goroutines, possibly concurrently; fn
+// must be safe for that and must not block. The summary call streams so its
+// topics (and TL;DR) can be reported as they are written.
+func WithProgress(ctx context.Context, fn func(Progress)) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// reportProgress passes p to ctx's WithProgress callback, if any.
+func reportProgress(ctx context.Context, p Progress) {
+	if fn, ok := ctx.Value(progressKey{}).(func(Progress)); ok {
+		fn(p)
+	}
+}
+
+func hasProgress(ctx context.Context) bool {
+	_, ok := ctx.Value(progressKey{}).(func(Progress))
+	return ok
+}
+
+// partialSummary reads what a streamed topic summary (the JSON of
+// buildTopicSummaryTask) holds so far: the TL;DR text and how many topics
+// are complete. It tolerates output cut anywhere.
+func partialSummary(s string) (tldr string, topics int) {
+	if i := jsonKey(s, "tldr"); i >= 0 {
+		tldr = partialJSONString(s[i:])
+	}
+	i := jsonKey(s, "topics")
+	if i < 0 {
+		return tldr, 0
+	}
+	rest := strings.TrimLeft(s[i:], " \t\r\n")
+	if !strings.HasPrefix(rest, "[") {
+		return tldr, 0
+	}
+	depth, inString, escaped := 0, false, false
+	for _, r := range rest[1:] {
+		switch {
+		case escaped:
+			escaped = false
+		case inString && r == '\\':
+			escaped = true
+		case r == '"':
+			inString = !inString
+		case inString:
+		case r == '{':
+			depth++
+		case r == '}':
+			depth--
+			if depth == 0 {
+				topics++
+			}
+		case r == ']' && depth == 0:
+			return tldr, topics
+		}
+	}
+	return tldr, topics
+}
+
+// jsonKey returns the offset just past `"key":` in s, or -1.
+func jsonKey(s, key string) int {
+	i := strings.Index(s, `"`+key+`"`)
+	if i < 0 {
+		return -1
+	}
+	rest := s[i+len(key)+2:]
+	trimmed := strings.TrimLeft(rest, " \t\r\n")
+	if !strings.HasPrefix(trimmed, ":") {
+		return -1
+	}
+	return len(s) - len(trimmed) + 1
+}
+
+// partialJSONString decodes the JSON string starting s (after whitespace) up
+// to its closing quote or, when cut short, as far as it goes.
+func partialJSONString(s string) string {
+	s = strings.TrimLeft(s, " \t\r\n")
+	if !strings.HasPrefix(s, `"`) {
+		return ""
+	}
+	var sb strings.Builder
+	for i := 1; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '"':
+			return sb.String()
+		case c != '\\':
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 && !utf8.FullRuneInString(s[i:]) {
+				return sb.String() // a rune cut mid-way
+			}
+			sb.WriteString(s[i : i+size])
+			i += size
+			continue
+		case i+1 >= len(s):
+			return sb.String()
+		}
+		switch s[i+1] {
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case 'r':
+		case 'u':
+			if i+6 > len(s) {
+				return sb.String()
+			}
+			var r rune
+			for _, h := range s[i+2 : i+6] {
+				r <<= 4
+				switch {
+				case h >= '0' && h <= '9':
+					r |= h - '0'
+				case h >= 'a' && h <= 'f':
+					r |= h - 'a' + 10
+				case h >= 'A' && h <= 'F':
+					r |= h - 'A' + 10
+				}
+			}
+			sb.WriteRune(r)
+			i += 6
+			continue
+		default: // \" \\ \/ and the rest stand for themselves
+			sb.WriteByte(s[i+1])
+		}
+		i += 2
+	}
+	return sb.String()
+}
+
+// streamProgress makes the summary call under ctx stream when ctx reports
+// progress, reporting the topics of total as the model finishes them and the
+// TL;DR as far as it has been written.
+func streamProgress(ctx context.Context, total int) context.Context {
+	if !hasProgress(ctx) {
+		return ctx
+	}
+	var (
+		buf      strings.Builder
+		lastTLDR string
+		lastDone int
+	)
+	return provider.WithDeltas(ctx, func(delta string) {
+		buf.WriteString(delta)
+		tldr, done := partialSummary(buf.String())
+		done = min(done, total)
+		if tldr == lastTLDR && done == lastDone {
+			return
+		}
+		lastTLDR, lastDone = tldr, done
+		reportProgress(ctx, Progress{Stage: StageSummarizing, Done: done, Total: total, TLDR: tldr})
+	})
+}
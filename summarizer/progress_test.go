@@ -0,0 +1,63 @@
+package summarizer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/metrics"
+)
+
+func TestPartialSummary(t *testing.T) {
+	full := `{"tldr":"Обсудили \"релиз\"\nи {скобки}.","topics":[{"title":"Релиз {1}","summary":"ок"},{"title":"Б","summary":"x"}]}`
+	tests := []struct {
+		name       string
+		in         string
+		wantTLDR   string
+		wantTopics int
+	}{
+		{"empty", ``, "", 0},
+		{"key only", `{"tldr":`, "", 0},
+		{"tldr cut", `{"tldr": "Обсудили \"рел`, `Обсудили "рел`, 0},
+		{"escape cut", `{"tldr":"Обсудили \`, "Обсудили ", 0},
+		{"rune cut", `{"tldr":"Об` + "\xd1", "Об", 0},
+		{"unicode escape", `{"tldr":"\u041e\u043a"}`, "Ок", 0},
+		{"unicode escape cut", `{"tldr":"Ок\u04`, "Ок", 0},
+		{"first topic open", `{"tldr":"x","topics":[{"title":"Релиз {1}"`, "x", 0},
+		{"first topic done", `{"tldr":"x","topics":[{"title":"Релиз {1}","summary":"ок"},{"ti`, "x", 1},
+		{"full", full, `Обсудили "релиз"` + "\nи {скобки}.", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tldr, topics := partialSummary(tt.in)
+			if tldr != tt.wantTLDR || topics != tt.wantTopics {
+				t.Fatalf("partialSummary(%q) = %q, %d; want %q, %d", tt.in, tldr, topics, tt.wantTLDR, tt.wantTopics)
+			}
+		})
+	}
+}
+
+func TestSummarizeByTopicsReportsProgress(t *testing.T) {
+	client := &fakeLLMClient{
+		responses: []string{
+			`{"topics":[{"title":"Релиз","message_indexes":[0,1],"message_count":2}]}`,
+			`{"tldr":"Обсудили релиз.","topics":[{"title":"Релиз","summary":"Договорились выкатить сегодня.","message_count":2}]}`,
+		},
+	}
+	sum := New(client, "test-model", metrics.New(), true)
+
+	var stages []Stage
+	ctx := WithProgress(context.Background(), func(p Progress) { stages = append(stages, p.Stage) })
+	_, err := sum.SummarizeByTopics(ctx, []db.Message{
+		{Text: "катим релиз", Timestamp: time.Unix(0, 0)},
+		{Text: "ок", Timestamp: time.Unix(60, 0)},
+	}, 5, "", i18n.Russian)
+	if err != nil {
+		t.Fatalf("SummarizeByTopics returned error: %v", err)
+	}
+	if len(stages) != 2 || stages[0] != StageClustering || stages[1] != StageSummarizing {
+		t.Fatalf("stages = %v, want clustering then summarizing", stages)
+	}
+}
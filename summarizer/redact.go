@@ -0,0 +1,196 @@
+package summarizer
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"telegram_summarize_bot/config"
+	"telegram_summarize_bot/db"
+)
+
+// Redactor replaces personal data in chat content bound for the LLM — emails,
+// card numbers, phone numbers, street addresses and any custom patterns —
+// with placeholders such as "[PHONE_1]". Placeholders are numbered per run
+// (see redaction), so within one summary a value keeps its placeholder and
+// the model can still tell two people's numbers apart.
+type Redactor struct {
+	rules []redactionRule
+}
+
+type redactionRule struct {
+	name  string
+	re    *regexp.Regexp
+	valid func(match string) bool // nil accepts every match
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+	cardPattern  = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+	phonePattern = regexp.MustCompile(`(?:\+|\b)\d[\d ()-]{7,17}\d\b`)
+	// addressPattern wants a street and a house number: "ул. Ленина, д. 5,
+	// кв. 12" or "221 Baker Street". A bare street name is not an address.
+	addressPattern = regexp.MustCompile(`(?i)(?:ул\.|улиц[аеуы]|пр-т|просп\.|проспект[аеу]?|пер\.|переул(?:ок|ке|ка)|б-р|бульвар[аеу]?|шоссе|наб\.|набережн(?:ая|ой))` +
+		`\s*[^,\n]{1,40}?,?\s*(?:д\.|дом)\s*\d+[а-яa-z]?(?:,?\s*(?:кв\.|квартира)\s*\d+)?` +
+		`|\b\d{1,5}\s+(?:[A-Z][a-z]+\s+){1,3}(?:Street|St|Avenue|Ave|Road|Rd|Boulevard|Blvd|Lane|Ln|Drive|Dr)\b\.?`)
+	isoDatePrefix = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}`)
+)
+
+// NewRedactor returns a Redactor with the built-in rules and custom, which
+// are applied first so they win over a built-in rule matching the same text.
+func NewRedactor(custom []config.PIIPattern) *Redactor {
+	r := &Redactor{}
+	for _, p := range custom {
+		r.rules = append(r.rules, redactionRule{name: p.Name, re: p.Pattern})
+	}
+	r.rules = append(r.rules,
+		redactionRule{name: "EMAIL", re: emailPattern},
+		redactionRule{name: "CARD", re: cardPattern, valid: isCardNumber},
+		redactionRule{name: "PHONE", re: phonePattern, valid: isPhoneNumber},
+		redactionRule{name: "ADDRESS", re: addressPattern},
+	)
+	return r
+}
+
+// isCardNumber reports whether s has the length and Luhn checksum of a
+// payment card number, so order and tracking numbers are left alone.
+func isCardNumber(s string) bool {
+	digits := onlyDigits(s)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	for i := range digits {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// isPhoneNumber reports whether s has the digit count of a phone number and
+// isn't a date with a time ("2024-01-15 10").
+func isPhoneNumber(s string) bool {
+	n := len(onlyDigits(s))
+	return n >= 10 && n <= 15 && (strings.HasPrefix(s, "+") || !isoDatePrefix.MatchString(s))
+}
+
+func onlyDigits(s string) string {
+	var sb strings.Builder
+	for _, c := range s {
+		if c >= '0' && c <= '9' {
+			sb.WriteRune(c)
+		}
+	}
+	return sb.String()
+}
+
+// redaction is one run of a Redactor, remembering each redacted value's
+// placeholder. A nil *redaction leaves text as is.
+type redaction struct {
+	rules        []redactionRule
+	placeholders map[string]string // rule name + "\x00" + value
+	counts       map[string]int
+}
+
+func (r *Redactor) run() *redaction {
+	return &redaction{
+		rules:        r.rules,
+		placeholders: make(map[string]string),
+		counts:       make(map[string]int),
+	}
+}
+
+// text returns s with every match of the rules replaced by its placeholder.
+func (rd *redaction) text(s string) string {
+	if rd == nil || s == "" {
+		return s
+	}
+	for _, rule := range rd.rules {
+		s = rule.re.ReplaceAllStringFunc(s, func(match string) string {
+			if rule.valid != nil && !rule.valid(match) {
+				return match
+			}
+			key := rule.name + "\x00" + match
+			if p, ok := rd.placeholders[key]; ok {
+				return p
+			}
+			rd.counts[rule.name]++
+			p := fmt.Sprintf("[%s_%d]", rule.name, rd.counts[rule.name])
+			rd.placeholders[key] = p
+			return p
+		})
+	}
+	return s
+}
+
+// messages returns copies of messages and descriptions with their text and
+// forward attribution redacted, so everything rendered from them — reply
+// breadcrumbs included — is clean.
+func (rd *redaction) messages(messages []db.Message, descriptions map[int64][]string) ([]db.Message, map[int64][]string) {
+	if rd == nil {
+		return messages, descriptions
+	}
+	out := make([]db.Message, len(messages))
+	for i, msg := range messages {
+		msg.Text = rd.text(msg.Text)
+		msg.ForwardedFrom = rd.text(msg.ForwardedFrom)
+		out[i] = msg
+	}
+	var descs map[int64][]string
+	if descriptions != nil {
+		descs = make(map[int64][]string, len(descriptions))
+		for id, list := range descriptions {
+			redacted := make([]string, len(list))
+			for i, d := range list {
+				redacted[i] = rd.text(d)
+			}
+			descs[id] = redacted
+		}
+	}
+	return out, descs
+}
+
+// restore returns s with the run's placeholders replaced by the values they
+// stand for.
+func (rd *redaction) restore(s string) string {
+	if rd == nil || len(rd.placeholders) == 0 || !strings.Contains(s, "[") {
+		return s
+	}
+	pairs := make([]string, 0, 2*len(rd.placeholders))
+	for key, p := range rd.placeholders {
+		_, value, _ := strings.Cut(key, "\x00")
+		pairs = append(pairs, p, value)
+	}
+	return strings.NewReplacer(pairs...).Replace(s)
+}
+
+type redactionKey struct{}
+
+// WithRedaction overrides, for calls made with the returned context, whether
+// chat content is redacted before it reaches the LLM (a group's setting).
+// It has no effect on a summarizer without a Redactor.
+func WithRedaction(ctx context.Context, enabled bool) context.Context {
+	return context.WithValue(ctx, redactionKey{}, enabled)
+}
+
+// redaction starts a redaction run for ctx, or returns nil when redaction is
+// off for it.
+func (s *Summarizer) redaction(ctx context.Context) *redaction {
+	if s.redactor == nil {
+		return nil
+	}
+	enabled := s.redactDefault
+	if v, ok := ctx.Value(redactionKey{}).(bool); ok {
+		enabled = v
+	}
+	if !enabled {
+		return nil
+	}
+	return s.redactor.run()
+}
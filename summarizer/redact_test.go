@@ -0,0 +1,130 @@
+package summarizer
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"telegram_summarize_bot/config"
+	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
+	"telegram_summarize_bot/metrics"
+)
+
+func TestRedactorText(t *testing.T) {
+	r := NewRedactor([]config.PIIPattern{{Name: "TICKET", Pattern: regexp.MustCompile(`ACME-\d+`)}})
+	tests := []struct {
+		name, in, want string
+	}{
+		{"email", "пиши на ivan.petrov@example.com", "пиши на [EMAIL_1]"},
+		{"phone with country code", "звони +7 (999) 123-45-67", "звони [PHONE_1]"},
+		{"phone without plus", "мой 89991234567, жду", "мой [PHONE_1], жду"},
+		{"international phone", "call +1 415 555 0100", "call [PHONE_1]"},
+		{"card", "карта 4111 1111 1111 1111", "карта [CARD_1]"},
+		{"card without spaces", "4111111111111111", "[CARD_1]"},
+		{"russian address", "живу: ул. Ленина, д. 5, кв. 12", "живу: [ADDRESS_1]"},
+		{"english address", "meet at 221 Baker Street tomorrow", "meet at [ADDRESS_1] tomorrow"},
+		{"custom rule", "see ACME-4242", "see [TICKET_1]"},
+		{"same value same placeholder", "a@b.io, a@b.io, c@d.io", "[EMAIL_1], [EMAIL_1], [EMAIL_2]"},
+		{"date and time kept", "релиз 2024-01-15 10:30", "релиз 2024-01-15 10:30"},
+		{"short numbers kept", "в 10:30, комната 1234", "в 10:30, комната 1234"},
+		{"non-luhn long number kept", "заказ 1234567890123456", "заказ 1234567890123456"},
+		{"street without house kept", "на улице Ленина пробка", "на улице Ленина пробка"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.run().text(tt.in); got != tt.want {
+				t.Errorf("text(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+// piiMessages hold one of each kind of PII, including in a replied-to message
+// whose text is quoted in its reply's breadcrumb and in a forward's
+// attribution.
+func piiMessages() ([]db.Message, []string) {
+	ts := time.Unix(0, 0).UTC()
+	secrets := []string{"ivan@example.com", "+7 999 123-45-67", "4111 1111 1111 1111", "ул. Ленина, д. 5", "ACME-77", "+7 900 555-01-02"}
+	return []db.Message{
+		{ID: 1, TgMessageID: 10, UserHash: "aaaa1111", Text: "мой телефон +7 999 123-45-67, почта ivan@example.com", Timestamp: ts},
+		{ID: 2, TgMessageID: 11, ReplyToTgID: 10, UserHash: "bbbb2222", Text: "переведи на 4111 1111 1111 1111 по ACME-77", Timestamp: ts},
+		{ID: 3, TgMessageID: 12, UserHash: "aaaa1111", Text: "привезите на ул. Ленина, д. 5; ivan@example.com", Timestamp: ts},
+		{ID: 4, TgMessageID: 13, UserHash: "bbbb2222", Text: "курьер будет в 10", ForwardedFrom: "+7 900 555-01-02", Timestamp: ts},
+	}, secrets
+}
+
+func TestRedactionKeepsPIIOutOfCompletionRequests(t *testing.T) {
+	messages, secrets := piiMessages()
+	client := &fakeLLMClient{
+		responses: []string{
+			`{"topics":[{"title":"Доставка","message_indexes":[0,1,2],"message_count":3}]}`,
+			`{"tldr":"Договорились.","topics":[{"title":"Доставка","summary":"Кратко.","message_count":3}]}`,
+			"Подробно.",
+			"Выжимка.",
+			"Страница.",
+		},
+	}
+	redactor := NewRedactor([]config.PIIPattern{{Name: "TICKET", Pattern: regexp.MustCompile(`ACME-\d+`)}})
+	sum := New(client, "test-model", metrics.New(), true).WithRedactor(redactor, false)
+	ctx := WithRedaction(context.Background(), true)
+
+	if _, err := sum.SummarizeByTopics(ctx, messages, 5, "", i18n.Russian); err != nil {
+		t.Fatalf("SummarizeByTopics: %v", err)
+	}
+	if _, err := sum.ExpandTopic(ctx, messages, "Доставка", "", i18n.Russian); err != nil {
+		t.Fatalf("ExpandTopic: %v", err)
+	}
+	if _, err := sum.SummarizeText(ctx, messages[0].Text, "", i18n.Russian); err != nil {
+		t.Fatalf("SummarizeText: %v", err)
+	}
+	if _, err := sum.SummarizeURL(ctx, "https://example.com/u/ivan@example.com", messages[1].Text, "", i18n.Russian); err != nil {
+		t.Fatalf("SummarizeURL: %v", err)
+	}
+
+	if len(client.requests) != 5 {
+		t.Fatalf("request count = %d, want 5", len(client.requests))
+	}
+	for i, req := range client.requests {
+		for _, m := range req.Messages {
+			for _, secret := range secrets {
+				if strings.Contains(m.Content, secret) {
+					t.Errorf("request %d (%s) leaks %q:\n%s", i, req.Operation, secret, m.Content)
+				}
+			}
+		}
+	}
+
+	// Within a run a value keeps its placeholder, so the email written twice
+	// reads as one address.
+	transcript := client.requests[0].Messages[1].Content
+	if !strings.Contains(transcript, "[EMAIL_1]\n") || strings.Contains(transcript, "[EMAIL_2]") {
+		t.Errorf("expected one stable email placeholder, got:\n%s", transcript)
+	}
+	for _, p := range []string{"[PHONE_1]", "[CARD_1]", "[ADDRESS_1]", "[TICKET_1]", "(fwd: [PHONE_2])"} {
+		if !strings.Contains(transcript, p) {
+			t.Errorf("transcript missing %s:\n%s", p, transcript)
+		}
+	}
+}
+
+func TestRedactionFollowsContext(t *testing.T) {
+	messages, _ := piiMessages()
+	client := &fakeLLMClient{responses: []string{"a", "b"}}
+	sum := New(client, "test-model", metrics.New(), true).WithRedactor(NewRedactor(nil), true)
+
+	if _, err := sum.SummarizeText(context.Background(), messages[0].Text, "", i18n.Russian); err != nil {
+		t.Fatalf("SummarizeText: %v", err)
+	}
+	if _, err := sum.SummarizeText(WithRedaction(context.Background(), false), messages[0].Text, "", i18n.Russian); err != nil {
+		t.Fatalf("SummarizeText: %v", err)
+	}
+	if got := client.requests[0].Messages[1].Content; strings.Contains(got, "ivan@example.com") {
+		t.Errorf("redaction on by default, yet the email was sent: %q", got)
+	}
+	if got := client.requests[1].Messages[1].Content; !strings.Contains(got, "ivan@example.com") {
+		t.Errorf("the group turned redaction off, yet the email was redacted: %q", got)
+	}
+}
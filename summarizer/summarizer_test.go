@@ -5,12 +5,15 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"reflect"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"telegram_summarize_bot/config"
 	"telegram_summarize_bot/db"
+	"telegram_summarize_bot/i18n"
 	"telegram_summarize_bot/metrics"
 	"telegram_summarize_bot/provider"
 )
@@ -53,7 +56,7 @@ func TestClusterTopicsSanitizesAssignments(t *testing.T) {
 		{Text: "Четвертое", Timestamp: time.Unix(180, 0)},
 	}
 
-	clusters, err := sum.ClusterTopics(context.Background(), messages, 5, nil)
+	clusters, err := sum.ClusterTopics(context.Background(), messages, 5, "", nil, i18n.Russian)
 	if err != nil {
 		t.Fatalf("ClusterTopics returned error: %v", err)
 	}
@@ -79,7 +82,7 @@ func TestClusterTopicsRejectsInvalidJSON(t *testing.T) {
 	}
 	sum := New(&fakeLLMClient{responses: responses}, "test-model", metrics.New(), true)
 
-	_, err := sum.ClusterTopics(context.Background(), []db.Message{{Text: "msg", Timestamp: time.Unix(0, 0)}}, 5, nil)
+	_, err := sum.ClusterTopics(context.Background(), []db.Message{{Text: "msg", Timestamp: time.Unix(0, 0)}}, 5, "", nil, i18n.Russian)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -100,7 +103,7 @@ func TestSummarizeByTopicsUsesConfiguredTokenBudget(t *testing.T) {
 	summary, err := sum.SummarizeByTopics(context.Background(), []db.Message{
 		{Text: "катим релиз", Timestamp: time.Unix(0, 0)},
 		{Text: "ок", Timestamp: time.Unix(60, 0)},
-	}, 5, "")
+	}, 5, "", i18n.Russian)
 	if err != nil {
 		t.Fatalf("SummarizeByTopics returned error: %v", err)
 	}
@@ -116,7 +119,32 @@ func TestSummarizeByTopicsUsesConfiguredTokenBudget(t *testing.T) {
 	}
 }
 
-func TestSummarizeByTopicsAppliesAdditionalInstructionsOnlyToFinalSummary(t *testing.T) {
+func TestSummarizeByTopicsSendsSchemas(t *testing.T) {
+	client := &fakeLLMClient{
+		responses: []string{
+			`{"topics":[{"title":"Релиз","message_indexes":[0],"message_count":1}]}`,
+			`{"tldr":"Обсудили релиз.","topics":[{"title":"Релиз","summary":"Катим.","message_count":1}]}`,
+		},
+	}
+	sum := New(client, "test-model", metrics.New(), true)
+
+	if _, err := sum.SummarizeByTopics(context.Background(), []db.Message{{Text: "катим релиз"}}, 5, "", i18n.Russian); err != nil {
+		t.Fatalf("SummarizeByTopics returned error: %v", err)
+	}
+	if got := client.requests[0].Schema; got != clusterSchema {
+		t.Errorf("cluster schema = %+v, want clusterSchema", got)
+	}
+	if got := client.requests[1].Schema; got != summarySchema {
+		t.Errorf("summary schema = %+v, want summarySchema", got)
+	}
+	// Fields the summarizer fills in itself are not asked of the model.
+	topic := summarySchema.Schema["properties"].(map[string]any)["topics"].(map[string]any)["items"].(map[string]any)
+	if _, ok := topic["properties"].(map[string]any)["first_tg_message_id"]; ok {
+		t.Error("summary schema should leave out first_tg_message_id")
+	}
+}
+
+func TestSummarizeByTopicsSharesCachedPrefix(t *testing.T) {
 	client := &fakeLLMClient{
 		responses: []string{
 			`{"topics":[{"title":"Релиз","message_indexes":[0],"message_count":1}]}`,
@@ -127,29 +155,82 @@ func TestSummarizeByTopicsAppliesAdditionalInstructionsOnlyToFinalSummary(t *tes
 
 	_, err := sum.SummarizeByTopics(context.Background(), []db.Message{
 		{Text: "катим релиз", Timestamp: time.Unix(0, 0)},
-	}, 5, "Выделяй риски отдельным предложением.")
+	}, 5, "Выделяй риски отдельным предложением.", i18n.Russian)
 	if err != nil {
 		t.Fatalf("SummarizeByTopics returned error: %v", err)
 	}
 	if len(client.requests) != 2 {
 		t.Fatalf("request count = %d, want 2", len(client.requests))
 	}
-	if strings.Contains(client.requests[0].Messages[0].Content, "Выделяй риски") {
-		t.Fatal("additional instructions leaked into clustering system prompt")
+	// Both calls open with the same system prompt and transcript, the latter
+	// marked for caching; only the trailing task differs.
+	cluster, final := client.requests[0].Messages, client.requests[1].Messages
+	if len(cluster) != 3 || len(final) != 3 {
+		t.Fatalf("message counts = %d, %d, want 3 each", len(cluster), len(final))
+	}
+	for i := range 2 {
+		if !reflect.DeepEqual(cluster[i], final[i]) {
+			t.Fatalf("message %d differs between calls:\n%+v\n%+v", i, cluster[i], final[i])
+		}
+	}
+	if !cluster[1].CacheBreakpoint || cluster[0].CacheBreakpoint || cluster[2].CacheBreakpoint {
+		t.Fatalf("cache breakpoint should be on the transcript only: %+v", cluster)
+	}
+	if !strings.Contains(cluster[1].Content, "катим релиз") {
+		t.Fatalf("transcript missing from prefix: %q", cluster[1].Content)
+	}
+	if !strings.Contains(final[2].Content, "Тема 1: Релиз (0)") {
+		t.Fatalf("summary task missing cluster index: %q", final[2].Content)
+	}
+
+	// The instructions ride in the shared system prompt, scoped to the summary.
+	systemPrompt := final[0].Content
+	if !strings.Contains(systemPrompt, "Выделяй риски отдельным предложением.") {
+		t.Fatalf("system prompt missing additional instructions: %q", systemPrompt)
+	}
+	if !strings.Contains(systemPrompt, "при выделении тем их не учитывай") {
+		t.Fatalf("system prompt should scope instructions to the summary: %q", systemPrompt)
+	}
+	if !strings.Contains(systemPrompt, "строго JSON") || !strings.Contains(systemPrompt, "только на русском языке") {
+		t.Fatalf("system prompt missing mandatory constraints: %q", systemPrompt)
+	}
+}
+
+func TestSummarizeByTopicsAutoLanguage(t *testing.T) {
+	client := &fakeLLMClient{
+		responses: []string{
+			`{"topics":[{"title":"","message_indexes":[0,1],"message_count":2}]}`,
+			`{"tldr":"Release on Friday.","topics":[{"title":"","summary":"Agreed.","message_count":2}]}`,
+		},
+	}
+	sum := New(client, "test-model", metrics.New(), true)
+
+	summary, err := sum.SummarizeByTopics(context.Background(), []db.Message{
+		{Text: "let's ship the release on Friday", Timestamp: time.Unix(0, 0)},
+		{Text: "ок", Timestamp: time.Unix(60, 0)},
+	}, 5, "", i18n.Auto)
+	if err != nil {
+		t.Fatalf("SummarizeByTopics returned error: %v", err)
+	}
+	if summary.Lang != i18n.English {
+		t.Fatalf("summary.Lang = %q, want en", summary.Lang)
 	}
-	finalPrompt := client.requests[1].Messages[0].Content
-	if !strings.Contains(finalPrompt, "Выделяй риски отдельным предложением.") {
-		t.Fatalf("final system prompt missing additional instructions: %q", finalPrompt)
+	if !strings.Contains(client.requests[0].Messages[2].Content, "на английском языке") {
+		t.Fatalf("cluster prompt missing language: %q", client.requests[0].Messages[2].Content)
 	}
-	if !strings.Contains(finalPrompt, "строго JSON") || !strings.Contains(finalPrompt, "только на русском языке") {
-		t.Fatalf("final system prompt missing mandatory constraints: %q", finalPrompt)
+	if !strings.Contains(client.requests[1].Messages[0].Content, "только на английском языке") {
+		t.Fatalf("summary system prompt missing language: %q", client.requests[1].Messages[0].Content)
+	}
+	formatted := FormatTelegramSummary(summary, 0)
+	if !strings.Contains(formatted, "**Summary:**") || !strings.Contains(formatted, "Topic 1") {
+		t.Fatalf("expected English labels, got %q", formatted)
 	}
 }
 
 func TestSummarizeByTopicsPropagatesClientError(t *testing.T) {
 	sum := New(&fakeLLMClient{err: errors.New("boom")}, "test-model", metrics.New(), true)
 
-	_, err := sum.SummarizeByTopics(context.Background(), []db.Message{{Text: "msg", Timestamp: time.Unix(0, 0)}}, 5, "")
+	_, err := sum.SummarizeByTopics(context.Background(), []db.Message{{Text: "msg", Timestamp: time.Unix(0, 0)}}, 5, "", i18n.Russian)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -212,7 +293,7 @@ type stubImageDescriber struct {
 	resp  map[string]string
 }
 
-func (s *stubImageDescriber) Describe(_ context.Context, photo db.PhotoRecord, _ string) (string, error) {
+func (s *stubImageDescriber) Describe(_ context.Context, photo db.PhotoRecord, _ string, _ i18n.Lang) (string, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.calls[photo.FileUniqueID]++
@@ -246,7 +327,7 @@ func TestResolveImageDescriptions_DedupsByFileUniqueID(t *testing.T) {
 		describeConcurrency: 2,
 	}
 	msgs := []db.Message{{ID: 1}, {ID: 2}, {ID: 3}}
-	got := s.resolveImageDescriptions(context.Background(), msgs)
+	got := s.resolveImageDescriptions(context.Background(), msgs, i18n.Russian)
 
 	if len(got) != 3 {
 		t.Fatalf("expected descriptions for 3 messages, got %d (%+v)", len(got), got)
@@ -267,7 +348,7 @@ func TestResolveImageDescriptions_DedupsByFileUniqueID(t *testing.T) {
 
 func TestResolveImageDescriptions_NilWhenDescriberDisabled(t *testing.T) {
 	s := &Summarizer{} // no describer/photos
-	got := s.resolveImageDescriptions(context.Background(), []db.Message{{ID: 1}})
+	got := s.resolveImageDescriptions(context.Background(), []db.Message{{ID: 1}}, i18n.Russian)
 	if got != nil {
 		t.Errorf("expected nil with no describer, got %+v", got)
 	}
@@ -354,7 +435,7 @@ func TestFormatIndexedMessages_ReplyThreadsEnabled(t *testing.T) {
 		{TgMessageID: 2, ReplyToTgID: 1, UserHash: "deadbeef", Text: "reply to first", Timestamp: ts},
 	}
 	s := New(&fakeLLMClient{}, "test-model", metrics.New(), true)
-	out := s.formatIndexedMessages(messages, nil)
+	out := s.formatIndexedMessages(messages, nil, true)
 	if !strings.Contains(out, "↩") {
 		t.Fatalf("expected reply annotation with replyThreads=true, got: %q", out)
 	}
@@ -367,37 +448,30 @@ func TestFormatIndexedMessages_ReplyThreadsDisabled(t *testing.T) {
 		{TgMessageID: 2, ReplyToTgID: 1, UserHash: "deadbeef", Text: "reply to first", Timestamp: ts},
 	}
 	s := New(&fakeLLMClient{}, "test-model", metrics.New(), false)
-	out := s.formatIndexedMessages(messages, nil)
+	out := s.formatIndexedMessages(messages, nil, false)
 	if strings.Contains(out, "↩") {
 		t.Fatalf("unexpected reply annotation with replyThreads=false, got: %q", out)
 	}
 }
 
-func TestFormatClustersForPrompt_ReplyThreadsEnabled(t *testing.T) {
-	ts := time.Unix(0, 0).UTC()
-	messages := []db.Message{
-		{TgMessageID: 1, UserHash: "a3f2b1c4", Text: "first", Timestamp: ts},
-		{TgMessageID: 2, ReplyToTgID: 1, UserHash: "deadbeef", Text: "reply to first", Timestamp: ts},
-	}
-	clusters := []TopicCluster{{Title: "Test", MessageIndexes: []int{0, 1}}}
+func TestReplyThreadsContextOverride(t *testing.T) {
 	s := New(&fakeLLMClient{}, "test-model", metrics.New(), true)
-	out := s.formatClustersForPrompt(messages, clusters, nil)
-	if !strings.Contains(out, "↩") {
-		t.Fatalf("expected reply annotation with replyThreads=true, got: %q", out)
+	if !s.replyThreadsEnabled(context.Background()) {
+		t.Fatal("expected global setting without override")
+	}
+	if s.replyThreadsEnabled(WithReplyThreads(context.Background(), false)) {
+		t.Fatal("expected per-call override to disable reply threads")
 	}
 }
 
-func TestFormatClustersForPrompt_ReplyThreadsDisabled(t *testing.T) {
-	ts := time.Unix(0, 0).UTC()
-	messages := []db.Message{
-		{TgMessageID: 1, UserHash: "a3f2b1c4", Text: "first", Timestamp: ts},
-		{TgMessageID: 2, ReplyToTgID: 1, UserHash: "deadbeef", Text: "reply to first", Timestamp: ts},
+func TestFormatClusterIndex(t *testing.T) {
+	clusters := []TopicCluster{
+		{Title: "Релиз", MessageIndexes: []int{0, 2}},
+		{Title: "Обед", MessageIndexes: []int{1}},
 	}
-	clusters := []TopicCluster{{Title: "Test", MessageIndexes: []int{0, 1}}}
-	s := New(&fakeLLMClient{}, "test-model", metrics.New(), false)
-	out := s.formatClustersForPrompt(messages, clusters, nil)
-	if strings.Contains(out, "↩") {
-		t.Fatalf("unexpected reply annotation with replyThreads=false, got: %q", out)
+	want := "Тема 1: Релиз (0, 2)\nТема 2: Обед (1)"
+	if got := formatClusterIndex(clusters); got != want {
+		t.Fatalf("formatClusterIndex = %q, want %q", got, want)
 	}
 }
 
@@ -407,7 +481,7 @@ func TestSummarizeURLPromptConstruction(t *testing.T) {
 	}
 	sum := New(client, "test-model", metrics.New(), true)
 
-	result, err := sum.SummarizeURL(context.Background(), "https://example.com/article", "Article text here", "")
+	result, err := sum.SummarizeURL(context.Background(), "https://example.com/article", "Article text here", "", i18n.Russian)
 	if err != nil {
 		t.Fatalf("SummarizeURL error: %v", err)
 	}
@@ -436,7 +510,7 @@ func TestSummarizeURLPromptConstruction(t *testing.T) {
 func TestSummarizeURLPropagatesError(t *testing.T) {
 	sum := New(&fakeLLMClient{err: errors.New("api error")}, "test-model", metrics.New(), true)
 
-	_, err := sum.SummarizeURL(context.Background(), "https://example.com", "content", "")
+	_, err := sum.SummarizeURL(context.Background(), "https://example.com", "content", "", i18n.Russian)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -476,9 +550,9 @@ func TestTelegramMsgLink(t *testing.T) {
 		{-999999, 42, ""},
 	}
 	for _, tc := range tests {
-		got := telegramMsgLink(tc.groupID, tc.msgID)
+		got := MessageLink(tc.groupID, tc.msgID)
 		if got != tc.want {
-			t.Errorf("telegramMsgLink(%d, %d) = %q, want %q", tc.groupID, tc.msgID, got, tc.want)
+			t.Errorf("MessageLink(%d, %d) = %q, want %q", tc.groupID, tc.msgID, got, tc.want)
 		}
 	}
 }
@@ -550,7 +624,7 @@ func TestClusterTopicsRetriesOnNetworkError(t *testing.T) {
 
 	clusters, err := sum.ClusterTopics(context.Background(), []db.Message{
 		{Text: "msg", Timestamp: time.Unix(0, 0)},
-	}, 5, nil)
+	}, 5, "", nil, i18n.Russian)
 	if err != nil {
 		t.Fatalf("expected success after retries, got: %v", err)
 	}
@@ -574,7 +648,7 @@ func TestClusterTopicsNoRetryOnContextCanceled(t *testing.T) {
 
 	_, err := sum.ClusterTopics(context.Background(), []db.Message{
 		{Text: "msg", Timestamp: time.Unix(0, 0)},
-	}, 5, nil)
+	}, 5, "", nil, i18n.Russian)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -597,7 +671,7 @@ func TestSummarizeTopicsRetriesOnNetworkError(t *testing.T) {
 	messages := []db.Message{{Text: "msg", Timestamp: time.Unix(0, 0)}}
 	clusters := []TopicCluster{{Title: "Тема", MessageIndexes: []int{0}}}
 
-	result, err := sum.SummarizeTopics(context.Background(), messages, clusters, "", nil)
+	result, err := sum.SummarizeTopics(context.Background(), messages, clusters, "", nil, i18n.Russian)
 	if err != nil {
 		t.Fatalf("expected success after retry, got: %v", err)
 	}
@@ -620,7 +694,7 @@ func TestSummarizeURLRetriesOnNetworkError(t *testing.T) {
 	sum := New(client, "test-model", metrics.New(), true)
 	sum.retryBaseDelay = 0
 
-	result, err := sum.SummarizeURL(context.Background(), "https://example.com", "content", "")
+	result, err := sum.SummarizeURL(context.Background(), "https://example.com", "content", "", i18n.Russian)
 	if err != nil {
 		t.Fatalf("expected success after retry, got: %v", err)
 	}
@@ -662,7 +736,7 @@ func TestSummarizeText(t *testing.T) {
 	client := &fakeLLMClient{responses: []string{"Единая выжимка."}}
 	sum := New(client, "test-model", metrics.New(), true)
 
-	got, err := sum.SummarizeText(context.Background(), "материал для выжимки", "выделяй риски")
+	got, err := sum.SummarizeText(context.Background(), "материал для выжимки", "выделяй риски", i18n.Russian)
 	if err != nil {
 		t.Fatalf("SummarizeText error: %v", err)
 	}
@@ -683,7 +757,7 @@ func TestSummarizeText(t *testing.T) {
 
 func TestDescribeImageVisionDisabled(t *testing.T) {
 	sum := New(&fakeLLMClient{}, "test-model", metrics.New(), true)
-	if _, err := sum.DescribeImage(context.Background(), db.PhotoRecord{FileUniqueID: "u1"}, ""); !errors.Is(err, ErrVisionDisabled) {
+	if _, err := sum.DescribeImage(context.Background(), db.PhotoRecord{FileUniqueID: "u1"}, "", i18n.Russian); !errors.Is(err, ErrVisionDisabled) {
 		t.Fatalf("DescribeImage error = %v, want ErrVisionDisabled", err)
 	}
 }
@@ -692,7 +766,7 @@ func TestDescribeImageDelegatesToDescriber(t *testing.T) {
 	desc := &stubImageDescriber{calls: map[string]int{}, resp: map[string]string{"u1": "На фото кот"}}
 	sum := &Summarizer{describer: desc}
 
-	got, err := sum.DescribeImage(context.Background(), db.PhotoRecord{FileUniqueID: "u1"}, "")
+	got, err := sum.DescribeImage(context.Background(), db.PhotoRecord{FileUniqueID: "u1"}, "", i18n.Russian)
 	if err != nil {
 		t.Fatalf("DescribeImage error: %v", err)
 	}
@@ -752,10 +826,24 @@ func TestSummarizeByTopicsConcurrentNoRace(t *testing.T) {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			if _, err := sum.SummarizeByTopics(context.Background(), msgs, 5, ""); err != nil {
+			if _, err := sum.SummarizeByTopics(context.Background(), msgs, 5, "", i18n.Russian); err != nil {
 				t.Errorf("SummarizeByTopics returned error: %v", err)
 			}
 		}()
 	}
 	wg.Wait()
 }
+
+func TestWithGroupSettings(t *testing.T) {
+	sum := New(&fakeLLMClient{}, "test-model", metrics.New(), false).WithRedactor(NewRedactor(nil), false)
+	moscow := time.FixedZone("MSK", 3*3600)
+
+	ctx := WithGroupSettings(context.Background(), config.GroupSettings{ReplyThreads: true, PIIRedaction: true, ActionItems: true, CalendarEvents: true}, moscow)
+	if !sum.replyThreadsEnabled(ctx) || sum.redaction(ctx) == nil || !actionsEnabled(ctx) || eventsLocation(ctx) != moscow {
+		t.Fatal("every stage the group turned on should be on")
+	}
+	ctx = WithGroupSettings(ctx, config.GroupSettings{}, moscow)
+	if sum.replyThreadsEnabled(ctx) || sum.redaction(ctx) != nil || actionsEnabled(ctx) || eventsLocation(ctx) != nil {
+		t.Fatal("a group's settings should override the ones before them")
+	}
+}
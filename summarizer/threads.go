@@ -53,3 +53,79 @@ func WalkAncestry(target db.Message, lookup AncestorLookup, maxDepth int) ([]db.
 	}
 	return chain, nil
 }
+
+// Hard ceilings on a reply-tree walk, regardless of the requested budgets.
+const (
+	maxDescendantDepth    = 50
+	maxDescendantMessages = 300
+)
+
+// RepliesLookup returns the stored replies to any of the given Telegram
+// message_ids, oldest first. A non-nil error aborts the walk.
+type RepliesLookup func(parentTgMessageIDs []int64) ([]db.Message, error)
+
+// ThreadNode is one message of a reply tree.
+type ThreadNode struct {
+	Message db.Message
+	// Depth is 0 for the root, 1 for its direct replies, and so on.
+	Depth int
+	// Branch numbers the root's direct reply this message descends from
+	// (1-based, in reply order); 0 for the root.
+	Branch int
+}
+
+// WalkDescendants returns the reply tree under root, root first and each
+// reply right after the message it answers (siblings oldest first), so
+// indenting by Depth reads as the thread. It walks level by level, stopping at
+// maxDepth reply levels or once the tree holds maxMessages messages, which
+// keeps the replies closest to root when the tree is cut. Both budgets are
+// clamped to their ceilings; values <= 0 use the ceiling.
+func WalkDescendants(root db.Message, lookup RepliesLookup, maxDepth, maxMessages int) ([]ThreadNode, error) {
+	if maxDepth <= 0 || maxDepth > maxDescendantDepth {
+		maxDepth = maxDescendantDepth
+	}
+	if maxMessages <= 0 || maxMessages > maxDescendantMessages {
+		maxMessages = maxDescendantMessages
+	}
+	if root.TgMessageID == 0 {
+		return []ThreadNode{{Message: root}}, nil
+	}
+
+	children := map[int64][]db.Message{}
+	seen := map[int64]bool{root.TgMessageID: true}
+	total := 1
+	level := []int64{root.TgMessageID}
+	for depth := 1; depth <= maxDepth && len(level) > 0 && total < maxMessages; depth++ {
+		replies, err := lookup(level)
+		if err != nil {
+			return nil, err
+		}
+		level = nil
+		for _, r := range replies {
+			if total >= maxMessages {
+				break
+			}
+			if r.TgMessageID == 0 || seen[r.TgMessageID] { // cycle or unaddressable
+				continue
+			}
+			seen[r.TgMessageID] = true
+			children[r.ReplyToTgID] = append(children[r.ReplyToTgID], r)
+			level = append(level, r.TgMessageID)
+			total++
+		}
+	}
+
+	nodes := make([]ThreadNode, 0, total)
+	var visit func(m db.Message, depth, branch int)
+	visit = func(m db.Message, depth, branch int) {
+		nodes = append(nodes, ThreadNode{Message: m, Depth: depth, Branch: branch})
+		for i, c := range children[m.TgMessageID] {
+			if depth == 0 {
+				branch = i + 1
+			}
+			visit(c, depth+1, branch)
+		}
+	}
+	visit(root, 0, 0)
+	return nodes, nil
+}
@@ -107,3 +107,104 @@ func TestWalkAncestryLookupError(t *testing.T) {
 		t.Fatalf("err = %v, want boom", err)
 	}
 }
+
+// repliesLookup builds a RepliesLookup over an in-memory set of messages,
+// counting the calls so tests can check the walk goes level by level.
+func repliesLookup(calls *int, msgs ...db.Message) RepliesLookup {
+	return func(parents []int64) ([]db.Message, error) {
+		*calls++
+		want := map[int64]bool{}
+		for _, id := range parents {
+			want[id] = true
+		}
+		var out []db.Message
+		for _, m := range msgs {
+			if want[m.ReplyToTgID] {
+				out = append(out, m)
+			}
+		}
+		return out, nil
+	}
+}
+
+func TestWalkDescendantsBranches(t *testing.T) {
+	// 1 ─┬─ 2 ── 4
+	//    └─ 3 ─┬─ 5
+	//          └─ 6
+	root := db.Message{TgMessageID: 1}
+	msgs := []db.Message{
+		{TgMessageID: 2, ReplyToTgID: 1},
+		{TgMessageID: 3, ReplyToTgID: 1},
+		{TgMessageID: 4, ReplyToTgID: 2},
+		{TgMessageID: 5, ReplyToTgID: 3},
+		{TgMessageID: 6, ReplyToTgID: 3},
+		{TgMessageID: 7, ReplyToTgID: 99}, // elsewhere in the chat
+	}
+	calls := 0
+	nodes, err := WalkDescendants(root, repliesLookup(&calls, msgs...), 10, 100)
+	if err != nil {
+		t.Fatalf("WalkDescendants: %v", err)
+	}
+
+	type node struct {
+		id            int64
+		depth, branch int
+	}
+	want := []node{{1, 0, 0}, {2, 1, 1}, {4, 2, 1}, {3, 1, 2}, {5, 2, 2}, {6, 2, 2}}
+	if len(nodes) != len(want) {
+		t.Fatalf("got %d nodes, want %d: %+v", len(nodes), len(want), nodes)
+	}
+	for i, n := range nodes {
+		if got := (node{n.Message.TgMessageID, n.Depth, n.Branch}); got != want[i] {
+			t.Errorf("node %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+	// Levels 1, 2 and an empty level 3.
+	if calls != 3 {
+		t.Errorf("lookup called %d times, want one per level", calls)
+	}
+}
+
+func TestWalkDescendantsBudgets(t *testing.T) {
+	// A chain 1 ← 2 ← 3 ← 4 plus a second reply 5 to the root.
+	root := db.Message{TgMessageID: 1}
+	msgs := []db.Message{
+		{TgMessageID: 2, ReplyToTgID: 1},
+		{TgMessageID: 5, ReplyToTgID: 1},
+		{TgMessageID: 3, ReplyToTgID: 2},
+		{TgMessageID: 4, ReplyToTgID: 3},
+	}
+	calls := 0
+
+	nodes, err := WalkDescendants(root, repliesLookup(&calls, msgs...), 2, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 4 { // 1, 2, 3, 5: depth 3 (message 4) is cut
+		t.Errorf("depth budget: got %d nodes, want 4", len(nodes))
+	}
+
+	nodes, err = WalkDescendants(root, repliesLookup(&calls, msgs...), 10, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The size budget keeps the shallowest replies: the root's two.
+	if len(nodes) != 3 || nodes[1].Message.TgMessageID != 2 || nodes[2].Message.TgMessageID != 5 {
+		t.Errorf("size budget: got %+v, want root, 2, 5", nodes)
+	}
+}
+
+func TestWalkDescendantsCycleAndErrors(t *testing.T) {
+	root := db.Message{TgMessageID: 1, ReplyToTgID: 2}
+	calls := 0
+	// 2 replies to 1 and 1 to 2: the root is not visited again.
+	nodes, err := WalkDescendants(root, repliesLookup(&calls, db.Message{TgMessageID: 2, ReplyToTgID: 1}, root), 10, 100)
+	if err != nil || len(nodes) != 2 {
+		t.Fatalf("cycle: %+v, %v", nodes, err)
+	}
+
+	boom := errors.New("db down")
+	if _, err := WalkDescendants(root, func([]int64) ([]db.Message, error) { return nil, boom }, 10, 100); !errors.Is(err, boom) {
+		t.Fatalf("lookup error = %v, want %v", err, boom)
+	}
+}
@@ -1,18 +1,19 @@
 package tgutil
 
 import (
-	"fmt"
 	"time"
+
+	"telegram_summarize_bot/i18n"
 )
 
-// FormatDuration renders a coarse, user-facing Russian duration ("N секунд" under
-// a minute, otherwise "N минут"). Shared by the group and admin handlers for
-// rate-limit wait messages.
-func FormatDuration(d time.Duration) string {
+// FormatDuration renders a coarse, user-facing duration in lang ("N секунд"
+// under a minute, otherwise "N минут"). Shared by the group and admin handlers
+// for rate-limit wait messages.
+func FormatDuration(lang i18n.Lang, d time.Duration) string {
 	seconds := int(d.Seconds())
 	if seconds < 60 {
-		return fmt.Sprintf("%d секунд", seconds)
+		return i18n.T(lang, i18n.DurationSeconds, seconds)
 	}
 	minutes := seconds / 60
-	return fmt.Sprintf("%d минут", minutes)
+	return i18n.T(lang, i18n.DurationMinutes, minutes)
 }
@@ -3,6 +3,8 @@ package tgutil
 import (
 	"testing"
 	"time"
+
+	"telegram_summarize_bot/i18n"
 )
 
 func TestFormatDuration(t *testing.T) {
@@ -19,9 +21,15 @@ func TestFormatDuration(t *testing.T) {
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			if got := FormatDuration(tc.d); got != tc.want {
+			if got := FormatDuration(i18n.Russian, tc.d); got != tc.want {
 				t.Errorf("FormatDuration(%v) = %q, want %q", tc.d, got, tc.want)
 			}
 		})
 	}
 }
+
+func TestFormatDurationEnglish(t *testing.T) {
+	if got := FormatDuration(i18n.English, 150*time.Second); got != "2 minutes" {
+		t.Errorf("FormatDuration(en) = %q, want %q", got, "2 minutes")
+	}
+}
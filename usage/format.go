@@ -44,7 +44,11 @@ func (r Report) Format() string {
 		sb.WriteString("\nПо операции\n")
 		parts := make([]string, 0, len(r.ByOperation))
 		for _, g := range r.ByOperation {
-			parts = append(parts, fmt.Sprintf("%s %s", labelOr(g.Label, "—"), abbrev(g.TotalTokens)))
+			part := fmt.Sprintf("%s %s", labelOr(g.Label, "—"), abbrev(g.TotalTokens))
+			if g.PromptTokens > 0 {
+				part += fmt.Sprintf(" (кэш %d%%)", g.CachedTokens*100/g.PromptTokens)
+			}
+			parts = append(parts, part)
 		}
 		sb.WriteString("  " + strings.Join(parts, " · ") + "\n")
 	}
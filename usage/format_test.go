@@ -17,7 +17,7 @@ func TestReportFormatFull(t *testing.T) {
 			{Label: "30 дней", Totals: db.TokenUsageTotals{TotalTokens: 51000000, Calls: 4100}},
 		},
 		ByModel:     []db.TokenUsageGroup{{Label: "gpt-5.5", TotalTokens: 1769970, Calls: 36}},
-		ByOperation: []db.TokenUsageGroup{{Label: "summarize", TotalTokens: 1200000}, {Label: "cluster", TotalTokens: 480000}},
+		ByOperation: []db.TokenUsageGroup{{Label: "summarize", PromptTokens: 1000000, CachedTokens: 750000, TotalTokens: 1200000}, {Label: "cluster", TotalTokens: 480000}},
 		ContextUsed: 69032,
 		ContextMax:  272000,
 		Quota: QuotaResult{
@@ -37,6 +37,7 @@ func TestReportFormatFull(t *testing.T) {
 		"Контекст: 69 032 / 272 000 (25%)",
 		"По модели",
 		"По операции",
+		"summarize 1.2M (кэш 75%) · cluster 480k",
 		"📈 Лимиты аккаунта",
 		"Провайдер: openai-codex (Plus)",
 		"Сессия (5ч): 84% осталось (16% исп.)",